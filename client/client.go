@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"net/url"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -10,20 +11,23 @@ import (
 
 // Client is a client to interact with the identity service.
 type Client struct {
-	baseURL string
-	apiKey  string
-	rest    *resty.Client
+	baseURL  string
+	authzURL string
+	apiKey   string
+	rest     *resty.Client
 }
 
 // New returns a new instance of Client.
 // The baseURL is the base URL of the identity service.
+// The apiKey is an API key of the daemon registered for the consuming service.
 func New(baseURL, apiKey string) *Client {
 	const clientTimeout = 10 * time.Second
 
 	return &Client{
-		baseURL: baseURL + "/api/v1/sessions/",
-		apiKey:  apiKey,
-		rest:    resty.New().SetTimeout(clientTimeout),
+		baseURL:  baseURL + "/api/v1/sessions/",
+		authzURL: baseURL + "/api/v1/authz/",
+		apiKey:   apiKey,
+		rest:     resty.New().SetTimeout(clientTimeout),
 	}
 }
 
@@ -71,6 +75,64 @@ func (c *Client) Logout(ctx context.Context, sessionID string) error {
 	return processErrorResponse(rsp)
 }
 
+// Verify verifies the given API key and returns the principal owning it.
+// The API key must grant all the given scopes.
+func (c *Client) Verify(ctx context.Context, apiKey string, scopes ...string) (Principal, error) {
+	var result Principal
+
+	req := c.rest.R().SetContext(ctx).SetHeader("Authorization", "Bearer "+apiKey).SetResult(&result)
+
+	if len(scopes) > 0 {
+		req.SetQueryParamsFromValues(url.Values{"scope": scopes})
+	}
+
+	rsp, err := req.Get(c.baseURL + "verify")
+	if err != nil {
+		return Principal{}, newIdentityServerError("failed to verify API key: %v", err)
+	}
+
+	if err := processErrorResponse(rsp); err != nil {
+		return Principal{}, err
+	}
+
+	return result, nil
+}
+
+// Check decides the authorization request.
+func (c *Client) Check(ctx context.Context, check AuthzCheck) (AuthzDecision, error) {
+	var result AuthzDecision
+
+	rsp, err := c.newRequest(ctx).SetBody(check).SetResult(&result).Post(c.authzURL + "check")
+	if err != nil {
+		return AuthzDecision{}, newIdentityServerError("failed to check authorization: %v", err)
+	}
+
+	if err := processErrorResponse(rsp); err != nil {
+		return AuthzDecision{}, err
+	}
+
+	return result, nil
+}
+
+// CheckBatch decides the authorization requests of the batch.
+// The decisions are returned in the order of the checks.
+func (c *Client) CheckBatch(ctx context.Context, batch AuthzBatch) ([]AuthzDecision, error) {
+	var result struct {
+		Decisions []AuthzDecision `json:"decisions"`
+	}
+
+	rsp, err := c.newRequest(ctx).SetBody(batch).SetResult(&result).Post(c.authzURL + "check/batch")
+	if err != nil {
+		return nil, newIdentityServerError("failed to check authorizations: %v", err)
+	}
+
+	if err := processErrorResponse(rsp); err != nil {
+		return nil, err
+	}
+
+	return result.Decisions, nil
+}
+
 func (c *Client) newRequest(ctx context.Context) *resty.Request {
 	return c.rest.R().SetContext(ctx).SetHeader("Authorization", "Bearer "+c.apiKey)
 }
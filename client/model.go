@@ -1,5 +1,7 @@
 package client
 
+import "time"
+
 // Session is a struct that contains session and user information.
 type Session struct {
 	Header Header `json:"header"`
@@ -14,8 +16,88 @@ type Header struct {
 
 // User is a struct that contains user information.
 type User struct {
-	ID          string `json:"id"`
-	Username    string `json:"username"`
-	DisplayName string `json:"displayName"`
-	Email       string `json:"email"`
+	ID          string         `json:"id"`
+	Username    string         `json:"username"`
+	DisplayName string         `json:"displayName"`
+	Email       string         `json:"email"`
+	Groups      []Group        `json:"groups"`
+	Attributes  map[string]any `json:"attributes,omitempty"`
+}
+
+// Group is a struct that contains the information of a group the user is a member of.
+type Group struct {
+	ID         string            `json:"id"`
+	Code       string            `json:"code"`
+	Name       string            `json:"name"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Principal is a struct that contains the user or the daemon authenticated by an API key.
+// Type is either "user" or "daemon". Code is the username of a user or the code of a daemon.
+// Role is only set for users, AllowedRealms only for daemons.
+type Principal struct {
+	Type          string     `json:"type"`
+	ID            string     `json:"id"`
+	Code          string     `json:"code"`
+	RealmID       string     `json:"realmId"`
+	Role          string     `json:"role,omitempty"`
+	AllowedRealms []string   `json:"allowedRealms,omitempty"`
+	APIKey        APIKeyInfo `json:"apiKey"`
+}
+
+// APIKeyInfo is a struct that contains the metadata of an API key.
+// API keys without scopes grant every scope.
+type APIKeyInfo struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Scopes    []string   `json:"scopes"`
+}
+
+// AuthzCheck is a struct that contains an authorization request of a downstream service.
+// The subject is either the user of the session or the principal. If neither is set,
+// the subject is the principal owning the API key of the client.
+type AuthzCheck struct {
+	SessionID string          `json:"sessionId,omitempty"`
+	Principal *AuthzPrincipal `json:"principal,omitempty"`
+	Action    string          `json:"action"`
+	Resource  string          `json:"resource"`
+}
+
+// AuthzBatch is a struct that contains several authorization requests of the same subject.
+type AuthzBatch struct {
+	SessionID string          `json:"sessionId,omitempty"`
+	Principal *AuthzPrincipal `json:"principal,omitempty"`
+	Checks    []AuthzAction   `json:"checks"`
+}
+
+// AuthzPrincipal is a struct that identifies the user or the daemon to authorize.
+// Type is either "user" or "daemon".
+type AuthzPrincipal struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	RealmID string `json:"realmId"`
+}
+
+// AuthzAction is a struct that contains an action on a resource to authorize.
+type AuthzAction struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
+// AuthzDecision is a struct that contains the outcome of an authorization request.
+// Decision is either "allow" or "deny". Policy is the matched policy; it is not set
+// if no policy matched, in which case the request is denied.
+type AuthzDecision struct {
+	Action   string     `json:"action"`
+	Resource string     `json:"resource"`
+	Allowed  bool       `json:"allowed"`
+	Decision string     `json:"decision"`
+	Policy   *PolicyRef `json:"policy,omitempty"`
+}
+
+// PolicyRef is a struct that identifies a policy.
+type PolicyRef struct {
+	ID   string `json:"id"`
+	Code string `json:"code"`
 }
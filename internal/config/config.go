@@ -1,13 +1,18 @@
 package config
 
+import "time"
+
 // Config contains server setup.
 type Config struct {
-	HTTP   HTTPConfig
-	Router RouterConfig
-	Mongo  MongoConfig
-	Auth   AuthenticatorConfig
-	Cookie CookieConfig
-	Redis  RedisConfig
+	HTTP      HTTPConfig
+	Router    RouterConfig
+	Mongo     MongoConfig
+	Auth      AuthenticatorConfig
+	Cookie    CookieConfig
+	Redis     RedisConfig
+	RateLimit RateLimitConfig
+	Lockout   LockoutConfig
+	Deletion  DeletionConfig
 }
 
 // HTTPConfig contains HTTP server setup.
@@ -17,8 +22,12 @@ type HTTPConfig struct {
 }
 
 // RouterConfig contains router setup.
+//
+// TrustedProxies lists the addresses and CIDR ranges of the reverse proxies allowed
+// to report the client IP in the X-Forwarded-For header.
 type RouterConfig struct {
-	AllowOrigin string `env:"ROUTER_ALLOW_ORIGIN"`
+	AllowOrigin    string   `env:"ROUTER_ALLOW_ORIGIN"`
+	TrustedProxies []string `env:"ROUTER_TRUSTED_PROXIES" envSeparator:","`
 }
 
 // MongoConfig contains MongoDB setup.
@@ -30,9 +39,20 @@ type MongoConfig struct {
 }
 
 // AuthenticatorConfig contains authenticator setup.
+//
+// APIKey is the deprecated shared API key of the sessions endpoint. The consuming
+// services should authenticate with the API keys of their daemons instead.
+//
+// ImpersonationDuration limits how long an admin can act as another user.
 type AuthenticatorConfig struct {
-	APIKey            string `env:"AUTH_API_KEY"`
-	LocalAdminEnabled bool   `env:"AUTH_LOCAL_ADMIN_ENABLED"`
+	APIKey                    string        `env:"AUTH_API_KEY"`
+	APIKeySecret              string        `env:"AUTH_API_KEY_SECRET"`
+	APIKeyRotationGracePeriod time.Duration `env:"AUTH_API_KEY_ROTATION_GRACE_PERIOD"`
+	APIKeyExpiryCheckInterval time.Duration `env:"AUTH_API_KEY_EXPIRY_CHECK_INTERVAL"`
+	APIKeyExpiryWarningPeriod time.Duration `env:"AUTH_API_KEY_EXPIRY_WARNING_PERIOD"`
+	APIKeyUsageFlushInterval  time.Duration `env:"AUTH_API_KEY_USAGE_FLUSH_INTERVAL"`
+	LocalAdminEnabled         bool          `env:"AUTH_LOCAL_ADMIN_ENABLED"`
+	ImpersonationDuration     time.Duration `env:"AUTH_IMPERSONATION_DURATION"`
 }
 
 // CookieConfig contains cookie setup.
@@ -50,3 +70,32 @@ type RedisConfig struct {
 	Namespace  string `env:"REDIS_NAMESPACE"`
 	Standalone bool   `env:"REDIS_STANDALONE"`
 }
+
+// RateLimitConfig contains rate limiter setup.
+//
+// The auth limits apply to the login flow, the sessions limits to the sessions API,
+// including the API key verification. A zero limit disables the limit.
+type RateLimitConfig struct {
+	AuthWindow             time.Duration `env:"RATE_LIMIT_AUTH_WINDOW"`
+	AuthPerClientIP        int           `env:"RATE_LIMIT_AUTH_PER_CLIENT_IP"`
+	SessionsWindow         time.Duration `env:"RATE_LIMIT_SESSIONS_WINDOW"`
+	SessionsPerClientIP    int           `env:"RATE_LIMIT_SESSIONS_PER_CLIENT_IP"`
+	SessionsPerAPIKeyRealm int           `env:"RATE_LIMIT_SESSIONS_PER_API_KEY_REALM"`
+}
+
+// LockoutConfig contains the setup of the lockouts after repeated failed authentications.
+// A zero threshold disables the lockouts.
+type LockoutConfig struct {
+	Threshold     int           `env:"LOCKOUT_THRESHOLD"`
+	FailureWindow time.Duration `env:"LOCKOUT_FAILURE_WINDOW"`
+	BaseDuration  time.Duration `env:"LOCKOUT_BASE_DURATION"`
+	MaxDuration   time.Duration `env:"LOCKOUT_MAX_DURATION"`
+}
+
+// DeletionConfig contains the setup of the soft deletions.
+// The deleted entities can be restored during the retention period, after which
+// they are purged. A zero purge interval disables the purge.
+type DeletionConfig struct {
+	RetentionPeriod time.Duration `env:"DELETION_RETENTION_PERIOD"`
+	PurgeInterval   time.Duration `env:"DELETION_PURGE_INTERVAL"`
+}
@@ -25,8 +25,12 @@ const (
 type AuthHandler struct {
 	sessionService    session.Service
 	userProvisioner   admin.UserProvisioner
+	membershipFinder  admin.MembershipFinder
+	userImpersonator  admin.UserImpersonator
 	cookieOperator    admin.CookieOperator
+	lockoutGuard      admin.LockoutGuard
 	localAdminEnabled bool
+	impersonationTTL  time.Duration
 	client            *resty.Client
 }
 
@@ -34,32 +38,49 @@ type AuthHandler struct {
 func NewAuthHandler(
 	sessionService session.Service,
 	userProvisioner admin.UserProvisioner,
+	membershipFinder admin.MembershipFinder,
+	userImpersonator admin.UserImpersonator,
 	cookieOperator admin.CookieOperator,
+	lockoutGuard admin.LockoutGuard,
 	localAdminEnabled bool,
+	impersonationTTL time.Duration,
 ) *AuthHandler {
 	const clientTimeout = 10 * time.Second
 
 	return &AuthHandler{
 		sessionService:    sessionService,
 		userProvisioner:   userProvisioner,
+		membershipFinder:  membershipFinder,
+		userImpersonator:  userImpersonator,
 		cookieOperator:    cookieOperator,
+		lockoutGuard:      lockoutGuard,
 		localAdminEnabled: localAdminEnabled,
+		impersonationTTL:  impersonationTTL,
 		client:            resty.New().SetTimeout(clientTimeout),
 	}
 }
 
 // BindWithMiddlewares binds the LoginHandler to a root provided by a router.
 func (h *AuthHandler) BindWithMiddlewares(root gin.IRouter, mws api.Middlewares) {
-	root.GET("/link", h.link)
-	root.POST("/login", h.login)
+	root.GET("/link", mws.RateLimitAuth, h.link)
+	root.POST("/login", mws.RateLimitAuth, h.login)
+	root.GET("/session", mws.RequireActor, h.session)
 	root.DELETE("/session", mws.RequireActor, h.logout)
+	root.GET("/session/realms", mws.RequireActor, h.realms)
+	root.PUT("/session/realm", mws.RequireActor, h.switchRealm)
+	root.POST("/session/impersonation", mws.RequireActor, h.startImpersonation)
+	root.DELETE("/session/impersonation", mws.RequireActor, h.stopImpersonation)
 }
 
 // link returns the link to the provider's login page.
+//
+// The optional returnTo parameter is validated against the realm's redirect URIs
+// and handed back in the login response.
 func (h *AuthHandler) link(c *gin.Context) {
 	realmCode := c.Query("realmCode")
 	providerCode := c.Query("providerCode")
 	action := c.Query("action")
+	returnTo := c.Query("returnTo")
 
 	if action == "" {
 		action = defaultAction
@@ -73,7 +94,7 @@ func (h *AuthHandler) link(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
-	link, err := h.sessionService.Link(ctx, realmCode, providerCode, action)
+	link, err := h.sessionService.Link(ctx, realmCode, providerCode, action, returnTo)
 	if err != nil {
 		_ = c.Error(err)
 
@@ -83,7 +104,8 @@ func (h *AuthHandler) link(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"link": link})
 }
 
-// login completes the login/signup process and returns the session ID.
+// login completes the login/signup process and returns the session ID,
+// along with the return URI requested when the login link was created.
 func (h *AuthHandler) login(c *gin.Context) {
 	code := c.Query("code")
 	state := c.Query("state")
@@ -97,6 +119,32 @@ func (h *AuthHandler) login(c *gin.Context) {
 	h.doLogin(c, code, state)
 }
 
+// session returns the session of the cookie. An impersonated session is flagged
+// with the admin acting as the user.
+func (h *AuthHandler) session(c *gin.Context) {
+	us, err := h.cookieOperator.ParseCookie(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if h.localAdminEnabled && us.SessionID == local.AdminSessionID {
+		c.JSON(http.StatusOK, toUserSessionInfo(us, localAdminUser()))
+
+		return
+	}
+
+	user, err := h.userProvisioner.GetUserSys(c.Request.Context(), admin.ID(us.RealmID), admin.ID(us.UserID))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserSessionInfo(us, user))
+}
+
 // logout logs out the user, deletes the session, and resets the cookie.
 func (h *AuthHandler) logout(c *gin.Context) {
 	if err := h.cookieOperator.ResetCookie(c); err != nil {
@@ -125,46 +173,239 @@ func (h *AuthHandler) logout(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
-func (h *AuthHandler) doLogin(c *gin.Context, code, state string) {
-	if h.localAdminEnabled && code == local.AdminProviderCode && state == local.AdminProviderCode {
-		h.loginLocal(c)
+// realms returns the realms the user can switch to: the realm of the user
+// and the realms the user is a member of.
+func (h *AuthHandler) realms(c *gin.Context) {
+	us, err := h.cookieOperator.ParseCookie(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	memberships, err := h.membershipFinder.GetUserMembershipsSys(c.Request.Context(), admin.ID(us.UserID))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, toSessionRealms(us, memberships, reqctx.Actor(c).RealmID))
+}
+
+// switchRealm switches the user to another realm and records the active realm
+// in the session cookie. Switching to the realm of the user switches back.
+func (h *AuthHandler) switchRealm(c *gin.Context) {
+	dtoRealm := sessionRealm{}
+
+	if err := c.ShouldBindJSON(&dtoRealm); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	us, err := h.cookieOperator.ParseCookie(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	realm := sessionRealm{RealmID: us.RealmID, Role: us.UserRole, Active: true}
+
+	if dtoRealm.RealmID != us.RealmID {
+		membership, mErr := h.membershipFinder.GetUserMembershipSys(
+			c.Request.Context(), admin.ID(dtoRealm.RealmID), admin.ID(us.UserID))
+		if mErr != nil {
+			if domain.IsNotFoundError(mErr) {
+				mErr = domain.NewAccessDeniedError("user %s is not a member of realm %s", us.UserID, dtoRealm.RealmID)
+			}
+
+			_ = c.Error(mErr)
+
+			return
+		}
+
+		realm = toSessionRealm(membership, true)
+	}
+
+	us.ActiveRealmID = realm.RealmID
+
+	if err := h.cookieOperator.CreateCookie(c, us); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, realm)
+}
+
+// startImpersonation lets the actor act as another user until the impersonation
+// expires or is stopped. The actor is recorded in the session cookie.
+func (h *AuthHandler) startImpersonation(c *gin.Context) {
+	dtoTarget := sessionImpersonationTarget{}
+
+	if err := c.ShouldBindJSON(&dtoTarget); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	us, err := h.cookieOperator.ParseCookie(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if h.localAdminEnabled && us.SessionID == local.AdminSessionID {
+		_ = c.Error(domain.NewBadRequestError("the local admin cannot impersonate users"))
 
 		return
 	}
 
 	ctx := c.Request.Context()
+	actor := reqctx.Actor(c)
 
-	sessionID, err := h.sessionService.Login(ctx, code, state)
+	user, err := h.userImpersonator.ImpersonateUser(ctx, actor, admin.ID(dtoTarget.RealmID), admin.ID(dtoTarget.UserID))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	us = us.Impersonate(user.RealmID.String(), user.ID.String(), user.Role.String(), time.Now().Add(h.impersonationTTL))
+
+	if err := h.cookieOperator.CreateCookie(c, us); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	reqctx.Logger(ctx).Info().
+		Str("impersonatorId", actor.UserID.String()).
+		Str("impersonatorRealmId", actor.RealmID.String()).
+		Str("userId", user.ID.String()).
+		Str("userRealmId", user.RealmID.String()).
+		Time("expiresAt", us.ImpersonationExpiresAt).
+		Msg("Impersonation started")
+
+	c.JSON(http.StatusOK, toUserSessionInfo(us, user))
+}
+
+// stopImpersonation switches the session back to the impersonator.
+func (h *AuthHandler) stopImpersonation(c *gin.Context) {
+	us, err := h.cookieOperator.ParseCookie(c)
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
-	cs, err := h.sessionService.Session(ctx, sessionID)
+	if !us.IsImpersonated() {
+		_ = c.Error(domain.NewBadRequestError("the session is not impersonated"))
+
+		return
+	}
+
+	if err := h.cookieOperator.CreateCookie(c, us.EndImpersonation()); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	reqctx.Logger(c.Request.Context()).Info().
+		Str("impersonatorId", us.ImpersonatorID).
+		Str("impersonatorRealmId", us.ImpersonatorRealmID).
+		Str("userId", us.UserID).
+		Str("userRealmId", us.RealmID).
+		Msg("Impersonation stopped")
+
+	c.Status(http.StatusOK)
+}
+
+func (h *AuthHandler) doLogin(c *gin.Context, code, state string) {
+	if h.localAdminEnabled && code == local.AdminProviderCode && state == local.AdminProviderCode {
+		h.loginLocal(c)
+
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	cs, err := h.completeLogin(c, code, state)
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
+	// logins of unknown or disabled users count as failed authentications of the user and
+	// the client IP; the lockout of the user has already been checked by the login
+	userKey := admin.LockoutKey{Kind: admin.LockoutKindUser, RealmID: admin.ID(cs.Header.RealmID), Subject: cs.User.BindID}
+
 	user, err := h.userProvisioner.GetUserByBindIDSys(ctx, admin.ID(cs.Header.RealmID), cs.User.BindID)
 	if err != nil {
+		if domain.IsNotFoundError(err) {
+			h.lockoutGuard.RecordFailure(ctx, userKey, clientIPLockoutKey(c))
+		}
+
 		_ = c.Error(err)
 
 		return
 	}
 
+	if !user.Enabled {
+		h.lockoutGuard.RecordFailure(ctx, userKey, clientIPLockoutKey(c))
+
+		_ = c.Error(domain.NewAccessDeniedError("user %s is disabled", user.ID))
+
+		return
+	}
+
 	h.serveSessionCookie(c, cs.Header, user)
 }
 
+// completeLogin completes the login with the provider and returns the new session.
+// Failed logins count as failed authentications of the client IP, except the logins
+// of locked out users.
+//
+//nolint:wrapcheck // the errors are already domain errors
+func (h *AuthHandler) completeLogin(c *gin.Context, code, state string) (session.Session, error) {
+	ctx := c.Request.Context()
+	ipKey := clientIPLockoutKey(c)
+
+	if err := h.lockoutGuard.CheckLockout(ctx, ipKey); err != nil {
+		return session.Session{}, err
+	}
+
+	sessionID, err := h.sessionService.Login(ctx, code, state)
+	if err != nil {
+		if !domain.IsRateLimitedError(err) {
+			h.lockoutGuard.RecordFailure(ctx, ipKey)
+		}
+
+		return session.Session{}, err
+	}
+
+	return h.sessionService.Session(ctx, sessionID)
+}
+
+func clientIPLockoutKey(c *gin.Context) admin.LockoutKey {
+	return admin.LockoutKey{Kind: admin.LockoutKindClientIP, Subject: reqctx.ClientIP(c)}
+}
+
 func (h *AuthHandler) loginLocal(c *gin.Context) {
 	header := session.Header{
 		SessionID: local.AdminSessionID,
 		RealmID:   local.AdminRealmID,
 	}
 
-	user := admin.User{
+	h.serveSessionCookie(c, header, localAdminUser())
+}
+
+func localAdminUser() admin.User {
+	return admin.User{
 		ID:          local.AdminID,
 		RealmID:     local.AdminRealmID,
 		Username:    "admin",
@@ -172,8 +413,6 @@ func (h *AuthHandler) loginLocal(c *gin.Context) {
 		DisplayName: "Local Admin",
 		Role:        local.AdminRole,
 	}
-
-	h.serveSessionCookie(c, header, user)
 }
 
 func (h *AuthHandler) doSignup(c *gin.Context, code, state string) {
@@ -185,14 +424,7 @@ func (h *AuthHandler) doSignup(c *gin.Context, code, state string) {
 
 	ctx := c.Request.Context()
 
-	sessionID, err := h.sessionService.Login(ctx, code, state)
-	if err != nil {
-		_ = c.Error(err)
-
-		return
-	}
-
-	cs, err := h.sessionService.Session(ctx, sessionID)
+	cs, err := h.completeLogin(c, code, state)
 	if err != nil {
 		_ = c.Error(err)
 
@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/domain/session"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthHandler_doLogin_lockout(t *testing.T) {
+	t.Parallel()
+
+	userKey := admin.LockoutKey{Kind: admin.LockoutKindUser, RealmID: "r1", Subject: "b1"}
+
+	tests := map[string]struct {
+		user         admin.User
+		userErr      error
+		wantError    error
+		wantFailures []admin.LockoutKey
+	}{
+		"enabledUser": {
+			user: admin.User{ID: "u1", RealmID: "r1", BindID: "b1", Enabled: true},
+		},
+		"disabledUser": {
+			user:         admin.User{ID: "u1", RealmID: "r1", BindID: "b1"},
+			wantError:    domain.AccessDeniedError{},
+			wantFailures: []admin.LockoutKey{userKey},
+		},
+		"unknownUser": {
+			userErr:      domain.NewNotFoundError("user not found"),
+			wantError:    domain.NotFoundError{},
+			wantFailures: []admin.LockoutKey{userKey},
+		},
+		"storeError": {
+			userErr:   domain.NewStoreError("forced error"),
+			wantError: domain.StoreError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			guard := &stubLockoutGuard{}
+			h := NewAuthHandler(stubSessionService{}, stubUserProvisioner{user: test.user, err: test.userErr},
+				nil, nil, stubCookieOperator{}, guard, false, 0)
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+
+			h.doLogin(c, "code", "login:s1")
+
+			// the failures of the user are recorded together with the client IP
+			var userFailures []admin.LockoutKey
+
+			for _, key := range guard.failures {
+				if key.Kind == admin.LockoutKindUser {
+					userFailures = append(userFailures, key)
+				}
+			}
+
+			require.Equal(t, test.wantFailures, userFailures)
+
+			if test.wantError != nil {
+				require.Len(t, c.Errors, 1)
+				require.ErrorAs(t, c.Errors[0].Err, &test.wantError)
+
+				return
+			}
+
+			require.Empty(t, c.Errors)
+		})
+	}
+}
+
+type stubSessionService struct {
+	session.Service
+}
+
+func (stubSessionService) Login(context.Context, string, string) (string, error) {
+	return "s1", nil
+}
+
+func (stubSessionService) Session(_ context.Context, sessionID string) (session.Session, error) {
+	return session.Session{
+		Header: session.Header{SessionID: sessionID, RealmID: "r1"},
+		User:   session.User{BindID: "b1"},
+	}, nil
+}
+
+type stubUserProvisioner struct {
+	admin.UserProvisioner
+	user admin.User
+	err  error
+}
+
+func (p stubUserProvisioner) GetUserByBindIDSys(context.Context, admin.ID, string) (admin.User, error) {
+	return p.user, p.err
+}
+
+type stubLockoutGuard struct {
+	failures []admin.LockoutKey
+}
+
+func (g *stubLockoutGuard) CheckLockout(context.Context, ...admin.LockoutKey) error {
+	return nil
+}
+
+func (g *stubLockoutGuard) RecordFailure(_ context.Context, keys ...admin.LockoutKey) {
+	g.failures = append(g.failures, keys...)
+}
+
+type stubCookieOperator struct {
+	admin.CookieOperator
+}
+
+func (stubCookieOperator) CreateCookie(*gin.Context, domain.UserSession) error {
+	return nil
+}
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -11,12 +12,17 @@ import (
 
 // DaemonHandler is an HTTP API handler for managing daemons.
 type DaemonHandler struct {
-	service admin.DaemonService
+	service     admin.DaemonService
+	gracePeriod time.Duration
 }
 
 // NewDaemonHandler creates a new DaemonHandler.
-func NewDaemonHandler(service admin.DaemonService) *DaemonHandler {
-	return &DaemonHandler{service: service}
+// The grace period is the default time a rotated API key remains valid.
+func NewDaemonHandler(service admin.DaemonService, gracePeriod time.Duration) *DaemonHandler {
+	return &DaemonHandler{
+		service:     service,
+		gracePeriod: gracePeriod,
+	}
 }
 
 // Bind binds the DaemonHandler to a root provided by a router.
@@ -25,12 +31,16 @@ func (h *DaemonHandler) Bind(root gin.IRouter) {
 	root.GET("/:id", h.findByID)
 	root.POST("", h.create)
 	root.PUT("/:id", h.update)
+	root.PATCH("/:id", h.patch)
 	root.DELETE("/:id", h.delete)
+	root.POST("/:id/restore", h.restore)
 
 	root.GET("/:id/api-keys", h.findAllAPIKeys)
 	root.GET("/:id/api-keys/:kid", h.findAPIKey)
 	root.POST("/:id/api-keys", h.createAPIKey)
 	root.PUT("/:id/api-keys/:kid", h.updateAPIKey)
+	root.PATCH("/:id/api-keys/:kid", h.patchAPIKey)
+	root.POST("/:id/api-keys/:kid/rotate", h.rotateAPIKey)
 	root.DELETE("/:id/api-keys/:kid", h.deleteAPIKey)
 }
 
@@ -39,14 +49,30 @@ func (h *DaemonHandler) findAll(c *gin.Context) {
 	realmID := c.Param("aid")
 	actor := reqctx.Actor(c)
 
-	daemons, err := h.service.GetDaemons(ctx, actor, admin.ID(realmID))
+	filter, err := toDaemonFilter(c.Request.URL.Query())
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
-	c.JSON(http.StatusOK, fromDaemons(daemons))
+	options, err := toListOptions(c.Query("cursor"), c.Query("limit"), c.Query("sort"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	page, err := h.service.GetDaemons(ctx, actor, admin.ID(realmID), filter, options)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setPageHeaders(c, page.Total, page.NextCursor)
+
+	c.JSON(http.StatusOK, fromDaemons(page.Items))
 }
 
 func (h *DaemonHandler) findByID(c *gin.Context) {
@@ -62,6 +88,7 @@ func (h *DaemonHandler) findByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, daemon.Version)
 	c.JSON(http.StatusOK, fromDaemon(daemon))
 }
 
@@ -89,6 +116,7 @@ func (h *DaemonHandler) create(c *gin.Context) {
 		return
 	}
 
+	setETag(c, daemon.Version)
 	c.JSON(http.StatusCreated, fromDaemon(daemon))
 }
 
@@ -98,6 +126,13 @@ func (h *DaemonHandler) update(c *gin.Context) {
 	id := c.Param("id")
 	actor := reqctx.Actor(c)
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
 	dtoDaemon := Daemon{}
 
 	if err := c.ShouldBindJSON(&dtoDaemon); err != nil {
@@ -110,14 +145,60 @@ func (h *DaemonHandler) update(c *gin.Context) {
 
 	daemon.ID = admin.ID(id)
 	daemon.RealmID = admin.ID(realmID)
+	daemon.Version = version
 
-	daemon, err := h.service.UpdateDaemon(ctx, actor, daemon)
+	daemon, err = h.service.UpdateDaemon(ctx, actor, daemon)
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
+	setETag(c, daemon.Version)
+	c.JSON(http.StatusOK, fromDaemon(daemon))
+}
+
+func (h *DaemonHandler) patch(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	daemon, err := h.service.GetDaemon(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoDaemon, err := bindMergePatch(c, fromDaemon(daemon))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	daemon = toDaemon(dtoDaemon)
+
+	daemon.ID = admin.ID(id)
+	daemon.RealmID = admin.ID(realmID)
+	daemon.Version = version
+
+	daemon, err = h.service.UpdateDaemon(ctx, actor, daemon)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, daemon.Version)
 	c.JSON(http.StatusOK, fromDaemon(daemon))
 }
 
@@ -127,7 +208,14 @@ func (h *DaemonHandler) delete(c *gin.Context) {
 	id := c.Param("id")
 	actor := reqctx.Actor(c)
 
-	if err := h.service.DeleteDaemon(ctx, actor, admin.ID(realmID), admin.ID(id)); err != nil {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.DeleteDaemon(ctx, actor, admin.ID(realmID), admin.ID(id), version); err != nil {
 		_ = c.Error(err)
 
 		return
@@ -136,13 +224,37 @@ func (h *DaemonHandler) delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+func (h *DaemonHandler) restore(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	daemon, err := h.service.RestoreDaemon(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, daemon.Version)
+	c.JSON(http.StatusOK, fromDaemon(daemon))
+}
+
 func (h *DaemonHandler) findAllAPIKeys(c *gin.Context) {
 	ctx := c.Request.Context()
 	realmID := c.Param("aid")
-	userID := c.Param("id")
+	daemonID := c.Param("id")
 	actor := reqctx.Actor(c)
 
-	apiKeys, err := h.service.GetAPIKeys(ctx, actor, admin.ID(realmID), admin.ID(userID))
+	filter, err := toAPIKeyFilter(c.Query("unusedForDays"), time.Now())
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	apiKeys, err := h.service.GetAPIKeys(ctx, actor, admin.ID(realmID), admin.ID(daemonID), filter)
 	if err != nil {
 		_ = c.Error(err)
 
@@ -155,11 +267,11 @@ func (h *DaemonHandler) findAllAPIKeys(c *gin.Context) {
 func (h *DaemonHandler) findAPIKey(c *gin.Context) {
 	ctx := c.Request.Context()
 	realmID := c.Param("aid")
-	userID := c.Param("id")
+	daemonID := c.Param("id")
 	keyID := c.Param("kid")
 	actor := reqctx.Actor(c)
 
-	apiKey, err := h.service.GetAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID))
+	apiKey, err := h.service.GetAPIKey(ctx, actor, admin.ID(realmID), admin.ID(daemonID), admin.ID(keyID))
 	if err != nil {
 		_ = c.Error(err)
 
@@ -172,7 +284,7 @@ func (h *DaemonHandler) findAPIKey(c *gin.Context) {
 func (h *DaemonHandler) createAPIKey(c *gin.Context) {
 	ctx := c.Request.Context()
 	realmID := c.Param("aid")
-	userID := c.Param("id")
+	daemonID := c.Param("id")
 	actor := reqctx.Actor(c)
 
 	dtoAPIKey := APIKey{}
@@ -185,7 +297,7 @@ func (h *DaemonHandler) createAPIKey(c *gin.Context) {
 
 	apiKey := toAPIKey(dtoAPIKey)
 
-	apiKey, err := h.service.CreateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), apiKey)
+	apiKey, err := h.service.CreateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(daemonID), apiKey)
 	if err != nil {
 		_ = c.Error(err)
 
@@ -198,10 +310,17 @@ func (h *DaemonHandler) createAPIKey(c *gin.Context) {
 func (h *DaemonHandler) updateAPIKey(c *gin.Context) {
 	ctx := c.Request.Context()
 	realmID := c.Param("aid")
-	userID := c.Param("id")
+	daemonID := c.Param("id")
 	keyID := c.Param("kid")
 	actor := reqctx.Actor(c)
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
 	dtoAPIKey := APIKey{}
 
 	if err := c.ShouldBindJSON(&dtoAPIKey); err != nil {
@@ -214,24 +333,118 @@ func (h *DaemonHandler) updateAPIKey(c *gin.Context) {
 
 	apiKey.ID = admin.ID(keyID)
 
-	apiKey, err := h.service.UpdateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID), apiKey)
+	apiKey, err = h.service.UpdateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(daemonID), admin.ID(keyID), version, apiKey)
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
+	setETag(c, version.Next())
 	c.JSON(http.StatusOK, fromAPIKey(apiKey))
 }
 
+func (h *DaemonHandler) patchAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	daemonID := c.Param("id")
+	keyID := c.Param("kid")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	apiKey, err := h.service.GetAPIKey(ctx, actor, admin.ID(realmID), admin.ID(daemonID), admin.ID(keyID))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoAPIKey, err := bindMergePatch(c, fromAPIKey(apiKey))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	apiKey = toAPIKey(dtoAPIKey)
+
+	apiKey.ID = admin.ID(keyID)
+
+	apiKey, err = h.service.UpdateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(daemonID), admin.ID(keyID), version, apiKey)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, version.Next())
+	c.JSON(http.StatusOK, fromAPIKey(apiKey))
+}
+
+func (h *DaemonHandler) rotateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	daemonID := c.Param("id")
+	keyID := c.Param("kid")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoRotation := APIKeyRotation{}
+
+	if err := c.ShouldBindJSON(&dtoRotation); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	gracePeriod, err := toGracePeriod(dtoRotation.GracePeriod, h.gracePeriod)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	successor := toRotatedAPIKey(dtoRotation)
+
+	successor, err = h.service.RotateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(daemonID), admin.ID(keyID),
+		version, successor, gracePeriod)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, version.Next())
+	c.JSON(http.StatusCreated, fromAPIKey(successor))
+}
+
 func (h *DaemonHandler) deleteAPIKey(c *gin.Context) {
 	ctx := c.Request.Context()
 	realmID := c.Param("aid")
-	userID := c.Param("id")
+	daemonID := c.Param("id")
 	keyID := c.Param("kid")
 	actor := reqctx.Actor(c)
 
-	if err := h.service.DeleteAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID)); err != nil {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.DeleteAPIKey(ctx, actor, admin.ID(realmID), admin.ID(daemonID), admin.ID(keyID), version); err != nil {
 		_ = c.Error(err)
 
 		return
@@ -0,0 +1,213 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+)
+
+// GroupHandler is an HTTP API handler for managing groups.
+type GroupHandler struct {
+	service admin.GroupService
+}
+
+// NewGroupHandler creates a new GroupHandler.
+func NewGroupHandler(service admin.GroupService) *GroupHandler {
+	return &GroupHandler{
+		service: service,
+	}
+}
+
+// Bind binds the GroupHandler to a root provided by a router.
+func (h *GroupHandler) Bind(root gin.IRouter) {
+	root.GET("", h.findAll)
+	root.GET("/:id", h.findByID)
+	root.POST("", h.create)
+	root.PUT("/:id", h.update)
+	root.DELETE("/:id", h.delete)
+	root.POST("/:id/restore", h.restore)
+
+	root.PUT("/:id/members/:uid", h.addMember)
+	root.DELETE("/:id/members/:uid", h.removeMember)
+}
+
+func (h *GroupHandler) findAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	options, err := toListOptions(c.Query("cursor"), c.Query("limit"), c.Query("sort"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	page, err := h.service.GetGroups(ctx, actor, admin.ID(realmID), options)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setPageHeaders(c, page.Total, page.NextCursor)
+
+	c.JSON(http.StatusOK, fromGroups(page.Items))
+}
+
+func (h *GroupHandler) findByID(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	group, err := h.service.GetGroup(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, group.Version)
+	c.JSON(http.StatusOK, fromGroup(group))
+}
+
+func (h *GroupHandler) create(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	dtoGroup := Group{}
+
+	if err := c.ShouldBindJSON(&dtoGroup); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	group := toGroup(dtoGroup)
+
+	group.RealmID = admin.ID(realmID)
+
+	group, err := h.service.CreateGroup(ctx, actor, group)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, group.Version)
+	c.JSON(http.StatusCreated, fromGroup(group))
+}
+
+func (h *GroupHandler) update(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoGroup := Group{}
+
+	if err := c.ShouldBindJSON(&dtoGroup); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	group := toGroup(dtoGroup)
+
+	group.ID = admin.ID(id)
+	group.RealmID = admin.ID(realmID)
+	group.Version = version
+
+	group, err = h.service.UpdateGroup(ctx, actor, group)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, group.Version)
+	c.JSON(http.StatusOK, fromGroup(group))
+}
+
+func (h *GroupHandler) delete(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.DeleteGroup(ctx, actor, admin.ID(realmID), admin.ID(id), version); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *GroupHandler) restore(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	group, err := h.service.RestoreGroup(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, group.Version)
+	c.JSON(http.StatusOK, fromGroup(group))
+}
+
+func (h *GroupHandler) addMember(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	userID := c.Param("uid")
+	actor := reqctx.Actor(c)
+
+	if err := h.service.AddGroupMember(ctx, actor, admin.ID(realmID), admin.ID(id), admin.ID(userID)); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *GroupHandler) removeMember(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	userID := c.Param("uid")
+	actor := reqctx.Actor(c)
+
+	if err := h.service.RemoveGroupMember(ctx, actor, admin.ID(realmID), admin.ID(id), admin.ID(userID)); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
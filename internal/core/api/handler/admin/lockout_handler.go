@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+)
+
+// LockoutHandler is an HTTP API handler for viewing and clearing lockouts.
+type LockoutHandler struct {
+	service admin.LockoutService
+}
+
+// NewLockoutHandler creates a new LockoutHandler.
+func NewLockoutHandler(service admin.LockoutService) *LockoutHandler {
+	return &LockoutHandler{service: service}
+}
+
+// Bind binds the LockoutHandler to a root provided by a router.
+func (h *LockoutHandler) Bind(root gin.IRouter) {
+	root.GET("", h.findAll)
+	root.GET("/:id", h.findByID)
+	root.DELETE("/:id", h.delete)
+}
+
+func (h *LockoutHandler) findAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	actor := reqctx.Actor(c)
+
+	options, err := toListOptions(c.Query("cursor"), c.Query("limit"), c.Query("sort"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	page, err := h.service.GetLockouts(ctx, actor, options)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setPageHeaders(c, page.Total, page.NextCursor)
+
+	c.JSON(http.StatusOK, fromLockouts(page.Items, time.Now()))
+}
+
+func (h *LockoutHandler) findByID(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	lockout, err := h.service.GetLockout(ctx, actor, admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, fromLockout(lockout, time.Now()))
+}
+
+func (h *LockoutHandler) delete(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	if err := h.service.DeleteLockout(ctx, actor, admin.ID(id)); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,19 +1,32 @@
 package admin
 
 import (
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
 )
 
+// day is the unit of the API key lifetime in the DTOs.
+const day = 24 * time.Hour
+
 // fromRealm converts a domain realm to a DTO realm.
 func fromRealm(realm admin.Realm) Realm {
 	return Realm{
-		ID:          string(realm.ID),
-		Code:        realm.Code,
-		Name:        realm.Name,
-		Description: realm.Description,
-		Enabled:     realm.Enabled,
+		ID:                    string(realm.ID),
+		Code:                  realm.Code,
+		Name:                  realm.Name,
+		Description:           realm.Description,
+		Enabled:               realm.Enabled,
+		RedirectURIs:          realm.RedirectURIs,
+		APIKeyMaxLifetimeDays: int(realm.APIKeyMaxLifetime / day),
+		APIKeyScopes:          realm.APIKeyScopes,
+		Roles:                 fromRoles(realm.Roles),
+		UserAttributes:        fromAttributeDefinitions(realm.UserAttributes),
+		Version:               int(realm.Version),
 	}
 }
 
@@ -31,12 +44,97 @@ func fromRealms(realms []admin.Realm) []Realm {
 // toRealm converts a DTO realm to a domain realm.
 func toRealm(realm Realm) admin.Realm {
 	return admin.Realm{
-		ID:          admin.ID(realm.ID),
-		Code:        realm.Code,
-		Name:        realm.Name,
-		Description: realm.Description,
-		Enabled:     realm.Enabled,
+		ID:                admin.ID(realm.ID),
+		Code:              realm.Code,
+		Name:              realm.Name,
+		Description:       realm.Description,
+		Enabled:           realm.Enabled,
+		RedirectURIs:      realm.RedirectURIs,
+		APIKeyMaxLifetime: time.Duration(realm.APIKeyMaxLifetimeDays) * day,
+		APIKeyScopes:      realm.APIKeyScopes,
+		Roles:             toRoles(realm.Roles),
+		UserAttributes:    toAttributeDefinitions(realm.UserAttributes),
+	}
+}
+
+// fromRoles converts a slice of domain roles to a slice of DTO roles.
+func fromRoles(roles []admin.Role) []Role {
+	if roles == nil {
+		return nil
+	}
+
+	dtos := make([]Role, len(roles))
+
+	for i, role := range roles {
+		dtos[i] = Role{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: fromPermissions(role.Permissions),
+		}
+	}
+
+	return dtos
+}
+
+// toRoles converts a slice of DTO roles to a slice of domain roles.
+func toRoles(dtos []Role) []admin.Role {
+	if dtos == nil {
+		return nil
+	}
+
+	roles := make([]admin.Role, len(dtos))
+
+	for i, dto := range dtos {
+		roles[i] = admin.Role{
+			Name:        dto.Name,
+			Description: dto.Description,
+			Permissions: toPermissions(dto.Permissions),
+		}
 	}
+
+	return roles
+}
+
+// fromAttributeDefinitions converts a slice of domain attribute definitions to a slice of DTO definitions.
+func fromAttributeDefinitions(definitions []admin.AttributeDefinition) []AttributeDefinition {
+	if definitions == nil {
+		return nil
+	}
+
+	dtos := make([]AttributeDefinition, len(definitions))
+
+	for i, definition := range definitions {
+		dtos[i] = AttributeDefinition{
+			Name:        definition.Name,
+			Description: definition.Description,
+			Type:        string(definition.Type),
+			Required:    definition.Required,
+			Values:      definition.Values,
+		}
+	}
+
+	return dtos
+}
+
+// toAttributeDefinitions converts a slice of DTO attribute definitions to a slice of domain definitions.
+func toAttributeDefinitions(dtos []AttributeDefinition) []admin.AttributeDefinition {
+	if dtos == nil {
+		return nil
+	}
+
+	definitions := make([]admin.AttributeDefinition, len(dtos))
+
+	for i, dto := range dtos {
+		definitions[i] = admin.AttributeDefinition{
+			Name:        dto.Name,
+			Description: dto.Description,
+			Type:        admin.AttributeType(dto.Type),
+			Required:    dto.Required,
+			Values:      dto.Values,
+		}
+	}
+
+	return definitions
 }
 
 // fromProvider converts a domain provider to a DTO provider.
@@ -51,6 +149,7 @@ func fromProvider(provider admin.Provider) Provider {
 		ClientID:     provider.ClientID,
 		ClientSecret: provider.ClientSecret,
 		RedirectURL:  provider.RedirectURL,
+		Version:      int(provider.Version),
 	}
 }
 
@@ -91,6 +190,10 @@ func fromUser(user admin.User) User {
 		Description: user.Description,
 		Enabled:     user.Enabled,
 		Role:        string(user.Role),
+		Roles:       user.Roles,
+		Attributes:  user.Attributes,
+		CreatedAt:   fromTimestamp(user.CreatedAt),
+		Version:     int(user.Version),
 	}
 }
 
@@ -116,17 +219,23 @@ func toUser(user User) admin.User {
 		Description: user.Description,
 		Enabled:     user.Enabled,
 		Role:        admin.SystemRole(user.Role),
+		Roles:       user.Roles,
+		Attributes:  user.Attributes,
 	}
 }
 
 // fromDaemon converts a domain daemon to a DTO daemon.
 func fromDaemon(daemon admin.Daemon) Daemon {
 	return Daemon{
-		ID:          string(daemon.ID),
-		Code:        daemon.Code,
-		Name:        daemon.Name,
-		Description: daemon.Description,
-		Enabled:     daemon.Enabled,
+		ID:            string(daemon.ID),
+		Code:          daemon.Code,
+		Name:          daemon.Name,
+		Description:   daemon.Description,
+		Enabled:       daemon.Enabled,
+		AllowedRealms: fromIDs(daemon.AllowedRealms),
+		AllowedCIDRs:  daemon.AllowedCIDRs,
+		CreatedAt:     fromTimestamp(daemon.CreatedAt),
+		Version:       int(daemon.Version),
 	}
 }
 
@@ -144,23 +253,208 @@ func fromDaemons(daemons []admin.Daemon) []Daemon {
 // toDaemon converts a DTO daemon to a domain daemon.
 func toDaemon(daemon Daemon) admin.Daemon {
 	return admin.Daemon{
-		ID:          admin.ID(daemon.ID),
-		Code:        daemon.Code,
-		Name:        daemon.Name,
-		Description: daemon.Description,
-		Enabled:     daemon.Enabled,
+		ID:            admin.ID(daemon.ID),
+		Code:          daemon.Code,
+		Name:          daemon.Name,
+		Description:   daemon.Description,
+		Enabled:       daemon.Enabled,
+		AllowedRealms: toIDs(daemon.AllowedRealms),
+		AllowedCIDRs:  daemon.AllowedCIDRs,
+	}
+}
+
+// fromGroup converts a domain group to a DTO group.
+func fromGroup(group admin.Group) Group {
+	return Group{
+		ID:          string(group.ID),
+		Code:        group.Code,
+		Name:        group.Name,
+		Description: group.Description,
+		Roles:       group.Roles,
+		Attributes:  group.Attributes,
+		Members:     fromIDs(group.Members),
+		Version:     int(group.Version),
+	}
+}
+
+// fromGroups converts a slice of domain groups to a slice of DTO groups.
+func fromGroups(groups []admin.Group) []Group {
+	dtos := make([]Group, len(groups))
+
+	for i, group := range groups {
+		dtos[i] = fromGroup(group)
+	}
+
+	return dtos
+}
+
+// toGroup converts a DTO group to a domain group.
+func toGroup(group Group) admin.Group {
+	return admin.Group{
+		ID:          admin.ID(group.ID),
+		Code:        group.Code,
+		Name:        group.Name,
+		Description: group.Description,
+		Roles:       group.Roles,
+		Attributes:  group.Attributes,
+	}
+}
+
+// fromMembership converts a domain membership to a DTO membership.
+func fromMembership(membership admin.Membership) Membership {
+	return Membership{
+		ID:          string(membership.ID),
+		UserID:      string(membership.UserID),
+		UserRealmID: string(membership.UserRealmID),
+		Role:        string(membership.Role),
+		Version:     int(membership.Version),
+	}
+}
+
+// fromMemberships converts a slice of domain memberships to a slice of DTO memberships.
+func fromMemberships(memberships []admin.Membership) []Membership {
+	dtos := make([]Membership, len(memberships))
+
+	for i, membership := range memberships {
+		dtos[i] = fromMembership(membership)
+	}
+
+	return dtos
+}
+
+// toMembership converts a DTO membership to a domain membership.
+func toMembership(membership Membership) admin.Membership {
+	return admin.Membership{
+		ID:          admin.ID(membership.ID),
+		UserID:      admin.ID(membership.UserID),
+		UserRealmID: admin.ID(membership.UserRealmID),
+		Role:        admin.SystemRole(membership.Role),
+	}
+}
+
+// fromPolicy converts a domain policy to a DTO policy.
+func fromPolicy(policy admin.Policy) Policy {
+	return Policy{
+		ID:          string(policy.ID),
+		Code:        policy.Code,
+		Description: policy.Description,
+		Effect:      string(policy.Effect),
+		Actions:     policy.Actions,
+		Resources:   policy.Resources,
+		Roles:       policy.Roles,
+		Groups:      policy.Groups,
+		Principals:  fromIDs(policy.Principals),
+		Version:     int(policy.Version),
+	}
+}
+
+// fromPolicies converts a slice of domain policies to a slice of DTO policies.
+func fromPolicies(policies []admin.Policy) []Policy {
+	dtos := make([]Policy, len(policies))
+
+	for i, policy := range policies {
+		dtos[i] = fromPolicy(policy)
+	}
+
+	return dtos
+}
+
+// toPolicy converts a DTO policy to a domain policy.
+func toPolicy(policy Policy) admin.Policy {
+	return admin.Policy{
+		ID:          admin.ID(policy.ID),
+		Code:        policy.Code,
+		Description: policy.Description,
+		Effect:      admin.PolicyEffect(policy.Effect),
+		Actions:     policy.Actions,
+		Resources:   policy.Resources,
+		Roles:       policy.Roles,
+		Groups:      policy.Groups,
+		Principals:  toIDs(policy.Principals),
+	}
+}
+
+// fromRule converts a domain access rule to a DTO rule.
+func fromRule(rule admin.AccessRule) Rule {
+	return Rule{
+		ID:          string(rule.ID),
+		Code:        rule.Code,
+		Description: rule.Description,
+		Enabled:     rule.Enabled,
+		Permissions: fromPermissions(rule.Permissions),
+		Expression:  rule.Expression,
+		Version:     int(rule.Version),
+	}
+}
+
+// fromRules converts a slice of domain access rules to a slice of DTO rules.
+func fromRules(rules []admin.AccessRule) []Rule {
+	dtos := make([]Rule, len(rules))
+
+	for i, rule := range rules {
+		dtos[i] = fromRule(rule)
+	}
+
+	return dtos
+}
+
+// toRule converts a DTO rule to a domain access rule.
+func toRule(rule Rule) admin.AccessRule {
+	return admin.AccessRule{
+		ID:          admin.ID(rule.ID),
+		Code:        rule.Code,
+		Description: rule.Description,
+		Enabled:     rule.Enabled,
+		Permissions: toPermissions(rule.Permissions),
+		Expression:  rule.Expression,
+	}
+}
+
+// toRuleInput converts the variables of a DTO dry run to a domain rule input.
+// Missing variables are empty maps, so that the expressions can test their keys.
+func toRuleInput(dryRun RuleDryRun) admin.RuleInput {
+	orEmpty := func(variables map[string]any) map[string]any {
+		if variables == nil {
+			return map[string]any{}
+		}
+
+		return variables
+	}
+
+	return admin.RuleInput{
+		Actor:   orEmpty(dryRun.Actor),
+		Target:  orEmpty(dryRun.Target),
+		Request: orEmpty(dryRun.Request),
+	}
+}
+
+// fromEffectiveRoles converts domain effective roles to DTO effective roles.
+func fromEffectiveRoles(roles admin.EffectiveRoles) EffectiveRoles {
+	return EffectiveRoles{
+		Role:        string(roles.Role),
+		Roles:       roles.Roles,
+		Groups:      fromIDs(roles.Groups),
+		Permissions: fromPermissions(roles.Permissions),
 	}
 }
 
 // fromAPIKey converts a domain API key to a DTO API key.
 func fromAPIKey(apiKey admin.APIKey) APIKey {
 	return APIKey{
-		ID:          string(apiKey.ID),
-		Name:        apiKey.Name,
-		Description: apiKey.Description,
-		Enabled:     apiKey.Enabled,
-		Key:         apiKey.Key,
-		ExpiresAt:   fromDate(apiKey.ExpiresAt),
+		ID:           string(apiKey.ID),
+		Name:         apiKey.Name,
+		Description:  apiKey.Description,
+		Enabled:      apiKey.Enabled,
+		Key:          apiKey.Key,
+		Prefix:       apiKey.Prefix,
+		CreatedAt:    fromDate(apiKey.CreatedAt),
+		ExpiresAt:    fromDate(apiKey.ExpiresAt),
+		Scopes:       apiKey.Scopes,
+		LastUsedAt:   fromTimestamp(apiKey.LastUsedAt),
+		LastUsedIP:   apiKey.LastUsedIP,
+		UsageCount:   apiKey.UsageCount(time.Now()),
+		AllowedCIDRs: apiKey.AllowedCIDRs,
+		RetiredAt:    fromTimestamp(apiKey.RetiredAt),
 	}
 }
 
@@ -178,15 +472,346 @@ func fromAPIKeys(apiKeys []admin.APIKey) []APIKey {
 // toAPIKey converts a DTO API key to a domain API key.
 func toAPIKey(apiKey APIKey) admin.APIKey {
 	return admin.APIKey{
-		ID:          admin.ID(apiKey.ID),
-		Name:        apiKey.Name,
-		Description: apiKey.Description,
-		Enabled:     apiKey.Enabled,
-		Key:         apiKey.Key,
-		ExpiresAt:   toDate(apiKey.ExpiresAt),
+		ID:           admin.ID(apiKey.ID),
+		Name:         apiKey.Name,
+		Description:  apiKey.Description,
+		Enabled:      apiKey.Enabled,
+		Key:          apiKey.Key,
+		ExpiresAt:    toDate(apiKey.ExpiresAt),
+		Scopes:       apiKey.Scopes,
+		AllowedCIDRs: apiKey.AllowedCIDRs,
+	}
+}
+
+// toRotatedAPIKey converts a DTO API key rotation to a domain successor API key.
+func toRotatedAPIKey(rotation APIKeyRotation) admin.APIKey {
+	return admin.APIKey{
+		Name:         rotation.Name,
+		Description:  rotation.Description,
+		Key:          rotation.Key,
+		ExpiresAt:    toDate(rotation.ExpiresAt),
+		Scopes:       rotation.Scopes,
+		AllowedCIDRs: rotation.AllowedCIDRs,
 	}
 }
 
+// toGracePeriod parses the grace period of an API key rotation.
+// It returns the fallback if the grace period is not given.
+func toGracePeriod(s string, fallback time.Duration) (time.Duration, error) {
+	if s == "" {
+		return fallback, nil
+	}
+
+	gracePeriod, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, domain.NewBadRequestError("invalid grace period: %v", err)
+	}
+
+	return gracePeriod, nil
+}
+
+// toAPIKeyFilter parses the unusedForDays query parameter of an API key listing.
+// It returns an empty filter, which matches all API keys, if the parameter is not given.
+func toAPIKeyFilter(unusedForDays string, now time.Time) (admin.APIKeyFilter, error) {
+	if unusedForDays == "" {
+		return admin.APIKeyFilter{}, nil
+	}
+
+	days, err := strconv.Atoi(unusedForDays)
+	if err != nil || days <= 0 {
+		return admin.APIKeyFilter{}, domain.NewBadRequestError("invalid unusedForDays: %s", unusedForDays)
+	}
+
+	return admin.APIKeyFilter{UnusedSince: now.Add(-time.Duration(days) * day)}, nil
+}
+
+// toListOptions parses the cursor, limit and sort query parameters of a listing.
+// A sort field prefixed with a minus sign sorts in descending order.
+func toListOptions(cursor, limit, sort string) (admin.ListOptions, error) {
+	options := admin.ListOptions{Cursor: cursor}
+
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return admin.ListOptions{}, domain.NewBadRequestError("invalid limit: %s", limit)
+		}
+
+		options.Limit = n
+	}
+
+	options.SortBy, options.Descending = strings.CutPrefix(sort, "-")
+
+	return options, nil
+}
+
+// toUserFilter converts the query parameters of a user listing to a user filter.
+// The attributes[name]=value parameters are parsed by the service according to
+// the attribute schema of the realm.
+func toUserFilter(query url.Values, attributes map[string]string) (admin.UserFilter, error) {
+	enabled, err := toEnabledFilter(query.Get("enabled"))
+	if err != nil {
+		return admin.UserFilter{}, err
+	}
+
+	createdAfter, createdBefore, err := toCreationRange(query.Get("createdAfter"), query.Get("createdBefore"))
+	if err != nil {
+		return admin.UserFilter{}, err
+	}
+
+	filter := admin.UserFilter{
+		Enabled:        enabled,
+		Role:           admin.SystemRole(query.Get("role")),
+		UsernamePrefix: query.Get("username"),
+		EmailPrefix:    query.Get("email"),
+		CreatedAfter:   createdAfter,
+		CreatedBefore:  createdBefore,
+	}
+
+	if len(attributes) > 0 {
+		filter.Attributes = make(map[string]any, len(attributes))
+
+		for name, value := range attributes {
+			filter.Attributes[name] = value
+		}
+	}
+
+	return filter, nil
+}
+
+// toDaemonFilter converts the query parameters of a daemon listing to a daemon filter.
+func toDaemonFilter(query url.Values) (admin.DaemonFilter, error) {
+	enabled, err := toEnabledFilter(query.Get("enabled"))
+	if err != nil {
+		return admin.DaemonFilter{}, err
+	}
+
+	createdAfter, createdBefore, err := toCreationRange(query.Get("createdAfter"), query.Get("createdBefore"))
+	if err != nil {
+		return admin.DaemonFilter{}, err
+	}
+
+	return admin.DaemonFilter{
+		Enabled:       enabled,
+		CodePrefix:    query.Get("code"),
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+	}, nil
+}
+
+// toEnabledFilter parses the enabled query parameter. It returns nil, which
+// matches both enabled and disabled entities, if the parameter is not given.
+func toEnabledFilter(s string) (*bool, error) {
+	if s == "" {
+		return nil, nil //nolint:nilnil // no filter
+	}
+
+	enabled, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, domain.NewBadRequestError("invalid enabled: %s", s)
+	}
+
+	return &enabled, nil
+}
+
+// toDryRun parses the dryRun query parameter. A missing parameter is false.
+func toDryRun(s string) (bool, error) {
+	if s == "" {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, domain.NewBadRequestError("invalid dryRun: %s", s)
+	}
+
+	return dryRun, nil
+}
+
+// toCreationRange parses the createdAfter and createdBefore query parameters,
+// given as RFC 3339 timestamps or dates.
+func toCreationRange(after, before string) (time.Time, time.Time, error) {
+	createdAfter, err := toTimestampFilter("createdAfter", after)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	createdBefore, err := toTimestampFilter("createdBefore", before)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return createdAfter, createdBefore, nil
+}
+
+func toTimestampFilter(name, s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, domain.NewBadRequestError("invalid %s: %s", name, s)
+	}
+
+	return t, nil
+}
+
+func fromIDs(ids []admin.ID) []string {
+	if ids == nil {
+		return nil
+	}
+
+	strs := make([]string, len(ids))
+
+	for i, id := range ids {
+		strs[i] = string(id)
+	}
+
+	return strs
+}
+
+func toIDs(strs []string) []admin.ID {
+	if strs == nil {
+		return nil
+	}
+
+	ids := make([]admin.ID, len(strs))
+
+	for i, str := range strs {
+		ids[i] = admin.ID(str)
+	}
+
+	return ids
+}
+
+func fromPermissions(permissions []admin.Permission) []string {
+	if permissions == nil {
+		return nil
+	}
+
+	strs := make([]string, len(permissions))
+
+	for i, permission := range permissions {
+		strs[i] = string(permission)
+	}
+
+	return strs
+}
+
+func toPermissions(strs []string) []admin.Permission {
+	if strs == nil {
+		return nil
+	}
+
+	permissions := make([]admin.Permission, len(strs))
+
+	for i, str := range strs {
+		permissions[i] = admin.Permission(str)
+	}
+
+	return permissions
+}
+
+// fromSearchHits converts a slice of domain search hits to a slice of DTO search hits.
+func fromSearchHits(hits []admin.SearchHit) []SearchHit {
+	dtos := make([]SearchHit, len(hits))
+
+	for i, hit := range hits {
+		dtos[i] = SearchHit{
+			Kind:        string(hit.Kind),
+			RealmID:     string(hit.RealmID),
+			ID:          string(hit.ID),
+			Name:        hit.Name,
+			DisplayName: hit.DisplayName,
+			Email:       hit.Email,
+			Score:       hit.Score,
+		}
+	}
+
+	return dtos
+}
+
+// fromRealmContent converts the domain content of a deleted realm to a DTO realm deletion.
+func fromRealmContent(content admin.RealmContent, dryRun bool) RealmDeletion {
+	return RealmDeletion{
+		DryRun:      dryRun,
+		Users:       content.Users,
+		Daemons:     content.Daemons,
+		Groups:      content.Groups,
+		Memberships: content.Memberships,
+		Policies:    content.Policies,
+		Rules:       content.Rules,
+	}
+}
+
+// fromUserImportReport converts a domain user import report to a DTO report.
+func fromUserImportReport(report admin.UserImportReport) UserImportReport {
+	results := make([]UserImportResult, len(report.Results))
+
+	for i, result := range report.Results {
+		results[i] = UserImportResult{
+			Line:   result.Line,
+			BindID: result.BindID,
+			ID:     string(result.ID),
+			Action: string(result.Action),
+		}
+
+		if result.Err != nil {
+			results[i].Error = result.Err.Error()
+		}
+	}
+
+	return UserImportReport{
+		DryRun:  report.DryRun,
+		Created: report.Created,
+		Updated: report.Updated,
+		Failed:  report.Failed,
+		Results: results,
+	}
+}
+
+// fromExportedUser converts an exported domain user to a DTO user, together with
+// the metadata of its API keys.
+func fromExportedUser(user admin.User) User {
+	dto := fromUser(user)
+
+	if len(user.APIKeys) > 0 {
+		dto.APIKeys = fromAPIKeys(user.APIKeys)
+	}
+
+	return dto
+}
+
+// fromLockout converts a domain lockout to a DTO lockout.
+func fromLockout(lockout admin.Lockout, now time.Time) Lockout {
+	return Lockout{
+		ID:            string(lockout.ID),
+		Kind:          string(lockout.Kind),
+		RealmID:       string(lockout.RealmID),
+		Subject:       lockout.Subject,
+		Failures:      lockout.Failures,
+		Lockouts:      lockout.Lockouts,
+		LastFailureAt: fromTimestamp(lockout.LastFailureAt),
+		LockedUntil:   fromTimestamp(lockout.LockedUntil),
+		Locked:        lockout.IsLocked(now),
+	}
+}
+
+// fromLockouts converts a slice of domain lockouts to a slice of DTO lockouts.
+func fromLockouts(lockouts []admin.Lockout, now time.Time) []Lockout {
+	dtos := make([]Lockout, len(lockouts))
+
+	for i, lockout := range lockouts {
+		dtos[i] = fromLockout(lockout, now)
+	}
+
+	return dtos
+}
+
 func fromDate(t time.Time) *string {
 	if t.IsZero() {
 		return nil
@@ -197,6 +822,16 @@ func fromDate(t time.Time) *string {
 	return &value
 }
 
+func fromTimestamp(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+
+	value := t.UTC().Format(time.RFC3339)
+
+	return &value
+}
+
 func toDate(s *string) time.Time {
 	if s == nil || *s == "" {
 		return time.Time{}
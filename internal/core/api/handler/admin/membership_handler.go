@@ -0,0 +1,179 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+)
+
+// MembershipHandler is an HTTP API handler for managing the memberships of users
+// of other realms in a realm.
+type MembershipHandler struct {
+	service admin.MembershipService
+}
+
+// NewMembershipHandler creates a new MembershipHandler.
+func NewMembershipHandler(service admin.MembershipService) *MembershipHandler {
+	return &MembershipHandler{
+		service: service,
+	}
+}
+
+// Bind binds the MembershipHandler to a root provided by a router.
+func (h *MembershipHandler) Bind(root gin.IRouter) {
+	root.GET("", h.findAll)
+	root.GET("/:id", h.findByID)
+	root.POST("", h.create)
+	root.PUT("/:id", h.update)
+	root.DELETE("/:id", h.delete)
+	root.POST("/:id/restore", h.restore)
+}
+
+func (h *MembershipHandler) findAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	options, err := toListOptions(c.Query("cursor"), c.Query("limit"), c.Query("sort"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	page, err := h.service.GetMemberships(ctx, actor, admin.ID(realmID), options)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setPageHeaders(c, page.Total, page.NextCursor)
+
+	c.JSON(http.StatusOK, fromMemberships(page.Items))
+}
+
+func (h *MembershipHandler) findByID(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	membership, err := h.service.GetMembership(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, membership.Version)
+	c.JSON(http.StatusOK, fromMembership(membership))
+}
+
+func (h *MembershipHandler) create(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	dtoMembership := Membership{}
+
+	if err := c.ShouldBindJSON(&dtoMembership); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	membership := toMembership(dtoMembership)
+
+	membership.RealmID = admin.ID(realmID)
+
+	membership, err := h.service.CreateMembership(ctx, actor, membership)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, membership.Version)
+	c.JSON(http.StatusCreated, fromMembership(membership))
+}
+
+func (h *MembershipHandler) update(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoMembership := Membership{}
+
+	if err := c.ShouldBindJSON(&dtoMembership); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	membership := toMembership(dtoMembership)
+
+	membership.ID = admin.ID(id)
+	membership.RealmID = admin.ID(realmID)
+	membership.Version = version
+
+	membership, err = h.service.UpdateMembership(ctx, actor, membership)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, membership.Version)
+	c.JSON(http.StatusOK, fromMembership(membership))
+}
+
+func (h *MembershipHandler) delete(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.DeleteMembership(ctx, actor, admin.ID(realmID), admin.ID(id), version); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *MembershipHandler) restore(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	membership, err := h.service.RestoreMembership(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, membership.Version)
+	c.JSON(http.StatusOK, fromMembership(membership))
+}
@@ -2,11 +2,33 @@ package admin
 
 // Realm represents a realm.
 type Realm struct {
-	ID          string `json:"id"`
-	Code        string `json:"code"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Enabled     bool   `json:"enabled"`
+	ID                    string                `json:"id"`
+	Code                  string                `json:"code"`
+	Name                  string                `json:"name"`
+	Description           string                `json:"description"`
+	Enabled               bool                  `json:"enabled"`
+	RedirectURIs          []string              `json:"redirectUris"`
+	APIKeyMaxLifetimeDays int                   `json:"apiKeyMaxLifetimeDays"`
+	APIKeyScopes          []string              `json:"apiKeyScopes"`
+	Roles                 []Role                `json:"roles"`
+	UserAttributes        []AttributeDefinition `json:"userAttributes"`
+	Version               int                   `json:"version"`
+}
+
+// Role represents a custom role of a realm.
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// AttributeDefinition represents the definition of a custom user attribute of a realm.
+type AttributeDefinition struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Values      []string `json:"values"`
 }
 
 // Provider represents an authentication provider.
@@ -20,38 +42,193 @@ type Provider struct {
 	ClientID     string `json:"clientId"`
 	ClientSecret string `json:"clientSecret"`
 	RedirectURL  string `json:"redirectUrl"`
+	Version      int    `json:"version"`
 }
 
 // User represents an organic sessionUser in the system.
 type User struct {
-	ID          string   `json:"id"`
-	BindID      string   `json:"bindId"`
-	Username    string   `json:"username"`
-	Email       string   `json:"email"`
-	DisplayName string   `json:"displayName"`
-	Description string   `json:"description"`
-	Enabled     bool     `json:"enabled"`
-	Role        string   `json:"role"`
-	APIKeys     []APIKey `json:"apiKeys"`
+	ID          string         `json:"id"`
+	BindID      string         `json:"bindId"`
+	Username    string         `json:"username"`
+	Email       string         `json:"email"`
+	DisplayName string         `json:"displayName"`
+	Description string         `json:"description"`
+	Enabled     bool           `json:"enabled"`
+	Role        string         `json:"role"`
+	Roles       []string       `json:"roles"`
+	Attributes  map[string]any `json:"attributes"`
+	APIKeys     []APIKey       `json:"apiKeys"`
+	CreatedAt   *string        `json:"createdAt"`
+	Version     int            `json:"version"`
 }
 
 // Daemon represents a non-organic sessionUser in the system.
 type Daemon struct {
+	ID            string   `json:"id"`
+	Code          string   `json:"code"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Enabled       bool     `json:"enabled"`
+	APIKeys       []APIKey `json:"apiKeys"`
+	AllowedRealms []string `json:"allowedRealms"`
+	AllowedCIDRs  []string `json:"allowedCidrs"`
+	CreatedAt     *string  `json:"createdAt"`
+	Version       int      `json:"version"`
+}
+
+// Group represents a group of users of a realm.
+// Members are managed with the membership endpoints and ignored on updates.
+type Group struct {
+	ID          string            `json:"id"`
+	Code        string            `json:"code"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Roles       []string          `json:"roles"`
+	Attributes  map[string]string `json:"attributes"`
+	Members     []string          `json:"members"`
+	Version     int               `json:"version"`
+}
+
+// Membership represents a membership of a user in a realm other than its own.
+// Only the role can be changed on updates.
+type Membership struct {
+	ID          string `json:"id"`
+	UserID      string `json:"userId"`
+	UserRealmID string `json:"userRealmId"`
+	Role        string `json:"role"`
+	Version     int    `json:"version"`
+}
+
+// Policy represents an authorization policy of a realm.
+type Policy struct {
+	ID          string   `json:"id"`
+	Code        string   `json:"code"`
+	Description string   `json:"description"`
+	Effect      string   `json:"effect"`
+	Actions     []string `json:"actions"`
+	Resources   []string `json:"resources"`
+	Roles       []string `json:"roles"`
+	Groups      []string `json:"groups"`
+	Principals  []string `json:"principals"`
+	Version     int      `json:"version"`
+}
+
+// Rule represents an access rule of a realm.
+type Rule struct {
 	ID          string   `json:"id"`
 	Code        string   `json:"code"`
-	Name        string   `json:"name"`
 	Description string   `json:"description"`
 	Enabled     bool     `json:"enabled"`
-	APIKeys     []APIKey `json:"apiKeys"`
+	Permissions []string `json:"permissions"`
+	Expression  string   `json:"expression"`
+	Version     int      `json:"version"`
+}
+
+// RuleDryRun represents a request to evaluate an expression against sample variables.
+type RuleDryRun struct {
+	Expression string         `json:"expression"`
+	Actor      map[string]any `json:"actor"`
+	Target     map[string]any `json:"target"`
+	Request    map[string]any `json:"request"`
+}
+
+// EffectiveRoles represents the roles a user holds, directly or through its groups.
+type EffectiveRoles struct {
+	Role        string   `json:"role"`
+	Roles       []string `json:"roles"`
+	Groups      []string `json:"groups"`
+	Permissions []string `json:"permissions"`
 }
 
 // APIKey represents an API key that can be used to authenticate a daemon.
 // It can also be used to authenticate a sessionUser.
+// UsageCount is the number of verifications in the last days, see admin.APIKeyUsageWindowDays.
+// RetiredAt is set once the API key has been rotated.
 type APIKey struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Enabled      bool     `json:"enabled"`
+	Key          string   `json:"key,omitempty"`
+	Prefix       string   `json:"prefix"`
+	CreatedAt    *string  `json:"createdAt"`
+	ExpiresAt    *string  `json:"expiresAt"`
+	Scopes       []string `json:"scopes"`
+	LastUsedAt   *string  `json:"lastUsedAt"`
+	LastUsedIP   string   `json:"lastUsedIp"`
+	UsageCount   int64    `json:"usageCount"`
+	AllowedCIDRs []string `json:"allowedCidrs"`
+	RetiredAt    *string  `json:"retiredAt"`
+}
+
+// APIKeyRotation represents a request to rotate an API key.
+// The successor key inherits the name and the description of the rotated key
+// if no name is given, and its scopes and CIDR ranges if none are given.
+// GracePeriod is a duration string, like "24h". The successor key is generated
+// by the server, a supplied key is rejected.
+type APIKeyRotation struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Key          string   `json:"key"`
+	ExpiresAt    *string  `json:"expiresAt"`
+	Scopes       []string `json:"scopes"`
+	AllowedCIDRs []string `json:"allowedCidrs"`
+	GracePeriod  string   `json:"gracePeriod"`
+}
+
+// SearchHit represents a user or a daemon matching a search, with its relevance score.
+// The name is the username of a user or the code of a daemon.
+type SearchHit struct {
+	Kind        string  `json:"kind"`
+	RealmID     string  `json:"realmId"`
 	ID          string  `json:"id"`
 	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Enabled     bool    `json:"enabled"`
-	Key         string  `json:"key"`
-	ExpiresAt   *string `json:"expiresAt"`
+	DisplayName string  `json:"displayName"`
+	Email       string  `json:"email,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+// RealmDeletion reports the content of a realm deleted with it, or that would be
+// deleted with it on a dry run.
+type RealmDeletion struct {
+	DryRun      bool `json:"dryRun"`
+	Users       int  `json:"users"`
+	Daemons     int  `json:"daemons"`
+	Groups      int  `json:"groups"`
+	Memberships int  `json:"memberships"`
+	Policies    int  `json:"policies"`
+	Rules       int  `json:"rules"`
+}
+
+// UserImportReport reports the outcome of a user import, with a result per row.
+type UserImportReport struct {
+	DryRun  bool               `json:"dryRun"`
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Failed  int                `json:"failed"`
+	Results []UserImportResult `json:"results"`
+}
+
+// UserImportResult reports the outcome of importing a row. Action is create, update
+// or fail.
+type UserImportResult struct {
+	Line   int    `json:"line"`
+	BindID string `json:"bindId"`
+	ID     string `json:"id,omitempty"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Lockout represents the failed authentications of an API key prefix, a user or a client IP.
+// The subject is locked out while LockedUntil is in the future.
+type Lockout struct {
+	ID            string  `json:"id"`
+	Kind          string  `json:"kind"`
+	RealmID       string  `json:"realmId"`
+	Subject       string  `json:"subject"`
+	Failures      int     `json:"failures"`
+	Lockouts      int     `json:"lockouts"`
+	LastFailureAt *string `json:"lastFailureAt"`
+	LockedUntil   *string `json:"lockedUntil"`
+	Locked        bool    `json:"locked"`
 }
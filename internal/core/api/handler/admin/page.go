@@ -0,0 +1,25 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers of the paginated listings. The body of a listing only holds the
+// items of the page, so that the clients unaware of the pagination keep working:
+// a listing requested without a limit nor a cursor returns all the items.
+const (
+	headerTotalCount = "X-Total-Count"
+	headerNextCursor = "X-Next-Cursor"
+)
+
+// setPageHeaders sets the total count of the listing and the cursor of the next
+// page. The cursor header is omitted on the last page.
+func setPageHeaders(c *gin.Context, total int, nextCursor string) {
+	c.Header(headerTotalCount, strconv.Itoa(total))
+
+	if nextCursor != "" {
+		c.Header(headerNextCursor, nextCursor)
+	}
+}
@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// bindMergePatch applies the JSON merge patch (RFC 7396) of the request body to
+// the current DTO and returns the patched DTO. The fields absent from the patch
+// keep their current value, and the fields set to null are cleared.
+//
+// The patched DTO goes through the same update as a full replacement, so that
+// it is validated the same way.
+func bindMergePatch[T any](c *gin.Context, current T) (T, error) {
+	var patched T
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return patched, domain.NewBadRequestError("failed to read request body: %v", err)
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return patched, domain.NewBadRequestError("failed to encode the current state: %v", err)
+	}
+
+	merged, err := applyMergePatch(doc, patch)
+	if err != nil {
+		return patched, err
+	}
+
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return patched, domain.NewBadRequestError("invalid request body: %v", err)
+	}
+
+	return patched, nil
+}
+
+// applyMergePatch applies the merge patch to the JSON document. The patch must be
+// a JSON object, since the patched documents are all objects.
+func applyMergePatch(doc, patch []byte) ([]byte, error) {
+	var target any
+
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, domain.NewBadRequestError("invalid document: %v", err)
+	}
+
+	var patchValue any
+
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, domain.NewBadRequestError("invalid request body: %v", err)
+	}
+
+	if _, ok := patchValue.(map[string]any); !ok {
+		return nil, domain.NewBadRequestError("the merge patch must be a JSON object")
+	}
+
+	merged, err := json.Marshal(mergePatch(target, patchValue))
+	if err != nil {
+		return nil, domain.NewBadRequestError("failed to encode the patched document: %v", err)
+	}
+
+	return merged, nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7396. The objects are
+// merged member by member, while any other value replaces the target.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any, len(patchObject))
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+
+			continue
+		}
+
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+
+	return targetObject
+}
@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyMergePatch(t *testing.T) {
+	t.Parallel()
+
+	doc := `{"name":"google","enabled":true,"clientSecret":"s3cret","scopes":["a","b"],"attributes":{"team":"ops","level":3}}`
+
+	tests := map[string]struct {
+		patch string
+		want  string
+	}{
+		"keepsAbsentFields": {
+			patch: `{"name":"Google"}`,
+			want:  `{"name":"Google","enabled":true,"clientSecret":"s3cret","scopes":["a","b"],"attributes":{"team":"ops","level":3}}`,
+		},
+		"clearsNullFields": {
+			patch: `{"clientSecret":null,"attributes":{"level":null}}`,
+			want:  `{"name":"google","enabled":true,"scopes":["a","b"],"attributes":{"team":"ops"}}`,
+		},
+		"replacesArrays": {
+			patch: `{"scopes":["c"],"enabled":false}`,
+			want:  `{"name":"google","enabled":false,"clientSecret":"s3cret","scopes":["c"],"attributes":{"team":"ops","level":3}}`,
+		},
+		"replacesObjectsWithScalars": {
+			patch: `{"attributes":"none"}`,
+			want:  `{"name":"google","enabled":true,"clientSecret":"s3cret","scopes":["a","b"],"attributes":"none"}`,
+		},
+		"addsNestedObjects": {
+			patch: `{"extra":{"a":{"b":1,"c":null}}}`,
+			want: `{"name":"google","enabled":true,"clientSecret":"s3cret","scopes":["a","b"],` +
+				`"attributes":{"team":"ops","level":3},"extra":{"a":{"b":1}}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			merged, err := applyMergePatch([]byte(doc), []byte(test.patch))
+			require.NoError(t, err)
+			require.JSONEq(t, test.want, string(merged))
+		})
+	}
+
+	for name, patch := range map[string]string{
+		"invalidJSON": `{"name":`,
+		"notAnObject": `["name"]`,
+		"null":        `null`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := applyMergePatch([]byte(doc), []byte(patch))
+			require.ErrorAs(t, err, &domain.BadRequestError{})
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+)
+
+// PolicyHandler is an HTTP API handler for managing policies.
+type PolicyHandler struct {
+	service admin.PolicyService
+}
+
+// NewPolicyHandler creates a new PolicyHandler.
+func NewPolicyHandler(service admin.PolicyService) *PolicyHandler {
+	return &PolicyHandler{
+		service: service,
+	}
+}
+
+// Bind binds the PolicyHandler to a root provided by a router.
+func (h *PolicyHandler) Bind(root gin.IRouter) {
+	root.GET("", h.findAll)
+	root.GET("/:id", h.findByID)
+	root.POST("", h.create)
+	root.PUT("/:id", h.update)
+	root.DELETE("/:id", h.delete)
+	root.POST("/:id/restore", h.restore)
+}
+
+func (h *PolicyHandler) findAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	options, err := toListOptions(c.Query("cursor"), c.Query("limit"), c.Query("sort"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	page, err := h.service.GetPolicies(ctx, actor, admin.ID(realmID), options)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setPageHeaders(c, page.Total, page.NextCursor)
+
+	c.JSON(http.StatusOK, fromPolicies(page.Items))
+}
+
+func (h *PolicyHandler) findByID(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	policy, err := h.service.GetPolicy(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, policy.Version)
+	c.JSON(http.StatusOK, fromPolicy(policy))
+}
+
+func (h *PolicyHandler) create(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	dtoPolicy := Policy{}
+
+	if err := c.ShouldBindJSON(&dtoPolicy); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	policy := toPolicy(dtoPolicy)
+
+	policy.RealmID = admin.ID(realmID)
+
+	policy, err := h.service.CreatePolicy(ctx, actor, policy)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, policy.Version)
+	c.JSON(http.StatusCreated, fromPolicy(policy))
+}
+
+func (h *PolicyHandler) update(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoPolicy := Policy{}
+
+	if err := c.ShouldBindJSON(&dtoPolicy); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	policy := toPolicy(dtoPolicy)
+
+	policy.ID = admin.ID(id)
+	policy.RealmID = admin.ID(realmID)
+	policy.Version = version
+
+	policy, err = h.service.UpdatePolicy(ctx, actor, policy)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, policy.Version)
+	c.JSON(http.StatusOK, fromPolicy(policy))
+}
+
+func (h *PolicyHandler) delete(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.DeletePolicy(ctx, actor, admin.ID(realmID), admin.ID(id), version); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PolicyHandler) restore(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	policy, err := h.service.RestorePolicy(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, policy.Version)
+	c.JSON(http.StatusOK, fromPolicy(policy))
+}
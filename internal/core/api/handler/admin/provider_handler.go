@@ -25,21 +25,32 @@ func (h *ProviderHandler) Bind(root gin.IRouter) {
 	root.GET("/:id", h.findByID)
 	root.POST("", h.create)
 	root.PUT("/:id", h.update)
+	root.PATCH("/:id", h.patch)
 	root.DELETE("/:id", h.delete)
+	root.POST("/:id/restore", h.restore)
 }
 
 func (h *ProviderHandler) findAll(c *gin.Context) {
 	ctx := c.Request.Context()
 	actor := reqctx.Actor(c)
 
-	providers, err := h.service.GetProviders(ctx, actor)
+	options, err := toListOptions(c.Query("cursor"), c.Query("limit"), c.Query("sort"))
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
-	c.JSON(http.StatusOK, fromProviders(providers))
+	page, err := h.service.GetProviders(ctx, actor, options)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setPageHeaders(c, page.Total, page.NextCursor)
+
+	c.JSON(http.StatusOK, fromProviders(page.Items))
 }
 
 func (h *ProviderHandler) findByID(c *gin.Context) {
@@ -54,6 +65,7 @@ func (h *ProviderHandler) findByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, provider.Version)
 	c.JSON(http.StatusOK, fromProvider(provider))
 }
 
@@ -78,6 +90,7 @@ func (h *ProviderHandler) create(c *gin.Context) {
 		return
 	}
 
+	setETag(c, provider.Version)
 	c.JSON(http.StatusCreated, fromProvider(provider))
 }
 
@@ -86,6 +99,13 @@ func (h *ProviderHandler) update(c *gin.Context) {
 	id := c.Param("id")
 	actor := reqctx.Actor(c)
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
 	dtoProvider := Provider{}
 
 	if err := c.ShouldBindJSON(&dtoProvider); err != nil {
@@ -97,14 +117,58 @@ func (h *ProviderHandler) update(c *gin.Context) {
 	provider := toProvider(dtoProvider)
 
 	provider.ID = admin.ID(id)
+	provider.Version = version
 
-	provider, err := h.service.UpdateProvider(ctx, actor, provider)
+	provider, err = h.service.UpdateProvider(ctx, actor, provider)
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
+	setETag(c, provider.Version)
+	c.JSON(http.StatusOK, fromProvider(provider))
+}
+
+func (h *ProviderHandler) patch(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	provider, err := h.service.GetProvider(ctx, actor, admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoProvider, err := bindMergePatch(c, fromProvider(provider))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	provider = toProvider(dtoProvider)
+
+	provider.ID = admin.ID(id)
+	provider.Version = version
+
+	provider, err = h.service.UpdateProvider(ctx, actor, provider)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, provider.Version)
 	c.JSON(http.StatusOK, fromProvider(provider))
 }
 
@@ -113,7 +177,14 @@ func (h *ProviderHandler) delete(c *gin.Context) {
 	id := c.Param("id")
 	actor := reqctx.Actor(c)
 
-	if err := h.service.DeleteProvider(ctx, actor, admin.ID(id)); err != nil {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.DeleteProvider(ctx, actor, admin.ID(id), version); err != nil {
 		_ = c.Error(err)
 
 		return
@@ -121,3 +192,19 @@ func (h *ProviderHandler) delete(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+func (h *ProviderHandler) restore(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	provider, err := h.service.RestoreProvider(ctx, actor, admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, provider.Version)
+	c.JSON(http.StatusOK, fromProvider(provider))
+}
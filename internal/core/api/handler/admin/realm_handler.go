@@ -25,21 +25,32 @@ func (h *RealmHandler) Bind(root gin.IRouter) {
 	root.GET("/:aid", h.findByID)
 	root.POST("", h.create)
 	root.PUT("/:aid", h.update)
+	root.PATCH("/:aid", h.patch)
 	root.DELETE("/:aid", h.delete)
+	root.POST("/:aid/restore", h.restore)
 }
 
 func (h *RealmHandler) findAll(c *gin.Context) {
 	ctx := c.Request.Context()
 	actor := reqctx.Actor(c)
 
-	realms, err := h.service.GetRealms(ctx, actor)
+	options, err := toListOptions(c.Query("cursor"), c.Query("limit"), c.Query("sort"))
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
-	c.JSON(http.StatusOK, fromRealms(realms))
+	page, err := h.service.GetRealms(ctx, actor, options)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setPageHeaders(c, page.Total, page.NextCursor)
+
+	c.JSON(http.StatusOK, fromRealms(page.Items))
 }
 
 func (h *RealmHandler) findByID(c *gin.Context) {
@@ -54,6 +65,7 @@ func (h *RealmHandler) findByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, realm.Version)
 	c.JSON(http.StatusOK, fromRealm(realm))
 }
 
@@ -76,6 +88,7 @@ func (h *RealmHandler) create(c *gin.Context) {
 		return
 	}
 
+	setETag(c, realm.Version)
 	c.JSON(http.StatusCreated, fromRealm(realm))
 }
 
@@ -84,6 +97,13 @@ func (h *RealmHandler) update(c *gin.Context) {
 	id := c.Param("aid")
 	actor := reqctx.Actor(c)
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
 	dtoRealm := Realm{}
 
 	if err := c.ShouldBindJSON(&dtoRealm); err != nil {
@@ -92,15 +112,61 @@ func (h *RealmHandler) update(c *gin.Context) {
 		return
 	}
 
-	dtoRealm.ID = id
+	realm := toRealm(dtoRealm)
+
+	realm.ID = admin.ID(id)
+	realm.Version = version
+
+	realm, err = h.service.UpdateRealm(ctx, actor, realm)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, realm.Version)
+	c.JSON(http.StatusOK, fromRealm(realm))
+}
+
+func (h *RealmHandler) patch(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	realm, err := h.service.GetRealm(ctx, actor, admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoRealm, err := bindMergePatch(c, fromRealm(realm))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	realm = toRealm(dtoRealm)
 
-	realm, err := h.service.UpdateRealm(ctx, actor, toRealm(dtoRealm))
+	realm.ID = admin.ID(id)
+	realm.Version = version
+
+	realm, err = h.service.UpdateRealm(ctx, actor, realm)
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
+	setETag(c, realm.Version)
 	c.JSON(http.StatusOK, fromRealm(realm))
 }
 
@@ -109,11 +175,42 @@ func (h *RealmHandler) delete(c *gin.Context) {
 	id := c.Param("aid")
 	actor := reqctx.Actor(c)
 
-	if err := h.service.DeleteRealm(ctx, actor, admin.ID(id)); err != nil {
+	version, err := ifMatchVersion(c)
+	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	dryRun, err := toDryRun(c.Query("dryRun"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	content, err := h.service.DeleteRealm(ctx, actor, admin.ID(id), version, dryRun)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, fromRealmContent(content, dryRun))
+}
+
+func (h *RealmHandler) restore(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	realm, err := h.service.RestoreRealm(ctx, actor, admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, realm.Version)
+	c.JSON(http.StatusOK, fromRealm(realm))
 }
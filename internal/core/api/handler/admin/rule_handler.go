@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+)
+
+// RuleHandler is an HTTP API handler for managing access rules.
+type RuleHandler struct {
+	service admin.RuleService
+}
+
+// NewRuleHandler creates a new RuleHandler.
+func NewRuleHandler(service admin.RuleService) *RuleHandler {
+	return &RuleHandler{
+		service: service,
+	}
+}
+
+// Bind binds the RuleHandler to a root provided by a router.
+func (h *RuleHandler) Bind(root gin.IRouter) {
+	root.GET("", h.findAll)
+	root.GET("/:id", h.findByID)
+	root.POST("", h.create)
+	root.PUT("/:id", h.update)
+	root.DELETE("/:id", h.delete)
+	root.POST("/:id/restore", h.restore)
+	root.POST("/dry-run", h.dryRun)
+}
+
+func (h *RuleHandler) findAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	options, err := toListOptions(c.Query("cursor"), c.Query("limit"), c.Query("sort"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	page, err := h.service.GetRules(ctx, actor, admin.ID(realmID), options)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setPageHeaders(c, page.Total, page.NextCursor)
+
+	c.JSON(http.StatusOK, fromRules(page.Items))
+}
+
+func (h *RuleHandler) findByID(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	rule, err := h.service.GetRule(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, rule.Version)
+	c.JSON(http.StatusOK, fromRule(rule))
+}
+
+func (h *RuleHandler) create(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	dtoRule := Rule{}
+
+	if err := c.ShouldBindJSON(&dtoRule); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	rule := toRule(dtoRule)
+
+	rule.RealmID = admin.ID(realmID)
+
+	rule, err := h.service.CreateRule(ctx, actor, rule)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, rule.Version)
+	c.JSON(http.StatusCreated, fromRule(rule))
+}
+
+func (h *RuleHandler) update(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoRule := Rule{}
+
+	if err := c.ShouldBindJSON(&dtoRule); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	rule := toRule(dtoRule)
+
+	rule.ID = admin.ID(id)
+	rule.RealmID = admin.ID(realmID)
+	rule.Version = version
+
+	rule, err = h.service.UpdateRule(ctx, actor, rule)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, rule.Version)
+	c.JSON(http.StatusOK, fromRule(rule))
+}
+
+func (h *RuleHandler) delete(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.DeleteRule(ctx, actor, admin.ID(realmID), admin.ID(id), version); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RuleHandler) restore(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	rule, err := h.service.RestoreRule(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, rule.Version)
+	c.JSON(http.StatusOK, fromRule(rule))
+}
+
+// dryRun evaluates an expression against sample variables, without storing a rule.
+func (h *RuleHandler) dryRun(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	dtoDryRun := RuleDryRun{}
+
+	if err := c.ShouldBindJSON(&dtoDryRun); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	allowed, err := h.service.DryRunRule(ctx, actor, admin.ID(realmID), dtoDryRun.Expression, toRuleInput(dtoDryRun))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"allowed": allowed})
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandler is an HTTP API handler for searching the users and the daemons.
+// It is bound below a realm, and system-wide where the realm parameter is absent.
+type SearchHandler struct {
+	service admin.SearchService
+}
+
+// NewSearchHandler creates a new SearchHandler.
+func NewSearchHandler(service admin.SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// Bind binds the SearchHandler to a root provided by a router.
+func (h *SearchHandler) Bind(root gin.IRouter) {
+	root.GET("", h.search)
+}
+
+func (h *SearchHandler) search(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	limit := 0
+
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			_ = c.Error(domain.NewBadRequestError("invalid limit: %s", s))
+
+			return
+		}
+
+		limit = n
+	}
+
+	hits, err := h.service.Search(ctx, actor, admin.ID(realmID), c.Query("q"), limit)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, fromSearchHits(hits))
+}
@@ -1,18 +1,38 @@
 package admin
 
 import (
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
 	"github.com/energimind/identity-server/internal/core/domain/session"
 )
 
 func toSessionInfo(header session.Header, user admin.User) sessionInfo {
 	return sessionInfo{
-		ID:      header.SessionID,
-		RealmID: header.RealmID,
-		User:    toSessionUser(user),
+		ID:       header.SessionID,
+		RealmID:  header.RealmID,
+		ReturnTo: header.ReturnTo,
+		User:     toSessionUser(user),
 	}
 }
 
+func toUserSessionInfo(us domain.UserSession, user admin.User) sessionInfo {
+	info := toSessionInfo(session.Header{SessionID: us.SessionID, RealmID: us.RealmID}, user)
+
+	if !us.IsImpersonated() {
+		return info
+	}
+
+	info.Impersonator = &sessionImpersonator{
+		ID:        us.ImpersonatorID,
+		RealmID:   us.ImpersonatorRealmID,
+		ExpiresAt: us.ImpersonationExpiresAt.UTC().Format(time.RFC3339),
+	}
+
+	return info
+}
+
 func toSessionUser(au admin.User) sessionUser {
 	return sessionUser{
 		ID:          au.ID.String(),
@@ -22,3 +42,27 @@ func toSessionUser(au admin.User) sessionUser {
 		Role:        au.Role.String(),
 	}
 }
+
+func toSessionRealms(us domain.UserSession, memberships []admin.Membership, activeRealmID admin.ID) []sessionRealm {
+	realms := make([]sessionRealm, 0, len(memberships)+1)
+
+	realms = append(realms, sessionRealm{
+		RealmID: us.RealmID,
+		Role:    us.UserRole,
+		Active:  admin.ID(us.RealmID) == activeRealmID,
+	})
+
+	for _, membership := range memberships {
+		realms = append(realms, toSessionRealm(membership, membership.RealmID == activeRealmID))
+	}
+
+	return realms
+}
+
+func toSessionRealm(membership admin.Membership, active bool) sessionRealm {
+	return sessionRealm{
+		RealmID: membership.RealmID.String(),
+		Role:    membership.Role.String(),
+		Active:  active,
+	}
+}
@@ -1,10 +1,27 @@
 package admin
 
 // session is a struct that contains session information.
+// Impersonator is set if an admin acts as the user.
 type sessionInfo struct {
-	ID      string      `json:"id"`
-	RealmID string      `json:"realmId"`
-	User    sessionUser `json:"user"`
+	ID           string               `json:"id"`
+	RealmID      string               `json:"realmId"`
+	ReturnTo     string               `json:"returnTo,omitempty"`
+	User         sessionUser          `json:"user"`
+	Impersonator *sessionImpersonator `json:"impersonator,omitempty"`
+}
+
+// sessionImpersonator is a struct that contains the admin acting as the user
+// of the session, and the expiry of the impersonation.
+type sessionImpersonator struct {
+	ID        string `json:"id"`
+	RealmID   string `json:"realmId"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// sessionImpersonationTarget is a struct that contains the user to impersonate.
+type sessionImpersonationTarget struct {
+	RealmID string `json:"realmId"`
+	UserID  string `json:"userId"`
 }
 
 // sessionUser is a struct that contains sessionUser information.
@@ -15,3 +32,11 @@ type sessionUser struct {
 	Email       string `json:"email"`
 	Role        string `json:"role"`
 }
+
+// sessionRealm is a struct that contains a realm the user can act in,
+// with the role of the user in the realm.
+type sessionRealm struct {
+	RealmID string `json:"realmId"`
+	Role    string `json:"role"`
+	Active  bool   `json:"active"`
+}
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -11,26 +12,39 @@ import (
 
 // UserHandler is an HTTP API handler for managing users.
 type UserHandler struct {
-	service admin.UserService
+	service     admin.UserService
+	gracePeriod time.Duration
 }
 
 // NewUserHandler creates a new UserHandler.
-func NewUserHandler(service admin.UserService) *UserHandler {
-	return &UserHandler{service: service}
+// The grace period is the default time a rotated API key remains valid.
+func NewUserHandler(service admin.UserService, gracePeriod time.Duration) *UserHandler {
+	return &UserHandler{
+		service:     service,
+		gracePeriod: gracePeriod,
+	}
 }
 
 // Bind binds the UserHandler to a root provided by a router.
 func (h *UserHandler) Bind(root gin.IRouter) {
 	root.GET("", h.findAll)
+	root.GET("/export", h.exportAll)
 	root.GET("/:id", h.findByID)
 	root.POST("", h.create)
+	root.POST("/import", h.importAll)
 	root.PUT("/:id", h.update)
+	root.PATCH("/:id", h.patch)
 	root.DELETE("/:id", h.delete)
+	root.POST("/:id/restore", h.restore)
+
+	root.GET("/:id/roles", h.findEffectiveRoles)
 
 	root.GET("/:id/api-keys", h.findAllAPIKeys)
 	root.GET("/:id/api-keys/:kid", h.findAPIKey)
 	root.POST("/:id/api-keys", h.createAPIKey)
 	root.PUT("/:id/api-keys/:kid", h.updateAPIKey)
+	root.PATCH("/:id/api-keys/:kid", h.patchAPIKey)
+	root.POST("/:id/api-keys/:kid/rotate", h.rotateAPIKey)
 	root.DELETE("/:id/api-keys/:kid", h.deleteAPIKey)
 }
 
@@ -39,14 +53,60 @@ func (h *UserHandler) findAll(c *gin.Context) {
 	realmID := c.Param("aid")
 	actor := reqctx.Actor(c)
 
-	users, err := h.service.GetUsers(ctx, actor, admin.ID(realmID))
+	filter, err := toUserFilter(c.Request.URL.Query(), c.QueryMap("attributes"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	options, err := toListOptions(c.Query("cursor"), c.Query("limit"), c.Query("sort"))
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
-	c.JSON(http.StatusOK, fromUsers(users))
+	page, err := h.service.GetUsers(ctx, actor, admin.ID(realmID), filter, options)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setPageHeaders(c, page.Total, page.NextCursor)
+
+	c.JSON(http.StatusOK, fromUsers(page.Items))
+}
+
+func (h *UserHandler) exportAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	filter, err := toUserFilter(c.Request.URL.Query(), c.QueryMap("attributes"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	exporter, err := newUserExporter(c, c.Query("format"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.ExportUsers(ctx, actor, admin.ID(realmID), filter, exporter.export); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := exporter.finish(); err != nil {
+		_ = c.Error(err)
+	}
 }
 
 func (h *UserHandler) findByID(c *gin.Context) {
@@ -62,6 +122,7 @@ func (h *UserHandler) findByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, fromUser(user))
 }
 
@@ -89,15 +150,54 @@ func (h *UserHandler) create(c *gin.Context) {
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(http.StatusCreated, fromUser(user))
 }
 
+func (h *UserHandler) importAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	actor := reqctx.Actor(c)
+
+	dryRun, err := toDryRun(c.Query("dryRun"))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	rows, err := readUserRows(c.ContentType(), body)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	report, err := h.service.ImportUsers(ctx, actor, admin.ID(realmID), rows, dryRun)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, fromUserImportReport(report))
+}
+
 func (h *UserHandler) update(c *gin.Context) {
 	ctx := c.Request.Context()
 	realmID := c.Param("aid")
 	id := c.Param("id")
 	actor := reqctx.Actor(c)
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
 	dtoUser := User{}
 
 	if err := c.ShouldBindJSON(&dtoUser); err != nil {
@@ -110,14 +210,60 @@ func (h *UserHandler) update(c *gin.Context) {
 
 	user.ID = admin.ID(id)
 	user.RealmID = admin.ID(realmID)
+	user.Version = version
 
-	user, err := h.service.UpdateUser(ctx, actor, user)
+	user, err = h.service.UpdateUser(ctx, actor, user)
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, fromUser(user))
+}
+
+func (h *UserHandler) patch(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	user, err := h.service.GetUser(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoUser, err := bindMergePatch(c, fromUser(user))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	user = toUser(dtoUser)
+
+	user.ID = admin.ID(id)
+	user.RealmID = admin.ID(realmID)
+	user.Version = version
+
+	user, err = h.service.UpdateUser(ctx, actor, user)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, fromUser(user))
 }
 
@@ -127,7 +273,14 @@ func (h *UserHandler) delete(c *gin.Context) {
 	id := c.Param("id")
 	actor := reqctx.Actor(c)
 
-	if err := h.service.DeleteUser(ctx, actor, admin.ID(realmID), admin.ID(id)); err != nil {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.DeleteUser(ctx, actor, admin.ID(realmID), admin.ID(id), version); err != nil {
 		_ = c.Error(err)
 
 		return
@@ -136,13 +289,53 @@ func (h *UserHandler) delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+func (h *UserHandler) restore(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	user, err := h.service.RestoreUser(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, fromUser(user))
+}
+
+func (h *UserHandler) findEffectiveRoles(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	id := c.Param("id")
+	actor := reqctx.Actor(c)
+
+	roles, err := h.service.GetEffectiveRoles(ctx, actor, admin.ID(realmID), admin.ID(id))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, fromEffectiveRoles(roles))
+}
+
 func (h *UserHandler) findAllAPIKeys(c *gin.Context) {
 	ctx := c.Request.Context()
 	realmID := c.Param("aid")
 	userID := c.Param("id")
 	actor := reqctx.Actor(c)
 
-	apiKeys, err := h.service.GetAPIKeys(ctx, actor, admin.ID(realmID), admin.ID(userID))
+	filter, err := toAPIKeyFilter(c.Query("unusedForDays"), time.Now())
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	apiKeys, err := h.service.GetAPIKeys(ctx, actor, admin.ID(realmID), admin.ID(userID), filter)
 	if err != nil {
 		_ = c.Error(err)
 
@@ -202,6 +395,13 @@ func (h *UserHandler) updateAPIKey(c *gin.Context) {
 	keyID := c.Param("kid")
 	actor := reqctx.Actor(c)
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
 	dtoAPIKey := APIKey{}
 
 	if err := c.ShouldBindJSON(&dtoAPIKey); err != nil {
@@ -214,16 +414,103 @@ func (h *UserHandler) updateAPIKey(c *gin.Context) {
 
 	apiKey.ID = admin.ID(keyID)
 
-	apiKey, err := h.service.UpdateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID), apiKey)
+	apiKey, err = h.service.UpdateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID), version, apiKey)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, version.Next())
+	c.JSON(http.StatusOK, fromAPIKey(apiKey))
+}
+
+func (h *UserHandler) patchAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	userID := c.Param("id")
+	keyID := c.Param("kid")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	apiKey, err := h.service.GetAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoAPIKey, err := bindMergePatch(c, fromAPIKey(apiKey))
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	apiKey = toAPIKey(dtoAPIKey)
+
+	apiKey.ID = admin.ID(keyID)
+
+	apiKey, err = h.service.UpdateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID), version, apiKey)
 	if err != nil {
 		_ = c.Error(err)
 
 		return
 	}
 
+	setETag(c, version.Next())
 	c.JSON(http.StatusOK, fromAPIKey(apiKey))
 }
 
+func (h *UserHandler) rotateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	realmID := c.Param("aid")
+	userID := c.Param("id")
+	keyID := c.Param("kid")
+	actor := reqctx.Actor(c)
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	dtoRotation := APIKeyRotation{}
+
+	if err := c.ShouldBindJSON(&dtoRotation); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	gracePeriod, err := toGracePeriod(dtoRotation.GracePeriod, h.gracePeriod)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	successor := toRotatedAPIKey(dtoRotation)
+
+	successor, err = h.service.RotateAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID),
+		version, successor, gracePeriod)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	setETag(c, version.Next())
+	c.JSON(http.StatusCreated, fromAPIKey(successor))
+}
+
 func (h *UserHandler) deleteAPIKey(c *gin.Context) {
 	ctx := c.Request.Context()
 	realmID := c.Param("aid")
@@ -231,7 +518,14 @@ func (h *UserHandler) deleteAPIKey(c *gin.Context) {
 	keyID := c.Param("kid")
 	actor := reqctx.Actor(c)
 
-	if err := h.service.DeleteAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID)); err != nil {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	if err := h.service.DeleteAPIKey(ctx, actor, admin.ID(realmID), admin.ID(userID), admin.ID(keyID), version); err != nil {
 		_ = c.Error(err)
 
 		return
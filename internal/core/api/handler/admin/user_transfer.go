@@ -0,0 +1,411 @@
+package admin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/gin-gonic/gin"
+)
+
+// Formats of the user imports and exports. The imports are selected by their
+// content type, the exports by the format query parameter.
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	mimeCSV    = "text/csv"
+	mimeNDJSON = "application/x-ndjson"
+)
+
+// Limits of the user imports. The rows beyond admin.MaxImportRows are not read.
+const (
+	maxImportBytes = 64 << 20
+	maxNDJSONLine  = 1 << 20
+)
+
+// rolesSeparator separates the custom roles in a CSV column.
+const rolesSeparator = ";"
+
+// userColumns are the CSV columns of the users. The attributes and the API keys
+// are JSON values. The columns id, apiKeys, createdAt and version are ignored by
+// the import, so that an export can be imported again.
+//
+//nolint:gochecknoglobals // read-only list
+var userColumns = []string{
+	"id", "bindId", "username", "email", "displayName", "description", "enabled",
+	"role", "roles", "attributes", "apiKeys", "createdAt", "version",
+}
+
+// userColumnFields maps the CSV columns and the JSON keys an import sets to the user
+// fields. The bind ID identifies the user and is not a field.
+//
+//nolint:gochecknoglobals // read-only map
+var userColumnFields = map[string]admin.UserField{
+	"username":    admin.UserFieldUsername,
+	"email":       admin.UserFieldEmail,
+	"displayName": admin.UserFieldDisplayName,
+	"description": admin.UserFieldDescription,
+	"enabled":     admin.UserFieldEnabled,
+	"role":        admin.UserFieldRole,
+	"roles":       admin.UserFieldRoles,
+	"attributes":  admin.UserFieldAttributes,
+}
+
+// readUserRows reads the users of an import in the format of its content type.
+// The lines that cannot be read are returned as failed rows, while an unreadable
+// body or one with more than admin.MaxImportRows rows fails the whole import.
+// The empty CSV cells of the enabled and attributes columns, and the null JSON
+// values, are not among the fields of a row.
+func readUserRows(contentType string, body io.Reader) ([]admin.UserImportRow, error) {
+	switch contentType {
+	case mimeCSV:
+		return readCSVUsers(body)
+	case mimeNDJSON:
+		return readNDJSONUsers(body)
+	default:
+		return nil, domain.NewBadRequestError("unsupported content type: %s", contentType)
+	}
+}
+
+// readCSVUsers reads the users of a CSV import. The first line holds the names of
+// the columns, in any order.
+func readCSVUsers(body io.Reader) ([]admin.UserImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, domain.NewBadRequestError("missing CSV header")
+		}
+
+		return nil, domain.NewBadRequestError("invalid CSV header: %v", err)
+	}
+
+	// spreadsheets may start the file with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	for i, column := range header {
+		if !slices.Contains(userColumns, column) {
+			return nil, domain.NewBadRequestError("unknown CSV column: %s", column)
+		}
+
+		if slices.Contains(header[:i], column) {
+			return nil, domain.NewBadRequestError("duplicate CSV column: %s", column)
+		}
+	}
+
+	var rows []admin.UserImportRow
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		if len(rows) == admin.MaxImportRows {
+			return nil, tooManyRowsError()
+		}
+
+		var parseErr *csv.ParseError
+
+		if errors.As(err, &parseErr) {
+			rows = append(rows, admin.UserImportRow{
+				Line: parseErr.StartLine,
+				Err:  domain.NewValidationError("invalid CSV: %v", parseErr.Err),
+			})
+
+			continue
+		}
+
+		if err != nil {
+			return nil, domain.NewBadRequestError("failed to read CSV: %v", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		user, fields, err := toCSVUser(header, record)
+
+		rows = append(rows, admin.UserImportRow{Line: line, User: user, Fields: fields, Err: err})
+	}
+}
+
+// toCSVUser converts a CSV record to a domain user, with the user fields it sets.
+func toCSVUser(header, record []string) (admin.User, []admin.UserField, error) {
+	if len(record) != len(header) {
+		return admin.User{}, nil, domain.NewValidationError("expected %d fields, got %d", len(header), len(record))
+	}
+
+	dto := User{}
+	fields := make([]admin.UserField, 0, len(header))
+
+	for i, column := range header {
+		value := record[i]
+
+		if field, ok := userColumnFields[column]; ok && !skipCSVCell(column, value) {
+			fields = append(fields, field)
+		}
+
+		switch column {
+		case "bindId":
+			dto.BindID = value
+		case "username":
+			dto.Username = value
+		case "email":
+			dto.Email = value
+		case "displayName":
+			dto.DisplayName = value
+		case "description":
+			dto.Description = value
+		case "enabled":
+			if value == "" {
+				continue
+			}
+
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return admin.User{}, nil, domain.NewValidationError("invalid enabled: %s", value)
+			}
+
+			dto.Enabled = enabled
+		case "role":
+			dto.Role = value
+		case "roles":
+			dto.Roles = splitRoles(value)
+		case "attributes":
+			if value == "" {
+				continue
+			}
+
+			if err := json.Unmarshal([]byte(value), &dto.Attributes); err != nil {
+				return admin.User{}, nil, domain.NewValidationError("invalid attributes: %v", err)
+			}
+		}
+	}
+
+	return toUser(dto), fields, nil
+}
+
+// skipCSVCell reports whether the cell is left out of the user, which is the case of
+// the empty cells of the enabled and attributes columns.
+func skipCSVCell(column, value string) bool {
+	return value == "" && (column == "enabled" || column == "attributes")
+}
+
+// readNDJSONUsers reads the users of a JSON lines import, one user object per line.
+// The blank lines are skipped.
+func readNDJSONUsers(body io.Reader) ([]admin.UserImportRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, maxNDJSONLine)
+
+	var rows []admin.UserImportRow
+
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		if len(rows) == admin.MaxImportRows {
+			return nil, tooManyRowsError()
+		}
+
+		dto := User{}
+		keys := map[string]json.RawMessage{}
+
+		if err := json.Unmarshal(data, &dto); err != nil {
+			rows = append(rows, admin.UserImportRow{Line: line, Err: domain.NewValidationError("invalid JSON: %v", err)})
+
+			continue
+		}
+
+		// the object has been read as a user already
+		_ = json.Unmarshal(data, &keys)
+
+		rows = append(rows, admin.UserImportRow{Line: line, User: toUser(dto), Fields: toJSONFields(keys)})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, domain.NewBadRequestError("failed to read JSON lines: %v", err)
+	}
+
+	return rows, nil
+}
+
+// toJSONFields returns the user fields set by the keys of a JSON user, in the order
+// of userColumns. The null values are left out.
+func toJSONFields(keys map[string]json.RawMessage) []admin.UserField {
+	fields := make([]admin.UserField, 0, len(keys))
+
+	for _, column := range userColumns {
+		field, ok := userColumnFields[column]
+		if !ok {
+			continue
+		}
+
+		if value, found := keys[column]; found && string(value) != "null" {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// tooManyRowsError returns the error of an import exceeding admin.MaxImportRows.
+func tooManyRowsError() error {
+	return domain.NewBadRequestError("import exceeds %d rows", admin.MaxImportRows)
+}
+
+// splitRoles splits the custom roles of a CSV column. An empty column has none.
+func splitRoles(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	return strings.Split(s, rolesSeparator)
+}
+
+// userExporter writes the exported users to the response as they are passed to it.
+// The response headers are only written with the first user, so that the errors
+// occurring before it are still reported with their status. An error occurring
+// later truncates the export.
+type userExporter struct {
+	c       *gin.Context
+	format  string
+	csv     *csv.Writer
+	started bool
+}
+
+// newUserExporter creates a userExporter writing the given format. An empty format
+// is JSON lines.
+func newUserExporter(c *gin.Context, format string) (*userExporter, error) {
+	switch format {
+	case "", formatNDJSON:
+		return &userExporter{c: c, format: formatNDJSON}, nil
+	case formatCSV:
+		return &userExporter{c: c, format: formatCSV, csv: csv.NewWriter(c.Writer)}, nil
+	default:
+		return nil, domain.NewBadRequestError("invalid format: %s", format)
+	}
+}
+
+// export writes the user.
+func (e *userExporter) export(user admin.User) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	dto := fromExportedUser(user)
+
+	if e.format == formatCSV {
+		record, err := toCSVRecord(dto)
+		if err != nil {
+			return err
+		}
+
+		if err := e.csv.Write(record); err != nil {
+			return fmt.Errorf("failed to write user: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := json.NewEncoder(e.c.Writer).Encode(dto); err != nil {
+		return fmt.Errorf("failed to write user: %w", err)
+	}
+
+	return nil
+}
+
+// finish completes the export, which may have no users.
+func (e *userExporter) finish() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	if e.format == formatCSV {
+		e.csv.Flush()
+
+		if err := e.csv.Error(); err != nil {
+			return fmt.Errorf("failed to write users: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// start writes the response headers, and the CSV header, once.
+func (e *userExporter) start() error {
+	if e.started {
+		return nil
+	}
+
+	e.started = true
+
+	if e.format == formatNDJSON {
+		e.c.Header("Content-Type", mimeNDJSON)
+		e.c.Status(http.StatusOK)
+
+		return nil
+	}
+
+	e.c.Header("Content-Type", mimeCSV)
+	e.c.Header("Content-Disposition", `attachment; filename="users.csv"`)
+	e.c.Status(http.StatusOK)
+
+	if err := e.csv.Write(userColumns); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	return nil
+}
+
+// toCSVRecord converts a DTO user to a CSV record in the order of userColumns.
+func toCSVRecord(user User) ([]string, error) {
+	attributes, err := toJSONColumn(user.Attributes, len(user.Attributes) == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := toJSONColumn(user.APIKeys, len(user.APIKeys) == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt := ""
+
+	if user.CreatedAt != nil {
+		createdAt = *user.CreatedAt
+	}
+
+	return []string{
+		user.ID, user.BindID, user.Username, user.Email, user.DisplayName, user.Description,
+		strconv.FormatBool(user.Enabled), user.Role, strings.Join(user.Roles, rolesSeparator),
+		attributes, apiKeys, createdAt, strconv.Itoa(user.Version),
+	}, nil
+}
+
+// toJSONColumn encodes the value of a CSV column holding JSON. An empty value is
+// an empty column.
+func toJSONColumn(value any, empty bool) (string, error) {
+	if empty {
+		return "", nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode CSV column: %w", err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,221 @@
+package admin
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readUserRows(t *testing.T) {
+	t.Parallel()
+
+	user1 := admin.User{
+		BindID:     "b1",
+		Username:   "user1",
+		Enabled:    true,
+		Roles:      []string{"editor", "viewer"},
+		Attributes: map[string]any{"level": 3.0},
+	}
+	user2 := admin.User{BindID: "b2", Username: "user2"}
+	fields1 := []admin.UserField{
+		admin.UserFieldUsername, admin.UserFieldEnabled, admin.UserFieldRoles, admin.UserFieldAttributes,
+	}
+	fields2 := []admin.UserField{admin.UserFieldUsername}
+
+	tests := map[string]struct {
+		contentType string
+		body        string
+		want        []admin.UserImportRow
+		wantErr     error
+	}{
+		"csv": {
+			contentType: mimeCSV,
+			body: "bindId,username,enabled,roles,attributes\n" +
+				"b1,user1,true,editor;viewer,\"{\"\"level\"\":3}\"\n" +
+				"b2,user2,,,\n",
+			want: []admin.UserImportRow{
+				{Line: 2, User: user1, Fields: fields1},
+				{Line: 3, User: user2, Fields: []admin.UserField{admin.UserFieldUsername, admin.UserFieldRoles}},
+			},
+		},
+		"csv-byteOrderMark": {
+			contentType: mimeCSV,
+			body:        "\ufeffbindId,username\nb2,user2\n",
+			want:        []admin.UserImportRow{{Line: 2, User: user2, Fields: fields2}},
+		},
+		"csv-exportOnlyColumns": {
+			contentType: mimeCSV,
+			body:        "id,bindId,username,createdAt,version\nu9,b2,user2,2024-01-02T03:04:05Z,7\n",
+			want:        []admin.UserImportRow{{Line: 2, User: user2, Fields: fields2}},
+		},
+		"csv-badRows": {
+			contentType: mimeCSV,
+			body:        "bindId,username,enabled\nb1,user1,maybe\nb2\nb2,user2,false\n",
+			want: []admin.UserImportRow{
+				{Line: 2, Err: domain.ValidationError{}},
+				{Line: 3, Err: domain.ValidationError{}},
+				{Line: 4, User: user2, Fields: []admin.UserField{admin.UserFieldUsername, admin.UserFieldEnabled}},
+			},
+		},
+		"csv-unknownColumn": {
+			contentType: mimeCSV,
+			body:        "bindId,shoeSize\nb1,44\n",
+			wantErr:     domain.BadRequestError{},
+		},
+		"csv-duplicateColumn": {
+			contentType: mimeCSV,
+			body:        "bindId,bindId\nb1,b1\n",
+			wantErr:     domain.BadRequestError{},
+		},
+		"csv-tooManyRows": {
+			contentType: mimeCSV,
+			body:        "bindId\n" + strings.Repeat("b1\n", admin.MaxImportRows+1),
+			wantErr:     domain.BadRequestError{},
+		},
+		"csv-empty": {
+			contentType: mimeCSV,
+			body:        "",
+			wantErr:     domain.BadRequestError{},
+		},
+		"ndjson": {
+			contentType: mimeNDJSON,
+			body: `{"bindId":"b1","username":"user1","enabled":true,"roles":["editor","viewer"],"attributes":{"level":3}}` +
+				"\n\n" + `{"bindId":"b2","username":"user2"}` + "\n",
+			want: []admin.UserImportRow{{Line: 1, User: user1, Fields: fields1}, {Line: 3, User: user2, Fields: fields2}},
+		},
+		"ndjson-nullValues": {
+			contentType: mimeNDJSON,
+			body:        `{"bindId":"b2","username":"user2","email":null,"attributes":null}`,
+			want:        []admin.UserImportRow{{Line: 1, User: user2, Fields: fields2}},
+		},
+		"ndjson-tooManyRows": {
+			contentType: mimeNDJSON,
+			body:        strings.Repeat(`{"bindId":"b1"}`+"\n", admin.MaxImportRows+1),
+			wantErr:     domain.BadRequestError{},
+		},
+		"ndjson-badLine": {
+			contentType: mimeNDJSON,
+			body:        "{\"bindId\":\n" + `{"bindId":"b2","username":"user2"}`,
+			want: []admin.UserImportRow{
+				{Line: 1, Err: domain.ValidationError{}},
+				{Line: 2, User: user2, Fields: fields2},
+			},
+		},
+		"unsupportedContentType": {
+			contentType: "application/xml",
+			body:        "<users/>",
+			wantErr:     domain.BadRequestError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rows, err := readUserRows(test.contentType, strings.NewReader(test.body))
+
+			if test.wantErr != nil {
+				require.ErrorAs(t, err, &test.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, rows, len(test.want))
+
+			for i, want := range test.want {
+				require.Equal(t, want.Line, rows[i].Line)
+
+				if want.Err != nil {
+					require.ErrorAs(t, rows[i].Err, &want.Err)
+
+					continue
+				}
+
+				require.NoError(t, rows[i].Err)
+				require.Equal(t, want.User, rows[i].User)
+				require.Equal(t, want.Fields, rows[i].Fields)
+			}
+		})
+	}
+}
+
+func Test_userExporter(t *testing.T) {
+	t.Parallel()
+
+	user := admin.User{
+		ID:         "u1",
+		BindID:     "b1",
+		Username:   "user1",
+		Enabled:    true,
+		Role:       admin.SystemRoleUser,
+		Roles:      []string{"editor", "viewer"},
+		Attributes: map[string]any{"level": 3.0},
+		APIKeys:    []admin.APIKey{{ID: "k1", Name: "key1", Prefix: "pre"}},
+		Version:    2,
+	}
+
+	tests := map[string]struct {
+		format      string
+		users       []admin.User
+		contentType string
+		want        string
+		wantErr     error
+	}{
+		"ndjson": {
+			format:      formatNDJSON,
+			users:       []admin.User{user},
+			contentType: mimeNDJSON,
+			want:        `"apiKeys":[{"id":"k1","name":"key1"`,
+		},
+		"csv": {
+			format:      formatCSV,
+			users:       []admin.User{user},
+			contentType: mimeCSV,
+			want: strings.Join(userColumns, ",") + "\n" +
+				`u1,b1,user1,,,,true,user,editor;viewer,"{""level"":3}","[{""id"":""k1"",""name"":""key1""`,
+		},
+		"csv-noUsers": {
+			format:      formatCSV,
+			contentType: mimeCSV,
+			want:        strings.Join(userColumns, ",") + "\n",
+		},
+		"invalidFormat": {
+			format:  "xml",
+			wantErr: domain.BadRequestError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+
+			exporter, err := newUserExporter(c, test.format)
+
+			if test.wantErr != nil {
+				require.ErrorAs(t, err, &test.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			for _, user := range test.users {
+				require.NoError(t, exporter.export(user))
+			}
+
+			require.NoError(t, exporter.finish())
+			require.Equal(t, test.contentType, recorder.Header().Get("Content-Type"))
+			require.Contains(t, recorder.Body.String(), test.want)
+			require.NotContains(t, recorder.Body.String(), `"key":"`)
+		})
+	}
+}
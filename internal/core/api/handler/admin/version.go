@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/gin-gonic/gin"
+)
+
+// Headers of the optimistic concurrency control. The entity tag of an entity is
+// its quoted version; the updates and the deletes must match it, so that they
+// do not overwrite the changes made since the entity was read. The API keys are
+// stored with their user or daemon, so their changes match the tag of the owner.
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// setETag sets the entity tag of the version of the entity.
+func setETag(c *gin.Context, version admin.Version) {
+	c.Header(headerETag, strconv.Quote(strconv.Itoa(int(version))))
+}
+
+// ifMatchVersion returns the version of the entity tag the request must match.
+// The tag is required, and it must be a single strong entity tag.
+func ifMatchVersion(c *gin.Context) (admin.Version, error) {
+	value := strings.TrimSpace(c.GetHeader(headerIfMatch))
+	if value == "" {
+		return 0, domain.NewPreconditionRequiredError("the %s header is required", headerIfMatch)
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		return 0, domain.NewBadRequestError("invalid %s header: %s", headerIfMatch, value)
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < int(admin.FirstVersion) {
+		return 0, domain.NewBadRequestError("invalid %s header: %s", headerIfMatch, value)
+	}
+
+	return admin.Version(version), nil
+}
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ifMatchVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		header  string
+		want    admin.Version
+		wantErr error
+	}{
+		"valid":        {header: `"3"`, want: 3},
+		"spaces":       {header: ` "12" `, want: 12},
+		"missing":      {header: "", wantErr: domain.PreconditionRequiredError{}},
+		"unquoted":     {header: "3", wantErr: domain.BadRequestError{}},
+		"weak":         {header: `W/"3"`, wantErr: domain.BadRequestError{}},
+		"wildcard":     {header: "*", wantErr: domain.BadRequestError{}},
+		"notANumber":   {header: `"abc"`, wantErr: domain.BadRequestError{}},
+		"zeroVersion":  {header: `"0"`, wantErr: domain.BadRequestError{}},
+		"severalQuote": {header: `"1", "2"`, wantErr: domain.BadRequestError{}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+			c.Request.Header.Set(headerIfMatch, test.header)
+
+			version, err := ifMatchVersion(c)
+
+			if test.wantErr != nil {
+				require.ErrorAs(t, err, &test.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.want, version)
+		})
+	}
+}
+
+func Test_setETag(t *testing.T) {
+	t.Parallel()
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	setETag(c, 7)
+
+	require.Equal(t, `"7"`, recorder.Header().Get(headerETag))
+}
+
+func TestAPIKeyRoutes_ifMatch(t *testing.T) {
+	t.Parallel()
+
+	const storedVersion = admin.Version(3)
+
+	routes := map[string]struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		"update": {method: http.MethodPut, path: "/api-keys/k1", body: `{"name":"key"}`, status: http.StatusOK},
+		"patch":  {method: http.MethodPatch, path: "/api-keys/k1", body: `{"name":"key"}`, status: http.StatusOK},
+		"rotate": {method: http.MethodPost, path: "/api-keys/k1/rotate", body: `{}`, status: http.StatusCreated},
+		"delete": {method: http.MethodDelete, path: "/api-keys/k1", status: http.StatusNoContent},
+	}
+
+	tests := map[string]struct {
+		ifMatch    string
+		wantStatus int
+	}{
+		"current": {ifMatch: `"3"`},
+		"missing": {wantStatus: http.StatusPreconditionRequired},
+		"stale":   {ifMatch: `"2"`, wantStatus: http.StatusPreconditionFailed},
+	}
+
+	owners := map[string]func(root gin.IRouter){
+		"user": func(root gin.IRouter) {
+			NewUserHandler(stubUserService{version: storedVersion}, time.Hour).Bind(root)
+		},
+		"daemon": func(root gin.IRouter) {
+			NewDaemonHandler(stubDaemonService{version: storedVersion}, time.Hour).Bind(root)
+		},
+	}
+
+	for owner, bind := range owners {
+		router := gin.New()
+		router.Use(middleware.ErrorMapper())
+		bind(router.Group("/realms/:aid/owners"))
+
+		for route, r := range routes {
+			for name, test := range tests {
+				t.Run(owner+"-"+route+"-"+name, func(t *testing.T) {
+					t.Parallel()
+
+					req := httptest.NewRequest(r.method, "/realms/a1/owners/o1"+r.path, strings.NewReader(r.body))
+					req.Header.Set("Content-Type", "application/json")
+
+					if test.ifMatch != "" {
+						req.Header.Set(headerIfMatch, test.ifMatch)
+					}
+
+					recorder := httptest.NewRecorder()
+					router.ServeHTTP(recorder, req)
+
+					if test.wantStatus != 0 {
+						require.Equal(t, test.wantStatus, recorder.Code)
+
+						return
+					}
+
+					require.Equal(t, r.status, recorder.Code)
+
+					// the owner is stored with the next version
+					if r.method != http.MethodDelete {
+						require.Equal(t, `"4"`, recorder.Header().Get(headerETag))
+					}
+				})
+			}
+		}
+	}
+}
+
+// stubUserService is a user service storing the API keys of a user with the given version.
+type stubUserService struct {
+	admin.UserService
+	version admin.Version
+}
+
+func (s stubUserService) GetAPIKey(_ context.Context, _ admin.Actor, _, _, id admin.ID) (admin.APIKey, error) {
+	return admin.APIKey{ID: id}, nil
+}
+
+func (s stubUserService) UpdateAPIKey(
+	_ context.Context, _ admin.Actor, _, _, _ admin.ID, version admin.Version, apiKey admin.APIKey,
+) (admin.APIKey, error) {
+	return apiKey, checkStubVersion(s.version, version)
+}
+
+func (s stubUserService) RotateAPIKey(
+	_ context.Context, _ admin.Actor, _, _, _ admin.ID, version admin.Version, successor admin.APIKey, _ time.Duration,
+) (admin.APIKey, error) {
+	return successor, checkStubVersion(s.version, version)
+}
+
+func (s stubUserService) DeleteAPIKey(_ context.Context, _ admin.Actor, _, _, _ admin.ID, version admin.Version) error {
+	return checkStubVersion(s.version, version)
+}
+
+// stubDaemonService is a daemon service storing the API keys of a daemon with the given version.
+type stubDaemonService struct {
+	admin.DaemonService
+	version admin.Version
+}
+
+func (s stubDaemonService) GetAPIKey(_ context.Context, _ admin.Actor, _, _, id admin.ID) (admin.APIKey, error) {
+	return admin.APIKey{ID: id}, nil
+}
+
+func (s stubDaemonService) UpdateAPIKey(
+	_ context.Context, _ admin.Actor, _, _, _ admin.ID, version admin.Version, apiKey admin.APIKey,
+) (admin.APIKey, error) {
+	return apiKey, checkStubVersion(s.version, version)
+}
+
+func (s stubDaemonService) RotateAPIKey(
+	_ context.Context, _ admin.Actor, _, _, _ admin.ID, version admin.Version, successor admin.APIKey, _ time.Duration,
+) (admin.APIKey, error) {
+	return successor, checkStubVersion(s.version, version)
+}
+
+func (s stubDaemonService) DeleteAPIKey(_ context.Context, _ admin.Actor, _, _, _ admin.ID, version admin.Version) error {
+	return checkStubVersion(s.version, version)
+}
+
+func checkStubVersion(stored, version admin.Version) error {
+	if version != stored {
+		return domain.NewStaleVersionError("stale version %d", version)
+	}
+
+	return nil
+}
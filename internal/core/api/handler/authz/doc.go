@@ -0,0 +1,2 @@
+// Package authz implements REST API handlers for the authorization decisions.
+package authz
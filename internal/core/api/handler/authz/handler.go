@@ -0,0 +1,196 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+
+	isclient "github.com/energimind/identity-server/client"
+	"github.com/energimind/identity-server/internal/core/api"
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/domain/session"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchChecks is the maximum number of checks in a batch request.
+const maxBatchChecks = 100
+
+// Handler is a handler that decides the authorization requests of the downstream services.
+type Handler struct {
+	service        admin.AuthzService
+	sessionService session.Service
+	userFinder     admin.UserFinder
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(service admin.AuthzService, sessionService session.Service, userFinder admin.UserFinder) *Handler {
+	return &Handler{
+		service:        service,
+		sessionService: sessionService,
+		userFinder:     userFinder,
+	}
+}
+
+// BindWithMiddlewares binds the Handler to a root provided by a router.
+//
+// Requests authenticated with a realm API key need the authz check scope. The API keys
+// of users must list it explicitly to check other subjects than the user itself.
+func (h *Handler) BindWithMiddlewares(root gin.IRouter, mws api.Middlewares) {
+	root.POST("/check", mws.RequireScope(admin.ScopeAuthzCheck), h.check)
+	root.POST("/check/batch", mws.RequireScope(admin.ScopeAuthzCheck), h.checkBatch)
+}
+
+// check decides a single authorization request.
+func (h *Handler) check(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	check := isclient.AuthzCheck{}
+
+	if err := c.ShouldBindJSON(&check); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	subject, err := h.resolveSubject(c, check.SessionID, check.Principal)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	request := admin.AuthzRequest{Action: check.Action, Resource: check.Resource}
+
+	decisions, err := h.service.Check(ctx, subject, []admin.AuthzRequest{request})
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, toClientDecision(decisions[0]))
+}
+
+// checkBatch decides several authorization requests of the same subject.
+// The decisions are returned in the order of the checks.
+func (h *Handler) checkBatch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	batch := isclient.AuthzBatch{}
+
+	if err := c.ShouldBindJSON(&batch); err != nil {
+		_ = c.Error(domain.NewBadRequestError("invalid request body: %v", err))
+
+		return
+	}
+
+	if len(batch.Checks) == 0 || len(batch.Checks) > maxBatchChecks {
+		_ = c.Error(domain.NewBadRequestError("batch must contain between 1 and %d checks", maxBatchChecks))
+
+		return
+	}
+
+	subject, err := h.resolveSubject(c, batch.SessionID, batch.Principal)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	requests := make([]admin.AuthzRequest, len(batch.Checks))
+
+	for i, check := range batch.Checks {
+		requests[i] = admin.AuthzRequest{Action: check.Action, Resource: check.Resource}
+	}
+
+	decisions, err := h.service.Check(ctx, subject, requests)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"decisions": toClientDecisions(decisions)})
+}
+
+// resolveSubject returns the subject of the authorization requests: the user of the
+// session, the given principal, or the principal owning the API key of the request.
+// The principal of the request must be able to access the realm of the subject.
+//
+// The decisions for a subject reveal its permissions, so only the daemons of the consuming
+// services can check other subjects: the API key of a user can only check the user itself.
+func (h *Handler) resolveSubject(
+	c *gin.Context,
+	sessionID string,
+	principal *isclient.AuthzPrincipal,
+) (admin.AuthzSubject, error) {
+	subject, err := h.lookupSubject(c, sessionID, principal)
+	if err != nil {
+		return admin.AuthzSubject{}, err
+	}
+
+	caller, ok := reqctx.APIKeyPrincipal(c)
+	if !ok {
+		return subject, nil
+	}
+
+	if caller.Kind != admin.PrincipalKindDaemon && !isCallerSubject(caller, subject) {
+		return admin.AuthzSubject{}, domain.NewAccessDeniedError("%s %s can only authorize itself",
+			caller.Kind, caller.OwnerID)
+	}
+
+	if !caller.CanAccessRealm(subject.RealmID) {
+		return admin.AuthzSubject{}, domain.NewAccessDeniedError("%s %s cannot authorize subjects of realm %s",
+			caller.Kind, caller.OwnerID, subject.RealmID)
+	}
+
+	return subject, nil
+}
+
+// isCallerSubject returns true if the subject is the principal of the request.
+func isCallerSubject(caller admin.APIKeyPrincipal, subject admin.AuthzSubject) bool {
+	return subject.Kind == caller.Kind && subject.RealmID == caller.RealmID && subject.ID == caller.OwnerID
+}
+
+func (h *Handler) lookupSubject(
+	c *gin.Context,
+	sessionID string,
+	principal *isclient.AuthzPrincipal,
+) (admin.AuthzSubject, error) {
+	switch {
+	case sessionID != "" && principal != nil:
+		return admin.AuthzSubject{}, domain.NewBadRequestError("either a session or a principal must be given, not both")
+	case sessionID != "":
+		return h.sessionSubject(c.Request.Context(), sessionID)
+	case principal != nil:
+		return admin.AuthzSubject{
+			Kind:    admin.PrincipalKind(principal.Type),
+			RealmID: admin.ID(principal.RealmID),
+			ID:      admin.ID(principal.ID),
+		}, nil
+	}
+
+	caller, ok := reqctx.APIKeyPrincipal(c)
+	if !ok {
+		return admin.AuthzSubject{}, domain.NewBadRequestError("a session or a principal must be given")
+	}
+
+	return admin.AuthzSubject{Kind: caller.Kind, RealmID: caller.RealmID, ID: caller.OwnerID}, nil
+}
+
+func (h *Handler) sessionSubject(ctx context.Context, sessionID string) (admin.AuthzSubject, error) {
+	sess, err := h.sessionService.Session(ctx, sessionID)
+	if err != nil {
+		return admin.AuthzSubject{}, err //nolint:wrapcheck // already a domain error
+	}
+
+	realmID := admin.ID(sess.Header.RealmID)
+
+	user, err := h.userFinder.GetUserByBindIDSys(ctx, realmID, sess.User.BindID)
+	if err != nil {
+		return admin.AuthzSubject{}, err //nolint:wrapcheck // already a domain error
+	}
+
+	return admin.AuthzSubject{Kind: admin.PrincipalKindUser, RealmID: realmID, ID: user.ID}, nil
+}
@@ -0,0 +1,106 @@
+package authz
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	isclient "github.com/energimind/identity-server/client"
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_resolveSubject(t *testing.T) {
+	t.Parallel()
+
+	userKey := func(scopes ...string) *admin.APIKeyPrincipal {
+		return &admin.APIKeyPrincipal{
+			Kind:    admin.PrincipalKindUser,
+			RealmID: "r1",
+			OwnerID: "u1",
+			APIKey:  admin.APIKey{ID: "k1", Scopes: scopes},
+		}
+	}
+
+	daemonKey := &admin.APIKeyPrincipal{
+		Kind:    admin.PrincipalKindDaemon,
+		RealmID: "r1",
+		OwnerID: "d1",
+		APIKey:  admin.APIKey{ID: "k2"},
+	}
+
+	self := &isclient.AuthzPrincipal{Type: "user", ID: "u1", RealmID: "r1"}
+	other := &isclient.AuthzPrincipal{Type: "user", ID: "u2", RealmID: "r1"}
+	foreign := &isclient.AuthzPrincipal{Type: "user", ID: "u3", RealmID: "r2"}
+
+	tests := map[string]struct {
+		caller      *admin.APIKeyPrincipal
+		principal   *isclient.AuthzPrincipal
+		wantSubject admin.AuthzSubject
+		wantError   error
+	}{
+		"daemon-otherSubject": {
+			caller:      daemonKey,
+			principal:   other,
+			wantSubject: admin.AuthzSubject{Kind: admin.PrincipalKindUser, RealmID: "r1", ID: "u2"},
+		},
+		"daemon-foreignRealm": {
+			caller:    daemonKey,
+			principal: foreign,
+			wantError: domain.AccessDeniedError{},
+		},
+		"user-itself": {
+			caller:      userKey(),
+			wantSubject: admin.AuthzSubject{Kind: admin.PrincipalKindUser, RealmID: "r1", ID: "u1"},
+		},
+		"user-itselfAsPrincipal": {
+			caller:      userKey(),
+			principal:   self,
+			wantSubject: admin.AuthzSubject{Kind: admin.PrincipalKindUser, RealmID: "r1", ID: "u1"},
+		},
+		"user-otherSubject": {
+			caller:    userKey(),
+			principal: other,
+			wantError: domain.AccessDeniedError{},
+		},
+		"user-otherSubject-explicitScope": {
+			caller:    userKey(admin.ScopeAuthzCheck),
+			principal: other,
+			wantError: domain.AccessDeniedError{},
+		},
+		"user-foreignRealm-explicitScope": {
+			caller:    userKey(admin.ScopeAuthzCheck),
+			principal: foreign,
+			wantError: domain.AccessDeniedError{},
+		},
+	}
+
+	handler := NewHandler(nil, nil, nil)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/check", nil)
+
+			if test.caller != nil {
+				reqctx.SetAPIKeyPrincipal(c, *test.caller)
+			}
+
+			subject, err := handler.resolveSubject(c, "", test.principal)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wantSubject, subject)
+		})
+	}
+}
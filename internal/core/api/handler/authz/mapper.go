@@ -0,0 +1,38 @@
+package authz
+
+import (
+	isclient "github.com/energimind/identity-server/client"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+func toClientDecision(decision admin.AuthzDecision) isclient.AuthzDecision {
+	result := isclient.AuthzDecision{
+		Action:   decision.Request.Action,
+		Resource: decision.Request.Resource,
+		Allowed:  decision.Allowed,
+		Decision: string(admin.PolicyEffectDeny),
+	}
+
+	if decision.Allowed {
+		result.Decision = string(admin.PolicyEffectAllow)
+	}
+
+	if decision.Policy.ID != "" {
+		result.Policy = &isclient.PolicyRef{
+			ID:   decision.Policy.ID.String(),
+			Code: decision.Policy.Code,
+		}
+	}
+
+	return result
+}
+
+func toClientDecisions(decisions []admin.AuthzDecision) []isclient.AuthzDecision {
+	result := make([]isclient.AuthzDecision, len(decisions))
+
+	for i, decision := range decisions {
+		result[i] = toClientDecision(decision)
+	}
+
+	return result
+}
@@ -1,40 +1,45 @@
 package session
 
 import (
-	"encoding/base64"
-	"fmt"
 	"net/http"
-	"strings"
 
+	"github.com/energimind/identity-server/internal/core/api"
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
 	"github.com/energimind/identity-server/internal/core/domain/session"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
 	"github.com/gin-gonic/gin"
 )
 
 // Handler is a handler that handles auth requests and sessions.
 type Handler struct {
-	service    session.Service
-	userFinder admin.UserFinder
+	service     session.Service
+	userFinder  admin.UserFinder
+	groupFinder admin.GroupFinder
 }
 
 // NewHandler returns a new Handler.
-func NewHandler(service session.Service, userFinder admin.UserFinder) *Handler {
+func NewHandler(service session.Service, userFinder admin.UserFinder, groupFinder admin.GroupFinder) *Handler {
 	return &Handler{
-		service:    service,
-		userFinder: userFinder,
+		service:     service,
+		userFinder:  userFinder,
+		groupFinder: groupFinder,
 	}
 }
 
-// Bind binds the Handler to a root provided by a router.
-func (h *Handler) Bind(root gin.IRouter) {
-	root.GET("/:sid", h.getSession)
-	root.PUT("/:sid/refresh", h.refreshSession)
-	root.DELETE("/:sid", h.deleteSession)
+// BindWithMiddlewares binds the Handler to a root provided by a router.
+//
+// Requests authenticated with a realm API key need the matching session scope.
+// The API keys of users must list it explicitly.
+func (h *Handler) BindWithMiddlewares(root gin.IRouter, mws api.Middlewares) {
+	root.GET("/:sid", mws.RequireScope(admin.ScopeSessionsRead), h.getSession)
+	root.PUT("/:sid/refresh", mws.RequireScope(admin.ScopeSessionsWrite), h.refreshSession)
+	root.DELETE("/:sid", mws.RequireScope(admin.ScopeSessionsWrite), h.deleteSession)
 	root.GET("/verify", h.verifyAPIKey)
 }
 
 // getSession returns the session associated with the session ID.
+// The user of the session is returned with the groups it is a member of.
 func (h *Handler) getSession(c *gin.Context) {
 	ctx := c.Request.Context()
 	sessionID := c.Param("sid")
@@ -46,6 +51,12 @@ func (h *Handler) getSession(c *gin.Context) {
 		return
 	}
 
+	if err := checkSessionRealm(c, sess, admin.ScopeSessionsRead); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
 	realmID := sess.Header.RealmID
 	userBindID := sess.User.BindID
 
@@ -56,7 +67,14 @@ func (h *Handler) getSession(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, toClientSession(sess, user))
+	groups, err := h.groupFinder.GetMemberGroupsSys(ctx, admin.ID(realmID), user.ID)
+	if err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, toClientSession(sess, user, groups))
 }
 
 // refreshSession refreshes the session associated with the session ID.
@@ -64,6 +82,12 @@ func (h *Handler) refreshSession(c *gin.Context) {
 	ctx := c.Request.Context()
 	sessionID := c.Param("sid")
 
+	if err := h.checkSessionAccess(c, sessionID, admin.ScopeSessionsWrite); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
 	refreshed, err := h.service.Refresh(ctx, sessionID)
 	if err != nil {
 		_ = c.Error(err)
@@ -80,6 +104,12 @@ func (h *Handler) deleteSession(c *gin.Context) {
 	ctx := c.Request.Context()
 	sessionID := c.Param("sid")
 
+	if err := h.checkSessionAccess(c, sessionID, admin.ScopeSessionsWrite); err != nil {
+		_ = c.Error(err)
+
+		return
+	}
+
 	err := h.service.Logout(ctx, sessionID)
 	if err != nil {
 		_ = c.Error(err)
@@ -91,58 +121,79 @@ func (h *Handler) deleteSession(c *gin.Context) {
 }
 
 // verifyAPIKey verifies the API key.
+//
+// The API key of the request has already been verified by the middleware, so the
+// principal owning it is taken from the request context. The optional scope query
+// parameters list the scopes the API key must grant. The principal is returned,
+// together with the API key metadata.
 func (h *Handler) verifyAPIKey(c *gin.Context) {
-	ctx := c.Request.Context()
+	scopes := c.QueryArray("scope")
 
-	realmID, apiKey, err := decodeAuthHeader(c.GetHeader("Authorization"))
-	if err != nil {
-		_ = c.Error(domain.NewBadRequestError("invalid authorization header: %v", err))
+	principal, ok := reqctx.APIKeyPrincipal(c)
+	if !ok {
+		_ = c.Error(domain.NewBadRequestError("request is not authenticated with a realm API key"))
 
 		return
 	}
 
-	err = h.service.VerifyAPIKey(ctx, admin.ID(realmID), apiKey)
-	if err != nil {
-		_ = c.Error(domain.NewUnauthorizedError("invalid API key: %v", err))
+	if !principal.APIKey.HasScopes(scopes...) {
+		_ = c.Error(domain.NewAccessDeniedError("API key %s does not grant scopes %v", principal.APIKey.ID, scopes))
 
 		return
 	}
 
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, toClientPrincipal(principal))
 }
 
-//nolint:goerr113 // no need to wrap this internal error
-func decodeAuthHeader(header string) (string, string, error) {
-	if header == "" {
-		return "", "", fmt.Errorf("authorization header must not be empty")
+// checkSessionAccess checks if the request may access the session associated with the session ID.
+func (h *Handler) checkSessionAccess(c *gin.Context, sessionID, scope string) error {
+	principal, ok := reqctx.APIKeyPrincipal(c)
+	if !ok {
+		return nil
 	}
 
-	const partCount = 2 // Bearer token
+	// deny users before looking the session up, not to reveal whether it exists
+	if err := checkDaemon(principal, scope); err != nil {
+		return err
+	}
 
-	parts := strings.SplitN(header, " ", partCount)
-	if len(parts) != partCount || strings.ToLower(parts[0]) != "bearer" {
-		return "", "", fmt.Errorf("invalid authorization header format")
+	sess, err := h.service.Session(c.Request.Context(), sessionID)
+	if err != nil {
+		return err //nolint:wrapcheck // already a domain error
 	}
 
-	return decodeAPIKeyToken(parts[1])
+	return checkSessionRealm(c, sess, scope)
 }
 
-//nolint:goerr113 // no need to wrap this internal error
-func decodeAPIKeyToken(token string) (string, string, error) {
-	decoded, err := base64.StdEncoding.DecodeString(token)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to decode API key token: %w", err)
+// checkSessionRealm checks if the principal of the request can access the realm of the session.
+// Daemons can access the sessions of their own realm and of the realms they serve.
+//
+// The sessions of a realm belong to all its users, so they are only served to the daemons
+// of the consuming services: the API keys of users cannot access sessions.
+func checkSessionRealm(c *gin.Context, sess session.Session, scope string) error {
+	principal, ok := reqctx.APIKeyPrincipal(c)
+	if !ok {
+		return nil
 	}
 
-	const partCount = 2 // realmID:apiKey
+	if err := checkDaemon(principal, scope); err != nil {
+		return err
+	}
 
-	parts := strings.Split(string(decoded), ":")
-	if len(parts) != partCount {
-		return "", "", fmt.Errorf("invalid API key token format")
+	if !principal.CanAccessRealm(admin.ID(sess.Header.RealmID)) {
+		return domain.NewAccessDeniedError("%s %s cannot access sessions of realm %s",
+			principal.Kind, principal.OwnerID, sess.Header.RealmID)
 	}
 
-	realmID := parts[0]
-	apiKey := parts[1]
+	return nil
+}
+
+// checkDaemon checks if the principal of the request is a daemon.
+func checkDaemon(principal admin.APIKeyPrincipal, scope string) error {
+	if principal.Kind != admin.PrincipalKindDaemon {
+		return domain.NewAccessDeniedError("%s %s cannot use scope %s: sessions are only served to daemons",
+			principal.Kind, principal.OwnerID, scope)
+	}
 
-	return realmID, apiKey, nil
+	return nil
 }
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/domain/session"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkSessionRealm(t *testing.T) {
+	t.Parallel()
+
+	sess := session.Session{Header: session.Header{SessionID: "s1", RealmID: "r1"}}
+
+	userKey := func(scopes ...string) *admin.APIKeyPrincipal {
+		return &admin.APIKeyPrincipal{
+			Kind:    admin.PrincipalKindUser,
+			RealmID: "r1",
+			OwnerID: "u1",
+			APIKey:  admin.APIKey{ID: "k1", Scopes: scopes},
+		}
+	}
+
+	daemonKey := func(realmID admin.ID, allowedRealms ...admin.ID) *admin.APIKeyPrincipal {
+		return &admin.APIKeyPrincipal{
+			Kind:          admin.PrincipalKindDaemon,
+			RealmID:       realmID,
+			OwnerID:       "d1",
+			AllowedRealms: allowedRealms,
+			APIKey:        admin.APIKey{ID: "k2"},
+		}
+	}
+
+	tests := map[string]struct {
+		principal *admin.APIKeyPrincipal
+		scope     string
+		wantError error
+	}{
+		"noAPIKey": {
+			scope: admin.ScopeSessionsWrite,
+		},
+		"daemon": {
+			principal: daemonKey("r1"),
+			scope:     admin.ScopeSessionsWrite,
+		},
+		"daemon-allowedRealm": {
+			principal: daemonKey("r0", "r1"),
+			scope:     admin.ScopeSessionsRead,
+		},
+		"daemon-foreignRealm": {
+			principal: daemonKey("r2"),
+			scope:     admin.ScopeSessionsRead,
+			wantError: domain.AccessDeniedError{},
+		},
+		"user-unrestrictedKey": {
+			principal: userKey(),
+			scope:     admin.ScopeSessionsWrite,
+			wantError: domain.AccessDeniedError{},
+		},
+		"user-otherScope": {
+			principal: userKey(admin.ScopeSessionsRead),
+			scope:     admin.ScopeSessionsWrite,
+			wantError: domain.AccessDeniedError{},
+		},
+		"user-explicitScope": {
+			principal: userKey(admin.ScopeSessionsRead),
+			scope:     admin.ScopeSessionsRead,
+			wantError: domain.AccessDeniedError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/s1", nil)
+
+			if test.principal != nil {
+				reqctx.SetAPIKeyPrincipal(c, *test.principal)
+			}
+
+			err := checkSessionRealm(c, sess, test.scope)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
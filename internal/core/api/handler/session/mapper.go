@@ -1,12 +1,25 @@
 package session
 
 import (
+	"time"
+
 	isclient "github.com/energimind/identity-server/client"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
 	"github.com/energimind/identity-server/internal/core/domain/session"
 )
 
-func toClientSession(session session.Session, user admin.User) isclient.Session {
+func toClientSession(session session.Session, user admin.User, groups []admin.Group) isclient.Session {
+	clientGroups := make([]isclient.Group, len(groups))
+
+	for i, group := range groups {
+		clientGroups[i] = isclient.Group{
+			ID:         group.ID.String(),
+			Code:       group.Code,
+			Name:       group.Name,
+			Attributes: group.Attributes,
+		}
+	}
+
 	return isclient.Session{
 		Header: isclient.Header{
 			SessionID: session.Header.SessionID,
@@ -17,6 +30,37 @@ func toClientSession(session session.Session, user admin.User) isclient.Session
 			Username:    user.Username,
 			DisplayName: user.DisplayName,
 			Email:       user.Email,
+			Groups:      clientGroups,
+			Attributes:  user.Attributes,
+		},
+	}
+}
+
+func toClientPrincipal(principal admin.APIKeyPrincipal) isclient.Principal {
+	allowedRealms := make([]string, len(principal.AllowedRealms))
+
+	for i, realmID := range principal.AllowedRealms {
+		allowedRealms[i] = realmID.String()
+	}
+
+	var expiresAt *time.Time
+
+	if !principal.APIKey.ExpiresAt.IsZero() {
+		expiresAt = &principal.APIKey.ExpiresAt
+	}
+
+	return isclient.Principal{
+		Type:          string(principal.Kind),
+		ID:            principal.OwnerID.String(),
+		Code:          principal.Code,
+		RealmID:       principal.RealmID.String(),
+		Role:          principal.Role.String(),
+		AllowedRealms: allowedRealms,
+		APIKey: isclient.APIKeyInfo{
+			ID:        principal.APIKey.ID.String(),
+			Name:      principal.APIKey.Name,
+			ExpiresAt: expiresAt,
+			Scopes:    principal.APIKey.Scopes,
 		},
 	}
 }
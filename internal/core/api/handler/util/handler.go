@@ -24,6 +24,8 @@ func (h *Handler) Bind(root gin.IRouter) {
 	root.GET("/key", h.getKey)
 }
 
+// getKey returns a random key. It is not formatted as an API key: the API keys are
+// only minted by the server when they are created.
 func (h *Handler) getKey(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"key": h.idgen.GenerateID()})
 }
@@ -17,12 +17,19 @@ type handlerWithMiddleware interface {
 
 // Handlers is a collection of handler that will be bound to the router.
 type Handlers struct {
-	Auth     anyHandler
-	Realm    anyHandler
-	Provider anyHandler
-	User     anyHandler
-	Daemon   anyHandler
-	Session  anyHandler
-	Util     anyHandler
-	Health   anyHandler
+	Auth       anyHandler
+	Realm      anyHandler
+	Provider   anyHandler
+	User       anyHandler
+	Daemon     anyHandler
+	Group      anyHandler
+	Membership anyHandler
+	Policy     anyHandler
+	Rule       anyHandler
+	Search     anyHandler
+	Lockout    anyHandler
+	Session    anyHandler
+	Authz      anyHandler
+	Util       anyHandler
+	Health     anyHandler
 }
@@ -3,7 +3,14 @@ package api
 import "github.com/gin-gonic/gin"
 
 // Middlewares is a collection of middlewares that will be bound to the router.
+//
+// RateLimitAuth limits the login flow, RateLimitSessions limits the sessions API per
+// client IP and RateLimitSessionsRealm per realm of the verified API key.
 type Middlewares struct {
-	RequireActor  gin.HandlerFunc
-	RequireAPIKey gin.HandlerFunc
+	RequireActor           gin.HandlerFunc
+	RequireAPIKey          gin.HandlerFunc
+	RequireScope           func(scope string) gin.HandlerFunc
+	RateLimitAuth          gin.HandlerFunc
+	RateLimitSessions      gin.HandlerFunc
+	RateLimitSessionsRealm gin.HandlerFunc
 }
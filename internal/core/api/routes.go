@@ -31,6 +31,18 @@ func (r *Routes) RegisterRoutes(root gin.IRouter) {
 			r.bind(realmsEndpoint, r.handlers.Realm)
 			r.bind(realmsEndpoint.Group("/:aid/users"), r.handlers.User)
 			r.bind(realmsEndpoint.Group("/:aid/daemons"), r.handlers.Daemon)
+			r.bind(realmsEndpoint.Group("/:aid/groups"), r.handlers.Group)
+			r.bind(realmsEndpoint.Group("/:aid/memberships"), r.handlers.Membership)
+			r.bind(realmsEndpoint.Group("/:aid/policies"), r.handlers.Policy)
+			r.bind(realmsEndpoint.Group("/:aid/rules"), r.handlers.Rule)
+			r.bind(realmsEndpoint.Group("/:aid/search"), r.handlers.Search)
+		}
+
+		searchEndpoint := adminEndpoint.Group("/search")
+		{
+			searchEndpoint.Use(r.middlewares.RequireActor)
+
+			r.bind(searchEndpoint, r.handlers.Search)
 		}
 
 		providersEndpoint := adminEndpoint.Group("/providers")
@@ -40,6 +52,13 @@ func (r *Routes) RegisterRoutes(root gin.IRouter) {
 			r.bind(providersEndpoint, r.handlers.Provider)
 		}
 
+		lockoutsEndpoint := adminEndpoint.Group("/lockouts")
+		{
+			lockoutsEndpoint.Use(r.middlewares.RequireActor)
+
+			r.bind(lockoutsEndpoint, r.handlers.Lockout)
+		}
+
 		adminAuthEndpoint := adminEndpoint.Group("/auth")
 		{
 			r.bind(adminAuthEndpoint, r.handlers.Auth)
@@ -53,11 +72,22 @@ func (r *Routes) RegisterRoutes(root gin.IRouter) {
 
 	sessionsEndpoint := api.Group("/sessions")
 	{
-		sessionsEndpoint.Use(r.middlewares.RequireAPIKey)
+		// the client IP limit also applies to invalid API keys, so it comes first,
+		// while only the realm of a verified API key is charged
+		sessionsEndpoint.Use(r.middlewares.RateLimitSessions, r.middlewares.RequireAPIKey,
+			r.middlewares.RateLimitSessionsRealm)
 
 		r.bind(sessionsEndpoint, r.handlers.Session)
 	}
 
+	authzEndpoint := api.Group("/authz")
+	{
+		authzEndpoint.Use(r.middlewares.RateLimitSessions, r.middlewares.RequireAPIKey,
+			r.middlewares.RateLimitSessionsRealm)
+
+		r.bind(authzEndpoint, r.handlers.Authz)
+	}
+
 	healthEndpoint := root.Group("/health")
 	{
 		r.bind(healthEndpoint, r.handlers.Health)
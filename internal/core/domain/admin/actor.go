@@ -2,14 +2,29 @@ package admin
 
 import (
 	"fmt"
+	"slices"
 )
 
+// ImpersonationDeniedPermissions contains the permissions an impersonated actor is
+// never granted: an admin acting as a user can neither issue credentials that outlive
+// the impersonation nor change roles on behalf of the user.
+//
+//nolint:gochecknoglobals // it is a constant
+var ImpersonationDeniedPermissions = []Permission{
+	PermissionUsersKeysWrite, PermissionDaemonsKeysWrite, PermissionUsersRolesWrite,
+}
+
 // Actor represents a user or realm that is performing an action.
 // The actor is used in the context of a service call.
+//
+// ImpersonatorID and ImpersonatorRealmID identify the admin acting as the user,
+// if any. The actor is then authorized as the impersonated user.
 type Actor struct {
-	UserID  ID
-	RealmID ID
-	Role    SystemRole
+	UserID              ID
+	RealmID             ID
+	Role                SystemRole
+	ImpersonatorID      ID
+	ImpersonatorRealmID ID
 }
 
 // NewActor returns a new actor.
@@ -26,7 +41,22 @@ func (a Actor) IsValid() bool {
 	return a.UserID != "" && a.RealmID != "" && a.Role != ""
 }
 
+// IsImpersonated returns true if an admin acts as the user of the actor.
+func (a Actor) IsImpersonated() bool {
+	return a.ImpersonatorID != ""
+}
+
+// IsDeniedByImpersonation returns true if the actor is impersonated and the permission
+// is denied to impersonated actors.
+func (a Actor) IsDeniedByImpersonation(permission Permission) bool {
+	return a.IsImpersonated() && slices.Contains(ImpersonationDeniedPermissions, permission)
+}
+
 // String implements the fmt.Stringer interface.
 func (a Actor) String() string {
+	if a.IsImpersonated() {
+		return fmt.Sprintf("%s@%s[%s] by %s@%s", a.UserID, a.RealmID, a.Role, a.ImpersonatorID, a.ImpersonatorRealmID)
+	}
+
 	return fmt.Sprintf("%s@%s[%s]", a.UserID, a.RealmID, a.Role)
 }
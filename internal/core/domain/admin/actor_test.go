@@ -72,4 +72,26 @@ func TestActor_String(t *testing.T) {
 	act := NewActor("user1", "realm1", SystemRoleManager)
 
 	require.Equal(t, "user1@realm1[manager]", act.String())
+
+	act.ImpersonatorID = "admin1"
+	act.ImpersonatorRealmID = "realm0"
+
+	require.True(t, act.IsImpersonated())
+	require.Equal(t, "user1@realm1[manager] by admin1@realm0", act.String())
+}
+
+func TestActor_IsDeniedByImpersonation(t *testing.T) {
+	t.Parallel()
+
+	act := NewActor("user1", "realm1", SystemRoleManager)
+
+	require.False(t, act.IsDeniedByImpersonation(PermissionUsersKeysWrite))
+
+	act.ImpersonatorID = "admin1"
+	act.ImpersonatorRealmID = "realm0"
+
+	require.True(t, act.IsDeniedByImpersonation(PermissionUsersKeysWrite))
+	require.True(t, act.IsDeniedByImpersonation(PermissionDaemonsKeysWrite))
+	require.True(t, act.IsDeniedByImpersonation(PermissionUsersRolesWrite))
+	require.False(t, act.IsDeniedByImpersonation(PermissionUsersWrite))
 }
@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"slices"
+	"time"
+)
+
+// APIKeyPrefixLength is the length of the public API key prefix.
+// The prefix is stored in plain text and used to look up the hashed key.
+const APIKeyPrefixLength = 8
+
+// APIKeyUsageWindowDays is the number of days, today included, over which the usage of
+// the API keys is counted. The usage of the days before is dropped.
+const APIKeyUsageWindowDays = 30
+
+// IsRetired returns true if the API key has been replaced by a successor.
+func (k APIKey) IsRetired() bool {
+	return !k.RetiredAt.IsZero()
+}
+
+// UsageDay returns the key of the UTC day of the given time in APIKey.UsageDays.
+// The keys sort in the order of the days.
+func UsageDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// OldestUsageDay returns the key of the first day of the usage window ending on
+// the day of the given time.
+func OldestUsageDay(t time.Time) string {
+	return UsageDay(t.AddDate(0, 0, 1-APIKeyUsageWindowDays))
+}
+
+// APIKeyPrefix returns the public prefix of the given plain text API key.
+// The prefix of a key in the server format is taken from its secret part,
+// because all keys of a realm share the same leading characters.
+// Short keys expose at most half of their characters.
+func APIKeyPrefix(key string) string {
+	const half = 2
+
+	if _, secret, ok := ParseAPIKey(key); ok {
+		key = secret
+	}
+
+	return key[:min(APIKeyPrefixLength, len(key)/half)]
+}
+
+// IsExpired returns true if the API key has an expiration time that is not after now.
+// API keys without an expiration time never expire.
+func (k APIKey) IsExpired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// UsageCount returns the number of verifications of the API key in the usage window
+// ending on the day of now.
+func (k APIKey) UsageCount(now time.Time) int64 {
+	oldest, today := OldestUsageDay(now), UsageDay(now)
+
+	var count int64
+
+	for day, n := range k.UsageDays {
+		if day >= oldest && day <= today {
+			count += n
+		}
+	}
+
+	return count
+}
+
+// Matches returns true if the API key matches the filter.
+//
+// An API key is unused since a given time if it has not been used after that time.
+// API keys that have never been used are considered used at their creation time.
+func (f APIKeyFilter) Matches(k APIKey) bool {
+	if f.UnusedSince.IsZero() {
+		return true
+	}
+
+	lastUsedAt := k.LastUsedAt
+	if lastUsedAt.IsZero() {
+		lastUsedAt = k.CreatedAt
+	}
+
+	return !lastUsedAt.After(f.UnusedSince)
+}
+
+// Filter returns the API keys matching the filter.
+func (f APIKeyFilter) Filter(apiKeys []APIKey) []APIKey {
+	filtered := make([]APIKey, 0, len(apiKeys))
+
+	for _, apiKey := range apiKeys {
+		if f.Matches(apiKey) {
+			filtered = append(filtered, apiKey)
+		}
+	}
+
+	return filtered
+}
+
+// CanAccessRealm returns true if the principal can access the resources of the realm:
+// its own realm and, for daemons, the realms it serves.
+func (p APIKeyPrincipal) CanAccessRealm(realmID ID) bool {
+	return p.RealmID == realmID || slices.Contains(p.AllowedRealms, realmID)
+}
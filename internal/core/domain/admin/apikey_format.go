@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"hash/crc32"
+	"strings"
+)
+
+// APIKeyTokenPrefix is the fixed prefix of the API keys minted by the server.
+// It makes leaked keys recognizable by secret scanners.
+const APIKeyTokenPrefix = "ids_"
+
+const (
+	// apiKeySeparator separates the parts of a formatted API key.
+	apiKeySeparator = "_"
+
+	// apiKeyChecksumLength is the length of the base62 encoded CRC32 checksum.
+	// Six base62 digits are enough for any 32-bit value.
+	apiKeyChecksumLength = 6
+
+	// apiKeyMinSecretLength is the minimum length of the secret part.
+	apiKeyMinSecretLength = 16
+
+	// base62Alphabet is the alphabet of the secret and the checksum.
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// FormatAPIKey formats an API key of the realm from the given random secret.
+//
+// The format is "ids_<realmID>_<secret>_<checksum>", where the checksum is the
+// base62 encoded CRC32 of everything before it. The secret must be base62.
+func FormatAPIKey(realmID ID, secret string) string {
+	body := APIKeyTokenPrefix + string(realmID) + apiKeySeparator + secret
+
+	return body + apiKeySeparator + apiKeyChecksum(body)
+}
+
+// IsFormattedAPIKey returns true if the key claims to be in the server format.
+// Such keys must be well formed; other keys are legacy keys.
+func IsFormattedAPIKey(key string) bool {
+	return strings.HasPrefix(key, APIKeyTokenPrefix)
+}
+
+// ParseAPIKey parses an API key in the server format and returns its realm ID
+// and secret. It returns false if the key is malformed or the checksum does not
+// match. The realm ID may contain separators, the secret and the checksum cannot.
+func ParseAPIKey(key string) (ID, string, bool) {
+	if !IsFormattedAPIKey(key) {
+		return "", "", false
+	}
+
+	body, checksum, found := cutLast(key, apiKeySeparator)
+	if !found || checksum != apiKeyChecksum(body) {
+		return "", "", false
+	}
+
+	realmID, secret, found := cutLast(strings.TrimPrefix(body, APIKeyTokenPrefix), apiKeySeparator)
+	if !found || realmID == "" || len(secret) < apiKeyMinSecretLength || !isBase62(secret) {
+		return "", "", false
+	}
+
+	return ID(realmID), secret, true
+}
+
+func apiKeyChecksum(body string) string {
+	sum := crc32.ChecksumIEEE([]byte(body))
+	digits := make([]byte, apiKeyChecksumLength)
+
+	for i := apiKeyChecksumLength - 1; i >= 0; i-- {
+		digits[i] = base62Alphabet[sum%uint32(len(base62Alphabet))]
+		sum /= uint32(len(base62Alphabet))
+	}
+
+	return string(digits)
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
+
+func isBase62(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune(base62Alphabet, r) {
+			return false
+		}
+	}
+
+	return true
+}
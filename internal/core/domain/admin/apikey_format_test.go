@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAPIKey(t *testing.T) {
+	t.Parallel()
+
+	key := FormatAPIKey("realm_1", "0123456789abcdefABCDEF")
+
+	require.True(t, IsFormattedAPIKey(key))
+	require.Regexp(t, `^ids_realm_1_0123456789abcdefABCDEF_[0-9A-Za-z]{6}$`, key)
+
+	realmID, secret, ok := ParseAPIKey(key)
+	require.True(t, ok)
+	require.Equal(t, ID("realm_1"), realmID)
+	require.Equal(t, "0123456789abcdefABCDEF", secret)
+	require.Equal(t, "01234567", APIKeyPrefix(key))
+}
+
+func TestParseAPIKey(t *testing.T) {
+	t.Parallel()
+
+	valid := FormatAPIKey("r1", "0123456789abcdefABCDEF")
+
+	tests := map[string]struct {
+		key    string
+		wantOK bool
+	}{
+		"valid": {
+			key:    valid,
+			wantOK: true,
+		},
+		"legacy": {
+			key: "0123456789abcdef0123",
+		},
+		"badChecksum": {
+			key: valid[:len(valid)-1] + "x",
+		},
+		"tamperedSecret": {
+			key: "ids_r1_1123456789abcdefABCDEF" + valid[len(valid)-7:],
+		},
+		"noChecksum": {
+			key: "ids_r1_0123456789abcdefABCDEF",
+		},
+		"noRealm": {
+			key: FormatAPIKey("", "0123456789abcdefABCDEF"),
+		},
+		"shortSecret": {
+			key: FormatAPIKey("r1", "0123"),
+		},
+		"nonBase62Secret": {
+			key: FormatAPIKey("r1", "0123456789abcdef-ABCDEF"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, ok := ParseAPIKey(test.key)
+
+			require.Equal(t, test.wantOK, ok)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package admin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyPrefix(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "", APIKeyPrefix(""))
+	require.Equal(t, "ab", APIKeyPrefix("abcd"))
+	require.Equal(t, "01234567", APIKeyPrefix("0123456789abcdef0123"))
+}
+
+func TestAPIKey_IsExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	require.False(t, APIKey{}.IsExpired(now))
+	require.False(t, APIKey{ExpiresAt: now.Add(time.Second)}.IsExpired(now))
+	require.True(t, APIKey{ExpiresAt: now}.IsExpired(now))
+	require.True(t, APIKey{ExpiresAt: now.Add(-time.Second)}.IsExpired(now))
+}
+
+func TestAPIKey_UsageCount(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)
+	apiKey := APIKey{UsageDays: map[string]int64{
+		"2024-03-01": 1, // before the window
+		"2024-03-02": 2, // first day of the window
+		"2024-03-31": 3,
+		"2024-04-01": 4, // after now
+	}}
+
+	require.Equal(t, "2024-03-02", OldestUsageDay(now))
+	require.Equal(t, int64(5), apiKey.UsageCount(now))
+	require.Zero(t, APIKey{}.UsageCount(now))
+}
+
+func TestAPIKeyFilter_Matches(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	since := now.Add(-30 * 24 * time.Hour)
+
+	tests := map[string]struct {
+		filter APIKeyFilter
+		apiKey APIKey
+		want   bool
+	}{
+		"noFilter": {
+			apiKey: APIKey{LastUsedAt: now},
+			want:   true,
+		},
+		"usedRecently": {
+			filter: APIKeyFilter{UnusedSince: since},
+			apiKey: APIKey{LastUsedAt: now},
+			want:   false,
+		},
+		"usedLongAgo": {
+			filter: APIKeyFilter{UnusedSince: since},
+			apiKey: APIKey{LastUsedAt: since.Add(-time.Hour)},
+			want:   true,
+		},
+		"neverUsed-createdRecently": {
+			filter: APIKeyFilter{UnusedSince: since},
+			apiKey: APIKey{CreatedAt: now},
+			want:   false,
+		},
+		"neverUsed-createdLongAgo": {
+			filter: APIKeyFilter{UnusedSince: since},
+			apiKey: APIKey{CreatedAt: since.Add(-time.Hour)},
+			want:   true,
+		},
+		"neverUsed-legacy": {
+			filter: APIKeyFilter{UnusedSince: since},
+			apiKey: APIKey{},
+			want:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.want, test.filter.Matches(test.apiKey))
+		})
+	}
+}
+
+func TestAPIKeyPrincipal_CanAccessRealm(t *testing.T) {
+	t.Parallel()
+
+	user := APIKeyPrincipal{Kind: PrincipalKindUser, RealmID: "r1"}
+	daemon := APIKeyPrincipal{Kind: PrincipalKindDaemon, RealmID: "r1", AllowedRealms: []ID{"r2"}}
+
+	require.True(t, user.CanAccessRealm("r1"))
+	require.False(t, user.CanAccessRealm("r2"))
+	require.True(t, daemon.CanAccessRealm("r1"))
+	require.True(t, daemon.CanAccessRealm("r2"))
+	require.False(t, daemon.CanAccessRealm("r3"))
+}
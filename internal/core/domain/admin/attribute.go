@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+)
+
+// FindAttribute returns the definition of the named attribute in the schema.
+func FindAttribute(schema []AttributeDefinition, name string) (AttributeDefinition, bool) {
+	for _, definition := range schema {
+		if definition.Name == name {
+			return definition, true
+		}
+	}
+
+	return AttributeDefinition{}, false
+}
+
+// NormalizeValue checks the value of the attribute against the definition and
+// returns it in its canonical type. Values decoded from JSON or BSON are accepted:
+// any numeric type for number attributes and slices of any for list attributes.
+// It returns a domain.ValidationError if the value does not match the definition.
+func (d AttributeDefinition) NormalizeValue(value any) (any, error) {
+	switch d.Type {
+	case AttributeTypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case AttributeTypeNumber:
+		if n, ok := toNumber(value); ok {
+			return n, nil
+		}
+	case AttributeTypeBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case AttributeTypeEnum:
+		if s, ok := value.(string); ok {
+			return s, d.checkAllowed(s)
+		}
+	case AttributeTypeList:
+		if items, ok := toStrings(value); ok {
+			for _, item := range items {
+				if err := d.checkAllowed(item); err != nil {
+					return nil, err
+				}
+			}
+
+			return items, nil
+		}
+	case AttributeTypeNone:
+	}
+
+	return nil, domain.NewValidationError("attribute %s must be of type %s", d.Name, d.Type)
+}
+
+// ParseValue parses the string representation of a value of the attribute, as
+// given in a query. For a list attribute it parses a single item.
+// It returns a domain.ValidationError if the value does not match the definition.
+func (d AttributeDefinition) ParseValue(s string) (any, error) {
+	switch d.Type {
+	case AttributeTypeNumber:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, domain.NewValidationError("attribute %s must be a number", d.Name)
+		}
+
+		return n, nil
+	case AttributeTypeBool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, domain.NewValidationError("attribute %s must be a bool", d.Name)
+		}
+
+		return b, nil
+	case AttributeTypeEnum, AttributeTypeList:
+		return s, d.checkAllowed(s)
+	case AttributeTypeString, AttributeTypeNone:
+	}
+
+	return s, nil
+}
+
+func (d AttributeDefinition) checkAllowed(value string) error {
+	if len(d.Values) > 0 && !slices.Contains(d.Values, value) {
+		return domain.NewValidationError("value %q is not allowed for attribute %s", value, d.Name)
+	}
+
+	return nil
+}
+
+func toNumber(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+	case float32:
+		return toNumber(float64(n))
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+
+	return 0, false
+}
+
+func toStrings(value any) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return slices.Clone(v), true
+	case []any:
+		items := make([]string, len(v))
+
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+
+			items[i] = s
+		}
+
+		return items, true
+	}
+
+	return nil, false
+}
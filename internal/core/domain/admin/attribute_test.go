@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeDefinition_NormalizeValue(t *testing.T) {
+	t.Parallel()
+
+	str := AttributeDefinition{Name: "a", Type: AttributeTypeString}
+	number := AttributeDefinition{Name: "a", Type: AttributeTypeNumber}
+	boolean := AttributeDefinition{Name: "a", Type: AttributeTypeBool}
+	enum := AttributeDefinition{Name: "a", Type: AttributeTypeEnum, Values: []string{"x", "y"}}
+	list := AttributeDefinition{Name: "a", Type: AttributeTypeList}
+	restricted := AttributeDefinition{Name: "a", Type: AttributeTypeList, Values: []string{"x"}}
+
+	tests := map[string]struct {
+		definition AttributeDefinition
+		value      any
+		want       any
+		wantError  bool
+	}{
+		"string":          {definition: str, value: "v", want: "v"},
+		"string-number":   {definition: str, value: 1.0, wantError: true},
+		"number-float":    {definition: number, value: 1.5, want: 1.5},
+		"number-int":      {definition: number, value: int64(2), want: 2.0},
+		"number-string":   {definition: number, value: "2", wantError: true},
+		"bool":            {definition: boolean, value: true, want: true},
+		"bool-string":     {definition: boolean, value: "true", wantError: true},
+		"enum":            {definition: enum, value: "x", want: "x"},
+		"enum-notAllowed": {definition: enum, value: "z", wantError: true},
+		"list-any":        {definition: list, value: []any{"x", "z"}, want: []string{"x", "z"}},
+		"list-strings":    {definition: list, value: []string{"x"}, want: []string{"x"}},
+		"list-mixed":      {definition: list, value: []any{"x", 1.0}, wantError: true},
+		"list-notAllowed": {definition: restricted, value: []any{"x", "z"}, wantError: true},
+		"list-scalar":     {definition: list, value: "x", wantError: true},
+		"none":            {definition: AttributeDefinition{Name: "a"}, value: "x", wantError: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := test.definition.NormalizeValue(test.value)
+
+			if test.wantError {
+				require.ErrorAs(t, err, &domain.ValidationError{})
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.want, got)
+			}
+		})
+	}
+}
+
+func TestAttributeDefinition_ParseValue(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		definition AttributeDefinition
+		value      string
+		want       any
+		wantError  bool
+	}{
+		"string":          {definition: AttributeDefinition{Type: AttributeTypeString}, value: "v", want: "v"},
+		"number":          {definition: AttributeDefinition{Type: AttributeTypeNumber}, value: "2.5", want: 2.5},
+		"number-invalid":  {definition: AttributeDefinition{Type: AttributeTypeNumber}, value: "NaN", wantError: true},
+		"bool":            {definition: AttributeDefinition{Type: AttributeTypeBool}, value: "false", want: false},
+		"bool-invalid":    {definition: AttributeDefinition{Type: AttributeTypeBool}, value: "no", wantError: true},
+		"enum-notAllowed": {definition: AttributeDefinition{Type: AttributeTypeEnum, Values: []string{"x"}}, value: "y", wantError: true},
+		"list-item":       {definition: AttributeDefinition{Type: AttributeTypeList}, value: "x", want: "x"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := test.definition.ParseValue(test.value)
+
+			if test.wantError {
+				require.ErrorAs(t, err, &domain.ValidationError{})
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.want, got)
+			}
+		})
+	}
+}
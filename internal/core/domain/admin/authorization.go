@@ -0,0 +1,286 @@
+package admin
+
+import "slices"
+
+// Permissions. A permission is named "<resource>.<operation>" and grants the
+// operation on the resources the role of the actor extends to.
+const (
+	PermissionRealmsRead         Permission = "realms.read"
+	PermissionRealmsWrite        Permission = "realms.write"
+	PermissionRealmsCreate       Permission = "realms.create"
+	PermissionRealmsDelete       Permission = "realms.delete"
+	PermissionProvidersRead      Permission = "providers.read"
+	PermissionProvidersWrite     Permission = "providers.write"
+	PermissionUsersRead          Permission = "users.read"
+	PermissionUsersWrite         Permission = "users.write"
+	PermissionUsersRolesWrite    Permission = "users.roles.write"
+	PermissionUsersImpersonate   Permission = "users.impersonate"
+	PermissionUsersKeysRead      Permission = "users.keys.read"
+	PermissionUsersKeysWrite     Permission = "users.keys.write"
+	PermissionDaemonsRead        Permission = "daemons.read"
+	PermissionDaemonsWrite       Permission = "daemons.write"
+	PermissionDaemonsRealmsWrite Permission = "daemons.realms.write"
+	PermissionDaemonsKeysRead    Permission = "daemons.keys.read"
+	PermissionDaemonsKeysWrite   Permission = "daemons.keys.write"
+	PermissionGroupsRead         Permission = "groups.read"
+	PermissionGroupsWrite        Permission = "groups.write"
+	PermissionMembershipsRead    Permission = "memberships.read"
+	PermissionMembershipsWrite   Permission = "memberships.write"
+	PermissionPoliciesRead       Permission = "policies.read"
+	PermissionPoliciesWrite      Permission = "policies.write"
+	PermissionRulesRead          Permission = "rules.read"
+	PermissionRulesWrite         Permission = "rules.write"
+	PermissionLockoutsRead       Permission = "lockouts.read"
+	PermissionLockoutsWrite      Permission = "lockouts.write"
+)
+
+// Role scopes.
+const (
+	roleScopeSystem roleScope = iota // all resources
+	roleScopeRealm                   // resources of the realm of the actor
+	roleScopeSelf                    // resources owned by the actor
+)
+
+// AllPermissions contains every permission known to the server.
+//
+//nolint:gochecknoglobals // it is a constant
+var AllPermissions = []Permission{
+	PermissionRealmsRead, PermissionRealmsWrite, PermissionRealmsCreate, PermissionRealmsDelete,
+	PermissionProvidersRead, PermissionProvidersWrite,
+	PermissionUsersRead, PermissionUsersWrite, PermissionUsersRolesWrite, PermissionUsersImpersonate,
+	PermissionUsersKeysRead, PermissionUsersKeysWrite,
+	PermissionDaemonsRead, PermissionDaemonsWrite, PermissionDaemonsRealmsWrite,
+	PermissionDaemonsKeysRead, PermissionDaemonsKeysWrite,
+	PermissionGroupsRead, PermissionGroupsWrite,
+	PermissionMembershipsRead, PermissionMembershipsWrite,
+	PermissionPoliciesRead, PermissionPoliciesWrite,
+	PermissionRulesRead, PermissionRulesWrite,
+	PermissionLockoutsRead, PermissionLockoutsWrite,
+}
+
+// RealmPermissions contains the permissions that can be granted by the custom
+// roles of a realm. The other permissions concern resources outside the realm,
+// or are reserved to the admins, like the impersonation of users.
+//
+//nolint:gochecknoglobals // it is a constant
+var RealmPermissions = []Permission{
+	PermissionRealmsRead, PermissionRealmsWrite,
+	PermissionUsersRead, PermissionUsersWrite, PermissionUsersRolesWrite,
+	PermissionUsersKeysRead, PermissionUsersKeysWrite,
+	PermissionDaemonsRead, PermissionDaemonsWrite,
+	PermissionDaemonsKeysRead, PermissionDaemonsKeysWrite,
+	PermissionGroupsRead, PermissionGroupsWrite,
+	PermissionMembershipsRead, PermissionMembershipsWrite,
+	PermissionPoliciesRead, PermissionPoliciesWrite,
+	PermissionRulesRead, PermissionRulesWrite,
+}
+
+// builtinRoles defines the system roles as permission sets. Actors with an
+// unknown role or without a role have no permissions.
+//
+//nolint:gochecknoglobals // it is a constant
+var builtinRoles = map[SystemRole]builtinRole{
+	SystemRoleUser: {
+		scope: roleScopeSelf,
+		permissions: []Permission{
+			PermissionUsersRead, PermissionUsersWrite,
+			PermissionUsersKeysRead, PermissionUsersKeysWrite,
+		},
+	},
+	SystemRoleManager: {
+		scope:       roleScopeRealm,
+		permissions: RealmPermissions,
+	},
+	SystemRoleAdmin: {
+		scope:       roleScopeSystem,
+		permissions: AllPermissions,
+	},
+	SystemRoleSystemAuditor: {
+		scope: roleScopeSystem,
+		permissions: []Permission{
+			PermissionRealmsRead, PermissionProvidersRead,
+			PermissionUsersRead, PermissionUsersKeysRead,
+			PermissionDaemonsRead, PermissionDaemonsKeysRead,
+			PermissionGroupsRead, PermissionMembershipsRead,
+			PermissionPoliciesRead, PermissionRulesRead,
+			PermissionLockoutsRead,
+		},
+	},
+	SystemRoleRealmAuditor: {
+		scope: roleScopeRealm,
+		permissions: []Permission{
+			PermissionRealmsRead,
+			PermissionUsersRead, PermissionUsersKeysRead,
+			PermissionDaemonsRead, PermissionDaemonsKeysRead,
+			PermissionGroupsRead, PermissionMembershipsRead,
+			PermissionPoliciesRead, PermissionRulesRead,
+		},
+	},
+	SystemRoleUserManager: {
+		scope: roleScopeRealm,
+		permissions: []Permission{
+			PermissionRealmsRead,
+			PermissionUsersRead, PermissionUsersWrite, PermissionUsersRolesWrite,
+			PermissionUsersKeysRead, PermissionUsersKeysWrite,
+			PermissionGroupsRead, PermissionMembershipsRead,
+		},
+	},
+	SystemRoleDaemonManager: {
+		scope: roleScopeRealm,
+		permissions: []Permission{
+			PermissionRealmsRead,
+			PermissionDaemonsRead, PermissionDaemonsWrite,
+			PermissionDaemonsKeysRead, PermissionDaemonsKeysWrite,
+		},
+	},
+}
+
+// Permission represents the right to perform an operation on a kind of resource.
+type Permission string
+
+// Role is a custom role defined by a realm. It grants its permissions on the
+// resources of the realm to the users it is assigned to.
+type Role struct {
+	Name        string
+	Description string
+	Permissions []Permission
+}
+
+// Resource identifies the target of an operation to authorize.
+//
+// RealmID is empty for the resources that do not belong to a realm, like the
+// providers. OwnerID is the user the resource belongs to, if any.
+type Resource struct {
+	RealmID ID
+	OwnerID ID
+}
+
+// SystemResource returns a resource that does not belong to a realm.
+func SystemResource() Resource {
+	return Resource{}
+}
+
+// RealmResource returns a resource of the given realm.
+func RealmResource(realmID ID) Resource {
+	return Resource{RealmID: realmID}
+}
+
+// OwnedResource returns a resource of the given realm owned by the given user.
+func OwnedResource(realmID, ownerID ID) Resource {
+	return Resource{RealmID: realmID, OwnerID: ownerID}
+}
+
+type roleScope int
+
+type builtinRole struct {
+	scope       roleScope
+	permissions []Permission
+}
+
+// IsBuiltinRole returns true if the name is the name of a system role.
+// Custom roles cannot take these names.
+func IsBuiltinRole(name string) bool {
+	return slices.Contains(AllSystemRoles, SystemRole(name))
+}
+
+// IsRealmPermission returns true if the permission can be granted by a custom role.
+func IsRealmPermission(permission Permission) bool {
+	return slices.Contains(RealmPermissions, permission)
+}
+
+// Permissions returns the permissions granted by the system role.
+func (r SystemRole) Permissions() []Permission {
+	return slices.Clone(builtinRoles[r].permissions)
+}
+
+// IsSystemWide returns true if the system role extends beyond the realm of the user.
+func (r SystemRole) IsSystemWide() bool {
+	role, found := builtinRoles[r]
+
+	return found && role.scope == roleScopeSystem
+}
+
+// IsRealmWide returns true if the system role extends to the resources of the
+// realm of the user, rather than just to the resources the user owns.
+func (r SystemRole) IsRealmWide() bool {
+	role, found := builtinRoles[r]
+
+	return found && role.scope == roleScopeRealm
+}
+
+// Permits returns true if the system role of the actor grants the permission
+// on the resource.
+func (r SystemRole) Permits(actor Actor, permission Permission, resource Resource) bool {
+	role, found := builtinRoles[r]
+	if !found || !slices.Contains(role.permissions, permission) {
+		return false
+	}
+
+	return role.scope.covers(actor, resource)
+}
+
+// Permits returns true if the custom role grants the permission on the resource
+// to the actor. Custom roles never extend beyond the realm of the actor.
+func (r Role) Permits(actor Actor, permission Permission, resource Resource) bool {
+	return slices.Contains(r.Permissions, permission) && roleScopeRealm.covers(actor, resource)
+}
+
+// FindRole returns the custom role of the realm with the given name.
+func (r Realm) FindRole(name string) (Role, bool) {
+	for _, role := range r.Roles {
+		if role.Name == name {
+			return role, true
+		}
+	}
+
+	return Role{}, false
+}
+
+// ResolveRoles returns the effective roles of the user of the realm, who is a
+// member of the given groups. Custom roles that are not defined in the realm
+// grant nothing and are skipped.
+func ResolveRoles(realm Realm, user User, groups []Group) EffectiveRoles {
+	effective := EffectiveRoles{Role: user.Role}
+
+	names := slices.Clone(user.Roles)
+
+	for _, group := range groups {
+		effective.Groups = append(effective.Groups, group.ID)
+		names = append(names, group.Roles...)
+	}
+
+	if role, found := builtinRoles[user.Role]; found {
+		effective.Permissions = append(effective.Permissions, role.permissions...)
+	}
+
+	for _, name := range names {
+		role, found := realm.FindRole(name)
+		if !found || slices.Contains(effective.Roles, name) {
+			continue
+		}
+
+		effective.Roles = append(effective.Roles, name)
+		effective.Permissions = append(effective.Permissions, role.Permissions...)
+	}
+
+	slices.Sort(effective.Roles)
+	slices.Sort(effective.Permissions)
+
+	effective.Permissions = slices.Compact(effective.Permissions)
+
+	return effective
+}
+
+func (s roleScope) covers(actor Actor, resource Resource) bool {
+	switch s {
+	case roleScopeSystem:
+		return true
+	case roleScopeRealm:
+		return resource.RealmID != "" && resource.RealmID == actor.RealmID
+	case roleScopeSelf:
+		return resource.RealmID != "" && resource.RealmID == actor.RealmID &&
+			resource.OwnerID != "" && resource.OwnerID == actor.UserID
+	default:
+		return false
+	}
+}
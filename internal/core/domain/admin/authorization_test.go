@@ -0,0 +1,256 @@
+package admin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemRole_Permits(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		actor      Actor
+		permission Permission
+		resource   Resource
+		want       bool
+	}{
+		"admin-system": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleAdmin},
+			permission: PermissionProvidersWrite,
+			resource:   SystemResource(),
+			want:       true,
+		},
+		"admin-otherRealm": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleAdmin},
+			permission: PermissionDaemonsKeysWrite,
+			resource:   RealmResource("r2"),
+			want:       true,
+		},
+		"manager-ownRealm": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleManager},
+			permission: PermissionDaemonsKeysWrite,
+			resource:   RealmResource("r1"),
+			want:       true,
+		},
+		"manager-otherRealm": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleManager},
+			permission: PermissionUsersRead,
+			resource:   RealmResource("r2"),
+		},
+		"manager-system": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleManager},
+			permission: PermissionRealmsRead,
+			resource:   SystemResource(),
+		},
+		"manager-notGranted": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleManager},
+			permission: PermissionRealmsDelete,
+			resource:   RealmResource("r1"),
+		},
+		"user-self": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleUser},
+			permission: PermissionUsersKeysWrite,
+			resource:   OwnedResource("r1", "u1"),
+			want:       true,
+		},
+		"user-otherUser": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleUser},
+			permission: PermissionUsersRead,
+			resource:   OwnedResource("r1", "u2"),
+		},
+		"user-realm": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleUser},
+			permission: PermissionUsersRead,
+			resource:   RealmResource("r1"),
+		},
+		"user-notGranted": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleUser},
+			permission: PermissionUsersRolesWrite,
+			resource:   OwnedResource("r1", "u1"),
+		},
+		"systemAuditor-otherRealm": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleSystemAuditor},
+			permission: PermissionUsersRead,
+			resource:   RealmResource("r2"),
+			want:       true,
+		},
+		"systemAuditor-system": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleSystemAuditor},
+			permission: PermissionProvidersRead,
+			resource:   SystemResource(),
+			want:       true,
+		},
+		"systemAuditor-write": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleSystemAuditor},
+			permission: PermissionUsersWrite,
+			resource:   RealmResource("r1"),
+		},
+		"realmAuditor-ownRealm": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleRealmAuditor},
+			permission: PermissionPoliciesRead,
+			resource:   RealmResource("r1"),
+			want:       true,
+		},
+		"realmAuditor-otherRealm": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleRealmAuditor},
+			permission: PermissionUsersRead,
+			resource:   RealmResource("r2"),
+		},
+		"realmAuditor-write": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleRealmAuditor},
+			permission: PermissionDaemonsWrite,
+			resource:   RealmResource("r1"),
+		},
+		"userManager-users": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleUserManager},
+			permission: PermissionUsersKeysWrite,
+			resource:   RealmResource("r1"),
+			want:       true,
+		},
+		"userManager-daemons": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleUserManager},
+			permission: PermissionDaemonsRead,
+			resource:   RealmResource("r1"),
+		},
+		"daemonManager-daemons": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleDaemonManager},
+			permission: PermissionDaemonsKeysWrite,
+			resource:   RealmResource("r1"),
+			want:       true,
+		},
+		"daemonManager-users": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleDaemonManager},
+			permission: PermissionUsersRead,
+			resource:   RealmResource("r1"),
+		},
+		"none": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleNone},
+			permission: PermissionUsersRead,
+			resource:   OwnedResource("r1", "u1"),
+		},
+		"unknown": {
+			actor:      Actor{UserID: "u1", RealmID: "r1", Role: "unknown"},
+			permission: PermissionUsersRead,
+			resource:   OwnedResource("r1", "u1"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.want, test.actor.Role.Permits(test.actor, test.permission, test.resource))
+		})
+	}
+}
+
+func TestSystemRole_scope(t *testing.T) {
+	t.Parallel()
+
+	for _, role := range AllSystemRoles {
+		require.False(t, role.IsSystemWide() && role.IsRealmWide(), role)
+	}
+
+	require.True(t, SystemRoleAdmin.IsSystemWide())
+	require.True(t, SystemRoleSystemAuditor.IsSystemWide())
+	require.True(t, SystemRoleManager.IsRealmWide())
+	require.True(t, SystemRoleRealmAuditor.IsRealmWide())
+	require.True(t, SystemRoleUserManager.IsRealmWide())
+	require.True(t, SystemRoleDaemonManager.IsRealmWide())
+	require.False(t, SystemRoleUser.IsSystemWide() || SystemRoleUser.IsRealmWide())
+	require.False(t, SystemRoleNone.IsSystemWide() || SystemRoleNone.IsRealmWide())
+	require.Empty(t, SystemRoleNone.Permissions())
+}
+
+func TestSystemRole_auditorsAreReadOnly(t *testing.T) {
+	t.Parallel()
+
+	for _, role := range []SystemRole{SystemRoleSystemAuditor, SystemRoleRealmAuditor} {
+		for _, permission := range role.Permissions() {
+			require.True(t, strings.HasSuffix(string(permission), ".read"), permission)
+		}
+	}
+}
+
+func TestRole_Permits(t *testing.T) {
+	t.Parallel()
+
+	role := Role{Name: "auditor", Permissions: []Permission{PermissionUsersRead, PermissionDaemonsRead}}
+	actor := Actor{UserID: "u1", RealmID: "r1", Role: SystemRoleUser}
+
+	tests := map[string]struct {
+		permission Permission
+		resource   Resource
+		want       bool
+	}{
+		"granted": {
+			permission: PermissionUsersRead,
+			resource:   RealmResource("r1"),
+			want:       true,
+		},
+		"notGranted": {
+			permission: PermissionUsersWrite,
+			resource:   RealmResource("r1"),
+		},
+		"otherRealm": {
+			permission: PermissionUsersRead,
+			resource:   RealmResource("r2"),
+		},
+		"system": {
+			permission: PermissionUsersRead,
+			resource:   SystemResource(),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.want, role.Permits(actor, test.permission, test.resource))
+		})
+	}
+}
+
+func TestRealmPermissions(t *testing.T) {
+	t.Parallel()
+
+	for _, permission := range RealmPermissions {
+		require.Contains(t, AllPermissions, permission)
+	}
+
+	require.False(t, IsRealmPermission(PermissionDaemonsRealmsWrite))
+	require.False(t, IsRealmPermission(PermissionProvidersWrite))
+	require.True(t, IsRealmPermission(PermissionUsersRead))
+}
+
+func TestResolveRoles(t *testing.T) {
+	t.Parallel()
+
+	realm := Realm{Roles: []Role{
+		{Name: "auditor", Permissions: []Permission{PermissionUsersRead, PermissionGroupsRead}},
+		{Name: "operator", Permissions: []Permission{PermissionDaemonsRead}},
+	}}
+	user := User{Role: SystemRoleUser, Roles: []string{"auditor", "undefined"}}
+	groups := []Group{
+		{ID: "g1", Roles: []string{"operator", "auditor"}},
+		{ID: "g2"},
+	}
+
+	effective := ResolveRoles(realm, user, groups)
+
+	require.Equal(t, EffectiveRoles{
+		Role:   SystemRoleUser,
+		Roles:  []string{"auditor", "operator"},
+		Groups: []ID{"g1", "g2"},
+		Permissions: []Permission{
+			PermissionDaemonsRead,
+			PermissionGroupsRead,
+			PermissionUsersKeysRead,
+			PermissionUsersKeysWrite,
+			PermissionUsersRead,
+			PermissionUsersWrite,
+		},
+	}, effective)
+}
@@ -0,0 +1,15 @@
+package admin
+
+// RealmContent counts the entities of a realm, which are deleted and restored
+// together with the realm.
+//
+// Memberships counts the memberships in the realm and the memberships of the
+// users of the realm in other realms.
+type RealmContent struct {
+	Users       int
+	Daemons     int
+	Groups      int
+	Memberships int
+	Policies    int
+	Rules       int
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// lockoutIDLength is the length of the lockout IDs in hex characters.
+const lockoutIDLength = 24
+
+// ID returns the ID of the lockout of the subject. The ID is derived from the key,
+// so that the failures of a subject are always counted in the same lockout.
+func (k LockoutKey) ID() ID {
+	sum := sha256.Sum256([]byte(string(k.Kind) + "\x00" + string(k.RealmID) + "\x00" + k.Subject))
+
+	return ID(hex.EncodeToString(sum[:])[:lockoutIDLength])
+}
+
+// IsLocked returns true if the subject is locked out at the given time.
+func (l Lockout) IsLocked(now time.Time) bool {
+	return now.Before(l.LockedUntil)
+}
+
+// Enabled returns true if the policy locks out subjects.
+func (p LockoutPolicy) Enabled() bool {
+	return p.Threshold > 0 && p.FailureWindow > 0 && p.BaseDuration > 0
+}
+
+// AddFailure returns the lockout with a failed authentication added at the given time.
+//
+// The failures and the lockouts are reset after a failure window without failures and
+// lockouts. The lockout expires a failure window after the failure, or after the end
+// of the current lockout.
+func (p LockoutPolicy) AddFailure(lockout Lockout, now time.Time) Lockout {
+	quietSince := lockout.LastFailureAt
+
+	if lockout.LockedUntil.After(quietSince) {
+		quietSince = lockout.LockedUntil
+	}
+
+	if now.Sub(quietSince) > p.FailureWindow {
+		lockout.Failures = 0
+		lockout.Lockouts = 0
+	}
+
+	lockout.Failures++
+	lockout.LastFailureAt = now
+	lockout.ExpiresAt = now.Add(p.FailureWindow)
+
+	if lockout.LockedUntil.After(now) {
+		lockout.ExpiresAt = lockout.LockedUntil.Add(p.FailureWindow)
+	}
+
+	return lockout
+}
+
+// ReachesThreshold returns true if the failures of the lockout lock out the subject.
+func (p LockoutPolicy) ReachesThreshold(lockout Lockout) bool {
+	return lockout.Failures >= p.Threshold
+}
+
+// LockOut returns the lockout with the subject locked out at the given time.
+// The failures start over, and the lockout lasts longer with every lockout.
+func (p LockoutPolicy) LockOut(lockout Lockout, now time.Time) Lockout {
+	lockout.Failures = 0
+	lockout.Lockouts++
+	lockout.LockedUntil = now.Add(p.lockoutDuration(lockout.Lockouts))
+	lockout.ExpiresAt = lockout.LockedUntil.Add(p.FailureWindow)
+
+	return lockout
+}
+
+// lockoutDuration returns the duration of the n-th lockout.
+func (p LockoutPolicy) lockoutDuration(n int) time.Duration {
+	duration := p.BaseDuration
+
+	for i := 1; i < n; i++ {
+		if p.MaxDuration > 0 && duration >= p.MaxDuration {
+			break
+		}
+
+		duration *= 2
+	}
+
+	if p.MaxDuration > 0 {
+		duration = min(duration, p.MaxDuration)
+	}
+
+	return duration
+}
@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutKey_ID(t *testing.T) {
+	t.Parallel()
+
+	key := LockoutKey{Kind: LockoutKindClientIP, Subject: "10.0.0.1"}
+
+	require.Len(t, key.ID(), lockoutIDLength)
+	require.Equal(t, key.ID(), key.ID())
+	require.NotEqual(t, key.ID(), LockoutKey{Kind: LockoutKindUser, Subject: "10.0.0.1"}.ID())
+}
+
+func TestLockoutPolicy_AddFailure(t *testing.T) {
+	t.Parallel()
+
+	policy := LockoutPolicy{Threshold: 2, FailureWindow: 10 * time.Minute, BaseDuration: time.Minute}
+	now := time.Now()
+
+	lockout := policy.AddFailure(Lockout{}, now)
+	require.Equal(t, 1, lockout.Failures)
+	require.Equal(t, now, lockout.LastFailureAt)
+	require.Equal(t, now.Add(10*time.Minute), lockout.ExpiresAt)
+	require.False(t, policy.ReachesThreshold(lockout))
+
+	// the failures within the window add up
+	now = now.Add(5 * time.Minute)
+	lockout = policy.AddFailure(lockout, now)
+	require.Equal(t, 2, lockout.Failures)
+	require.True(t, policy.ReachesThreshold(lockout))
+
+	// a failure during a lockout keeps the lockout until a window after its end
+	lockout = Lockout{Lockouts: 1, LockedUntil: now.Add(time.Hour)}
+	lockout = policy.AddFailure(lockout, now)
+	require.Equal(t, 1, lockout.Lockouts)
+	require.Equal(t, now.Add(70*time.Minute), lockout.ExpiresAt)
+
+	// everything is forgotten after a quiet failure window
+	now = lockout.LockedUntil.Add(policy.FailureWindow + time.Second)
+	lockout = policy.AddFailure(lockout, now)
+	require.Equal(t, 1, lockout.Failures)
+	require.Zero(t, lockout.Lockouts)
+	require.False(t, lockout.IsLocked(now))
+}
+
+func TestLockoutPolicy_LockOut(t *testing.T) {
+	t.Parallel()
+
+	policy := LockoutPolicy{
+		Threshold:     3,
+		FailureWindow: 10 * time.Minute,
+		BaseDuration:  time.Minute,
+		MaxDuration:   3 * time.Minute,
+	}
+
+	now := time.Now()
+
+	lockout := policy.LockOut(Lockout{Failures: 3}, now)
+	require.True(t, lockout.IsLocked(now))
+	require.Zero(t, lockout.Failures)
+	require.Equal(t, 1, lockout.Lockouts)
+	require.Equal(t, now.Add(time.Minute), lockout.LockedUntil)
+	require.Equal(t, now.Add(11*time.Minute), lockout.ExpiresAt)
+	require.False(t, lockout.IsLocked(lockout.LockedUntil))
+
+	// the next lockout lasts twice as long
+	lockout = policy.LockOut(lockout, now)
+	require.Equal(t, 2, lockout.Lockouts)
+	require.Equal(t, now.Add(2*time.Minute), lockout.LockedUntil)
+
+	// the lockout duration is limited
+	lockout = policy.LockOut(lockout, now)
+	require.Equal(t, 3, lockout.Lockouts)
+	require.Equal(t, now.Add(3*time.Minute), lockout.LockedUntil)
+}
@@ -10,10 +10,71 @@ const (
 
 // System roles.
 const (
-	SystemRoleNone    SystemRole = ""        // no access
-	SystemRoleUser    SystemRole = "user"    // user only access
-	SystemRoleManager SystemRole = "manager" // realm management access
-	SystemRoleAdmin   SystemRole = "admin"   // system-wide access
+	SystemRoleNone          SystemRole = ""              // no access
+	SystemRoleUser          SystemRole = "user"          // user only access
+	SystemRoleManager       SystemRole = "manager"       // realm management access
+	SystemRoleAdmin         SystemRole = "admin"         // system-wide access
+	SystemRoleSystemAuditor SystemRole = "systemAuditor" // system-wide read-only access
+	SystemRoleRealmAuditor  SystemRole = "realmAuditor"  // realm read-only access
+	SystemRoleUserManager   SystemRole = "userManager"   // realm user management access
+	SystemRoleDaemonManager SystemRole = "daemonManager" // realm daemon management access
+)
+
+// Principal kinds.
+const (
+	PrincipalKindUser   PrincipalKind = "user"
+	PrincipalKindDaemon PrincipalKind = "daemon"
+)
+
+// Lockout kinds.
+const (
+	LockoutKindNone         LockoutKind = ""
+	LockoutKindAPIKeyPrefix LockoutKind = "apiKeyPrefix"
+	LockoutKindUser         LockoutKind = "user"
+	LockoutKindClientIP     LockoutKind = "clientIp"
+)
+
+// Policy effects.
+const (
+	PolicyEffectNone  PolicyEffect = ""
+	PolicyEffectAllow PolicyEffect = "allow"
+	PolicyEffectDeny  PolicyEffect = "deny"
+)
+
+// Attribute types.
+const (
+	AttributeTypeNone   AttributeType = ""
+	AttributeTypeString AttributeType = "string"
+	AttributeTypeNumber AttributeType = "number"
+	AttributeTypeBool   AttributeType = "bool"
+	AttributeTypeEnum   AttributeType = "enum"
+	AttributeTypeList   AttributeType = "list"
+)
+
+// Sort fields of the listings.
+const (
+	SortByUsername      = "username"
+	SortByEmail         = "email"
+	SortByCode          = "code"
+	SortByCreatedAt     = "createdAt"
+	SortByUserID        = "userId"
+	SortBySubject       = "subject"
+	SortByLastFailureAt = "lastFailureAt"
+)
+
+// Sort fields available per listing. The first field is the default one.
+//
+//nolint:gochecknoglobals // they are constants
+var (
+	UserSortFields       = []string{SortByUsername, SortByEmail, SortByCreatedAt}
+	DaemonSortFields     = []string{SortByCode, SortByCreatedAt}
+	RealmSortFields      = []string{SortByCode}
+	ProviderSortFields   = []string{SortByCode}
+	GroupSortFields      = []string{SortByCode}
+	MembershipSortFields = []string{SortByUserID}
+	PolicySortFields     = []string{SortByCode}
+	RuleSortFields       = []string{SortByCode}
+	LockoutSortFields    = []string{SortByLastFailureAt, SortBySubject}
 )
 
 // All enums. Used for testing purposes to validate that all enum values are
@@ -22,18 +83,58 @@ const (
 //nolint:gochecknoglobals
 var (
 	AllProviderTypes = []ProviderType{ProviderTypeNone, ProviderTypeGoogle}
-	AllSystemRoles   = []SystemRole{SystemRoleNone, SystemRoleUser, SystemRoleManager, SystemRoleAdmin}
+	AllSystemRoles   = []SystemRole{
+		SystemRoleNone, SystemRoleUser, SystemRoleManager, SystemRoleAdmin,
+		SystemRoleSystemAuditor, SystemRoleRealmAuditor, SystemRoleUserManager, SystemRoleDaemonManager,
+	}
+	AllLockoutKinds   = []LockoutKind{LockoutKindNone, LockoutKindAPIKeyPrefix, LockoutKindUser, LockoutKindClientIP}
+	AllPolicyEffects  = []PolicyEffect{PolicyEffectNone, PolicyEffectAllow, PolicyEffectDeny}
+	AllAttributeTypes = []AttributeType{
+		AttributeTypeNone, AttributeTypeString, AttributeTypeNumber,
+		AttributeTypeBool, AttributeTypeEnum, AttributeTypeList,
+	}
 )
 
 // Realm represents a realm that can be used to authenticate
 // users. It is used to group providers and users.
 // It is the top level entity in the admin domain.
+//
+// APIKeyMaxLifetime limits the lifetime of the API keys issued in the realm.
+// A zero value means that the API keys may never expire.
+//
+// APIKeyScopes is the catalogue of custom scopes that can be granted to the API keys
+// issued in the realm, in addition to the built-in scopes.
+//
+// Roles are the custom roles that can be assigned to the users of the realm.
+//
+// UserAttributes is the schema of the custom attributes of the users of the realm.
 type Realm struct {
-	ID          ID
-	Code        string
+	ID                ID
+	Code              string
+	Name              string
+	Description       string
+	Enabled           bool
+	RedirectURIs      []string
+	APIKeyMaxLifetime time.Duration
+	APIKeyScopes      []string
+	Roles             []Role
+	UserAttributes    []AttributeDefinition
+	Version           Version
+}
+
+// AttributeType represents the type of a custom user attribute.
+type AttributeType string
+
+// AttributeDefinition defines a custom attribute of the users of a realm.
+//
+// Values lists the allowed values of an enum attribute. For a list attribute
+// it optionally restricts the items of the list.
+type AttributeDefinition struct {
 	Name        string
 	Description string
-	Enabled     bool
+	Type        AttributeType
+	Required    bool
+	Values      []string
 }
 
 // ProviderType represents the type of authentication provider.
@@ -50,8 +151,12 @@ type Provider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	Version      Version
 }
 
+// PrincipalKind represents the kind of the principal authenticated by an API key.
+type PrincipalKind string
+
 // SystemRole represents the role of a user in the system.
 type SystemRole string
 
@@ -62,6 +167,13 @@ func (r SystemRole) String() string {
 
 // User represents an organic user in the system.
 // The user authenticates with the system using an authentication provider.
+//
+// Roles lists the custom roles of the realm assigned to the user, in addition
+// to the system role.
+//
+// Attributes holds the custom attributes defined by the schema of the realm.
+// The values are strings for string and enum attributes, float64 for number
+// attributes, bools for bool attributes and string slices for list attributes.
 type User struct {
 	ID          ID
 	RealmID     ID
@@ -72,28 +184,328 @@ type User struct {
 	Description string
 	Enabled     bool
 	Role        SystemRole
+	Roles       []string
+	Attributes  map[string]any
 	APIKeys     []APIKey
+	CreatedAt   time.Time
+	Version     Version
 }
 
 // Daemon represents a non-organic user in the system.
 // The daemon authenticates with the system using an API key.
+//
+// AllowedRealms lists the realms the daemon serves in addition to its own realm.
+// The daemon can access the sessions of these realms.
+//
+// AllowedCIDRs restrict the networks all API keys of the daemon can be used from,
+// in addition to the CIDR ranges of the API keys themselves.
 type Daemon struct {
+	ID            ID
+	RealmID       ID
+	Code          string
+	Name          string
+	Description   string
+	Enabled       bool
+	APIKeys       []APIKey
+	AllowedRealms []ID
+	AllowedCIDRs  []string
+	CreatedAt     time.Time
+	Version       Version
+}
+
+// Group represents a group of users of a realm.
+//
+// The custom roles of the group are granted to all its members, in addition to
+// their own roles. The attributes are passed on to the downstream services with
+// the sessions of the members.
+//
+// Members are managed by the membership methods only.
+type Group struct {
 	ID          ID
 	RealmID     ID
 	Code        string
 	Name        string
 	Description string
+	Roles       []string
+	Attributes  map[string]string
+	Members     []ID
+	Version     Version
+}
+
+// Membership grants a user access to a realm other than the realm of the user.
+//
+// UserRealmID is the realm the user belongs to. Role is the system role of the
+// user in the realm of the membership; it is resolved when the user switches
+// to the realm, instead of the role of the user in its own realm.
+type Membership struct {
+	ID          ID
+	RealmID     ID
+	UserID      ID
+	UserRealmID ID
+	Role        SystemRole
+	Version     Version
+}
+
+// PolicyEffect represents the effect of a policy on the requests it matches.
+type PolicyEffect string
+
+// Policy is an authorization rule of a realm, evaluated on behalf of the
+// downstream services.
+//
+// The policy matches the requests for one of its actions on one of its resources.
+// Actions and resources are patterns: "*" matches anything, and a trailing "*"
+// matches any suffix, like "invoices:*".
+//
+// Roles, Groups and Principals restrict the subjects the policy applies to. Roles are
+// system or custom role names, Groups are group codes, Principals are the IDs of users
+// or daemons. The subject must match one of them; a policy without any of them applies
+// to every subject of the realm.
+type Policy struct {
+	ID          ID
+	RealmID     ID
+	Code        string
+	Description string
+	Effect      PolicyEffect
+	Actions     []string
+	Resources   []string
+	Roles       []string
+	Groups      []string
+	Principals  []ID
+	Version     Version
+}
+
+// AuthzSubject represents the user or the daemon an authorization decision is made for.
+// Roles holds the system role and the effective custom roles of a user, Groups the
+// codes of its groups. Daemons have neither.
+type AuthzSubject struct {
+	Kind    PrincipalKind
+	RealmID ID
+	ID      ID
+	Roles   []string
+	Groups  []string
+}
+
+// AuthzRequest represents an action on a resource to authorize.
+type AuthzRequest struct {
+	Action   string
+	Resource string
+}
+
+// AuthzDecision represents the outcome of the evaluation of an authorization request.
+// Policy is the policy that decided the request; it is empty if no policy matched,
+// in which case the request is denied.
+type AuthzDecision struct {
+	Request AuthzRequest
+	Allowed bool
+	Policy  Policy
+}
+
+// AccessRule is an attribute-based access rule of a realm. Its expression is
+// evaluated against the actor, the target entity and the request before the
+// mutations that need one of its permissions, and denies them unless it holds.
+//
+// Disabled rules are kept but not evaluated.
+type AccessRule struct {
+	ID          ID
+	RealmID     ID
+	Code        string
+	Description string
 	Enabled     bool
-	APIKeys     []APIKey
+	Permissions []Permission
+	Expression  string
+	Version     Version
+}
+
+// EffectiveRoles represents the roles a user holds in its realm, granted
+// directly or through the groups it is a member of.
+//
+// Roles lists the custom roles defined in the realm, Permissions the union of
+// the permissions of the system role and the custom roles.
+type EffectiveRoles struct {
+	Role        SystemRole
+	Roles       []string
+	Groups      []ID
+	Permissions []Permission
 }
 
 // APIKey represents an API key that can be used to authenticate a daemon.
 // It can also be used to authenticate a user.
+//
+// The key itself is never stored. Only its public prefix and keyed hash are persisted.
+// The plain text Key is set only in the response to the creation of the key.
+//
+// Scopes restrict what the API key can be used for. API keys without scopes are unrestricted.
+//
+// LastUsedAt, LastUsedIP and UsageDays track the successful verifications of the API key.
+// UsageDays counts the verifications per UTC day, keyed by UsageDay, over the last
+// APIKeyUsageWindowDays days; see UsageCount. They are updated asynchronously and may lag behind.
+//
+// AllowedCIDRs restrict the networks the API key can be used from.
+// API keys without CIDR ranges can be used from everywhere.
+//
+// RetiredAt is set when the API key is rotated. A retired API key stays usable until
+// it expires at the end of the grace period of the rotation.
 type APIKey struct {
+	ID           ID
+	Name         string
+	Description  string
+	Enabled      bool
+	Key          string
+	Prefix       string
+	Hash         string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	Scopes       []string
+	LastUsedAt   time.Time
+	LastUsedIP   string
+	UsageDays    map[string]int64
+	AllowedCIDRs []string
+	RetiredAt    time.Time
+}
+
+// APIKeyUsage represents the aggregated usage of an API key since the last time
+// the usage was recorded.
+type APIKeyUsage struct {
+	APIKeyID   ID
+	LastUsedAt time.Time
+	LastUsedIP string
+	Count      int64
+}
+
+// APIKeyFilter represents a filter for API keys.
+// The zero value matches all API keys.
+type APIKeyFilter struct {
+	UnusedSince time.Time
+}
+
+// UserFilter represents a filter for users.
+// Attributes matches the custom attributes by value; a list attribute matches
+// if it contains the value. The username and the email match by prefix, and the
+// creation range is half-open. The zero value matches all users.
+type UserFilter struct {
+	Enabled        *bool
+	Role           SystemRole
+	UsernamePrefix string
+	EmailPrefix    string
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	Attributes     map[string]any
+}
+
+// DaemonFilter represents a filter for daemons.
+// The code matches by prefix, and the creation range is half-open. The zero value
+// matches all daemons.
+type DaemonFilter struct {
+	Enabled       *bool
+	CodePrefix    string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// ListOptions represents the pagination and the sort order of a listing.
+//
+// Cursor is the opaque position returned with the previous page, or empty for the
+// first page. The items are sorted by the SortBy field, then by their ID. A zero
+// Limit returns all the remaining items.
+//
+// The zero value lists all the items in the default sort order of the listing.
+type ListOptions struct {
+	Cursor     string
+	Limit      int
+	SortBy     string
+	Descending bool
+}
+
+// Page represents a page of a listing.
+// NextCursor is empty on the last page. Total counts the items matching the filter
+// on all pages.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+	Total      int
+}
+
+// SearchHit represents a user or a daemon matching a search.
+// Name is the username of a user or the code of a daemon, DisplayName the display
+// name of a user or the name of a daemon. Email is only set for users. A higher
+// score means a better match.
+type SearchHit struct {
+	Kind        PrincipalKind
+	RealmID     ID
 	ID          ID
 	Name        string
-	Description string
-	Enabled     bool
-	Key         string
-	ExpiresAt   time.Time
+	DisplayName string
+	Email       string
+	Score       float64
+}
+
+// OwnedAPIKey represents an API key together with the user or the daemon owning it.
+type OwnedAPIKey struct {
+	RealmID ID
+	OwnerID ID
+	APIKey  APIKey
+}
+
+// APIKeyPrincipal represents the user or the daemon authenticated by an API key.
+// Code is the username of a user or the code of a daemon. Role is only set for users,
+// AllowedRealms only for daemons.
+type APIKeyPrincipal struct {
+	Kind          PrincipalKind
+	RealmID       ID
+	OwnerID       ID
+	Code          string
+	Role          SystemRole
+	AllowedRealms []ID
+	APIKey        APIKey
+}
+
+// APIKeyExpiryReport represents the outcome of an API key expiry run.
+// DisabledOwners is the number of users and daemons that had expired API keys disabled.
+type APIKeyExpiryReport struct {
+	DisabledOwners int
+	ExpiringUser   []OwnedAPIKey
+	ExpiringDaemon []OwnedAPIKey
+}
+
+// LockoutKind represents the kind of the subject of a lockout.
+type LockoutKind string
+
+// LockoutKey identifies the subject failed authentications are counted for:
+// an API key prefix or a user of a realm, or a client IP. The realm of a client
+// IP is empty.
+type LockoutKey struct {
+	Kind    LockoutKind
+	RealmID ID
+	Subject string
+}
+
+// Lockout represents the failed authentications of a subject.
+//
+// The subject is locked out until LockedUntil once the failures reach the threshold
+// of the lockout policy. Lockouts counts the lockouts since the last quiet period,
+// the lockout duration doubles with every lockout. The lockout can be removed once
+// it expires at ExpiresAt.
+type Lockout struct {
+	ID            ID
+	Kind          LockoutKind
+	RealmID       ID
+	Subject       string
+	Failures      int
+	Lockouts      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+	ExpiresAt     time.Time
+}
+
+// LockoutPolicy configures the lockouts of subjects with repeated failed authentications.
+//
+// Threshold is the number of failures within the failure window that locks out the subject.
+// The first lockout lasts BaseDuration, every subsequent lockout lasts twice as long as the
+// previous one, up to MaxDuration. The failures and the lockouts are forgotten after a failure
+// window without failures. A zero threshold disables the lockouts.
+type LockoutPolicy struct {
+	Threshold     int
+	FailureWindow time.Duration
+	BaseDuration  time.Duration
+	MaxDuration   time.Duration
 }
@@ -0,0 +1,28 @@
+package admin
+
+import "net/netip"
+
+// IsIPAllowed returns true if the IP address is contained in one of the CIDR ranges.
+// An empty list of CIDR ranges allows every IP address. Invalid IP addresses and
+// invalid CIDR ranges never match.
+func IsIPAllowed(cidrs []string, ip string) bool {
+	if len(cidrs) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
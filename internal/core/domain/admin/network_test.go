@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsIPAllowed(t *testing.T) {
+	t.Parallel()
+
+	cidrs := []string{"10.0.0.0/8", "192.168.1.10/32", "2001:db8::/32"}
+
+	tests := map[string]struct {
+		cidrs []string
+		ip    string
+		want  bool
+	}{
+		"noRestriction": {
+			ip:   "203.0.113.1",
+			want: true,
+		},
+		"inRange": {
+			cidrs: cidrs,
+			ip:    "10.1.2.3",
+			want:  true,
+		},
+		"singleAddress": {
+			cidrs: cidrs,
+			ip:    "192.168.1.10",
+			want:  true,
+		},
+		"ipv4MappedIPv6": {
+			cidrs: cidrs,
+			ip:    "::ffff:10.1.2.3",
+			want:  true,
+		},
+		"ipv6": {
+			cidrs: cidrs,
+			ip:    "2001:db8::1",
+			want:  true,
+		},
+		"outOfRange": {
+			cidrs: cidrs,
+			ip:    "192.168.1.11",
+		},
+		"invalidIP": {
+			cidrs: cidrs,
+			ip:    "not-an-ip",
+		},
+		"emptyIP": {
+			cidrs: cidrs,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.want, IsIPAllowed(test.cidrs, test.ip))
+		})
+	}
+}
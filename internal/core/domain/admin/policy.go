@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// EvaluatePolicies decides the authorization request of the subject against the
+// policies of its realm.
+//
+// A matching deny policy takes precedence over the allow policies. Requests
+// that no policy matches are denied. The policies are considered in the order
+// of their codes, so that the decisive policy is stable.
+func EvaluatePolicies(policies []Policy, subject AuthzSubject, request AuthzRequest) AuthzDecision {
+	decision := AuthzDecision{Request: request}
+
+	sorted := slices.Clone(policies)
+
+	slices.SortFunc(sorted, func(a, b Policy) int {
+		return strings.Compare(a.Code, b.Code)
+	})
+
+	for _, policy := range sorted {
+		if !policy.Matches(subject, request) {
+			continue
+		}
+
+		switch policy.Effect {
+		case PolicyEffectDeny:
+			return AuthzDecision{Request: request, Policy: policy}
+		case PolicyEffectAllow:
+			if !decision.Allowed {
+				decision.Allowed = true
+				decision.Policy = policy
+			}
+		case PolicyEffectNone:
+		}
+	}
+
+	return decision
+}
+
+// Matches returns true if the policy applies to the subject and to the request.
+func (p Policy) Matches(subject AuthzSubject, request AuthzRequest) bool {
+	return p.RealmID == subject.RealmID &&
+		p.appliesTo(subject) &&
+		matchAnyPattern(p.Actions, request.Action) &&
+		matchAnyPattern(p.Resources, request.Resource)
+}
+
+func (p Policy) appliesTo(subject AuthzSubject) bool {
+	if len(p.Roles) == 0 && len(p.Groups) == 0 && len(p.Principals) == 0 {
+		return true
+	}
+
+	return slices.Contains(p.Principals, subject.ID) ||
+		containsAny(p.Roles, subject.Roles) ||
+		containsAny(p.Groups, subject.Groups)
+}
+
+// IsValidPattern returns true if the pattern is a valid action or resource pattern:
+// it is not empty, has no whitespace, and has a wildcard at the end only.
+func IsValidPattern(pattern string) bool {
+	if pattern == "" || strings.ContainsFunc(pattern, unicode.IsSpace) {
+		return false
+	}
+
+	return !strings.Contains(strings.TrimSuffix(pattern, wildcard), wildcard)
+}
+
+func matchAnyPattern(patterns []string, value string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		return matchPattern(pattern, value)
+	})
+}
+
+func containsAny(values, candidates []string) bool {
+	return slices.ContainsFunc(values, func(value string) bool {
+		return slices.Contains(candidates, value)
+	})
+}
+
+func matchPattern(pattern, value string) bool {
+	if prefix, found := strings.CutSuffix(pattern, wildcard); found {
+		return strings.HasPrefix(value, prefix)
+	}
+
+	return pattern == value
+}
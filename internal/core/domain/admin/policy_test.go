@@ -0,0 +1,107 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluatePolicies(t *testing.T) {
+	t.Parallel()
+
+	subject := AuthzSubject{Kind: PrincipalKindUser, RealmID: "r1", ID: "u1", Roles: []string{"auditor"}, Groups: []string{"sales"}}
+
+	allowAll := Policy{ID: "p1", RealmID: "r1", Code: "b-allow", Effect: PolicyEffectAllow, Actions: []string{"*"}, Resources: []string{"*"}}
+	allowRead := Policy{ID: "p2", RealmID: "r1", Code: "a-allow", Effect: PolicyEffectAllow, Actions: []string{"invoices:read"}, Resources: []string{"invoices/*"}}
+	denyWrite := Policy{ID: "p3", RealmID: "r1", Code: "z-deny", Effect: PolicyEffectDeny, Actions: []string{"invoices:write"}, Resources: []string{"invoices/*"}}
+	otherRealm := Policy{ID: "p4", RealmID: "r2", Code: "allow", Effect: PolicyEffectAllow, Actions: []string{"*"}, Resources: []string{"*"}}
+	forRole := Policy{ID: "p5", RealmID: "r1", Code: "role", Effect: PolicyEffectAllow, Actions: []string{"*"}, Resources: []string{"*"}, Roles: []string{"auditor"}}
+	forGroup := Policy{ID: "p6", RealmID: "r1", Code: "group", Effect: PolicyEffectAllow, Actions: []string{"*"}, Resources: []string{"*"}, Groups: []string{"sales"}}
+	forOther := Policy{ID: "p7", RealmID: "r1", Code: "other", Effect: PolicyEffectAllow, Actions: []string{"*"}, Resources: []string{"*"}, Roles: []string{"manager"}, Principals: []ID{"u2"}}
+	forUser := Policy{ID: "p8", RealmID: "r1", Code: "user", Effect: PolicyEffectAllow, Actions: []string{"*"}, Resources: []string{"*"}, Principals: []ID{"u1"}}
+
+	read := AuthzRequest{Action: "invoices:read", Resource: "invoices/1"}
+	write := AuthzRequest{Action: "invoices:write", Resource: "invoices/1"}
+
+	tests := map[string]struct {
+		policies    []Policy
+		request     AuthzRequest
+		wantAllowed bool
+		wantPolicy  ID
+	}{
+		"noPolicies": {
+			request: read,
+		},
+		"allow": {
+			policies:    []Policy{allowRead},
+			request:     read,
+			wantAllowed: true,
+			wantPolicy:  "p2",
+		},
+		"noMatch": {
+			policies: []Policy{allowRead},
+			request:  write,
+		},
+		"firstAllowByCode": {
+			policies:    []Policy{allowAll, allowRead},
+			request:     read,
+			wantAllowed: true,
+			wantPolicy:  "p2",
+		},
+		"denyOverridesAllow": {
+			policies:   []Policy{allowAll, denyWrite},
+			request:    write,
+			wantPolicy: "p3",
+		},
+		"otherRealm": {
+			policies: []Policy{otherRealm},
+			request:  read,
+		},
+		"role": {
+			policies:    []Policy{forRole},
+			request:     read,
+			wantAllowed: true,
+			wantPolicy:  "p5",
+		},
+		"group": {
+			policies:    []Policy{forGroup},
+			request:     read,
+			wantAllowed: true,
+			wantPolicy:  "p6",
+		},
+		"principal": {
+			policies:    []Policy{forUser},
+			request:     read,
+			wantAllowed: true,
+			wantPolicy:  "p8",
+		},
+		"otherSubject": {
+			policies: []Policy{forOther},
+			request:  read,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			decision := EvaluatePolicies(test.policies, subject, test.request)
+
+			require.Equal(t, test.request, decision.Request)
+			require.Equal(t, test.wantAllowed, decision.Allowed)
+			require.Equal(t, test.wantPolicy, decision.Policy.ID)
+		})
+	}
+}
+
+func TestIsValidPattern(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, IsValidPattern("invoices:read"))
+	require.True(t, IsValidPattern("invoices/*"))
+	require.True(t, IsValidPattern("*"))
+	require.False(t, IsValidPattern(""))
+	require.False(t, IsValidPattern("invoices read"))
+	require.False(t, IsValidPattern("invoices/*/lines"))
+	require.False(t, IsValidPattern("*/lines"))
+}
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// wildcard is the wildcard character supported in redirect URI patterns.
+const wildcard = "*"
+
+// IsRedirectAllowed returns true if the given URI matches one of the redirect
+// URIs registered for the realm.
+//
+// A redirect URI is either an exact absolute URI or a pattern. Patterns may use
+// a wildcard as the leftmost host label (https://*.example.com/) and as the last
+// character of the path (https://app.example.com/console/*). The scheme must always
+// match exactly. URIs carrying user information are never allowed, nor are paths
+// with dot segments or empty segments, which could escape a path prefix.
+func (r Realm) IsRedirectAllowed(uri string) bool {
+	target, err := url.Parse(uri)
+	if err != nil || !target.IsAbs() || target.User != nil || target.Host == "" {
+		return false
+	}
+
+	for _, pattern := range r.RedirectURIs {
+		if matchRedirectURI(pattern, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchRedirectURI(pattern string, target *url.URL) bool {
+	allowed, err := url.Parse(pattern)
+	if err != nil {
+		return false
+	}
+
+	if !strings.EqualFold(allowed.Scheme, target.Scheme) {
+		return false
+	}
+
+	return matchRedirectHost(allowed.Host, target.Host) && matchRedirectPath(allowed.Path, target.Path)
+}
+
+func matchRedirectHost(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	host = strings.ToLower(host)
+
+	if suffix, ok := strings.CutPrefix(pattern, wildcard); ok {
+		return strings.HasPrefix(suffix, ".") && strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+
+	return pattern == host
+}
+
+func matchRedirectPath(pattern, target string) bool {
+	if !isCleanPath(target) {
+		return false
+	}
+
+	if prefix, ok := strings.CutSuffix(pattern, wildcard); ok {
+		return strings.HasPrefix(target, prefix)
+	}
+
+	return strings.TrimSuffix(pattern, "/") == strings.TrimSuffix(target, "/")
+}
+
+// isCleanPath returns true if cleaning the path does not change it, apart from
+// a trailing slash.
+func isCleanPath(target string) bool {
+	if target == "" || target == "/" {
+		return true
+	}
+
+	return path.Clean(target) == strings.TrimSuffix(target, "/")
+}
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealm_IsRedirectAllowed(t *testing.T) {
+	t.Parallel()
+
+	realm := Realm{
+		RedirectURIs: []string{
+			"https://app.example.com/",
+			"https://*.example.org/console/*",
+			"http://localhost:3000/*",
+		},
+	}
+
+	tests := map[string]struct {
+		uri     string
+		allowed bool
+	}{
+		"exact": {
+			uri:     "https://app.example.com/",
+			allowed: true,
+		},
+		"exact-noTrailingSlash": {
+			uri:     "https://app.example.com",
+			allowed: true,
+		},
+		"exact-otherPath": {
+			uri:     "https://app.example.com/other",
+			allowed: false,
+		},
+		"wrongScheme": {
+			uri:     "http://app.example.com/",
+			allowed: false,
+		},
+		"wildcardHost": {
+			uri:     "https://eu.example.org/console/users?id=1",
+			allowed: true,
+		},
+		"wildcardHost-bareDomain": {
+			uri:     "https://example.org/console/users",
+			allowed: false,
+		},
+		"wildcardHost-suffixAttack": {
+			uri:     "https://evilexample.org/console/users",
+			allowed: false,
+		},
+		"wildcardPath-outside": {
+			uri:     "https://eu.example.org/other",
+			allowed: false,
+		},
+		"wildcardPath-traversal": {
+			uri:     "https://eu.example.org/console/../admin",
+			allowed: false,
+		},
+		"wildcardPath-encodedTraversal": {
+			uri:     "https://eu.example.org/console/%2e%2e/admin",
+			allowed: false,
+		},
+		"wildcardPath-emptySegment": {
+			uri:     "https://eu.example.org/console//users",
+			allowed: false,
+		},
+		"wildcardPath-trailingSlash": {
+			uri:     "https://eu.example.org/console/users/",
+			allowed: true,
+		},
+		"port": {
+			uri:     "http://localhost:3000/dashboard",
+			allowed: true,
+		},
+		"wrongPort": {
+			uri:     "http://localhost:3001/dashboard",
+			allowed: false,
+		},
+		"userInfo": {
+			uri:     "https://evil.com@app.example.com/",
+			allowed: false,
+		},
+		"relative": {
+			uri:     "/dashboard",
+			allowed: false,
+		},
+		"schemeRelative": {
+			uri:     "//app.example.com/",
+			allowed: false,
+		},
+		"empty": {
+			uri:     "",
+			allowed: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.allowed, realm.IsRedirectAllowed(test.uri))
+		})
+	}
+}
+
+func TestRealm_IsRedirectAllowed_noRedirectURIs(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, Realm{}.IsRedirectAllowed("https://app.example.com/"))
+}
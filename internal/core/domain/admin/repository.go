@@ -1,42 +1,150 @@
 package admin
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // RealmRepository defines the realm repository interface.
+//
+// The deletions are soft: a deleted entity is ignored by the repositories as if it
+// did not exist, until it is restored or purged. The restore methods return
+// the time the entity was deleted at.
 type RealmRepository interface {
-	GetRealms(ctx context.Context) ([]Realm, error)
+	GetRealms(ctx context.Context, options ListOptions) (Page[Realm], error)
 	GetRealm(ctx context.Context, id ID) (Realm, error)
 	CreateRealm(ctx context.Context, realm Realm) error
 	UpdateRealm(ctx context.Context, realm Realm) error
-	DeleteRealm(ctx context.Context, id ID) error
+	DeleteRealm(ctx context.Context, id ID, version Version, deletedAt time.Time) error
+	RestoreRealm(ctx context.Context, id ID) (time.Time, error)
 }
 
 // ProviderRepository defines the provider repository interface.
 type ProviderRepository interface {
-	GetProviders(ctx context.Context) ([]Provider, error)
+	GetProviders(ctx context.Context, options ListOptions) (Page[Provider], error)
 	GetProvider(ctx context.Context, id ID) (Provider, error)
 	CreateProvider(ctx context.Context, provider Provider) error
 	UpdateProvider(ctx context.Context, provider Provider) error
-	DeleteProvider(ctx context.Context, id ID) error
+	DeleteProvider(ctx context.Context, id ID, version Version, deletedAt time.Time) error
+	RestoreProvider(ctx context.Context, id ID) (time.Time, error)
 }
 
 // UserRepository defines the user repository interface.
 type UserRepository interface {
-	GetUsers(ctx context.Context, realmID ID) ([]User, error)
+	GetUsers(ctx context.Context, realmID ID, filter UserFilter, options ListOptions) (Page[User], error)
 	GetUser(ctx context.Context, realmID, id ID) (User, error)
 	CreateUser(ctx context.Context, user User) error
 	UpdateUser(ctx context.Context, user User) error
-	DeleteUser(ctx context.Context, realmID, id ID) error
+	DeleteUser(ctx context.Context, realmID, id ID, version Version, deletedAt time.Time) error
+	RestoreUser(ctx context.Context, realmID, id ID) (time.Time, error)
+	GetDeletedUser(ctx context.Context, realmID, id ID) (User, error)
 	GetUserByBindID(ctx context.Context, realmID ID, bindID string) (User, error)
-	GetAPIKey(ctx context.Context, realmID ID, key string) (APIKey, error)
+	SearchUsers(ctx context.Context, realmID ID, text string, limit int) ([]SearchHit, error)
+	GetAPIKeysByPrefix(ctx context.Context, realmID ID, prefix string) ([]OwnedAPIKey, error)
+	GetAPIKeysExpiringBefore(ctx context.Context, before time.Time) ([]OwnedAPIKey, error)
+	DisableExpiredAPIKeys(ctx context.Context, now time.Time) (int, error)
+	RecordAPIKeyUsage(ctx context.Context, usages []APIKeyUsage) error
 }
 
 // DaemonRepository defines the daemon repository interface.
 type DaemonRepository interface {
-	GetDaemons(ctx context.Context, realmID ID) ([]Daemon, error)
+	GetDaemons(ctx context.Context, realmID ID, filter DaemonFilter, options ListOptions) (Page[Daemon], error)
 	GetDaemon(ctx context.Context, realmID, id ID) (Daemon, error)
 	CreateDaemon(ctx context.Context, daemon Daemon) error
 	UpdateDaemon(ctx context.Context, daemon Daemon) error
-	DeleteDaemon(ctx context.Context, realmID, id ID) error
-	GetAPIKey(ctx context.Context, realmID ID, key string) (APIKey, error)
+	DeleteDaemon(ctx context.Context, realmID, id ID, version Version, deletedAt time.Time) error
+	RestoreDaemon(ctx context.Context, realmID, id ID) (time.Time, error)
+	GetDeletedDaemon(ctx context.Context, realmID, id ID) (Daemon, error)
+	SearchDaemons(ctx context.Context, realmID ID, text string, limit int) ([]SearchHit, error)
+	GetAPIKeysByPrefix(ctx context.Context, realmID ID, prefix string) ([]OwnedAPIKey, error)
+	GetAPIKeysExpiringBefore(ctx context.Context, before time.Time) ([]OwnedAPIKey, error)
+	DisableExpiredAPIKeys(ctx context.Context, now time.Time) (int, error)
+	RecordAPIKeyUsage(ctx context.Context, usages []APIKeyUsage) error
+}
+
+// GroupRepository defines the group repository interface.
+// GetMemberGroups returns the groups the user is a member of.
+// RemoveMember removes the user from all the groups of the realm.
+type GroupRepository interface {
+	GetGroups(ctx context.Context, realmID ID, options ListOptions) (Page[Group], error)
+	GetGroup(ctx context.Context, realmID, id ID) (Group, error)
+	CreateGroup(ctx context.Context, group Group) error
+	UpdateGroup(ctx context.Context, group Group) error
+	DeleteGroup(ctx context.Context, realmID, id ID, version Version, deletedAt time.Time) error
+	RestoreGroup(ctx context.Context, realmID, id ID) (time.Time, error)
+	GetDeletedGroup(ctx context.Context, realmID, id ID) (Group, error)
+	GetMemberGroups(ctx context.Context, realmID, userID ID) ([]Group, error)
+	AddGroupMember(ctx context.Context, realmID, id, userID ID) error
+	RemoveGroupMember(ctx context.Context, realmID, id, userID ID) error
+	RemoveMember(ctx context.Context, realmID, userID ID) error
+}
+
+// MembershipRepository defines the membership repository interface.
+// GetUserMemberships returns the memberships of the user in all realms.
+// DeleteUserMemberships deletes the memberships of the user in all realms.
+// RestoreUserMemberships restores the memberships of the user deleted at the given time.
+type MembershipRepository interface {
+	GetMemberships(ctx context.Context, realmID ID, options ListOptions) (Page[Membership], error)
+	GetMembership(ctx context.Context, realmID, id ID) (Membership, error)
+	GetUserMembership(ctx context.Context, realmID, userID ID) (Membership, error)
+	GetUserMemberships(ctx context.Context, userID ID) ([]Membership, error)
+	CreateMembership(ctx context.Context, membership Membership) error
+	UpdateMembership(ctx context.Context, membership Membership) error
+	DeleteMembership(ctx context.Context, realmID, id ID, version Version, deletedAt time.Time) error
+	RestoreMembership(ctx context.Context, realmID, id ID) (time.Time, error)
+	DeleteUserMemberships(ctx context.Context, userID ID, deletedAt time.Time) error
+	RestoreUserMemberships(ctx context.Context, userID ID, deletedAt time.Time) error
+}
+
+// PolicyRepository defines the policy repository interface.
+type PolicyRepository interface {
+	GetPolicies(ctx context.Context, realmID ID, options ListOptions) (Page[Policy], error)
+	GetPolicy(ctx context.Context, realmID, id ID) (Policy, error)
+	CreatePolicy(ctx context.Context, policy Policy) error
+	UpdatePolicy(ctx context.Context, policy Policy) error
+	DeletePolicy(ctx context.Context, realmID, id ID, version Version, deletedAt time.Time) error
+	RestorePolicy(ctx context.Context, realmID, id ID) (time.Time, error)
+}
+
+// RuleRepository defines the access rule repository interface.
+type RuleRepository interface {
+	GetRules(ctx context.Context, realmID ID, options ListOptions) (Page[AccessRule], error)
+	GetRule(ctx context.Context, realmID, id ID) (AccessRule, error)
+	CreateRule(ctx context.Context, rule AccessRule) error
+	UpdateRule(ctx context.Context, rule AccessRule) error
+	DeleteRule(ctx context.Context, realmID, id ID, version Version, deletedAt time.Time) error
+	RestoreRule(ctx context.Context, realmID, id ID) (time.Time, error)
+}
+
+// DeletionRepository defines the repository of the deletions spanning the entities
+// of several kinds.
+//
+// The content of a realm is deleted with the realm at the same time, so that it is
+// restored with the realm, except for the entities deleted before. PurgeDeleted
+// removes for good the entities of all kinds deleted before the given time, and
+// returns their number.
+type DeletionRepository interface {
+	CountRealmContent(ctx context.Context, realmID ID) (RealmContent, error)
+	DeleteRealmContent(ctx context.Context, realmID ID, deletedAt time.Time) (RealmContent, error)
+	RestoreRealmContent(ctx context.Context, realmID ID, deletedAt time.Time) (RealmContent, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+}
+
+// LockoutRepository defines the lockout repository interface.
+//
+// GetLockouts returns the lockouts still active at the given time.
+//
+// AddLockoutFailure atomically adds a failed authentication at the given time to the
+// lockout of the subject of the key, like LockoutPolicy.AddFailure with the window as
+// the failure window, and returns the updated lockout. The lockout is created if needed.
+//
+// LockOut stores the lockout returned by LockoutPolicy.LockOut, but only if the failures
+// of the stored lockout still reach the threshold and its subject has not been locked
+// out by a concurrent failure in the meantime. It returns false otherwise.
+type LockoutRepository interface {
+	GetLockouts(ctx context.Context, now time.Time, options ListOptions) (Page[Lockout], error)
+	GetLockout(ctx context.Context, id ID) (Lockout, error)
+	AddLockoutFailure(ctx context.Context, key LockoutKey, now time.Time, window time.Duration) (Lockout, error)
+	LockOut(ctx context.Context, lockout Lockout, threshold int) (bool, error)
+	DeleteLockout(ctx context.Context, id ID) error
 }
@@ -0,0 +1,213 @@
+package admin
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Rule operations.
+const (
+	RuleOperationCreate RuleOperation = "create"
+	RuleOperationUpdate RuleOperation = "update"
+	RuleOperationDelete RuleOperation = "delete"
+)
+
+// Rule target kinds.
+const (
+	RuleTargetUser   = "user"
+	RuleTargetDaemon = "daemon"
+	RuleTargetGroup  = "group"
+)
+
+// RulePermissions contains the permissions of the mutations the access rules
+// are evaluated before.
+//
+//nolint:gochecknoglobals // it is a constant
+var RulePermissions = []Permission{
+	PermissionUsersWrite, PermissionDaemonsWrite, PermissionGroupsWrite,
+}
+
+// RuleOperation represents the kind of mutation an access rule is evaluated for.
+type RuleOperation string
+
+// RuleInput holds the variables the expression of an access rule is evaluated
+// against: actor, target and request.
+type RuleInput struct {
+	Actor   map[string]any
+	Target  map[string]any
+	Request map[string]any
+}
+
+// RuleTarget represents the entity a mutation applies to. Fields holds the
+// variables exposed to the access rules, besides the kind, the realm and the ID.
+//
+// For create operations the target is the entity as it will be stored, for delete
+// operations the entity as it is stored. Update operations are evaluated twice, with
+// the entity as it is stored and as it will be stored, and both must be allowed, so
+// that an entity cannot be moved out of the reach of the rules.
+type RuleTarget struct {
+	Kind    string
+	RealmID ID
+	ID      ID
+	Fields  map[string]any
+}
+
+// IsRulePermission returns true if access rules can be bound to the permission.
+func IsRulePermission(permission Permission) bool {
+	return slices.Contains(RulePermissions, permission)
+}
+
+// AppliesTo returns true if the rule is enabled and bound to the permission.
+func (r AccessRule) AppliesTo(permission Permission) bool {
+	return r.Enabled && slices.Contains(r.Permissions, permission)
+}
+
+// UserTarget returns the user as the target of a mutation.
+func UserTarget(user User) RuleTarget {
+	return RuleTarget{
+		Kind:    RuleTargetUser,
+		RealmID: user.RealmID,
+		ID:      user.ID,
+		Fields: map[string]any{
+			"username":    user.Username,
+			"email":       user.Email,
+			"displayName": user.DisplayName,
+			"role":        user.Role.String(),
+			"roles":       nonNil(user.Roles),
+			"enabled":     user.Enabled,
+			"custom":      nonNilAttributes(user.Attributes),
+		},
+	}
+}
+
+// DaemonTarget returns the daemon as the target of a mutation.
+func DaemonTarget(daemon Daemon) RuleTarget {
+	return RuleTarget{
+		Kind:    RuleTargetDaemon,
+		RealmID: daemon.RealmID,
+		ID:      daemon.ID,
+		Fields: map[string]any{
+			"code":    daemon.Code,
+			"name":    daemon.Name,
+			"enabled": daemon.Enabled,
+		},
+	}
+}
+
+// GroupTarget returns the group as the target of a mutation.
+func GroupTarget(group Group) RuleTarget {
+	return RuleTarget{
+		Kind:    RuleTargetGroup,
+		RealmID: group.RealmID,
+		ID:      group.ID,
+		Fields: map[string]any{
+			"code":       group.Code,
+			"name":       group.Name,
+			"roles":      nonNil(group.Roles),
+			"attributes": nonNilMap(group.Attributes),
+		},
+	}
+}
+
+// Variables returns the target variable of the access rules. The attributes
+// are set for the targets that have no attributes of their own.
+func (t RuleTarget) Variables(attributes map[string]string) map[string]any {
+	variables := maps.Clone(t.Fields)
+	if variables == nil {
+		variables = make(map[string]any)
+	}
+
+	variables["kind"] = t.Kind
+	variables["id"] = t.ID.String()
+	variables["realmId"] = t.RealmID.String()
+
+	if _, found := variables["attributes"]; !found {
+		variables["attributes"] = nonNilMap(attributes)
+	}
+
+	return variables
+}
+
+// ActorVariables returns the actor variable of the access rules. The user is
+// the user of the actor in the realm, with the groups it is a member of; it is
+// empty if the actor has no user in the realm.
+//
+// The attributes of the actor are the attributes of its groups; the custom
+// attributes of its user are exposed as custom.
+func ActorVariables(actor Actor, user User, groups []Group) map[string]any {
+	roles := slices.Clone(user.Roles)
+	codes := make([]string, 0, len(groups))
+
+	for _, group := range groups {
+		roles = append(roles, group.Roles...)
+		codes = append(codes, group.Code)
+	}
+
+	slices.Sort(roles)
+	slices.Sort(codes)
+
+	return map[string]any{
+		"id":         actor.UserID.String(),
+		"realmId":    actor.RealmID.String(),
+		"role":       actor.Role.String(),
+		"roles":      nonNil(slices.Compact(roles)),
+		"groups":     codes,
+		"attributes": MergeAttributes(groups),
+		"custom":     nonNilAttributes(user.Attributes),
+	}
+}
+
+// RequestVariables returns the request variable of the access rules.
+func RequestVariables(permission Permission, operation RuleOperation) map[string]any {
+	return map[string]any{
+		"permission": string(permission),
+		"operation":  string(operation),
+	}
+}
+
+// MergeAttributes returns the attributes of the groups. If several groups set
+// the same attribute, the group with the lowest code wins.
+func MergeAttributes(groups []Group) map[string]string {
+	sorted := slices.Clone(groups)
+
+	slices.SortFunc(sorted, func(a, b Group) int {
+		return strings.Compare(a.Code, b.Code)
+	})
+
+	attributes := make(map[string]string)
+
+	for _, group := range sorted {
+		for key, value := range group.Attributes {
+			if _, found := attributes[key]; !found {
+				attributes[key] = value
+			}
+		}
+	}
+
+	return attributes
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
+
+func nonNilMap(values map[string]string) map[string]string {
+	if values == nil {
+		return map[string]string{}
+	}
+
+	return values
+}
+
+func nonNilAttributes(values map[string]any) map[string]any {
+	if values == nil {
+		return map[string]any{}
+	}
+
+	return values
+}
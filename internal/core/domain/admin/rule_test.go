@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessRule_AppliesTo(t *testing.T) {
+	t.Parallel()
+
+	rule := AccessRule{Enabled: true, Permissions: []Permission{PermissionUsersWrite}}
+
+	require.True(t, rule.AppliesTo(PermissionUsersWrite))
+	require.False(t, rule.AppliesTo(PermissionGroupsWrite))
+
+	rule.Enabled = false
+
+	require.False(t, rule.AppliesTo(PermissionUsersWrite))
+}
+
+func TestMergeAttributes(t *testing.T) {
+	t.Parallel()
+
+	groups := []Group{
+		{Code: "b", Attributes: map[string]string{"department": "support", "site": "berlin"}},
+		{Code: "a", Attributes: map[string]string{"department": "sales"}},
+	}
+
+	require.Equal(t, map[string]string{"department": "sales", "site": "berlin"}, MergeAttributes(groups))
+	require.Equal(t, map[string]string{}, MergeAttributes(nil))
+}
+
+func TestRuleTarget_Variables(t *testing.T) {
+	t.Parallel()
+
+	user := UserTarget(User{ID: "u1", RealmID: "r1", Username: "user1"}).Variables(map[string]string{"site": "berlin"})
+
+	require.Equal(t, "user", user["kind"])
+	require.Equal(t, "u1", user["id"])
+	require.Equal(t, "r1", user["realmId"])
+	require.Equal(t, "user1", user["username"])
+	require.Equal(t, map[string]string{"site": "berlin"}, user["attributes"])
+	require.Equal(t, map[string]any{}, user["custom"])
+
+	group := GroupTarget(Group{ID: "g1", RealmID: "r1", Attributes: map[string]string{"department": "sales"}}).
+		Variables(map[string]string{"site": "berlin"})
+
+	require.Equal(t, map[string]string{"department": "sales"}, group["attributes"])
+}
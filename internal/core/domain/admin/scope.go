@@ -0,0 +1,39 @@
+package admin
+
+import "slices"
+
+// Built-in API key scopes. They guard the endpoints of the identity server itself.
+const (
+	ScopeSessionsRead  = "sessions:read"
+	ScopeSessionsWrite = "sessions:write"
+	ScopeAuthzCheck    = "authz:check"
+)
+
+// BuiltinScopes contains the scopes available in every realm.
+//
+//nolint:gochecknoglobals // it is a constant
+var BuiltinScopes = []string{ScopeSessionsRead, ScopeSessionsWrite, ScopeAuthzCheck}
+
+// IsScopeDefined returns true if the scope is either a built-in scope or
+// a custom scope registered in the scope catalogue of the realm.
+func (r Realm) IsScopeDefined(scope string) bool {
+	return slices.Contains(BuiltinScopes, scope) || slices.Contains(r.APIKeyScopes, scope)
+}
+
+// HasScopes returns true if the API key grants all the given scopes.
+//
+// API keys without scopes are unrestricted and grant every scope. This keeps
+// the API keys issued before the introduction of scopes working.
+func (k APIKey) HasScopes(scopes ...string) bool {
+	if len(k.Scopes) == 0 {
+		return true
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(k.Scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealm_IsScopeDefined(t *testing.T) {
+	t.Parallel()
+
+	realm := Realm{APIKeyScopes: []string{"reports:read"}}
+
+	require.True(t, realm.IsScopeDefined(ScopeSessionsRead))
+	require.True(t, realm.IsScopeDefined("reports:read"))
+	require.False(t, realm.IsScopeDefined("reports:write"))
+}
+
+func TestAPIKey_HasScopes(t *testing.T) {
+	t.Parallel()
+
+	unrestricted := APIKey{}
+	readOnly := APIKey{Scopes: []string{ScopeSessionsRead, "reports:read"}}
+
+	require.True(t, unrestricted.HasScopes(ScopeSessionsWrite))
+	require.True(t, readOnly.HasScopes())
+	require.True(t, readOnly.HasScopes(ScopeSessionsRead))
+	require.True(t, readOnly.HasScopes(ScopeSessionsRead, "reports:read"))
+	require.False(t, readOnly.HasScopes(ScopeSessionsWrite))
+	require.False(t, readOnly.HasScopes(ScopeSessionsRead, ScopeSessionsWrite))
+}
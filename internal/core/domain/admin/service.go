@@ -1,42 +1,67 @@
 package admin
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // RealmService defines the realm service interface.
+// DeleteRealm deletes the realm with its content and returns the content deleted.
+// A dry run only returns the content that would be deleted.
 type RealmService interface {
-	GetRealms(ctx context.Context, actor Actor) ([]Realm, error)
+	GetRealms(ctx context.Context, actor Actor, options ListOptions) (Page[Realm], error)
 	GetRealm(ctx context.Context, actor Actor, id ID) (Realm, error)
 	CreateRealm(ctx context.Context, actor Actor, realm Realm) (Realm, error)
 	UpdateRealm(ctx context.Context, actor Actor, realm Realm) (Realm, error)
-	DeleteRealm(ctx context.Context, actor Actor, id ID) error
+	DeleteRealm(ctx context.Context, actor Actor, id ID, version Version, dryRun bool) (RealmContent, error)
+	RestoreRealm(ctx context.Context, actor Actor, id ID) (Realm, error)
 }
 
 // ProviderService defines the provider service interface.
 type ProviderService interface {
-	GetProviders(ctx context.Context, actor Actor) ([]Provider, error)
+	GetProviders(ctx context.Context, actor Actor, options ListOptions) (Page[Provider], error)
 	GetProvider(ctx context.Context, actor Actor, id ID) (Provider, error)
 	CreateProvider(ctx context.Context, actor Actor, provider Provider) (Provider, error)
 	UpdateProvider(ctx context.Context, actor Actor, provider Provider) (Provider, error)
-	DeleteProvider(ctx context.Context, actor Actor, id ID) error
+	DeleteProvider(ctx context.Context, actor Actor, id ID, version Version) error
+	RestoreProvider(ctx context.Context, actor Actor, id ID) (Provider, error)
 }
 
 // UserService defines the user service interface.
+// ImportUsers creates the users of the rows, or updates the users with the same bind
+// ID. ExportUsers passes the users matching the filter to the export function one
+// at a time, and stops at the first error it returns.
+//
+// The API keys are stored with their user: the updates, the rotations and the deletions
+// of the API keys must match the version of the user, like the updates of the user.
 type UserService interface {
-	GetUsers(ctx context.Context, actor Actor, realmID ID) ([]User, error)
+	GetUsers(ctx context.Context, actor Actor, realmID ID, filter UserFilter, options ListOptions) (Page[User], error)
 	GetUser(ctx context.Context, actor Actor, realmID, id ID) (User, error)
 	CreateUser(ctx context.Context, actor Actor, user User) (User, error)
 	UpdateUser(ctx context.Context, actor Actor, user User) (User, error)
-	DeleteUser(ctx context.Context, actor Actor, realmID, id ID) error
-	GetAPIKeys(ctx context.Context, actor Actor, realmID, userID ID) ([]APIKey, error)
+	DeleteUser(ctx context.Context, actor Actor, realmID, id ID, version Version) error
+	RestoreUser(ctx context.Context, actor Actor, realmID, id ID) (User, error)
+	GetAPIKeys(ctx context.Context, actor Actor, realmID, userID ID, filter APIKeyFilter) ([]APIKey, error)
 	GetAPIKey(ctx context.Context, actor Actor, realmID, userID, id ID) (APIKey, error)
 	CreateAPIKey(ctx context.Context, actor Actor, realmID, userID ID, apiKey APIKey) (APIKey, error)
-	UpdateAPIKey(ctx context.Context, actor Actor, realmID, userID, id ID, apiKey APIKey) (APIKey, error)
-	DeleteAPIKey(ctx context.Context, actor Actor, realmID, userID, id ID) error
+	UpdateAPIKey(ctx context.Context, actor Actor, realmID, userID, id ID, version Version, apiKey APIKey) (APIKey, error)
+	RotateAPIKey(ctx context.Context, actor Actor, realmID, userID, id ID, version Version, successor APIKey, gracePeriod time.Duration) (APIKey, error)
+	DeleteAPIKey(ctx context.Context, actor Actor, realmID, userID, id ID, version Version) error
+	GetEffectiveRoles(ctx context.Context, actor Actor, realmID, userID ID) (EffectiveRoles, error)
+	ImportUsers(ctx context.Context, actor Actor, realmID ID, rows []UserImportRow, dryRun bool) (UserImportReport, error)
+	ExportUsers(ctx context.Context, actor Actor, realmID ID, filter UserFilter, export func(User) error) error
+}
+
+// UserImpersonator defines the user impersonator interface.
+// ImpersonateUser returns the user the actor is allowed to act as.
+type UserImpersonator interface {
+	ImpersonateUser(ctx context.Context, actor Actor, realmID, id ID) (User, error)
 }
 
 // UserFinder defines the user finder interface.
 // This is a system operation and should not be used in the API.
 type UserFinder interface {
+	GetUserSys(ctx context.Context, realmID, id ID) (User, error)
 	GetUserByBindIDSys(ctx context.Context, realmID ID, bindID string) (User, error)
 }
 
@@ -53,30 +78,159 @@ type UserProvisioner interface {
 }
 
 // DaemonService defines the daemon service interface.
+//
+// The API keys are stored with their daemon: the updates, the rotations and the
+// deletions of the API keys must match the version of the daemon.
 type DaemonService interface {
-	GetDaemons(ctx context.Context, actor Actor, realmID ID) ([]Daemon, error)
+	GetDaemons(ctx context.Context, actor Actor, realmID ID, filter DaemonFilter, options ListOptions) (Page[Daemon], error)
 	GetDaemon(ctx context.Context, actor Actor, realmID, id ID) (Daemon, error)
 	CreateDaemon(ctx context.Context, actor Actor, daemon Daemon) (Daemon, error)
 	UpdateDaemon(ctx context.Context, actor Actor, daemon Daemon) (Daemon, error)
-	DeleteDaemon(ctx context.Context, actor Actor, realmID, id ID) error
-	GetAPIKeys(ctx context.Context, actor Actor, realmID, daemonID ID) ([]APIKey, error)
+	DeleteDaemon(ctx context.Context, actor Actor, realmID, id ID, version Version) error
+	RestoreDaemon(ctx context.Context, actor Actor, realmID, id ID) (Daemon, error)
+	GetAPIKeys(ctx context.Context, actor Actor, realmID, daemonID ID, filter APIKeyFilter) ([]APIKey, error)
 	GetAPIKey(ctx context.Context, actor Actor, realmID, daemonID, id ID) (APIKey, error)
 	CreateAPIKey(ctx context.Context, actor Actor, realmID, daemonID ID, apiKey APIKey) (APIKey, error)
-	UpdateAPIKey(ctx context.Context, actor Actor, realmID, daemonID, id ID, apiKey APIKey) (APIKey, error)
-	DeleteAPIKey(ctx context.Context, actor Actor, realmID, daemonID, id ID) error
+	UpdateAPIKey(ctx context.Context, actor Actor, realmID, daemonID, id ID, version Version, apiKey APIKey) (APIKey, error)
+	RotateAPIKey(ctx context.Context, actor Actor, realmID, daemonID, id ID, version Version, successor APIKey, gracePeriod time.Duration) (APIKey, error)
+	DeleteAPIKey(ctx context.Context, actor Actor, realmID, daemonID, id ID, version Version) error
+}
+
+// GroupService defines the group service interface.
+type GroupService interface {
+	GetGroups(ctx context.Context, actor Actor, realmID ID, options ListOptions) (Page[Group], error)
+	GetGroup(ctx context.Context, actor Actor, realmID, id ID) (Group, error)
+	CreateGroup(ctx context.Context, actor Actor, group Group) (Group, error)
+	UpdateGroup(ctx context.Context, actor Actor, group Group) (Group, error)
+	DeleteGroup(ctx context.Context, actor Actor, realmID, id ID, version Version) error
+	RestoreGroup(ctx context.Context, actor Actor, realmID, id ID) (Group, error)
+	AddGroupMember(ctx context.Context, actor Actor, realmID, id, userID ID) error
+	RemoveGroupMember(ctx context.Context, actor Actor, realmID, id, userID ID) error
+}
+
+// GroupFinder defines the group finder interface.
+// This is a system operation and should not be used in the API.
+type GroupFinder interface {
+	GetMemberGroupsSys(ctx context.Context, realmID, userID ID) ([]Group, error)
+}
+
+// MembershipService defines the membership service interface.
+type MembershipService interface {
+	GetMemberships(ctx context.Context, actor Actor, realmID ID, options ListOptions) (Page[Membership], error)
+	GetMembership(ctx context.Context, actor Actor, realmID, id ID) (Membership, error)
+	CreateMembership(ctx context.Context, actor Actor, membership Membership) (Membership, error)
+	UpdateMembership(ctx context.Context, actor Actor, membership Membership) (Membership, error)
+	DeleteMembership(ctx context.Context, actor Actor, realmID, id ID, version Version) error
+	RestoreMembership(ctx context.Context, actor Actor, realmID, id ID) (Membership, error)
+}
+
+// MembershipFinder defines the membership finder interface.
+// This is a system operation and should not be used in the API.
+type MembershipFinder interface {
+	GetUserMembershipSys(ctx context.Context, realmID, userID ID) (Membership, error)
+	GetUserMembershipsSys(ctx context.Context, userID ID) ([]Membership, error)
+}
+
+// PolicyService defines the policy service interface.
+type PolicyService interface {
+	GetPolicies(ctx context.Context, actor Actor, realmID ID, options ListOptions) (Page[Policy], error)
+	GetPolicy(ctx context.Context, actor Actor, realmID, id ID) (Policy, error)
+	CreatePolicy(ctx context.Context, actor Actor, policy Policy) (Policy, error)
+	UpdatePolicy(ctx context.Context, actor Actor, policy Policy) (Policy, error)
+	DeletePolicy(ctx context.Context, actor Actor, realmID, id ID, version Version) error
+	RestorePolicy(ctx context.Context, actor Actor, realmID, id ID) (Policy, error)
+}
+
+// RuleService defines the access rule service interface.
+// DryRunRule evaluates an expression against sample variables without storing it.
+type RuleService interface {
+	GetRules(ctx context.Context, actor Actor, realmID ID, options ListOptions) (Page[AccessRule], error)
+	GetRule(ctx context.Context, actor Actor, realmID, id ID) (AccessRule, error)
+	CreateRule(ctx context.Context, actor Actor, rule AccessRule) (AccessRule, error)
+	UpdateRule(ctx context.Context, actor Actor, rule AccessRule) (AccessRule, error)
+	DeleteRule(ctx context.Context, actor Actor, realmID, id ID, version Version) error
+	RestoreRule(ctx context.Context, actor Actor, realmID, id ID) (AccessRule, error)
+	DryRunRule(ctx context.Context, actor Actor, realmID ID, expression string, input RuleInput) (bool, error)
+}
+
+// AuthzService defines the authorization decision service interface.
+// The subject only needs its kind, realm and ID; its roles and groups are resolved
+// by the service. This is a system operation: the caller is authenticated by the API.
+type AuthzService interface {
+	Check(ctx context.Context, subject AuthzSubject, requests []AuthzRequest) ([]AuthzDecision, error)
 }
 
 // RealmLookupService defines the realm lookup service interface.
+// LookupRealmByID also finds the disabled realms, but not the deleted ones.
 type RealmLookupService interface {
 	LookupRealm(ctx context.Context, realmCode string) (Realm, error)
+	LookupRealmByID(ctx context.Context, id ID) (Realm, error)
 }
 
 // ProviderLookupService defines the provider lookup service interface.
+// LookupProviderByID also finds the disabled providers, but not the deleted ones.
 type ProviderLookupService interface {
 	LookupProvider(ctx context.Context, providerCode string) (Provider, error)
+	LookupProviderByID(ctx context.Context, id ID) (Provider, error)
 }
 
 // APIKeyLookupService defines the API key lookup service interface.
 type APIKeyLookupService interface {
-	LookupAPIKey(ctx context.Context, realmID ID, key string) (APIKey, error)
+	LookupAPIKey(ctx context.Context, realmID ID, key, clientIP string) (APIKeyPrincipal, error)
+}
+
+// APIKeyUsageRecorder defines the API key usage recorder interface.
+// Recording must not block the caller.
+type APIKeyUsageRecorder interface {
+	RecordAPIKeyUsage(usage APIKeyUsage)
+}
+
+// APIKeyExpiryService defines the API key expiry service interface.
+type APIKeyExpiryService interface {
+	ExpireAPIKeys(ctx context.Context, now time.Time, warnWithin time.Duration) (APIKeyExpiryReport, error)
+}
+
+// PurgeService defines the purge service interface.
+// PurgeDeleted removes for good the entities deleted before the given time.
+type PurgeService interface {
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+}
+
+// SearchService defines the search service interface.
+// An empty realm ID searches all the realms. The hits are sorted by decreasing score.
+type SearchService interface {
+	Search(ctx context.Context, actor Actor, realmID ID, text string, limit int) ([]SearchHit, error)
+}
+
+// LockoutService defines the lockout service interface.
+type LockoutService interface {
+	GetLockouts(ctx context.Context, actor Actor, options ListOptions) (Page[Lockout], error)
+	GetLockout(ctx context.Context, actor Actor, id ID) (Lockout, error)
+	DeleteLockout(ctx context.Context, actor Actor, id ID) error
+}
+
+// LockoutGuard defines the interface for guarding authentications against brute force.
+// CheckLockout returns an error if one of the subjects is locked out.
+type LockoutGuard interface {
+	CheckLockout(ctx context.Context, keys ...LockoutKey) error
+	RecordFailure(ctx context.Context, keys ...LockoutKey)
+}
+
+// Authorizer defines the interface for authorizing the operations of the actors.
+// Authorize returns a domain.AccessDeniedError if the actor lacks the permission
+// on the resource.
+//
+// Enforce returns a domain.AccessDeniedError if one of the access rules of the realm
+// of the target denies the operation. It is called after Authorize, before mutations.
+type Authorizer interface {
+	Authorize(ctx context.Context, actor Actor, permission Permission, resource Resource) error
+	Enforce(ctx context.Context, actor Actor, permission Permission, operation RuleOperation, target RuleTarget) error
+}
+
+// RuleEngine defines the interface for compiling and evaluating the expressions
+// of the access rules. Compile returns a domain.ValidationError if the expression
+// is invalid or does not yield a boolean.
+type RuleEngine interface {
+	Compile(expression string) error
+	Evaluate(expression string, input RuleInput) (bool, error)
 }
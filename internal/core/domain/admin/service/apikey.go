@@ -0,0 +1,166 @@
+package service
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// issueAPIKey validates a new API key, assigns its ID and creation time and applies
+// the API key policy of the realm. The returned API key is still in plain text.
+//
+// The key is always minted by the server in the realm API key format from a random
+// secret. A key supplied by the client is rejected.
+func issueAPIKey(
+	apiKey admin.APIKey,
+	realm admin.Realm,
+	idgen, keygen domain.IDGenerator,
+	now time.Time,
+) (admin.APIKey, error) {
+	apiKey, err := validateAPIKey(apiKey)
+	if err != nil {
+		return admin.APIKey{}, err
+	}
+
+	if apiKey.Key != "" {
+		return admin.APIKey{}, domain.NewValidationError("API keys are generated by the server")
+	}
+
+	apiKey.Key = admin.FormatAPIKey(realm.ID, keygen.GenerateID())
+
+	if apiKey.IsExpired(now) {
+		return admin.APIKey{}, domain.NewValidationError("API key expiration time must be in the future")
+	}
+
+	apiKey.ID = admin.ID(idgen.GenerateID())
+	apiKey.CreatedAt = now
+
+	return applyAPIKeyPolicy(apiKey, realm)
+}
+
+// applyAPIKeyPolicy applies the API key policy of the realm: the granted scopes must be
+// defined in the realm, and the lifetime of the API key is limited.
+func applyAPIKeyPolicy(apiKey admin.APIKey, realm admin.Realm) (admin.APIKey, error) {
+	for _, scope := range apiKey.Scopes {
+		if !realm.IsScopeDefined(scope) {
+			return admin.APIKey{}, domain.NewValidationError("scope %s is not defined in realm %s", scope, realm.ID)
+		}
+	}
+
+	return limitAPIKeyLifetime(apiKey, realm)
+}
+
+// limitAPIKeyLifetime applies the API key lifetime policy of the realm.
+//
+// API keys without an expiration time get the maximum lifetime. API keys expiring
+// after the maximum lifetime are rejected. Legacy API keys without a creation time
+// are not checked.
+func limitAPIKeyLifetime(apiKey admin.APIKey, realm admin.Realm) (admin.APIKey, error) {
+	if realm.APIKeyMaxLifetime == 0 || apiKey.CreatedAt.IsZero() {
+		return apiKey, nil
+	}
+
+	limit := apiKey.CreatedAt.Add(realm.APIKeyMaxLifetime)
+
+	if apiKey.ExpiresAt.IsZero() {
+		apiKey.ExpiresAt = limit
+
+		return apiKey, nil
+	}
+
+	if apiKey.ExpiresAt.After(limit) {
+		return admin.APIKey{}, domain.NewValidationError("API key cannot outlive the realm limit of %v",
+			realm.APIKeyMaxLifetime)
+	}
+
+	return apiKey, nil
+}
+
+// retireAPIKey marks a rotated API key as retired and shortens its lifetime to the
+// grace period. API keys already expiring within the grace period keep their expiry.
+func retireAPIKey(apiKey admin.APIKey, gracePeriod time.Duration, now time.Time) admin.APIKey {
+	retireAt := now.Add(gracePeriod)
+
+	apiKey.RetiredAt = now
+
+	if apiKey.ExpiresAt.IsZero() || apiKey.ExpiresAt.After(retireAt) {
+		apiKey.ExpiresAt = retireAt
+	}
+
+	return apiKey
+}
+
+// checkGracePeriod checks the grace period of an API key rotation.
+func checkGracePeriod(gracePeriod time.Duration) error {
+	if gracePeriod < 0 {
+		return domain.NewValidationError("grace period cannot be negative")
+	}
+
+	return nil
+}
+
+// sealAPIKey replaces the plain text key with its public prefix and keyed hash.
+// The returned API key is safe to be persisted.
+func sealAPIKey(apiKey admin.APIKey, hasher domain.KeyHasher) admin.APIKey {
+	apiKey.Prefix = admin.APIKeyPrefix(apiKey.Key)
+	apiKey.Hash = hasher.HashKey(apiKey.Key)
+	apiKey.Key = ""
+
+	return apiKey
+}
+
+// sealNewAPIKeys issues the API keys supplied with a new user or daemon
+// and returns them sealed.
+func sealNewAPIKeys(
+	apiKeys []admin.APIKey,
+	realm admin.Realm,
+	idgen, keygen domain.IDGenerator,
+	hasher domain.KeyHasher,
+	now time.Time,
+) ([]admin.APIKey, error) {
+	sealed := make([]admin.APIKey, 0, len(apiKeys))
+
+	for _, apiKey := range apiKeys {
+		apiKey, err := issueAPIKey(apiKey, realm, idgen, keygen, now)
+		if err != nil {
+			return nil, err
+		}
+
+		sealed = append(sealed, sealAPIKey(apiKey, hasher))
+	}
+
+	return sealed, nil
+}
+
+// revealAPIKey returns the sealed API key together with its plain text key.
+// It is only used in response to the creation of the key.
+func revealAPIKey(sealed admin.APIKey, key string) admin.APIKey {
+	sealed.Key = key
+
+	return sealed
+}
+
+// matchAPIKey checks in constant time if the plain text key matches the stored hash.
+func matchAPIKey(apiKey admin.APIKey, key string, hasher domain.KeyHasher) bool {
+	return subtle.ConstantTimeCompare([]byte(apiKey.Hash), []byte(hasher.HashKey(key))) == 1
+}
+
+// exportAPIKeys returns the metadata of the sealed API keys, without their hashes.
+// It returns none if the keys are not to be exported.
+func exportAPIKeys(apiKeys []admin.APIKey, export bool) []admin.APIKey {
+	if !export || len(apiKeys) == 0 {
+		return nil
+	}
+
+	exported := make([]admin.APIKey, len(apiKeys))
+
+	for i, apiKey := range apiKeys {
+		apiKey.Key = ""
+		apiKey.Hash = ""
+		exported[i] = apiKey
+	}
+
+	return exported
+}
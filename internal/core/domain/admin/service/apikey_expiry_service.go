@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// APIKeyExpiryService provides a service for expiring API keys of users and daemons.
+//
+// It implements the admin.APIKeyExpiryService interface.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type APIKeyExpiryService struct {
+	userRepo   admin.UserRepository
+	daemonRepo admin.DaemonRepository
+}
+
+// NewAPIKeyExpiryService returns a new APIKeyExpiryService instance.
+func NewAPIKeyExpiryService(
+	userRepo admin.UserRepository,
+	daemonRepo admin.DaemonRepository,
+) *APIKeyExpiryService {
+	return &APIKeyExpiryService{
+		userRepo:   userRepo,
+		daemonRepo: daemonRepo,
+	}
+}
+
+// Ensure service implements the admin.APIKeyExpiryService interface.
+var _ admin.APIKeyExpiryService = (*APIKeyExpiryService)(nil)
+
+// ExpireAPIKeys implements the admin.APIKeyExpiryService interface.
+//
+// It disables the API keys expired at the given time and reports the API keys
+// expiring within the given warning period. Retired API keys are not reported:
+// they expire at the end of the grace period of their rotation by design.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *APIKeyExpiryService) ExpireAPIKeys(
+	ctx context.Context,
+	now time.Time,
+	warnWithin time.Duration,
+) (admin.APIKeyExpiryReport, error) {
+	disabledUsers, err := s.userRepo.DisableExpiredAPIKeys(ctx, now)
+	if err != nil {
+		return admin.APIKeyExpiryReport{}, err
+	}
+
+	disabledDaemons, err := s.daemonRepo.DisableExpiredAPIKeys(ctx, now)
+	if err != nil {
+		return admin.APIKeyExpiryReport{}, err
+	}
+
+	warnBefore := now.Add(warnWithin)
+
+	expiringUser, err := s.userRepo.GetAPIKeysExpiringBefore(ctx, warnBefore)
+	if err != nil {
+		return admin.APIKeyExpiryReport{}, err
+	}
+
+	expiringDaemon, err := s.daemonRepo.GetAPIKeysExpiringBefore(ctx, warnBefore)
+	if err != nil {
+		return admin.APIKeyExpiryReport{}, err
+	}
+
+	return admin.APIKeyExpiryReport{
+		DisabledOwners: disabledUsers + disabledDaemons,
+		ExpiringUser:   withoutRetiredAPIKeys(expiringUser),
+		ExpiringDaemon: withoutRetiredAPIKeys(expiringDaemon),
+	}, nil
+}
+
+// withoutRetiredAPIKeys returns the API keys that have not been rotated.
+func withoutRetiredAPIKeys(apiKeys []admin.OwnedAPIKey) []admin.OwnedAPIKey {
+	return slices.DeleteFunc(apiKeys, func(owned admin.OwnedAPIKey) bool {
+		return owned.APIKey.IsRetired()
+	})
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyExpiryService_ExpireAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		forcedError error
+		wantError   error
+	}{
+		"success": {},
+		"repoError": {
+			forcedError: errors.New("forcedError"),
+			wantError:   domain.StoreError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			userRepo := &mockUserRepository{forcedError: test.forcedError}
+			daemonRepo := &mockDaemonRepository{}
+			svc := NewAPIKeyExpiryService(userRepo, daemonRepo)
+
+			report, err := svc.ExpireAPIKeys(context.Background(), time.Now(), time.Hour)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Zero(t, report.DisabledOwners)
+				require.Empty(t, report.ExpiringUser)
+				require.Empty(t, report.ExpiringDaemon)
+			}
+		})
+	}
+}
+
+func TestAPIKeyExpiryService_ExpireAPIKeys_retired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	active := admin.APIKey{ID: "k1", Enabled: true, ExpiresAt: now.Add(time.Minute)}
+	retired := admin.APIKey{ID: "k2", Enabled: true, ExpiresAt: now.Add(time.Minute), RetiredAt: now}
+
+	userRepo := &mockUserRepository{apiKeys: []admin.APIKey{retired, active}}
+	daemonRepo := &mockDaemonRepository{apiKeys: []admin.APIKey{retired}}
+	svc := NewAPIKeyExpiryService(userRepo, daemonRepo)
+
+	// the rotated API keys expire at the end of the grace period by design
+	report, err := svc.ExpireAPIKeys(context.Background(), now, time.Hour)
+
+	require.NoError(t, err)
+	require.Equal(t, ownAPIKeys("r1", "u1", []admin.APIKey{active}), report.ExpiringUser)
+	require.Empty(t, report.ExpiringDaemon)
+}
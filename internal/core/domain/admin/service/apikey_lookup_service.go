@@ -2,32 +2,47 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
 )
 
 // APIKeyLookupService provides a service for looking up API keys for
-// a user or a daemon.
+// a user or a daemon. The lookup returns the principal owning the API key.
 //
 // It implements the service.APIKeyLookupService interface.
 //
-// We use the repository to look up the API key for a user and a daemon.
+// We use the repository to look up the API key candidates for a user and a daemon
+// by the public key prefix. The candidates are verified by comparing the keyed hash
+// of the given key with the stored hash in constant time. Expired API keys are ignored,
+// even if the expiry job has not disabled them yet. The usage of the found API key
+// is recorded asynchronously. Keys in the server format that are malformed or belong
+// to another realm are rejected without a repository lookup. API keys restricted to
+// CIDR ranges, directly or through their daemon, are rejected with a network denied
+// error if the client IP is outside of these ranges; their usage is not recorded.
 //
 // We do not wrap the errors returned by the repository because they are already
 // packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
 type APIKeyLookupService struct {
-	userRepo   admin.UserRepository
-	daemonRepo admin.DaemonRepository
+	userRepo      admin.UserRepository
+	daemonRepo    admin.DaemonRepository
+	hasher        domain.KeyHasher
+	usageRecorder admin.APIKeyUsageRecorder
 }
 
 // NewAPIKeyLookupService returns a new APIKeyLookupService instance.
 func NewAPIKeyLookupService(
 	userRepo admin.UserRepository,
 	daemonRepo admin.DaemonRepository,
+	hasher domain.KeyHasher,
+	usageRecorder admin.APIKeyUsageRecorder,
 ) *APIKeyLookupService {
 	return &APIKeyLookupService{
-		userRepo:   userRepo,
-		daemonRepo: daemonRepo,
+		userRepo:      userRepo,
+		daemonRepo:    daemonRepo,
+		hasher:        hasher,
+		usageRecorder: usageRecorder,
 	}
 }
 
@@ -37,16 +52,112 @@ var _ admin.APIKeyLookupService = (*APIKeyLookupService)(nil)
 // LookupAPIKey implements the service.APIKeyLookupService interface.
 //
 //nolint:wrapcheck // see comment in the header
-func (s *APIKeyLookupService) LookupAPIKey(ctx context.Context, realmID admin.ID, key string) (admin.APIKey, error) {
-	fromUser, err := s.userRepo.GetAPIKey(ctx, realmID, key)
-	if err == nil {
-		return fromUser, nil
+func (s *APIKeyLookupService) LookupAPIKey(
+	ctx context.Context,
+	realmID admin.ID,
+	key, clientIP string,
+) (admin.APIKeyPrincipal, error) {
+	if admin.IsFormattedAPIKey(key) {
+		if keyRealmID, _, ok := admin.ParseAPIKey(key); !ok || keyRealmID != realmID {
+			return admin.APIKeyPrincipal{}, domain.NewNotFoundError("API key not found")
+		}
 	}
 
-	fromDaemon, err := s.daemonRepo.GetAPIKey(ctx, realmID, key)
-	if err == nil {
-		return fromDaemon, nil
+	prefix := admin.APIKeyPrefix(key)
+	now := time.Now()
+
+	fromUsers, err := s.userRepo.GetAPIKeysByPrefix(ctx, realmID, prefix)
+	if err != nil {
+		return admin.APIKeyPrincipal{}, err
+	}
+
+	if owned, found := s.findAPIKey(fromUsers, key, now); found {
+		user, uErr := s.userRepo.GetUser(ctx, owned.RealmID, owned.OwnerID)
+		if uErr != nil {
+			return admin.APIKeyPrincipal{}, uErr
+		}
+
+		if nErr := checkClientIP(owned.APIKey, nil, clientIP); nErr != nil {
+			return admin.APIKeyPrincipal{}, nErr
+		}
+
+		s.recordUsage(owned.APIKey, clientIP, now)
+
+		return admin.APIKeyPrincipal{
+			Kind:    admin.PrincipalKindUser,
+			RealmID: owned.RealmID,
+			OwnerID: owned.OwnerID,
+			Code:    user.Username,
+			Role:    user.Role,
+			APIKey:  owned.APIKey,
+		}, nil
+	}
+
+	fromDaemons, err := s.daemonRepo.GetAPIKeysByPrefix(ctx, realmID, prefix)
+	if err != nil {
+		return admin.APIKeyPrincipal{}, err
+	}
+
+	if owned, found := s.findAPIKey(fromDaemons, key, now); found {
+		daemon, dErr := s.daemonRepo.GetDaemon(ctx, owned.RealmID, owned.OwnerID)
+		if dErr != nil {
+			return admin.APIKeyPrincipal{}, dErr
+		}
+
+		if nErr := checkClientIP(owned.APIKey, daemon.AllowedCIDRs, clientIP); nErr != nil {
+			return admin.APIKeyPrincipal{}, nErr
+		}
+
+		s.recordUsage(owned.APIKey, clientIP, now)
+
+		return admin.APIKeyPrincipal{
+			Kind:          admin.PrincipalKindDaemon,
+			RealmID:       owned.RealmID,
+			OwnerID:       owned.OwnerID,
+			Code:          daemon.Code,
+			AllowedRealms: daemon.AllowedRealms,
+			APIKey:        owned.APIKey,
+		}, nil
 	}
 
-	return admin.APIKey{}, err
+	return admin.APIKeyPrincipal{}, domain.NewNotFoundError("API key not found")
+}
+
+// findAPIKey returns the candidate matching the plain text key. Expired candidates are skipped.
+func (s *APIKeyLookupService) findAPIKey(
+	candidates []admin.OwnedAPIKey,
+	key string,
+	now time.Time,
+) (admin.OwnedAPIKey, bool) {
+	for _, owned := range candidates {
+		if !owned.APIKey.IsExpired(now) && matchAPIKey(owned.APIKey, key, s.hasher) {
+			return owned, true
+		}
+	}
+
+	return admin.OwnedAPIKey{}, false
+}
+
+// checkClientIP checks the client IP against the CIDR ranges of the API key
+// and the CIDR ranges of its owner. Both restrictions must be satisfied.
+func checkClientIP(apiKey admin.APIKey, ownerCIDRs []string, clientIP string) error {
+	if !admin.IsIPAllowed(apiKey.AllowedCIDRs, clientIP) {
+		return domain.NewNetworkDeniedError("client IP %s is not allowed to use API key %s", clientIP, apiKey.ID)
+	}
+
+	if !admin.IsIPAllowed(ownerCIDRs, clientIP) {
+		return domain.NewNetworkDeniedError("client IP %s is not allowed to use the API keys of the owner of API key %s",
+			clientIP, apiKey.ID)
+	}
+
+	return nil
+}
+
+func (s *APIKeyLookupService) recordUsage(apiKey admin.APIKey, clientIP string, now time.Time) {
+	s.usageRecorder.RecordAPIKeyUsage(admin.APIKeyUsage{
+		APIKeyID:   apiKey.ID,
+		LastUsedAt: now,
+		LastUsedIP: clientIP,
+		Count:      1,
+	})
 }
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyLookupService_LookupAPIKey(t *testing.T) {
+	t.Parallel()
+
+	userKey := admin.APIKey{ID: "k1", Prefix: "userkey0", Hash: "hash:userkey0123456789"}
+	daemonKey := admin.APIKey{ID: "k2", Prefix: "daemonke", Hash: "hash:daemonkey0123456789"}
+	expiredKey := admin.APIKey{
+		ID:        "k3",
+		Prefix:    "expiredk",
+		Hash:      "hash:expiredkey0123456789",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+	allowedKey := admin.APIKey{
+		ID:           "k4",
+		Prefix:       "allowedk",
+		Hash:         "hash:allowedkey0123456789",
+		AllowedCIDRs: []string{"10.0.0.0/8"},
+	}
+	deniedKey := admin.APIKey{
+		ID:           "k5",
+		Prefix:       "deniedke",
+		Hash:         "hash:deniedkey0123456789",
+		AllowedCIDRs: []string{"192.168.0.0/16"},
+	}
+
+	tests := map[string]struct {
+		key           string
+		daemonCIDRs   []string
+		forcedError   error
+		wantPrincipal admin.APIKeyPrincipal
+		wantError     error
+	}{
+		"user": {
+			key: "userkey0123456789",
+			wantPrincipal: admin.APIKeyPrincipal{
+				Kind:    admin.PrincipalKindUser,
+				RealmID: "a1",
+				OwnerID: "u1",
+				Code:    "mockUser",
+				APIKey:  userKey,
+			},
+		},
+		"daemon": {
+			key: "daemonkey0123456789",
+			wantPrincipal: admin.APIKeyPrincipal{
+				Kind:          admin.PrincipalKindDaemon,
+				RealmID:       "a1",
+				OwnerID:       "u1",
+				Code:          "mockDaemon",
+				AllowedRealms: []admin.ID{"a2"},
+				APIKey:        daemonKey,
+			},
+		},
+		"networkAllowed": {
+			key: "allowedkey0123456789",
+			wantPrincipal: admin.APIKeyPrincipal{
+				Kind:    admin.PrincipalKindUser,
+				RealmID: "a1",
+				OwnerID: "u1",
+				Code:    "mockUser",
+				APIKey:  allowedKey,
+			},
+		},
+		"networkDenied": {
+			key:       "deniedkey0123456789",
+			wantError: domain.NetworkDeniedError{},
+		},
+		"daemonNetworkDenied": {
+			key:         "daemonkey0123456789",
+			daemonCIDRs: []string{"192.168.0.0/16"},
+			wantError:   domain.NetworkDeniedError{},
+		},
+		"expired": {
+			key:       "expiredkey0123456789",
+			wantError: domain.NotFoundError{},
+		},
+		"hashMismatch": {
+			key:       "userkey0-wrong-secret",
+			wantError: domain.NotFoundError{},
+		},
+		"foreignRealmKey": {
+			key:         admin.FormatAPIKey("a2", "userkey0123456789"),
+			forcedError: domain.NewStoreError("forcedError"),
+			wantError:   domain.NotFoundError{},
+		},
+		"malformedKey": {
+			key:         "ids_a1_userkey0123456789_000000",
+			forcedError: domain.NewStoreError("forcedError"),
+			wantError:   domain.NotFoundError{},
+		},
+		"repoError": {
+			key:         "userkey0123456789",
+			forcedError: domain.NewStoreError("forcedError"),
+			wantError:   domain.StoreError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			userRepo := &mockUserRepository{
+				apiKeys:     []admin.APIKey{userKey, expiredKey, allowedKey, deniedKey},
+				forcedError: test.forcedError,
+			}
+			daemonRepo := &mockDaemonRepository{
+				apiKeys:       []admin.APIKey{daemonKey},
+				allowedRealms: []admin.ID{"a2"},
+				allowedCIDRs:  test.daemonCIDRs,
+			}
+			usageRecorder := newMockAPIKeyUsageRecorder()
+			svc := NewAPIKeyLookupService(userRepo, daemonRepo, newMockKeyHasher(), usageRecorder)
+
+			res, err := svc.LookupAPIKey(context.Background(), "a1", test.key, "10.0.0.1")
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+				require.Empty(t, usageRecorder.usages)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.wantPrincipal, res)
+				require.Len(t, usageRecorder.usages, 1)
+				require.Equal(t, test.wantPrincipal.APIKey.ID, usageRecorder.usages[0].APIKeyID)
+				require.Equal(t, "10.0.0.1", usageRecorder.usages[0].LastUsedIP)
+				require.Equal(t, int64(1), usageRecorder.usages[0].Count)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/energimind/go-kit/slog"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+const (
+	// defaultUsageFlushInterval is used if no positive flush interval is given.
+	defaultUsageFlushInterval = 30 * time.Second
+
+	// usageQueueSize is the number of usages that can be queued before
+	// new usages are dropped.
+	usageQueueSize = 4096
+
+	// usageBatchSize is the number of distinct API keys that triggers a flush
+	// before the flush interval elapses.
+	usageBatchSize = 512
+
+	// usageFlushTimeout is the timeout of a single flush.
+	usageFlushTimeout = 10 * time.Second
+)
+
+// APIKeyUsageRecorder records the usage of API keys asynchronously.
+//
+// It implements the admin.APIKeyUsageRecorder interface.
+//
+// Usages are aggregated per API key in memory and written to the repositories
+// in batches, either periodically or when the batch is full. Usages are dropped
+// rather than blocking the caller when the queue is full, and once the recorder
+// is stopped.
+type APIKeyUsageRecorder struct {
+	userRepo      admin.UserRepository
+	daemonRepo    admin.DaemonRepository
+	flushInterval time.Duration
+	queue         chan admin.APIKeyUsage
+	done          chan struct{}
+	mu            sync.RWMutex // guards the queue against being closed while sending
+	stopped       bool
+}
+
+// NewAPIKeyUsageRecorder returns a new APIKeyUsageRecorder instance.
+// The recorder must be started with Start and stopped with Stop.
+func NewAPIKeyUsageRecorder(
+	userRepo admin.UserRepository,
+	daemonRepo admin.DaemonRepository,
+	flushInterval time.Duration,
+) *APIKeyUsageRecorder {
+	if flushInterval <= 0 {
+		flushInterval = defaultUsageFlushInterval
+	}
+
+	return &APIKeyUsageRecorder{
+		userRepo:      userRepo,
+		daemonRepo:    daemonRepo,
+		flushInterval: flushInterval,
+		queue:         make(chan admin.APIKeyUsage, usageQueueSize),
+		done:          make(chan struct{}),
+	}
+}
+
+// Ensure service implements the admin.APIKeyUsageRecorder interface.
+var _ admin.APIKeyUsageRecorder = (*APIKeyUsageRecorder)(nil)
+
+// RecordAPIKeyUsage implements the admin.APIKeyUsageRecorder interface.
+func (r *APIKeyUsageRecorder) RecordAPIKeyUsage(usage admin.APIKeyUsage) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.stopped {
+		return
+	}
+
+	select {
+	case r.queue <- usage:
+	default:
+		slog.Warn().Str("apiKeyId", usage.APIKeyID.String()).Msg("API key usage queue is full, usage dropped")
+	}
+}
+
+// Start starts recording the queued usages in the background.
+func (r *APIKeyUsageRecorder) Start() {
+	go r.run()
+}
+
+// Stop stops the recorder. The pending usages are flushed before it returns.
+// The usages recorded after it are dropped.
+func (r *APIKeyUsageRecorder) Stop() {
+	r.mu.Lock()
+
+	if r.stopped {
+		r.mu.Unlock()
+
+		return
+	}
+
+	r.stopped = true
+	close(r.queue)
+	r.mu.Unlock()
+
+	<-r.done
+}
+
+func (r *APIKeyUsageRecorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	pending := make(map[admin.ID]admin.APIKeyUsage)
+
+	for {
+		select {
+		case usage, ok := <-r.queue:
+			if !ok {
+				r.flush(pending)
+
+				return
+			}
+
+			pending[usage.APIKeyID] = mergeAPIKeyUsage(pending[usage.APIKeyID], usage)
+
+			if len(pending) >= usageBatchSize {
+				r.flush(pending)
+			}
+		case <-ticker.C:
+			r.flush(pending)
+		}
+	}
+}
+
+// flush writes the pending usages to the repositories and clears them.
+// The API key IDs are unique across users and daemons, so each usage
+// updates at most one API key.
+func (r *APIKeyUsageRecorder) flush(pending map[admin.ID]admin.APIKeyUsage) {
+	if len(pending) == 0 {
+		return
+	}
+
+	usages := make([]admin.APIKeyUsage, 0, len(pending))
+
+	for _, usage := range pending {
+		usages = append(usages, usage)
+	}
+
+	clear(pending)
+
+	ctx, cancel := context.WithTimeout(context.Background(), usageFlushTimeout)
+	defer cancel()
+
+	if err := r.userRepo.RecordAPIKeyUsage(ctx, usages); err != nil {
+		slog.Error().Err(err).Int("count", len(usages)).Msg("Failed to record user API key usage")
+	}
+
+	if err := r.daemonRepo.RecordAPIKeyUsage(ctx, usages); err != nil {
+		slog.Error().Err(err).Int("count", len(usages)).Msg("Failed to record daemon API key usage")
+	}
+}
+
+// mergeAPIKeyUsage merges the usage into the aggregated usage of the same API key.
+func mergeAPIKeyUsage(aggregated, usage admin.APIKeyUsage) admin.APIKeyUsage {
+	aggregated.APIKeyID = usage.APIKeyID
+	aggregated.Count += usage.Count
+
+	if !usage.LastUsedAt.Before(aggregated.LastUsedAt) {
+		aggregated.LastUsedAt = usage.LastUsedAt
+		aggregated.LastUsedIP = usage.LastUsedIP
+	}
+
+	return aggregated
+}
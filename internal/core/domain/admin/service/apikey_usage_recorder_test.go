@@ -0,0 +1,85 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyUsageRecorder_RecordAPIKeyUsage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	userRepo := newMockUserRepository()
+	daemonRepo := newMockDaemonRepository()
+	recorder := NewAPIKeyUsageRecorder(userRepo, daemonRepo, time.Hour)
+
+	recorder.Start()
+
+	recorder.RecordAPIKeyUsage(admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now, LastUsedIP: "10.0.0.2", Count: 1})
+	recorder.RecordAPIKeyUsage(admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now.Add(-time.Minute), LastUsedIP: "10.0.0.1", Count: 1})
+	recorder.RecordAPIKeyUsage(admin.APIKeyUsage{APIKeyID: "k2", LastUsedAt: now, LastUsedIP: "10.0.0.3", Count: 1})
+
+	recorder.Stop()
+
+	want := []admin.APIKeyUsage{
+		{APIKeyID: "k1", LastUsedAt: now, LastUsedIP: "10.0.0.2", Count: 2},
+		{APIKeyID: "k2", LastUsedAt: now, LastUsedIP: "10.0.0.3", Count: 1},
+	}
+
+	require.ElementsMatch(t, want, userRepo.usages)
+	require.ElementsMatch(t, want, daemonRepo.usages)
+}
+
+func TestAPIKeyUsageRecorder_RecordAPIKeyUsage_afterStop(t *testing.T) {
+	t.Parallel()
+
+	userRepo := newMockUserRepository()
+	recorder := NewAPIKeyUsageRecorder(userRepo, newMockDaemonRepository(), time.Hour)
+
+	recorder.Start()
+	recorder.Stop()
+
+	require.NotPanics(t, func() {
+		recorder.RecordAPIKeyUsage(admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: time.Now(), Count: 1})
+		recorder.Stop()
+	})
+	require.Empty(t, userRepo.usages)
+}
+
+func Test_mergeAPIKeyUsage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := map[string]struct {
+		aggregated admin.APIKeyUsage
+		usage      admin.APIKeyUsage
+		want       admin.APIKeyUsage
+	}{
+		"first": {
+			usage: admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now, LastUsedIP: "10.0.0.1", Count: 1},
+			want:  admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now, LastUsedIP: "10.0.0.1", Count: 1},
+		},
+		"later": {
+			aggregated: admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now, LastUsedIP: "10.0.0.1", Count: 2},
+			usage:      admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now.Add(time.Second), LastUsedIP: "10.0.0.2", Count: 1},
+			want:       admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now.Add(time.Second), LastUsedIP: "10.0.0.2", Count: 3},
+		},
+		"earlier": {
+			aggregated: admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now, LastUsedIP: "10.0.0.1", Count: 2},
+			usage:      admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now.Add(-time.Second), LastUsedIP: "10.0.0.2", Count: 1},
+			want:       admin.APIKeyUsage{APIKeyID: "k1", LastUsedAt: now, LastUsedIP: "10.0.0.1", Count: 3},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.want, mergeAPIKeyUsage(test.aggregated, test.usage))
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// Authorizer authorizes the operations of the actors on the admin resources.
+//
+// It implements the admin.Authorizer interface.
+//
+// The system role of the actor is checked first. The custom roles assigned to the
+// actor, directly or through its groups, are looked up only if the system role
+// does not grant the permission and the resource belongs to the realm of the actor,
+// because custom roles never extend beyond it.
+//
+// The access rules of a realm are enforced on the actors that are not system
+// administrators. An expression that cannot be evaluated denies the operation.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type Authorizer struct {
+	userRepo  admin.UserRepository
+	realmRepo admin.RealmRepository
+	groupRepo admin.GroupRepository
+	ruleRepo  admin.RuleRepository
+	engine    admin.RuleEngine
+}
+
+// NewAuthorizer returns a new Authorizer instance.
+func NewAuthorizer(
+	userRepo admin.UserRepository,
+	realmRepo admin.RealmRepository,
+	groupRepo admin.GroupRepository,
+	ruleRepo admin.RuleRepository,
+	engine admin.RuleEngine,
+) *Authorizer {
+	return &Authorizer{
+		userRepo:  userRepo,
+		realmRepo: realmRepo,
+		groupRepo: groupRepo,
+		ruleRepo:  ruleRepo,
+		engine:    engine,
+	}
+}
+
+// Ensure service implements the admin.Authorizer interface.
+var _ admin.Authorizer = (*Authorizer)(nil)
+
+// Authorize implements the admin.Authorizer interface.
+// Impersonated actors are denied the admin.ImpersonationDeniedPermissions whatever
+// their role.
+func (a *Authorizer) Authorize(
+	ctx context.Context,
+	actor admin.Actor,
+	permission admin.Permission,
+	resource admin.Resource,
+) error {
+	if actor.IsDeniedByImpersonation(permission) {
+		return domain.NewAccessDeniedError("impersonated actor %s is not allowed to %s", actor, permission)
+	}
+
+	if actor.Role.Permits(actor, permission, resource) {
+		return nil
+	}
+
+	if actor.UserID != "" && resource.RealmID != "" && resource.RealmID == actor.RealmID {
+		granted, err := a.customRolesPermit(ctx, actor, permission, resource)
+		if err != nil {
+			return err
+		}
+
+		if granted {
+			return nil
+		}
+	}
+
+	return domain.NewAccessDeniedError("actor %s is not allowed to %s", actor, permission)
+}
+
+// Enforce implements the admin.Authorizer interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (a *Authorizer) Enforce(
+	ctx context.Context,
+	actor admin.Actor,
+	permission admin.Permission,
+	operation admin.RuleOperation,
+	target admin.RuleTarget,
+) error {
+	if actor.Role == admin.SystemRoleAdmin {
+		return nil
+	}
+
+	page, err := a.ruleRepo.GetRules(ctx, target.RealmID, admin.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	rules := slices.DeleteFunc(page.Items, func(rule admin.AccessRule) bool {
+		return !rule.AppliesTo(permission)
+	})
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	input, err := a.ruleInput(ctx, actor, permission, operation, target)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		allowed, err := a.engine.Evaluate(rule.Expression, input)
+		if err != nil {
+			return domain.NewAccessDeniedError("access rule %s could not be evaluated: %v", rule.Code, err)
+		}
+
+		if !allowed {
+			return domain.NewAccessDeniedError("access rule %s denies actor %s to %s %s %s",
+				rule.Code, actor, operation, target.Kind, target.ID)
+		}
+	}
+
+	return nil
+}
+
+// ruleInput returns the variables the access rules are evaluated against.
+// The attributes of a user are looked up in the realm of the user.
+//
+//nolint:wrapcheck // see comment in the header
+func (a *Authorizer) ruleInput(
+	ctx context.Context,
+	actor admin.Actor,
+	permission admin.Permission,
+	operation admin.RuleOperation,
+	target admin.RuleTarget,
+) (admin.RuleInput, error) {
+	var (
+		actorUser   admin.User
+		actorGroups []admin.Group
+	)
+
+	if actor.UserID != "" {
+		user, err := a.userRepo.GetUser(ctx, actor.RealmID, actor.UserID)
+
+		switch {
+		case err == nil:
+			actorUser = user
+		case !domain.IsNotFoundError(err):
+			return admin.RuleInput{}, err
+		}
+
+		actorGroups, err = a.groupRepo.GetMemberGroups(ctx, actor.RealmID, actor.UserID)
+		if err != nil {
+			return admin.RuleInput{}, err
+		}
+	}
+
+	var targetGroups []admin.Group
+
+	if target.Kind == admin.RuleTargetUser && target.ID != "" {
+		groups, err := a.groupRepo.GetMemberGroups(ctx, target.RealmID, target.ID)
+		if err != nil {
+			return admin.RuleInput{}, err
+		}
+
+		targetGroups = groups
+	}
+
+	return admin.RuleInput{
+		Actor:   admin.ActorVariables(actor, actorUser, actorGroups),
+		Target:  target.Variables(admin.MergeAttributes(targetGroups)),
+		Request: admin.RequestVariables(permission, operation),
+	}, nil
+}
+
+// customRolesPermit returns true if one of the custom roles assigned to the actor
+// grants the permission on the resource. The roles are looked up on every call,
+// so that revoked roles take effect immediately.
+//
+//nolint:wrapcheck // see comment in the header
+func (a *Authorizer) customRolesPermit(
+	ctx context.Context,
+	actor admin.Actor,
+	permission admin.Permission,
+	resource admin.Resource,
+) (bool, error) {
+	realm, err := a.realmRepo.GetRealm(ctx, actor.RealmID)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if len(realm.Roles) == 0 {
+		return false, nil
+	}
+
+	user, err := a.userRepo.GetUser(ctx, actor.RealmID, actor.UserID)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if !user.Enabled {
+		return false, nil
+	}
+
+	groups, err := a.groupRepo.GetMemberGroups(ctx, actor.RealmID, actor.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range admin.ResolveRoles(realm, user, groups).Roles {
+		if role, found := realm.FindRole(name); found && role.Permits(actor, permission, resource) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
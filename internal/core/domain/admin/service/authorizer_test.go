@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizer_Authorize(t *testing.T) {
+	t.Parallel()
+
+	auditor := admin.Role{Name: "auditor", Permissions: []admin.Permission{admin.PermissionUsersRead}}
+	actor := admin.Actor{UserID: "u1", RealmID: "1", Role: admin.SystemRoleUser}
+
+	tests := map[string]struct {
+		actor       admin.Actor
+		realmRoles  []admin.Role
+		userRoles   []string
+		groupRoles  []string
+		permission  admin.Permission
+		resource    admin.Resource
+		forcedError error
+		wantError   error
+	}{
+		"systemRole": {
+			actor:      actor,
+			permission: admin.PermissionUsersRead,
+			resource:   admin.OwnedResource("1", "u1"),
+		},
+		"customRole": {
+			actor:      actor,
+			realmRoles: []admin.Role{auditor},
+			userRoles:  []string{"auditor"},
+			permission: admin.PermissionUsersRead,
+			resource:   admin.RealmResource("1"),
+		},
+		"groupRole": {
+			actor:      actor,
+			realmRoles: []admin.Role{auditor},
+			groupRoles: []string{"auditor"},
+			permission: admin.PermissionUsersRead,
+			resource:   admin.RealmResource("1"),
+		},
+		"customRole-notGranted": {
+			actor:      actor,
+			realmRoles: []admin.Role{auditor},
+			userRoles:  []string{"auditor"},
+			permission: admin.PermissionUsersWrite,
+			resource:   admin.RealmResource("1"),
+			wantError:  domain.AccessDeniedError{},
+		},
+		"customRole-otherRealm": {
+			actor:      actor,
+			realmRoles: []admin.Role{auditor},
+			userRoles:  []string{"auditor"},
+			permission: admin.PermissionUsersRead,
+			resource:   admin.RealmResource("2"),
+			wantError:  domain.AccessDeniedError{},
+		},
+		"customRole-notAssigned": {
+			actor:      actor,
+			realmRoles: []admin.Role{auditor},
+			permission: admin.PermissionUsersRead,
+			resource:   admin.RealmResource("1"),
+			wantError:  domain.AccessDeniedError{},
+		},
+		"customRole-undefined": {
+			actor:      actor,
+			userRoles:  []string{"auditor"},
+			permission: admin.PermissionUsersRead,
+			resource:   admin.RealmResource("1"),
+			wantError:  domain.AccessDeniedError{},
+		},
+		"customRole-anonymous": {
+			actor:      admin.Actor{RealmID: "1"},
+			realmRoles: []admin.Role{auditor},
+			userRoles:  []string{"auditor"},
+			permission: admin.PermissionUsersRead,
+			resource:   admin.RealmResource("1"),
+			wantError:  domain.AccessDeniedError{},
+		},
+		"repoError": {
+			actor:       actor,
+			realmRoles:  []admin.Role{auditor},
+			userRoles:   []string{"auditor"},
+			permission:  admin.PermissionUsersRead,
+			resource:    admin.RealmResource("1"),
+			forcedError: domain.NewStoreError("forcedError"),
+			wantError:   domain.StoreError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			userRepo := newMockUserRepository()
+			userRepo.roles = test.userRoles
+			userRepo.forcedError = test.forcedError
+			realmRepo := newMockRealmRepository()
+			realmRepo.roles = test.realmRoles
+			groupRepo := newMockGroupRepository()
+			groupRepo.roles = test.groupRoles
+			authorizer := NewAuthorizer(userRepo, realmRepo, groupRepo, newMockRuleRepository(), newMockRuleEngine())
+
+			err := authorizer.Authorize(context.Background(), test.actor, test.permission, test.resource)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthorizer_Enforce(t *testing.T) {
+	t.Parallel()
+
+	actor := admin.Actor{UserID: "u1", RealmID: "a1", Role: admin.SystemRoleManager}
+	target := admin.UserTarget(admin.User{ID: "u2", RealmID: "a1", Username: "user2"})
+
+	rule := func(expression string, enabled bool, permissions ...admin.Permission) admin.AccessRule {
+		return admin.AccessRule{
+			ID:          "r1",
+			RealmID:     "a1",
+			Code:        "rule",
+			Enabled:     enabled,
+			Permissions: permissions,
+			Expression:  expression,
+		}
+	}
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		rules     []admin.AccessRule
+		wantError error
+	}{
+		"noRules": {
+			actor: actor,
+		},
+		"allow": {
+			actor: actor,
+			rules: []admin.AccessRule{rule("true", true, admin.PermissionUsersWrite)},
+		},
+		"deny": {
+			actor:     actor,
+			rules:     []admin.AccessRule{rule("false", true, admin.PermissionUsersWrite)},
+			wantError: domain.AccessDeniedError{},
+		},
+		"deny-oneOfMany": {
+			actor: actor,
+			rules: []admin.AccessRule{
+				rule("true", true, admin.PermissionUsersWrite),
+				rule("false", true, admin.PermissionUsersWrite),
+			},
+			wantError: domain.AccessDeniedError{},
+		},
+		"deny-otherPermission": {
+			actor: actor,
+			rules: []admin.AccessRule{rule("false", true, admin.PermissionGroupsWrite)},
+		},
+		"deny-disabled": {
+			actor: actor,
+			rules: []admin.AccessRule{rule("false", false, admin.PermissionUsersWrite)},
+		},
+		"deny-admin": {
+			actor: admin.Actor{Role: admin.SystemRoleAdmin},
+			rules: []admin.AccessRule{rule("false", true, admin.PermissionUsersWrite)},
+		},
+		"evaluationError": {
+			actor:     actor,
+			rules:     []admin.AccessRule{rule("error", true, admin.PermissionUsersWrite)},
+			wantError: domain.AccessDeniedError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ruleRepo := newMockRuleRepository()
+			ruleRepo.rules = test.rules
+			engine := newMockRuleEngine()
+			authorizer := NewAuthorizer(newMockUserRepository(), newMockRealmRepository(), newMockGroupRepository(),
+				ruleRepo, engine)
+
+			err := authorizer.Enforce(context.Background(), test.actor, admin.PermissionUsersWrite,
+				admin.RuleOperationUpdate, target)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthorizer_Enforce_input(t *testing.T) {
+	t.Parallel()
+
+	actor := admin.Actor{UserID: "u1", RealmID: "a1", Role: admin.SystemRoleManager}
+	target := admin.UserTarget(admin.User{ID: "u2", RealmID: "a1", Username: "user2"})
+
+	ruleRepo := newMockRuleRepository()
+	ruleRepo.rules = []admin.AccessRule{{
+		RealmID:     "a1",
+		Code:        "rule",
+		Enabled:     true,
+		Permissions: []admin.Permission{admin.PermissionUsersWrite},
+		Expression:  "true",
+	}}
+	groupRepo := newMockGroupRepository()
+	groupRepo.roles = []string{}
+	groupRepo.attributes = map[string]string{"department": "sales"}
+	engine := newMockRuleEngine()
+	authorizer := NewAuthorizer(newMockUserRepository(), newMockRealmRepository(), groupRepo, ruleRepo, engine)
+
+	err := authorizer.Enforce(context.Background(), actor, admin.PermissionUsersWrite, admin.RuleOperationDelete, target)
+	require.NoError(t, err)
+
+	input := engine.lastInput
+
+	require.Equal(t, "u1", input.Actor["id"])
+	require.Equal(t, "manager", input.Actor["role"])
+	require.Equal(t, []string{"mockGroup"}, input.Actor["groups"])
+	require.Equal(t, map[string]string{"department": "sales"}, input.Actor["attributes"])
+	require.Equal(t, "user", input.Target["kind"])
+	require.Equal(t, "u2", input.Target["id"])
+	require.Equal(t, "user2", input.Target["username"])
+	require.Equal(t, map[string]string{"department": "sales"}, input.Target["attributes"])
+	require.Equal(t, "users.write", input.Request["permission"])
+	require.Equal(t, "delete", input.Request["operation"])
+}
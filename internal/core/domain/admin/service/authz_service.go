@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// AuthzService is a service for deciding the authorization requests of the
+// downstream services against the policies of the realms.
+//
+// It implements the service.AuthzService interface.
+//
+// The roles and the groups of the subject are looked up on every call, so that
+// the decisions reflect the current assignments. Disabled users and daemons are
+// denied everything.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type AuthzService struct {
+	policyRepo admin.PolicyRepository
+	userRepo   admin.UserRepository
+	daemonRepo admin.DaemonRepository
+	realmRepo  admin.RealmRepository
+	groupRepo  admin.GroupRepository
+}
+
+// NewAuthzService returns a new AuthzService instance.
+func NewAuthzService(
+	policyRepo admin.PolicyRepository,
+	userRepo admin.UserRepository,
+	daemonRepo admin.DaemonRepository,
+	realmRepo admin.RealmRepository,
+	groupRepo admin.GroupRepository,
+) *AuthzService {
+	return &AuthzService{
+		policyRepo: policyRepo,
+		userRepo:   userRepo,
+		daemonRepo: daemonRepo,
+		realmRepo:  realmRepo,
+		groupRepo:  groupRepo,
+	}
+}
+
+// Ensure service implements the service.AuthzService interface.
+var _ admin.AuthzService = (*AuthzService)(nil)
+
+// Check implements the service.AuthzService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *AuthzService) Check(
+	ctx context.Context,
+	subject admin.AuthzSubject,
+	requests []admin.AuthzRequest,
+) ([]admin.AuthzDecision, error) {
+	subject, enabled, err := s.resolveSubject(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var policies admin.Page[admin.Policy]
+
+	if enabled {
+		policies, err = s.policyRepo.GetPolicies(ctx, subject.RealmID, admin.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decisions := make([]admin.AuthzDecision, len(requests))
+
+	for i, request := range requests {
+		decisions[i] = admin.EvaluatePolicies(policies.Items, subject, request)
+	}
+
+	return decisions, nil
+}
+
+// resolveSubject returns the subject with its roles and groups, and whether it is enabled.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *AuthzService) resolveSubject(
+	ctx context.Context,
+	subject admin.AuthzSubject,
+) (admin.AuthzSubject, bool, error) {
+	switch subject.Kind {
+	case admin.PrincipalKindUser:
+		return s.resolveUser(ctx, subject)
+	case admin.PrincipalKindDaemon:
+		daemon, err := s.daemonRepo.GetDaemon(ctx, subject.RealmID, subject.ID)
+		if err != nil {
+			return admin.AuthzSubject{}, false, err
+		}
+
+		return subject, daemon.Enabled, nil
+	default:
+		return admin.AuthzSubject{}, false, domain.NewValidationError("invalid subject kind: %s", subject.Kind)
+	}
+}
+
+//nolint:wrapcheck // see comment in the header
+func (s *AuthzService) resolveUser(
+	ctx context.Context,
+	subject admin.AuthzSubject,
+) (admin.AuthzSubject, bool, error) {
+	user, err := s.userRepo.GetUser(ctx, subject.RealmID, subject.ID)
+	if err != nil {
+		return admin.AuthzSubject{}, false, err
+	}
+
+	realm, err := s.realmRepo.GetRealm(ctx, subject.RealmID)
+	if err != nil {
+		return admin.AuthzSubject{}, false, err
+	}
+
+	groups, err := s.groupRepo.GetMemberGroups(ctx, subject.RealmID, subject.ID)
+	if err != nil {
+		return admin.AuthzSubject{}, false, err
+	}
+
+	effective := admin.ResolveRoles(realm, user, groups)
+
+	subject.Roles = nil
+	subject.Groups = nil
+
+	if user.Role != admin.SystemRoleNone {
+		subject.Roles = append(subject.Roles, user.Role.String())
+	}
+
+	subject.Roles = append(subject.Roles, effective.Roles...)
+
+	for _, group := range groups {
+		subject.Groups = append(subject.Groups, group.Code)
+	}
+
+	return subject, user.Enabled, nil
+}
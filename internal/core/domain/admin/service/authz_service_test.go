@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthzService_Check(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+	user := admin.AuthzSubject{Kind: admin.PrincipalKindUser, RealmID: realmID, ID: "u1"}
+	daemon := admin.AuthzSubject{Kind: admin.PrincipalKindDaemon, RealmID: realmID, ID: "d1"}
+	request := admin.AuthzRequest{Action: "invoices:read", Resource: "invoices/1"}
+
+	policies := []admin.Policy{
+		{
+			ID:        "p1",
+			RealmID:   realmID,
+			Code:      "auditors",
+			Effect:    admin.PolicyEffectAllow,
+			Actions:   []string{"invoices:*"},
+			Resources: []string{"invoices/*"},
+			Roles:     []string{"auditor"},
+		},
+		{
+			ID:        "p2",
+			RealmID:   realmID,
+			Code:      "sales",
+			Effect:    admin.PolicyEffectAllow,
+			Actions:   []string{"invoices:read"},
+			Resources: []string{"invoices/*"},
+			Groups:    []string{"mockGroup"},
+		},
+		{
+			ID:         "p3",
+			RealmID:    realmID,
+			Code:       "daemons",
+			Effect:     admin.PolicyEffectAllow,
+			Actions:    []string{"invoices:read"},
+			Resources:  []string{"*"},
+			Principals: []admin.ID{"d1"},
+		},
+	}
+
+	tests := map[string]struct {
+		subject     admin.AuthzSubject
+		userRoles   []string
+		groupRoles  []string
+		disabled    bool
+		wantAllowed bool
+		wantPolicy  admin.ID
+		wantError   error
+	}{
+		"user-noMatch": {
+			subject: user,
+		},
+		"user-role": {
+			subject:     user,
+			userRoles:   []string{"auditor"},
+			wantAllowed: true,
+			wantPolicy:  "p1",
+		},
+		"user-group": {
+			subject:     user,
+			groupRoles:  []string{},
+			wantAllowed: true,
+			wantPolicy:  "p2",
+		},
+		"user-disabled": {
+			subject:   user,
+			userRoles: []string{"auditor"},
+			disabled:  true,
+		},
+		"daemon": {
+			subject:     daemon,
+			wantAllowed: true,
+			wantPolicy:  "p3",
+		},
+		"invalidKind": {
+			subject:   admin.AuthzSubject{Kind: "other", RealmID: realmID, ID: "x1"},
+			wantError: domain.ValidationError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policyRepo := newMockPolicyRepository()
+			policyRepo.policies = policies
+			userRepo := newMockUserRepository()
+			userRepo.roles = test.userRoles
+			realmRepo := newMockRealmRepository()
+			realmRepo.roles = []admin.Role{{Name: "auditor"}}
+			groupRepo := newMockGroupRepository()
+			groupRepo.roles = test.groupRoles
+
+			if test.disabled {
+				policyRepo.forcedError = domain.NewStoreError("policies must not be loaded")
+				userRepo.disabled = true
+			}
+
+			svc := NewAuthzService(policyRepo, userRepo, newMockDaemonRepository(), realmRepo, groupRepo)
+
+			decisions, err := svc.Check(context.Background(), test.subject, []admin.AuthzRequest{request})
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, decisions, 1)
+			require.Equal(t, request, decisions[0].Request)
+			require.Equal(t, test.wantAllowed, decisions[0].Allowed)
+			require.Equal(t, test.wantPolicy, decisions[0].Policy.ID)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -14,18 +16,30 @@ import (
 // We do not wrap the errors returned by the repository because they are already
 // packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
 type DaemonService struct {
-	repo  admin.DaemonRepository
-	idgen domain.IDGenerator
+	repo       admin.DaemonRepository
+	realmRepo  admin.RealmRepository
+	authorizer admin.Authorizer
+	idgen      domain.IDGenerator
+	keygen     domain.IDGenerator
+	hasher     domain.KeyHasher
 }
 
 // NewDaemonService returns a new DaemonService instance.
 func NewDaemonService(
 	repo admin.DaemonRepository,
+	realmRepo admin.RealmRepository,
+	authorizer admin.Authorizer,
 	idgen domain.IDGenerator,
+	keygen domain.IDGenerator,
+	hasher domain.KeyHasher,
 ) *DaemonService {
 	return &DaemonService{
-		repo:  repo,
-		idgen: idgen,
+		repo:       repo,
+		realmRepo:  realmRepo,
+		authorizer: authorizer,
+		idgen:      idgen,
+		keygen:     keygen,
+		hasher:     hasher,
 	}
 }
 
@@ -39,72 +53,42 @@ func (s *DaemonService) GetDaemons(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID admin.ID,
-) ([]admin.Daemon, error) {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return nil, domain.NewAccessDeniedError("user %s cannot get daemons", actor.UserID)
-	case admin.SystemRoleManager:
-		if actor.RealmID != realmID {
-			return nil, domain.NewAccessDeniedError("manager %s cannot get daemons for realm %s", actor.UserID, realmID)
-		}
+	filter admin.DaemonFilter,
+	options admin.ListOptions,
+) (admin.Page[admin.Daemon], error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionDaemonsRead, admin.RealmResource(realmID)); err != nil {
+		return admin.Page[admin.Daemon]{}, err
+	}
 
-		daemons, err := s.repo.GetDaemons(ctx, realmID)
-		if err != nil {
-			return nil, err
-		}
+	options, err := validateListOptions(options, admin.DaemonSortFields)
+	if err != nil {
+		return admin.Page[admin.Daemon]{}, err
+	}
 
-		return daemons, nil
-	case admin.SystemRoleAdmin:
-		daemons, err := s.repo.GetDaemons(ctx, realmID)
-		if err != nil {
-			return nil, err
-		}
+	filter, err = validateDaemonFilter(filter)
+	if err != nil {
+		return admin.Page[admin.Daemon]{}, err
+	}
 
-		return daemons, nil
-	case admin.SystemRoleNone:
-		return nil, domain.NewAccessDeniedError("anonymous user cannot get daemons")
-	default:
-		return nil, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	page, err := s.repo.GetDaemons(ctx, realmID, filter, options)
+	if err != nil {
+		return admin.Page[admin.Daemon]{}, err
 	}
+
+	return page, nil
 }
 
 // GetDaemon implements the service.DaemonService interface.
-//
-//nolint:wrapcheck // see comment in the header
 func (s *DaemonService) GetDaemon(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, id admin.ID,
 ) (admin.Daemon, error) {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.Daemon{}, domain.NewAccessDeniedError("user %s cannot get daemon %s", actor.UserID, id)
-	case admin.SystemRoleManager:
-		if actor.RealmID != realmID {
-			return admin.Daemon{}, domain.NewAccessDeniedError("manager %s cannot get daemon %s", actor.UserID, id)
-		}
-
-		daemon, err := s.repo.GetDaemon(ctx, realmID, id)
-		if err != nil {
-			return admin.Daemon{}, err
-		}
-
-		return daemon, nil
-	case admin.SystemRoleAdmin:
-		user, err := s.repo.GetDaemon(ctx, realmID, id)
-		if err != nil {
-			return admin.Daemon{}, err
-		}
-
-		return user, nil
-	case admin.SystemRoleNone:
-		return admin.Daemon{}, domain.NewAccessDeniedError("anonymous user cannot get daemon %s", id)
-	default:
-		return admin.Daemon{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
-	}
+	return s.getDaemon(ctx, actor, admin.PermissionDaemonsRead, realmID, id)
 }
 
 // CreateDaemon implements the service.DaemonService interface.
+// Allowing the daemon to serve other realms requires the permission to do so.
 //
 //nolint:wrapcheck // see comment in the header
 func (s *DaemonService) CreateDaemon(
@@ -117,37 +101,44 @@ func (s *DaemonService) CreateDaemon(
 		return admin.Daemon{}, err
 	}
 
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.Daemon{}, domain.NewAccessDeniedError("user %s cannot create daemon", actor.UserID)
-	case admin.SystemRoleManager:
-		if actor.RealmID != daemon.RealmID {
-			return admin.Daemon{}, domain.NewAccessDeniedError("manager %s cannot create daemon", actor.UserID)
-		}
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionDaemonsWrite, admin.RealmResource(daemon.RealmID)); err != nil {
+		return admin.Daemon{}, err
+	}
 
-		daemon.ID = admin.ID(s.idgen.GenerateID())
+	if err := s.authorizeAllowedRealms(ctx, actor, daemon.AllowedRealms, nil); err != nil {
+		return admin.Daemon{}, err
+	}
 
-		if err := s.repo.CreateDaemon(ctx, daemon); err != nil {
-			return admin.Daemon{}, err
-		}
+	if err := s.checkAllowedRealms(ctx, daemon.AllowedRealms); err != nil {
+		return admin.Daemon{}, err
+	}
 
-		return daemon, nil
-	case admin.SystemRoleAdmin:
-		daemon.ID = admin.ID(s.idgen.GenerateID())
+	if len(daemon.APIKeys) > 0 && actor.IsDeniedByImpersonation(admin.PermissionDaemonsKeysWrite) {
+		return admin.Daemon{}, domain.NewAccessDeniedError("impersonated actor %s cannot create API keys", actor)
+	}
 
-		if err := s.repo.CreateDaemon(ctx, daemon); err != nil {
-			return admin.Daemon{}, err
-		}
+	daemon.APIKeys, err = s.sealNewAPIKeys(ctx, daemon.RealmID, daemon.APIKeys)
+	if err != nil {
+		return admin.Daemon{}, err
+	}
+
+	daemon.ID = admin.ID(s.idgen.GenerateID())
+	daemon.Version = admin.FirstVersion
+	daemon.CreatedAt = time.Now()
+
+	if err := s.enforce(ctx, actor, admin.RuleOperationCreate, daemon); err != nil {
+		return admin.Daemon{}, err
+	}
 
-		return daemon, nil
-	case admin.SystemRoleNone:
-		return admin.Daemon{}, domain.NewAccessDeniedError("anonymous user cannot create daemon")
-	default:
-		return admin.Daemon{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	if err := s.repo.CreateDaemon(ctx, daemon); err != nil {
+		return admin.Daemon{}, err
 	}
+
+	return daemon, nil
 }
 
 // UpdateDaemon implements the service.DaemonService interface.
+// Changing the realms the daemon serves requires the permission to do so.
 //
 //nolint:wrapcheck // see comment in the header
 func (s *DaemonService) UpdateDaemon(
@@ -160,30 +151,34 @@ func (s *DaemonService) UpdateDaemon(
 		return admin.Daemon{}, err
 	}
 
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.Daemon{}, domain.NewAccessDeniedError("user %s cannot update daemon %s", actor.UserID, daemon.ID)
-	case admin.SystemRoleManager:
-		if actor.RealmID != daemon.RealmID {
-			return admin.Daemon{}, domain.NewAccessDeniedError("manager %s cannot update daemon %s", actor.UserID, daemon.ID)
-		}
+	stored, err := s.getDaemon(ctx, actor, admin.PermissionDaemonsWrite, daemon.RealmID, daemon.ID)
+	if err != nil {
+		return admin.Daemon{}, err
+	}
 
-		if err := s.repo.UpdateDaemon(ctx, daemon); err != nil {
-			return admin.Daemon{}, err
-		}
+	if err := s.authorizeAllowedRealms(ctx, actor, daemon.AllowedRealms, stored.AllowedRealms); err != nil {
+		return admin.Daemon{}, err
+	}
 
-		return daemon, nil
-	case admin.SystemRoleAdmin:
-		if err := s.repo.UpdateDaemon(ctx, daemon); err != nil {
-			return admin.Daemon{}, err
-		}
+	if err := s.checkAllowedRealms(ctx, daemon.AllowedRealms); err != nil {
+		return admin.Daemon{}, err
+	}
 
-		return daemon, nil
-	case admin.SystemRoleNone:
-		return admin.Daemon{}, domain.NewAccessDeniedError("anonymous user cannot update daemon %s", daemon.ID)
-	default:
-		return admin.Daemon{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	// API keys are managed by the API key methods only
+	daemon.APIKeys = stored.APIKeys
+	daemon.CreatedAt = stored.CreatedAt
+
+	if err := s.enforceUpdate(ctx, actor, stored, daemon); err != nil {
+		return admin.Daemon{}, err
 	}
+
+	if err := s.repo.UpdateDaemon(ctx, daemon); err != nil {
+		return admin.Daemon{}, err
+	}
+
+	daemon.Version = daemon.Version.Next()
+
+	return daemon, nil
 }
 
 // DeleteDaemon implements the service.DaemonService interface.
@@ -193,31 +188,51 @@ func (s *DaemonService) DeleteDaemon(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, id admin.ID,
+	version admin.Version,
 ) error {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return domain.NewAccessDeniedError("user %s cannot delete daemon %s", actor.UserID, id)
-	case admin.SystemRoleManager:
-		if actor.RealmID != realmID {
-			return domain.NewAccessDeniedError("manager %s cannot delete daemon %s", actor.UserID, id)
-		}
+	stored, err := s.getDaemon(ctx, actor, admin.PermissionDaemonsWrite, realmID, id)
+	if err != nil {
+		return err
+	}
 
-		if err := s.repo.DeleteDaemon(ctx, realmID, id); err != nil {
-			return err
-		}
+	if err := s.enforce(ctx, actor, admin.RuleOperationDelete, stored); err != nil {
+		return err
+	}
 
-		return nil
-	case admin.SystemRoleAdmin:
-		if err := s.repo.DeleteDaemon(ctx, realmID, id); err != nil {
-			return err
-		}
+	if err := s.repo.DeleteDaemon(ctx, realmID, id, version, time.Now()); err != nil {
+		return err
+	}
 
-		return nil
-	case admin.SystemRoleNone:
-		return domain.NewAccessDeniedError("anonymous user cannot delete daemon %s", id)
-	default:
-		return domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	return nil
+}
+
+// RestoreDaemon implements the service.DaemonService interface.
+// The access rules are checked as for the creation of the daemon.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *DaemonService) RestoreDaemon(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.Daemon, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionDaemonsWrite, admin.RealmResource(realmID)); err != nil {
+		return admin.Daemon{}, err
 	}
+
+	deleted, err := s.repo.GetDeletedDaemon(ctx, realmID, id)
+	if err != nil {
+		return admin.Daemon{}, err
+	}
+
+	if err := s.enforce(ctx, actor, admin.RuleOperationCreate, deleted); err != nil {
+		return admin.Daemon{}, err
+	}
+
+	if _, err := s.repo.RestoreDaemon(ctx, realmID, id); err != nil {
+		return admin.Daemon{}, err
+	}
+
+	return s.repo.GetDaemon(ctx, realmID, id)
 }
 
 // GetAPIKeys implements the service.DaemonService interface.
@@ -225,13 +240,14 @@ func (s *DaemonService) GetAPIKeys(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, daemonID admin.ID,
+	filter admin.APIKeyFilter,
 ) ([]admin.APIKey, error) {
-	daemon, err := s.GetDaemon(ctx, actor, realmID, daemonID)
+	daemon, err := s.getDaemon(ctx, actor, admin.PermissionDaemonsKeysRead, realmID, daemonID)
 	if err != nil {
 		return nil, err
 	}
 
-	return daemon.APIKeys, nil
+	return filter.Filter(daemon.APIKeys), nil
 }
 
 // GetAPIKey implements the service.DaemonService interface.
@@ -240,7 +256,7 @@ func (s *DaemonService) GetAPIKey(
 	actor admin.Actor,
 	realmID, daemonID, id admin.ID,
 ) (admin.APIKey, error) {
-	daemon, err := s.GetDaemon(ctx, actor, realmID, daemonID)
+	daemon, err := s.getDaemon(ctx, actor, admin.PermissionDaemonsKeysRead, realmID, daemonID)
 	if err != nil {
 		return admin.APIKey{}, err
 	}
@@ -263,25 +279,31 @@ func (s *DaemonService) CreateAPIKey(
 	realmID, daemonID admin.ID,
 	apiKey admin.APIKey,
 ) (admin.APIKey, error) {
-	apiKey, err := validateAPIKey(apiKey)
+	daemon, err := s.getDaemon(ctx, actor, admin.PermissionDaemonsKeysWrite, realmID, daemonID)
+	if err != nil {
+		return admin.APIKey{}, err
+	}
+
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
 	if err != nil {
 		return admin.APIKey{}, err
 	}
 
-	daemon, err := s.GetDaemon(ctx, actor, realmID, daemonID)
+	apiKey, err = issueAPIKey(apiKey, realm, s.idgen, s.keygen, time.Now())
 	if err != nil {
 		return admin.APIKey{}, err
 	}
 
-	apiKey.ID = admin.ID(s.idgen.GenerateID())
+	sealed := sealAPIKey(apiKey, s.hasher)
 
-	daemon.APIKeys = append(daemon.APIKeys, apiKey)
+	daemon.APIKeys = append(daemon.APIKeys, sealed)
 
 	if uErr := s.repo.UpdateDaemon(ctx, daemon); uErr != nil {
 		return admin.APIKey{}, uErr
 	}
 
-	return apiKey, nil
+	// the plain text key is returned only once, in response to the creation
+	return revealAPIKey(sealed, apiKey.Key), nil
 }
 
 // UpdateAPIKey implements the service.DaemonService interface.
@@ -291,20 +313,42 @@ func (s *DaemonService) UpdateAPIKey(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, daemonID, id admin.ID,
+	version admin.Version,
 	apiKey admin.APIKey,
 ) (admin.APIKey, error) {
-	apiKey, err := validateAPIKey(apiKey)
+	apiKey, err := validateAPIKeyUpdate(apiKey)
+	if err != nil {
+		return admin.APIKey{}, err
+	}
+
+	daemon, err := s.getDaemon(ctx, actor, admin.PermissionDaemonsKeysWrite, realmID, daemonID)
 	if err != nil {
 		return admin.APIKey{}, err
 	}
 
-	daemon, err := s.GetDaemon(ctx, actor, realmID, daemonID)
+	daemon.Version = version
+
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
 	if err != nil {
 		return admin.APIKey{}, err
 	}
 
 	for i, ak := range daemon.APIKeys {
 		if ak.ID == id {
+			apiKey.ID = ak.ID
+			apiKey.Prefix = ak.Prefix
+			apiKey.Hash = ak.Hash
+			apiKey.CreatedAt = ak.CreatedAt
+			apiKey.LastUsedAt = ak.LastUsedAt
+			apiKey.LastUsedIP = ak.LastUsedIP
+			apiKey.UsageDays = ak.UsageDays
+			apiKey.RetiredAt = ak.RetiredAt
+
+			apiKey, err = applyAPIKeyPolicy(apiKey, realm)
+			if err != nil {
+				return admin.APIKey{}, err
+			}
+
 			daemon.APIKeys[i] = apiKey
 
 			if uErr := s.repo.UpdateDaemon(ctx, daemon); uErr != nil {
@@ -318,6 +362,82 @@ func (s *DaemonService) UpdateAPIKey(
 	return admin.APIKey{}, domain.NewNotFoundError("API key %s not found", id)
 }
 
+// RotateAPIKey implements the service.DaemonService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *DaemonService) RotateAPIKey(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, daemonID, id admin.ID,
+	version admin.Version,
+	successor admin.APIKey,
+	gracePeriod time.Duration,
+) (admin.APIKey, error) {
+	if err := checkGracePeriod(gracePeriod); err != nil {
+		return admin.APIKey{}, err
+	}
+
+	daemon, err := s.getDaemon(ctx, actor, admin.PermissionDaemonsKeysWrite, realmID, daemonID)
+	if err != nil {
+		return admin.APIKey{}, err
+	}
+
+	daemon.Version = version
+
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
+	if err != nil {
+		return admin.APIKey{}, err
+	}
+
+	now := time.Now()
+
+	for i, predecessor := range daemon.APIKeys {
+		if predecessor.ID != id {
+			continue
+		}
+
+		if !predecessor.Enabled || predecessor.IsExpired(now) {
+			return admin.APIKey{}, domain.NewValidationError("API key %s is not active", id)
+		}
+
+		if successor.Name == "" {
+			successor.Name = predecessor.Name
+			successor.Description = predecessor.Description
+		}
+
+		// a successor without scopes would be unrestricted
+		if len(successor.Scopes) == 0 {
+			successor.Scopes = predecessor.Scopes
+		}
+
+		// a successor without CIDR ranges would be usable from everywhere
+		if len(successor.AllowedCIDRs) == 0 {
+			successor.AllowedCIDRs = predecessor.AllowedCIDRs
+		}
+
+		successor.Enabled = true
+
+		successor, err = issueAPIKey(successor, realm, s.idgen, s.keygen, now)
+		if err != nil {
+			return admin.APIKey{}, err
+		}
+
+		sealed := sealAPIKey(successor, s.hasher)
+
+		daemon.APIKeys[i] = retireAPIKey(predecessor, gracePeriod, now)
+		daemon.APIKeys = append(daemon.APIKeys, sealed)
+
+		if uErr := s.repo.UpdateDaemon(ctx, daemon); uErr != nil {
+			return admin.APIKey{}, uErr
+		}
+
+		// the plain text key is returned only once, in response to the creation
+		return revealAPIKey(sealed, successor.Key), nil
+	}
+
+	return admin.APIKey{}, domain.NewNotFoundError("API key %s not found", id)
+}
+
 // DeleteAPIKey implements the service.DaemonService interface.
 //
 //nolint:wrapcheck // see comment in the header
@@ -325,12 +445,15 @@ func (s *DaemonService) DeleteAPIKey(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, daemonID, id admin.ID,
+	version admin.Version,
 ) error {
-	daemon, err := s.GetDaemon(ctx, actor, realmID, daemonID)
+	daemon, err := s.getDaemon(ctx, actor, admin.PermissionDaemonsKeysWrite, realmID, daemonID)
 	if err != nil {
 		return err
 	}
 
+	daemon.Version = version
+
 	var found bool
 
 	for i, apiKey := range daemon.APIKeys {
@@ -352,3 +475,96 @@ func (s *DaemonService) DeleteAPIKey(
 
 	return nil
 }
+
+// getDaemon authorizes the permission on the daemon and returns the daemon.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *DaemonService) getDaemon(
+	ctx context.Context,
+	actor admin.Actor,
+	permission admin.Permission,
+	realmID, id admin.ID,
+) (admin.Daemon, error) {
+	if err := s.authorizer.Authorize(ctx, actor, permission, admin.RealmResource(realmID)); err != nil {
+		return admin.Daemon{}, err
+	}
+
+	daemon, err := s.repo.GetDaemon(ctx, realmID, id)
+	if err != nil {
+		return admin.Daemon{}, err
+	}
+
+	return daemon, nil
+}
+
+// enforce checks the access rules of the realm of the daemon.
+func (s *DaemonService) enforce(
+	ctx context.Context,
+	actor admin.Actor,
+	operation admin.RuleOperation,
+	daemon admin.Daemon,
+) error {
+	return s.authorizer.Enforce(ctx, actor, admin.PermissionDaemonsWrite, operation, admin.DaemonTarget(daemon))
+}
+
+// enforceUpdate checks the access rules for the update of the stored daemon. The rules
+// must allow the update of both the stored and the updated daemon.
+func (s *DaemonService) enforceUpdate(ctx context.Context, actor admin.Actor, stored, daemon admin.Daemon) error {
+	if err := s.enforce(ctx, actor, admin.RuleOperationUpdate, stored); err != nil {
+		return err
+	}
+
+	return s.enforce(ctx, actor, admin.RuleOperationUpdate, daemon)
+}
+
+// authorizeAllowedRealms checks that the actor can change the realms a daemon
+// serves, if they differ from the current ones. The allowed realms grant access
+// to the sessions of other realms, so the permission is required on the whole system.
+func (s *DaemonService) authorizeAllowedRealms(
+	ctx context.Context,
+	actor admin.Actor,
+	allowedRealms, current []admin.ID,
+) error {
+	if slices.Equal(allowedRealms, current) {
+		return nil
+	}
+
+	return s.authorizer.Authorize(ctx, actor, admin.PermissionDaemonsRealmsWrite, admin.SystemResource())
+}
+
+// sealNewAPIKeys issues and seals the API keys supplied with a new daemon.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *DaemonService) sealNewAPIKeys(
+	ctx context.Context,
+	realmID admin.ID,
+	apiKeys []admin.APIKey,
+) ([]admin.APIKey, error) {
+	if len(apiKeys) == 0 {
+		return nil, nil
+	}
+
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
+	if err != nil {
+		return nil, err
+	}
+
+	return sealNewAPIKeys(apiKeys, realm, s.idgen, s.keygen, s.hasher, time.Now())
+}
+
+// checkAllowedRealms checks that the realms a daemon serves exist.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *DaemonService) checkAllowedRealms(ctx context.Context, realmIDs []admin.ID) error {
+	for _, realmID := range realmIDs {
+		if _, err := s.realmRepo.GetRealm(ctx, realmID); err != nil {
+			if domain.IsNotFoundError(err) {
+				return domain.NewValidationError("allowed realm %s does not exist", realmID)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
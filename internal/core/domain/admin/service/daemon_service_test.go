@@ -5,6 +5,7 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -45,6 +46,22 @@ func TestDaemonService_GetDaemons(t *testing.T) {
 			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
 			wantError: domain.StoreError{},
 		},
+		"systemAuditor": {
+			actor:      admin.Actor{Role: admin.SystemRoleSystemAuditor, RealmID: "wrongRealmID"},
+			wantResult: true,
+		},
+		"realmAuditor": {
+			actor:      admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: realmID},
+			wantResult: true,
+		},
+		"userManager": {
+			actor:     admin.Actor{Role: admin.SystemRoleUserManager, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
+		"daemonManager": {
+			actor:      admin.Actor{Role: admin.SystemRoleDaemonManager, RealmID: realmID},
+			wantResult: true,
+		},
 		"none": {
 			actor:     admin.Actor{Role: admin.SystemRoleNone},
 			wantError: domain.AccessDeniedError{},
@@ -56,7 +73,7 @@ func TestDaemonService_GetDaemons(t *testing.T) {
 	}
 
 	repo := newMockDaemonRepository()
-	svc := NewDaemonService(repo, nil)
+	svc := NewDaemonService(repo, newMockRealmRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -66,10 +83,10 @@ func TestDaemonService_GetDaemons(t *testing.T) {
 		}
 
 		t.Run(name, func(t *testing.T) {
-			res, err := svc.GetDaemons(context.Background(), test.actor, realmID)
+			res, err := svc.GetDaemons(context.Background(), test.actor, realmID, admin.DaemonFilter{}, admin.ListOptions{})
 
 			if test.wantResult {
-				require.Len(t, res, 1)
+				require.Len(t, res.Items, 1)
 			}
 
 			if test.wantError != nil {
@@ -81,6 +98,55 @@ func TestDaemonService_GetDaemons(t *testing.T) {
 	}
 }
 
+func TestDaemonService_GetDaemons_listOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		options     admin.ListOptions
+		wantOptions admin.ListOptions
+		wantError   error
+	}{
+		"defaults": {
+			wantOptions: admin.ListOptions{SortBy: admin.SortByCode},
+		},
+		"cursorWithoutLimit": {
+			options:     admin.ListOptions{Cursor: "c"},
+			wantOptions: admin.ListOptions{Cursor: "c", SortBy: admin.SortByCode, Limit: defaultListLimit},
+		},
+		"sortedByCreation": {
+			options:     admin.ListOptions{SortBy: admin.SortByCreatedAt, Limit: 5},
+			wantOptions: admin.ListOptions{SortBy: admin.SortByCreatedAt, Limit: 5},
+		},
+		"invalidSortField": {
+			options:   admin.ListOptions{SortBy: admin.SortByUsername},
+			wantError: domain.ValidationError{},
+		},
+		"negativeLimit": {
+			options:   admin.ListOptions{Limit: -1},
+			wantError: domain.ValidationError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockDaemonRepository()
+			svc := NewDaemonService(repo, newMockRealmRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
+
+			_, err := svc.GetDaemons(context.Background(), admin.Actor{Role: admin.SystemRoleAdmin}, "a1",
+				admin.DaemonFilter{}, test.options)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.wantOptions, repo.options)
+			}
+		})
+	}
+}
+
 func TestDaemonService_GetDaemon(t *testing.T) {
 	t.Parallel()
 
@@ -127,7 +193,7 @@ func TestDaemonService_GetDaemon(t *testing.T) {
 	}
 
 	repo := newMockDaemonRepository()
-	svc := NewDaemonService(repo, nil)
+	svc := NewDaemonService(repo, newMockRealmRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -186,6 +252,22 @@ func TestDaemonService_CreateDaemon(t *testing.T) {
 			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
 			wantError: domain.StoreError{},
 		},
+		"systemAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleSystemAuditor},
+			wantError: domain.AccessDeniedError{},
+		},
+		"realmAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
+		"userManager": {
+			actor:     admin.Actor{Role: admin.SystemRoleUserManager, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
+		"daemonManager": {
+			actor:      admin.Actor{Role: admin.SystemRoleDaemonManager, RealmID: realmID},
+			wantResult: true,
+		},
 		"none": {
 			actor:     admin.Actor{Role: admin.SystemRoleNone},
 			wantError: domain.AccessDeniedError{},
@@ -197,7 +279,7 @@ func TestDaemonService_CreateDaemon(t *testing.T) {
 	}
 
 	repo := newMockDaemonRepository()
-	svc := NewDaemonService(repo, newMockIDGenerator())
+	svc := NewDaemonService(repo, newMockRealmRepository(), newTestAuthorizer(), newMockIDGenerator(), newMockKeyGenerator(), newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -275,7 +357,7 @@ func TestDaemonService_UpdateDaemon(t *testing.T) {
 	}
 
 	repo := newMockDaemonRepository()
-	svc := NewDaemonService(repo, nil)
+	svc := NewDaemonService(repo, newMockRealmRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -353,7 +435,7 @@ func TestDaemonService_DeleteDaemon(t *testing.T) {
 	}
 
 	repo := newMockDaemonRepository()
-	svc := NewDaemonService(repo, nil)
+	svc := NewDaemonService(repo, newMockRealmRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -363,7 +445,7 @@ func TestDaemonService_DeleteDaemon(t *testing.T) {
 		}
 
 		t.Run(name, func(t *testing.T) {
-			err := svc.DeleteDaemon(context.Background(), test.actor, realmID, userID)
+			err := svc.DeleteDaemon(context.Background(), test.actor, realmID, userID, 1)
 
 			if test.wantResult {
 				require.NoError(t, err)
@@ -378,8 +460,78 @@ func TestDaemonService_DeleteDaemon(t *testing.T) {
 	}
 }
 
+func TestDaemonService_AllowedRealms(t *testing.T) {
+	t.Parallel()
+
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"}
+	sysAdmin := admin.Actor{Role: admin.SystemRoleAdmin}
+	daemon := admin.Daemon{
+		ID:            "u1",
+		RealmID:       "a1",
+		Code:          "code",
+		Name:          "name",
+		AllowedRealms: []admin.ID{"a3", "a1", "a2", "a3"},
+	}
+
+	t.Run("admin-create", func(t *testing.T) {
+		t.Parallel()
+
+		svc := NewDaemonService(newMockDaemonRepository(), newMockRealmRepository(), newTestAuthorizer(), newMockIDGenerator(), nil, nil)
+
+		res, err := svc.CreateDaemon(context.Background(), sysAdmin, daemon)
+		require.NoError(t, err)
+		require.Equal(t, []admin.ID{"a2", "a3"}, res.AllowedRealms)
+	})
+
+	t.Run("manager-create", func(t *testing.T) {
+		t.Parallel()
+
+		svc := NewDaemonService(newMockDaemonRepository(), newMockRealmRepository(), newTestAuthorizer(), newMockIDGenerator(), nil, nil)
+
+		_, err := svc.CreateDaemon(context.Background(), manager, daemon)
+		require.ErrorAs(t, err, &domain.AccessDeniedError{})
+	})
+
+	t.Run("manager-update-unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockDaemonRepository{allowedRealms: []admin.ID{"a2", "a3"}}
+		svc := NewDaemonService(repo, newMockRealmRepository(), newTestAuthorizer(), nil, nil, nil)
+
+		_, err := svc.UpdateDaemon(context.Background(), manager, daemon)
+		require.NoError(t, err)
+	})
+
+	t.Run("manager-update-changed", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &mockDaemonRepository{allowedRealms: []admin.ID{"a2"}}
+		svc := NewDaemonService(repo, newMockRealmRepository(), newTestAuthorizer(), nil, nil, nil)
+
+		_, err := svc.UpdateDaemon(context.Background(), manager, daemon)
+		require.ErrorAs(t, err, &domain.AccessDeniedError{})
+	})
+
+	t.Run("unknownRealm", func(t *testing.T) {
+		t.Parallel()
+
+		realmRepo := newMockRealmRepository()
+		realmRepo.forcedError = domain.NewNotFoundError("realm not found")
+		svc := NewDaemonService(newMockDaemonRepository(), realmRepo, newTestAuthorizer(), newMockIDGenerator(), nil, nil)
+
+		_, err := svc.CreateDaemon(context.Background(), sysAdmin, daemon)
+		require.ErrorAs(t, err, &domain.ValidationError{})
+	})
+}
+
 type mockDaemonRepository struct {
-	forcedError error
+	apiKeys       []admin.APIKey
+	allowedRealms []admin.ID
+	allowedCIDRs  []string
+	usages        []admin.APIKeyUsage
+	filter        admin.DaemonFilter
+	options       admin.ListOptions
+	forcedError   error
 }
 
 // ensure mockDaemonRepository implements admin.DaemonRepository.
@@ -389,12 +541,20 @@ func newMockDaemonRepository() *mockDaemonRepository {
 	return &mockDaemonRepository{}
 }
 
-func (r *mockDaemonRepository) GetDaemons(_ context.Context, realmID admin.ID) ([]admin.Daemon, error) {
+func (r *mockDaemonRepository) GetDaemons(
+	_ context.Context,
+	realmID admin.ID,
+	filter admin.DaemonFilter,
+	options admin.ListOptions,
+) (admin.Page[admin.Daemon], error) {
 	if realmID == "" {
-		return nil, errors.New("test-precondition: empty realmID")
+		return admin.Page[admin.Daemon]{}, errors.New("test-precondition: empty realmID")
 	}
 
-	return []admin.Daemon{r.mockDaemon()}, r.forcedError
+	r.filter = filter
+	r.options = options
+
+	return admin.Page[admin.Daemon]{Items: []admin.Daemon{r.mockDaemon()}, Total: 1}, r.forcedError
 }
 
 func (r *mockDaemonRepository) GetDaemon(_ context.Context, realmID, id admin.ID) (admin.Daemon, error) {
@@ -425,7 +585,15 @@ func (r *mockDaemonRepository) UpdateDaemon(_ context.Context, user admin.Daemon
 	return r.forcedError
 }
 
-func (r *mockDaemonRepository) DeleteDaemon(_ context.Context, realmID, id admin.ID) error {
+func (r *mockDaemonRepository) DeleteDaemon(_ context.Context, realmID, id admin.ID, version admin.Version, deletedAt time.Time) error {
+	if version < admin.FirstVersion {
+		return errors.New("test-precondition: no version")
+	}
+
+	if deletedAt.IsZero() {
+		return errors.New("test-precondition: no deletion time")
+	}
+
 	if realmID == "" {
 		return errors.New("test-precondition: empty realmID")
 	}
@@ -437,22 +605,86 @@ func (r *mockDaemonRepository) DeleteDaemon(_ context.Context, realmID, id admin
 	return r.forcedError
 }
 
-func (r *mockDaemonRepository) GetAPIKey(_ context.Context, realmID admin.ID, key string) (admin.APIKey, error) {
+func (r *mockDaemonRepository) RestoreDaemon(_ context.Context, realmID, id admin.ID) (time.Time, error) {
+	if realmID == "" {
+		return time.Time{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return time.Time{}, errors.New("test-precondition: empty id")
+	}
+
+	return mockDeletedAt(), r.forcedError
+}
+
+func (r *mockDaemonRepository) GetDeletedDaemon(_ context.Context, realmID, id admin.ID) (admin.Daemon, error) {
+	if realmID == "" {
+		return admin.Daemon{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return admin.Daemon{}, errors.New("test-precondition: empty id")
+	}
+
+	return r.mockDaemon(), r.forcedError
+}
+
+func (r *mockDaemonRepository) SearchDaemons(_ context.Context, realmID admin.ID, text string, limit int) ([]admin.SearchHit, error) {
+	if text == "" || limit <= 0 {
+		return nil, errors.New("test-precondition: empty text or limit")
+	}
+
+	hit := admin.SearchHit{Kind: admin.PrincipalKindDaemon, RealmID: realmID, ID: "d1", Name: "mockDaemon", Score: 3}
+
+	return []admin.SearchHit{hit}, r.forcedError
+}
+
+func (r *mockDaemonRepository) GetAPIKeysByPrefix(_ context.Context, realmID admin.ID, prefix string) ([]admin.OwnedAPIKey, error) {
 	if realmID == "" {
-		return admin.APIKey{}, errors.New("test-precondition: empty realmID")
+		return nil, errors.New("test-precondition: empty realmID")
 	}
 
-	if key == "" {
-		return admin.APIKey{}, errors.New("test-precondition: empty key")
+	if prefix == "" {
+		return nil, errors.New("test-precondition: empty prefix")
 	}
 
-	return admin.APIKey{}, nil
+	return ownAPIKeys(realmID, "u1", r.apiKeys), nil
+}
+
+func (r *mockDaemonRepository) GetAPIKeysExpiringBefore(_ context.Context, before time.Time) ([]admin.OwnedAPIKey, error) {
+	if before.IsZero() {
+		return nil, errors.New("test-precondition: zero time")
+	}
+
+	return ownAPIKeys("r1", "d1", r.apiKeys), r.forcedError
+}
+
+func (r *mockDaemonRepository) DisableExpiredAPIKeys(_ context.Context, now time.Time) (int, error) {
+	if now.IsZero() {
+		return 0, errors.New("test-precondition: zero time")
+	}
+
+	return 0, r.forcedError
+}
+
+func (r *mockDaemonRepository) RecordAPIKeyUsage(_ context.Context, usages []admin.APIKeyUsage) error {
+	if r.forcedError != nil {
+		return r.forcedError
+	}
+
+	r.usages = append(r.usages, usages...)
+
+	return nil
 }
 
 func (r *mockDaemonRepository) mockDaemon() admin.Daemon {
 	return admin.Daemon{
-		ID:      "u1",
-		RealmID: "a1",
-		Name:    "mockDaemon",
+		ID:            "u1",
+		RealmID:       "a1",
+		Code:          "mockDaemon",
+		Name:          "mockDaemon",
+		Enabled:       true,
+		AllowedRealms: r.allowedRealms,
+		AllowedCIDRs:  r.allowedCIDRs,
 	}
 }
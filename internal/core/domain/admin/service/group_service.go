@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// GroupService is a service for managing the groups of users and their members.
+//
+// It implements the service.GroupService and the admin.GroupFinder interfaces.
+//
+// The roles of a group are granted to all its members. Therefore, changing the
+// roles of a group, or the members of a group with roles, requires the permission
+// to assign roles in addition to the permission to manage the groups.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type GroupService struct {
+	repo       admin.GroupRepository
+	userRepo   admin.UserRepository
+	realmRepo  admin.RealmRepository
+	authorizer admin.Authorizer
+	idgen      domain.IDGenerator
+}
+
+// NewGroupService returns a new GroupService instance.
+func NewGroupService(
+	repo admin.GroupRepository,
+	userRepo admin.UserRepository,
+	realmRepo admin.RealmRepository,
+	authorizer admin.Authorizer,
+	idgen domain.IDGenerator,
+) *GroupService {
+	return &GroupService{
+		repo:       repo,
+		userRepo:   userRepo,
+		realmRepo:  realmRepo,
+		authorizer: authorizer,
+		idgen:      idgen,
+	}
+}
+
+// Ensure service implements the service.GroupService and the admin.GroupFinder interfaces.
+var (
+	_ admin.GroupService = (*GroupService)(nil)
+	_ admin.GroupFinder  = (*GroupService)(nil)
+)
+
+// GetGroups implements the service.GroupService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *GroupService) GetGroups(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	options admin.ListOptions,
+) (admin.Page[admin.Group], error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionGroupsRead, admin.RealmResource(realmID)); err != nil {
+		return admin.Page[admin.Group]{}, err
+	}
+
+	options, err := validateListOptions(options, admin.GroupSortFields)
+	if err != nil {
+		return admin.Page[admin.Group]{}, err
+	}
+
+	page, err := s.repo.GetGroups(ctx, realmID, options)
+	if err != nil {
+		return admin.Page[admin.Group]{}, err
+	}
+
+	return page, nil
+}
+
+// GetGroup implements the service.GroupService interface.
+func (s *GroupService) GetGroup(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.Group, error) {
+	return s.getGroup(ctx, actor, admin.PermissionGroupsRead, realmID, id)
+}
+
+// CreateGroup implements the service.GroupService interface.
+// The group is created without members.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *GroupService) CreateGroup(
+	ctx context.Context,
+	actor admin.Actor,
+	group admin.Group,
+) (admin.Group, error) {
+	group, err := validateGroup(group)
+	if err != nil {
+		return admin.Group{}, err
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionGroupsWrite, admin.RealmResource(group.RealmID)); err != nil {
+		return admin.Group{}, err
+	}
+
+	if err := s.authorizeRoles(ctx, actor, group.RealmID, len(group.Roles) > 0); err != nil {
+		return admin.Group{}, err
+	}
+
+	if err := checkRoles(ctx, s.realmRepo, group.RealmID, group.Roles, nil); err != nil {
+		return admin.Group{}, err
+	}
+
+	group.ID = admin.ID(s.idgen.GenerateID())
+	group.Version = admin.FirstVersion
+	group.Members = nil
+
+	if err := s.enforce(ctx, actor, admin.RuleOperationCreate, group); err != nil {
+		return admin.Group{}, err
+	}
+
+	if err := s.repo.CreateGroup(ctx, group); err != nil {
+		return admin.Group{}, err
+	}
+
+	return group, nil
+}
+
+// UpdateGroup implements the service.GroupService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *GroupService) UpdateGroup(
+	ctx context.Context,
+	actor admin.Actor,
+	group admin.Group,
+) (admin.Group, error) {
+	group, err := validateGroup(group)
+	if err != nil {
+		return admin.Group{}, err
+	}
+
+	stored, err := s.getGroup(ctx, actor, admin.PermissionGroupsWrite, group.RealmID, group.ID)
+	if err != nil {
+		return admin.Group{}, err
+	}
+
+	if err := s.authorizeRoles(ctx, actor, group.RealmID, !slices.Equal(group.Roles, stored.Roles)); err != nil {
+		return admin.Group{}, err
+	}
+
+	if err := checkRoles(ctx, s.realmRepo, group.RealmID, group.Roles, stored.Roles); err != nil {
+		return admin.Group{}, err
+	}
+
+	// members are managed by the membership methods only
+	group.Members = stored.Members
+
+	if err := s.enforceUpdate(ctx, actor, stored, group); err != nil {
+		return admin.Group{}, err
+	}
+
+	if err := s.repo.UpdateGroup(ctx, group); err != nil {
+		return admin.Group{}, err
+	}
+
+	group.Version = group.Version.Next()
+
+	return group, nil
+}
+
+// DeleteGroup implements the service.GroupService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *GroupService) DeleteGroup(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+	version admin.Version,
+) error {
+	stored, err := s.getGroup(ctx, actor, admin.PermissionGroupsWrite, realmID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.enforce(ctx, actor, admin.RuleOperationDelete, stored); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteGroup(ctx, realmID, id, version, time.Now()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestoreGroup implements the service.GroupService interface.
+// The access rules are checked as for the creation of the group.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *GroupService) RestoreGroup(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.Group, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionGroupsWrite, admin.RealmResource(realmID)); err != nil {
+		return admin.Group{}, err
+	}
+
+	deleted, err := s.repo.GetDeletedGroup(ctx, realmID, id)
+	if err != nil {
+		return admin.Group{}, err
+	}
+
+	if err := s.enforce(ctx, actor, admin.RuleOperationCreate, deleted); err != nil {
+		return admin.Group{}, err
+	}
+
+	if _, err := s.repo.RestoreGroup(ctx, realmID, id); err != nil {
+		return admin.Group{}, err
+	}
+
+	return s.repo.GetGroup(ctx, realmID, id)
+}
+
+// AddGroupMember implements the service.GroupService interface.
+// The user must belong to the realm of the group.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *GroupService) AddGroupMember(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id, userID admin.ID,
+) error {
+	group, err := s.getGroup(ctx, actor, admin.PermissionGroupsWrite, realmID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authorizeRoles(ctx, actor, realmID, len(group.Roles) > 0); err != nil {
+		return err
+	}
+
+	if _, err := s.userRepo.GetUser(ctx, realmID, userID); err != nil {
+		return err
+	}
+
+	if err := s.repo.AddGroupMember(ctx, realmID, id, userID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RemoveGroupMember implements the service.GroupService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *GroupService) RemoveGroupMember(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id, userID admin.ID,
+) error {
+	group, err := s.getGroup(ctx, actor, admin.PermissionGroupsWrite, realmID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authorizeRoles(ctx, actor, realmID, len(group.Roles) > 0); err != nil {
+		return err
+	}
+
+	if !slices.Contains(group.Members, userID) {
+		return domain.NewNotFoundError("user %s is not a member of group %s", userID, id)
+	}
+
+	if err := s.repo.RemoveGroupMember(ctx, realmID, id, userID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetMemberGroupsSys returns the groups the user is a member of.
+// This method is not exposed in the API. It does not include acting user checks.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *GroupService) GetMemberGroupsSys(
+	ctx context.Context,
+	realmID, userID admin.ID,
+) ([]admin.Group, error) {
+	return s.repo.GetMemberGroups(ctx, realmID, userID)
+}
+
+// getGroup authorizes the permission on the group and returns the group.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *GroupService) getGroup(
+	ctx context.Context,
+	actor admin.Actor,
+	permission admin.Permission,
+	realmID, id admin.ID,
+) (admin.Group, error) {
+	if err := s.authorizer.Authorize(ctx, actor, permission, admin.RealmResource(realmID)); err != nil {
+		return admin.Group{}, err
+	}
+
+	group, err := s.repo.GetGroup(ctx, realmID, id)
+	if err != nil {
+		return admin.Group{}, err
+	}
+
+	return group, nil
+}
+
+// enforce checks the access rules of the realm of the group.
+func (s *GroupService) enforce(
+	ctx context.Context,
+	actor admin.Actor,
+	operation admin.RuleOperation,
+	group admin.Group,
+) error {
+	return s.authorizer.Enforce(ctx, actor, admin.PermissionGroupsWrite, operation, admin.GroupTarget(group))
+}
+
+// enforceUpdate checks the access rules for the update of the stored group. The rules
+// must allow the update of both the stored and the updated group.
+func (s *GroupService) enforceUpdate(ctx context.Context, actor admin.Actor, stored, group admin.Group) error {
+	if err := s.enforce(ctx, actor, admin.RuleOperationUpdate, stored); err != nil {
+		return err
+	}
+
+	return s.enforce(ctx, actor, admin.RuleOperationUpdate, group)
+}
+
+// authorizeRoles checks that the actor can assign roles in the realm, if the
+// operation changes the roles granted through a group.
+func (s *GroupService) authorizeRoles(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	changesRoles bool,
+) error {
+	if !changesRoles {
+		return nil
+	}
+
+	return s.authorizer.Authorize(ctx, actor, admin.PermissionUsersRolesWrite, admin.RealmResource(realmID))
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupService_GetGroups(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+
+	tests := map[string]struct {
+		actor      admin.Actor
+		wantResult bool
+		wantError  error
+	}{
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager": {
+			actor:      admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			wantResult: true,
+		},
+		"manager-wrongRealmID": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "wrongRealmID"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-repoError": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			wantError: domain.StoreError{},
+		},
+		"admin": {
+			actor:      admin.Actor{Role: admin.SystemRoleAdmin},
+			wantResult: true,
+		},
+		"none": {
+			actor:     admin.Actor{Role: admin.SystemRoleNone},
+			wantError: domain.AccessDeniedError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockGroupRepository()
+
+			if errors.Is(test.wantError, domain.StoreError{}) {
+				repo.forcedError = domain.NewStoreError("forcedError")
+			}
+
+			svc := NewGroupService(repo, newMockUserRepository(), newMockRealmRepository(), newTestAuthorizer(), nil)
+
+			res, err := svc.GetGroups(context.Background(), test.actor, realmID, admin.ListOptions{})
+
+			if test.wantResult {
+				require.Len(t, res.Items, 1)
+			}
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGroupService_CreateGroup(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID}
+	auditor := admin.Role{Name: "auditor", Permissions: []admin.Permission{admin.PermissionUsersRead}}
+	groupsWriter := admin.Role{Name: "groups-writer", Permissions: []admin.Permission{admin.PermissionGroupsWrite}}
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		userRoles []string
+		group     admin.Group
+		wantError error
+	}{
+		"manager": {
+			actor: manager,
+			group: admin.Group{RealmID: realmID, Code: "sales", Name: "Sales", Roles: []string{"auditor"}},
+		},
+		"manager-undefinedRole": {
+			actor:     manager,
+			group:     admin.Group{RealmID: realmID, Code: "sales", Name: "Sales", Roles: []string{"unknown"}},
+			wantError: domain.ValidationError{},
+		},
+		"manager-invalidAttribute": {
+			actor: manager,
+			group: admin.Group{
+				RealmID:    realmID,
+				Code:       "sales",
+				Name:       "Sales",
+				Attributes: map[string]string{"1st": "value"},
+			},
+			wantError: domain.ValidationError{},
+		},
+		"manager-dottedAttribute": {
+			actor: manager,
+			group: admin.Group{
+				RealmID:    realmID,
+				Code:       "sales",
+				Name:       "Sales",
+				Attributes: map[string]string{"cost.centre": "value"},
+			},
+			wantError: domain.ValidationError{},
+		},
+		"groupsWriter-noRoles": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			userRoles: []string{"groups-writer"},
+			group:     admin.Group{RealmID: realmID, Code: "sales", Name: "Sales"},
+		},
+		"groupsWriter-roles": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			userRoles: []string{"groups-writer"},
+			group:     admin.Group{RealmID: realmID, Code: "sales", Name: "Sales", Roles: []string{"auditor"}},
+			wantError: domain.AccessDeniedError{},
+		},
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			group:     admin.Group{RealmID: realmID, Code: "sales", Name: "Sales"},
+			wantError: domain.AccessDeniedError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			userRepo := newMockUserRepository()
+			userRepo.roles = test.userRoles
+			realmRepo := newMockRealmRepository()
+			realmRepo.roles = []admin.Role{auditor, groupsWriter}
+			authorizer := NewAuthorizer(userRepo, realmRepo, newMockGroupRepository(), newMockRuleRepository(), newMockRuleEngine())
+			svc := NewGroupService(newMockGroupRepository(), userRepo, realmRepo, authorizer, newMockIDGenerator())
+
+			group, err := svc.CreateGroup(context.Background(), test.actor, test.group)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, admin.ID("1"), group.ID)
+				require.Empty(t, group.Members)
+			}
+		})
+	}
+}
+
+func TestGroupService_UpdateGroup(t *testing.T) {
+	t.Parallel()
+
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"}
+
+	repo := newMockGroupRepository()
+	repo.members = []admin.ID{"u1"}
+	svc := NewGroupService(repo, newMockUserRepository(), newMockRealmRepository(), newTestAuthorizer(), nil)
+
+	group, err := svc.UpdateGroup(context.Background(), manager, admin.Group{
+		ID:      "g1",
+		RealmID: "a1",
+		Code:    "sales",
+		Name:    "Sales",
+		Members: []admin.ID{"u2"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []admin.ID{"u1"}, group.Members)
+}
+
+func TestGroupService_members(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID}
+
+	t.Run("add", func(t *testing.T) {
+		t.Parallel()
+
+		svc := NewGroupService(newMockGroupRepository(), newMockUserRepository(), newMockRealmRepository(),
+			newTestAuthorizer(), nil)
+
+		require.NoError(t, svc.AddGroupMember(context.Background(), manager, realmID, "g1", "u1"))
+	})
+
+	t.Run("add-unknownUser", func(t *testing.T) {
+		t.Parallel()
+
+		userRepo := newMockUserRepository()
+		userRepo.forcedError = domain.NewNotFoundError("user not found")
+		svc := NewGroupService(newMockGroupRepository(), userRepo, newMockRealmRepository(), newTestAuthorizer(), nil)
+
+		err := svc.AddGroupMember(context.Background(), manager, realmID, "g1", "u1")
+		require.ErrorAs(t, err, &domain.NotFoundError{})
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newMockGroupRepository()
+		repo.members = []admin.ID{"u1"}
+		svc := NewGroupService(repo, newMockUserRepository(), newMockRealmRepository(), newTestAuthorizer(), nil)
+
+		require.NoError(t, svc.RemoveGroupMember(context.Background(), manager, realmID, "g1", "u1"))
+	})
+
+	t.Run("remove-notMember", func(t *testing.T) {
+		t.Parallel()
+
+		svc := NewGroupService(newMockGroupRepository(), newMockUserRepository(), newMockRealmRepository(),
+			newTestAuthorizer(), nil)
+
+		err := svc.RemoveGroupMember(context.Background(), manager, realmID, "g1", "u1")
+		require.ErrorAs(t, err, &domain.NotFoundError{})
+	})
+
+	t.Run("user", func(t *testing.T) {
+		t.Parallel()
+
+		actor := admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"}
+		svc := NewGroupService(newMockGroupRepository(), newMockUserRepository(), newMockRealmRepository(),
+			newTestAuthorizer(), nil)
+
+		err := svc.AddGroupMember(context.Background(), actor, realmID, "g1", "u1")
+		require.ErrorAs(t, err, &domain.AccessDeniedError{})
+	})
+}
+
+type mockGroupRepository struct {
+	roles       []string
+	members     []admin.ID
+	attributes  map[string]string
+	forcedError error
+}
+
+// ensure mockGroupRepository implements admin.GroupRepository.
+var _ admin.GroupRepository = (*mockGroupRepository)(nil)
+
+func newMockGroupRepository() *mockGroupRepository {
+	return &mockGroupRepository{}
+}
+
+func (r *mockGroupRepository) GetGroups(
+	_ context.Context,
+	realmID admin.ID,
+	_ admin.ListOptions,
+) (admin.Page[admin.Group], error) {
+	if realmID == "" {
+		return admin.Page[admin.Group]{}, errors.New("test-precondition: empty realmID")
+	}
+
+	return admin.Page[admin.Group]{Items: []admin.Group{r.mockGroup()}, Total: 1}, r.forcedError
+}
+
+func (r *mockGroupRepository) GetGroup(_ context.Context, realmID, id admin.ID) (admin.Group, error) {
+	if realmID == "" {
+		return admin.Group{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return admin.Group{}, errors.New("test-precondition: empty id")
+	}
+
+	return r.mockGroup(), r.forcedError
+}
+
+func (r *mockGroupRepository) CreateGroup(_ context.Context, group admin.Group) error {
+	if (reflect.DeepEqual(group, admin.Group{})) {
+		return errors.New("test-precondition: empty group")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockGroupRepository) UpdateGroup(_ context.Context, group admin.Group) error {
+	if (reflect.DeepEqual(group, admin.Group{})) {
+		return errors.New("test-precondition: empty group")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockGroupRepository) DeleteGroup(_ context.Context, realmID, id admin.ID, version admin.Version, deletedAt time.Time) error {
+	if version < admin.FirstVersion {
+		return errors.New("test-precondition: no version")
+	}
+
+	if deletedAt.IsZero() {
+		return errors.New("test-precondition: no deletion time")
+	}
+
+	if realmID == "" {
+		return errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return errors.New("test-precondition: empty id")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockGroupRepository) RestoreGroup(_ context.Context, realmID, id admin.ID) (time.Time, error) {
+	if realmID == "" {
+		return time.Time{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return time.Time{}, errors.New("test-precondition: empty id")
+	}
+
+	return mockDeletedAt(), r.forcedError
+}
+
+func (r *mockGroupRepository) GetDeletedGroup(_ context.Context, realmID, id admin.ID) (admin.Group, error) {
+	if realmID == "" {
+		return admin.Group{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return admin.Group{}, errors.New("test-precondition: empty id")
+	}
+
+	return r.mockGroup(), r.forcedError
+}
+
+func (r *mockGroupRepository) GetMemberGroups(_ context.Context, realmID, userID admin.ID) ([]admin.Group, error) {
+	if realmID == "" {
+		return nil, errors.New("test-precondition: empty realmID")
+	}
+
+	if userID == "" {
+		return nil, errors.New("test-precondition: empty userID")
+	}
+
+	if r.roles == nil {
+		return nil, r.forcedError
+	}
+
+	return []admin.Group{r.mockGroup()}, r.forcedError
+}
+
+func (r *mockGroupRepository) AddGroupMember(_ context.Context, realmID, id, userID admin.ID) error {
+	return r.checkMember(realmID, id, userID)
+}
+
+func (r *mockGroupRepository) RemoveGroupMember(_ context.Context, realmID, id, userID admin.ID) error {
+	return r.checkMember(realmID, id, userID)
+}
+
+func (r *mockGroupRepository) RemoveMember(_ context.Context, realmID, userID admin.ID) error {
+	return r.checkMember(realmID, "g1", userID)
+}
+
+func (r *mockGroupRepository) checkMember(realmID, id, userID admin.ID) error {
+	if realmID == "" {
+		return errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return errors.New("test-precondition: empty id")
+	}
+
+	if userID == "" {
+		return errors.New("test-precondition: empty userID")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockGroupRepository) mockGroup() admin.Group {
+	return admin.Group{
+		ID:         "g1",
+		RealmID:    "a1",
+		Code:       "mockGroup",
+		Roles:      r.roles,
+		Attributes: r.attributes,
+		Members:    r.members,
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/go-kit/slog"
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// LockoutService is a service for locking out subjects with repeated failed
+// authentications, and for managing the lockouts.
+//
+// It implements the service.LockoutService and the service.LockoutGuard interfaces.
+//
+// The failures are counted per subject according to the lockout policy. A subject
+// that is locked out is rejected with a rate limited error until the lockout ends.
+// Every lockout is reported to the security log. Viewing and clearing the lockouts
+// requires the lockout permissions, which only the admins have.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type LockoutService struct {
+	repo       admin.LockoutRepository
+	authorizer admin.Authorizer
+	policy     admin.LockoutPolicy
+}
+
+// NewLockoutService returns a new LockoutService instance.
+func NewLockoutService(
+	repo admin.LockoutRepository,
+	authorizer admin.Authorizer,
+	policy admin.LockoutPolicy,
+) *LockoutService {
+	return &LockoutService{
+		repo:       repo,
+		authorizer: authorizer,
+		policy:     policy,
+	}
+}
+
+// Ensure service implements the service.LockoutService and the service.LockoutGuard interfaces.
+var (
+	_ admin.LockoutService = (*LockoutService)(nil)
+	_ admin.LockoutGuard   = (*LockoutService)(nil)
+)
+
+// GetLockouts implements the service.LockoutService interface.
+// Expired lockouts that have not been removed yet are skipped.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *LockoutService) GetLockouts(
+	ctx context.Context,
+	actor admin.Actor,
+	options admin.ListOptions,
+) (admin.Page[admin.Lockout], error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionLockoutsRead, admin.SystemResource()); err != nil {
+		return admin.Page[admin.Lockout]{}, err
+	}
+
+	options, err := validateListOptions(options, admin.LockoutSortFields)
+	if err != nil {
+		return admin.Page[admin.Lockout]{}, err
+	}
+
+	page, err := s.repo.GetLockouts(ctx, time.Now(), options)
+	if err != nil {
+		return admin.Page[admin.Lockout]{}, err
+	}
+
+	return page, nil
+}
+
+// GetLockout implements the service.LockoutService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *LockoutService) GetLockout(
+	ctx context.Context,
+	actor admin.Actor,
+	id admin.ID,
+) (admin.Lockout, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionLockoutsRead, admin.SystemResource()); err != nil {
+		return admin.Lockout{}, err
+	}
+
+	lockout, err := s.repo.GetLockout(ctx, id)
+	if err != nil {
+		return admin.Lockout{}, err
+	}
+
+	return lockout, nil
+}
+
+// DeleteLockout implements the service.LockoutService interface.
+// Deleting a lockout clears it and resets the failures of the subject.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *LockoutService) DeleteLockout(
+	ctx context.Context,
+	actor admin.Actor,
+	id admin.ID,
+) error {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionLockoutsWrite, admin.SystemResource()); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteLockout(ctx, id); err != nil {
+		return err
+	}
+
+	slog.FromContext(ctx).Info().
+		Str("log", "security").
+		Str("event", "lockoutCleared").
+		Str("lockoutId", id.String()).
+		Str("actorId", actor.UserID.String()).
+		Msg("Lockout cleared")
+
+	return nil
+}
+
+// CheckLockout implements the service.LockoutGuard interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *LockoutService) CheckLockout(ctx context.Context, keys ...admin.LockoutKey) error {
+	if !s.policy.Enabled() {
+		return nil
+	}
+
+	now := time.Now()
+
+	for _, key := range keys {
+		lockout, err := s.repo.GetLockout(ctx, key.ID())
+		if err != nil {
+			if domain.IsNotFoundError(err) {
+				continue
+			}
+
+			return err
+		}
+
+		if lockout.IsLocked(now) {
+			return domain.NewRateLimitedError(lockout.LockedUntil.Sub(now),
+				"too many failed authentications, try again later")
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure implements the service.LockoutGuard interface.
+// Failures that cannot be recorded are logged.
+func (s *LockoutService) RecordFailure(ctx context.Context, keys ...admin.LockoutKey) {
+	if !s.policy.Enabled() {
+		return
+	}
+
+	now := time.Now()
+
+	for _, key := range keys {
+		lockout, err := s.recordFailure(ctx, key, now)
+		if err != nil {
+			slog.FromContext(ctx).Error().Err(err).Str("lockoutKind", string(key.Kind)).Msg("Failed to record failure")
+
+			continue
+		}
+
+		if lockout.IsLocked(now) && lockout.Failures == 0 {
+			slog.FromContext(ctx).Warn().
+				Str("log", "security").
+				Str("event", "lockout").
+				Str("lockoutId", lockout.ID.String()).
+				Str("lockoutKind", string(lockout.Kind)).
+				Str("realmId", lockout.RealmID.String()).
+				Str("subject", lockout.Subject).
+				Int("lockouts", lockout.Lockouts).
+				Time("lockedUntil", lockout.LockedUntil).
+				Msg("Subject locked out after repeated failed authentications")
+		}
+	}
+}
+
+// recordFailure adds the failure to the lockout of the subject, and locks out the
+// subject if its failures reach the threshold. The repository adds the failure
+// atomically, so that concurrent failures are all counted and only one of them
+// locks out the subject.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *LockoutService) recordFailure(ctx context.Context, key admin.LockoutKey, now time.Time) (admin.Lockout, error) {
+	lockout, err := s.repo.AddLockoutFailure(ctx, key, now, s.policy.FailureWindow)
+	if err != nil {
+		return admin.Lockout{}, err
+	}
+
+	if !s.policy.ReachesThreshold(lockout) {
+		return lockout, nil
+	}
+
+	locked := s.policy.LockOut(lockout, now)
+
+	lockedOut, err := s.repo.LockOut(ctx, locked, s.policy.Threshold)
+	if err != nil {
+		return admin.Lockout{}, err
+	}
+
+	if !lockedOut {
+		return lockout, nil
+	}
+
+	return locked, nil
+}
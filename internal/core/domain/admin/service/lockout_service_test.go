@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutService_GetLockouts(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		wantError error
+	}{
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"admin": {
+			actor: admin.Actor{Role: admin.SystemRoleAdmin},
+		},
+		"none": {
+			actor:     admin.Actor{Role: admin.SystemRoleNone},
+			wantError: domain.AccessDeniedError{},
+		},
+		"unknown": {
+			actor:     admin.Actor{Role: "unknown"},
+			wantError: domain.AccessDeniedError{},
+		},
+	}
+
+	now := time.Now()
+	repo := newMockLockoutRepository()
+	repo.lockouts["active"] = admin.Lockout{ID: "active", ExpiresAt: now.Add(time.Hour)}
+	repo.lockouts["expired"] = admin.Lockout{ID: "expired", ExpiresAt: now.Add(-time.Hour)}
+	svc := NewLockoutService(repo, newTestAuthorizer(), admin.LockoutPolicy{})
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lockouts, err := svc.GetLockouts(context.Background(), test.actor, admin.ListOptions{})
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, lockouts.Items, 1)
+				require.Equal(t, admin.ID("active"), lockouts.Items[0].ID)
+			}
+		})
+	}
+}
+
+func TestLockoutService_Lockout(t *testing.T) {
+	t.Parallel()
+
+	policy := admin.LockoutPolicy{
+		Threshold:     3,
+		FailureWindow: time.Minute,
+		BaseDuration:  time.Minute,
+	}
+
+	ctx := context.Background()
+	ipKey := admin.LockoutKey{Kind: admin.LockoutKindClientIP, Subject: "10.0.0.1"}
+	userKey := admin.LockoutKey{Kind: admin.LockoutKindUser, RealmID: "a1", Subject: "u1"}
+	actor := admin.Actor{Role: admin.SystemRoleAdmin}
+
+	t.Run("locked", func(t *testing.T) {
+		t.Parallel()
+
+		svc := NewLockoutService(newMockLockoutRepository(), newTestAuthorizer(), policy)
+
+		for range 2 {
+			svc.RecordFailure(ctx, ipKey)
+		}
+
+		require.NoError(t, svc.CheckLockout(ctx, ipKey, userKey))
+
+		svc.RecordFailure(ctx, ipKey)
+
+		err := svc.CheckLockout(ctx, userKey, ipKey)
+		require.ErrorAs(t, err, &domain.RateLimitedError{})
+		require.NoError(t, svc.CheckLockout(ctx, userKey))
+
+		// clearing the lockout lets the subject in again
+		require.NoError(t, svc.DeleteLockout(ctx, actor, ipKey.ID()))
+		require.NoError(t, svc.CheckLockout(ctx, ipKey))
+	})
+
+	t.Run("concurrentFailures", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newMockLockoutRepository()
+		svc := NewLockoutService(repo, newTestAuthorizer(), admin.LockoutPolicy{
+			Threshold:     20,
+			FailureWindow: time.Minute,
+			BaseDuration:  time.Minute,
+		})
+
+		var wg sync.WaitGroup
+
+		for range 39 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				svc.RecordFailure(ctx, userKey)
+			}()
+		}
+
+		wg.Wait()
+
+		// every failure is counted, and the subject is locked out once
+		lockout := repo.lockouts[userKey.ID()]
+		require.Equal(t, 1, lockout.Lockouts)
+		require.Equal(t, 19, lockout.Failures)
+		require.ErrorAs(t, svc.CheckLockout(ctx, userKey), &domain.RateLimitedError{})
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newMockLockoutRepository()
+		svc := NewLockoutService(repo, newTestAuthorizer(), admin.LockoutPolicy{})
+
+		for range 5 {
+			svc.RecordFailure(ctx, ipKey)
+		}
+
+		require.NoError(t, svc.CheckLockout(ctx, ipKey))
+		require.Empty(t, repo.lockouts)
+	})
+
+	t.Run("repoError", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newMockLockoutRepository()
+		repo.forcedError = domain.NewStoreError("forcedError")
+		svc := NewLockoutService(repo, newTestAuthorizer(), policy)
+
+		svc.RecordFailure(ctx, ipKey)
+
+		err := svc.CheckLockout(ctx, ipKey)
+		require.ErrorAs(t, err, &domain.StoreError{})
+	})
+}
+
+type mockLockoutRepository struct {
+	mu          sync.Mutex
+	lockouts    map[admin.ID]admin.Lockout
+	forcedError error
+}
+
+// ensure mockLockoutRepository implements admin.LockoutRepository.
+var _ admin.LockoutRepository = (*mockLockoutRepository)(nil)
+
+func newMockLockoutRepository() *mockLockoutRepository {
+	return &mockLockoutRepository{lockouts: make(map[admin.ID]admin.Lockout)}
+}
+
+func (r *mockLockoutRepository) GetLockouts(
+	_ context.Context,
+	now time.Time,
+	_ admin.ListOptions,
+) (admin.Page[admin.Lockout], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lockouts := make([]admin.Lockout, 0, len(r.lockouts))
+
+	for _, lockout := range r.lockouts {
+		if lockout.ExpiresAt.After(now) {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+
+	return admin.Page[admin.Lockout]{Items: lockouts, Total: len(lockouts)}, r.forcedError
+}
+
+func (r *mockLockoutRepository) GetLockout(_ context.Context, id admin.ID) (admin.Lockout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.forcedError != nil {
+		return admin.Lockout{}, r.forcedError
+	}
+
+	lockout, found := r.lockouts[id]
+	if !found {
+		return admin.Lockout{}, domain.NewNotFoundError("lockout %v not found", id)
+	}
+
+	return lockout, nil
+}
+
+func (r *mockLockoutRepository) AddLockoutFailure(
+	_ context.Context,
+	key admin.LockoutKey,
+	now time.Time,
+	window time.Duration,
+) (admin.Lockout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.forcedError != nil {
+		return admin.Lockout{}, r.forcedError
+	}
+
+	lockout, found := r.lockouts[key.ID()]
+	if !found {
+		lockout = admin.Lockout{ID: key.ID(), Kind: key.Kind, RealmID: key.RealmID, Subject: key.Subject}
+	}
+
+	lockout = admin.LockoutPolicy{FailureWindow: window}.AddFailure(lockout, now)
+	r.lockouts[lockout.ID] = lockout
+
+	return lockout, nil
+}
+
+func (r *mockLockoutRepository) LockOut(_ context.Context, lockout admin.Lockout, threshold int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.forcedError != nil {
+		return false, r.forcedError
+	}
+
+	stored, found := r.lockouts[lockout.ID]
+	if !found || stored.Lockouts != lockout.Lockouts-1 || stored.Failures < threshold {
+		return false, nil
+	}
+
+	r.lockouts[lockout.ID] = lockout
+
+	return true, nil
+}
+
+func (r *mockLockoutRepository) DeleteLockout(_ context.Context, id admin.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, found := r.lockouts[id]; !found {
+		return domain.NewNotFoundError("lockout %v not found", id)
+	}
+
+	delete(r.lockouts, id)
+
+	return r.forcedError
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// MembershipService is a service for managing the memberships of users in
+// realms other than their own.
+//
+// It implements the service.MembershipService and the admin.MembershipFinder interfaces.
+//
+// The memberships are managed by the realm that grants them. A membership assigns
+// a system role in the realm, so managing memberships requires the permission to
+// assign roles in addition to the permission to manage the memberships. As for the
+// roles of users, the actor must also hold every permission of the role it assigns
+// and of the role of the membership it changes.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type MembershipService struct {
+	repo       admin.MembershipRepository
+	userRepo   admin.UserRepository
+	realmRepo  admin.RealmRepository
+	authorizer admin.Authorizer
+	idgen      domain.IDGenerator
+}
+
+// NewMembershipService returns a new MembershipService instance.
+func NewMembershipService(
+	repo admin.MembershipRepository,
+	userRepo admin.UserRepository,
+	realmRepo admin.RealmRepository,
+	authorizer admin.Authorizer,
+	idgen domain.IDGenerator,
+) *MembershipService {
+	return &MembershipService{
+		repo:       repo,
+		userRepo:   userRepo,
+		realmRepo:  realmRepo,
+		authorizer: authorizer,
+		idgen:      idgen,
+	}
+}
+
+// Ensure service implements the service.MembershipService and the admin.MembershipFinder interfaces.
+var (
+	_ admin.MembershipService = (*MembershipService)(nil)
+	_ admin.MembershipFinder  = (*MembershipService)(nil)
+)
+
+// GetMemberships implements the service.MembershipService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *MembershipService) GetMemberships(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	options admin.ListOptions,
+) (admin.Page[admin.Membership], error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionMembershipsRead, admin.RealmResource(realmID)); err != nil {
+		return admin.Page[admin.Membership]{}, err
+	}
+
+	options, err := validateListOptions(options, admin.MembershipSortFields)
+	if err != nil {
+		return admin.Page[admin.Membership]{}, err
+	}
+
+	page, err := s.repo.GetMemberships(ctx, realmID, options)
+	if err != nil {
+		return admin.Page[admin.Membership]{}, err
+	}
+
+	return page, nil
+}
+
+// GetMembership implements the service.MembershipService interface.
+func (s *MembershipService) GetMembership(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.Membership, error) {
+	return s.getMembership(ctx, actor, admin.PermissionMembershipsRead, realmID, id)
+}
+
+// CreateMembership implements the service.MembershipService interface.
+// The user must exist in its realm and must not be a member of the realm yet.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *MembershipService) CreateMembership(
+	ctx context.Context,
+	actor admin.Actor,
+	membership admin.Membership,
+) (admin.Membership, error) {
+	membership, err := validateMembership(membership)
+	if err != nil {
+		return admin.Membership{}, err
+	}
+
+	if err := s.authorize(ctx, actor, membership, membership.Role); err != nil {
+		return admin.Membership{}, err
+	}
+
+	if _, err := s.realmRepo.GetRealm(ctx, membership.RealmID); err != nil {
+		return admin.Membership{}, err
+	}
+
+	if _, err := s.userRepo.GetUser(ctx, membership.UserRealmID, membership.UserID); err != nil {
+		if domain.IsNotFoundError(err) {
+			return admin.Membership{}, domain.NewValidationError("user %s not found in realm %s",
+				membership.UserID, membership.UserRealmID)
+		}
+
+		return admin.Membership{}, err
+	}
+
+	_, err = s.repo.GetUserMembership(ctx, membership.RealmID, membership.UserID)
+	if err == nil {
+		return admin.Membership{}, domain.NewConflictError("user %s is already a member of realm %s",
+			membership.UserID, membership.RealmID)
+	}
+
+	if !domain.IsNotFoundError(err) {
+		return admin.Membership{}, err
+	}
+
+	membership.ID = admin.ID(s.idgen.GenerateID())
+	membership.Version = admin.FirstVersion
+
+	if err := s.repo.CreateMembership(ctx, membership); err != nil {
+		return admin.Membership{}, err
+	}
+
+	return membership, nil
+}
+
+// UpdateMembership implements the service.MembershipService interface.
+// Only the role of the membership can be changed.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *MembershipService) UpdateMembership(
+	ctx context.Context,
+	actor admin.Actor,
+	membership admin.Membership,
+) (admin.Membership, error) {
+	stored, err := s.getMembership(ctx, actor, admin.PermissionMembershipsWrite, membership.RealmID, membership.ID)
+	if err != nil {
+		return admin.Membership{}, err
+	}
+
+	current := stored.Role
+
+	stored.Role = membership.Role
+	stored.Version = membership.Version
+
+	stored, err = validateMembership(stored)
+	if err != nil {
+		return admin.Membership{}, err
+	}
+
+	if err := s.authorize(ctx, actor, stored, current, stored.Role); err != nil {
+		return admin.Membership{}, err
+	}
+
+	if err := s.repo.UpdateMembership(ctx, stored); err != nil {
+		return admin.Membership{}, err
+	}
+
+	stored.Version = stored.Version.Next()
+
+	return stored, nil
+}
+
+// DeleteMembership implements the service.MembershipService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *MembershipService) DeleteMembership(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+	version admin.Version,
+) error {
+	stored, err := s.getMembership(ctx, actor, admin.PermissionMembershipsWrite, realmID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authorizeRoles(ctx, actor, stored, stored.Role); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteMembership(ctx, realmID, id, version, time.Now()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestoreMembership implements the service.MembershipService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *MembershipService) RestoreMembership(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.Membership, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionMembershipsWrite, admin.RealmResource(realmID)); err != nil {
+		return admin.Membership{}, err
+	}
+
+	if _, err := s.repo.RestoreMembership(ctx, realmID, id); err != nil {
+		return admin.Membership{}, err
+	}
+
+	return s.repo.GetMembership(ctx, realmID, id)
+}
+
+// GetUserMembershipSys returns the membership of the user in the realm.
+// This method is not exposed in the API. It does not include acting user checks.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *MembershipService) GetUserMembershipSys(
+	ctx context.Context,
+	realmID, userID admin.ID,
+) (admin.Membership, error) {
+	return s.repo.GetUserMembership(ctx, realmID, userID)
+}
+
+// GetUserMembershipsSys returns the memberships of the user in all realms.
+// This method is not exposed in the API. It does not include acting user checks.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *MembershipService) GetUserMembershipsSys(
+	ctx context.Context,
+	userID admin.ID,
+) ([]admin.Membership, error) {
+	return s.repo.GetUserMemberships(ctx, userID)
+}
+
+// getMembership authorizes the permission on the membership and returns the membership.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *MembershipService) getMembership(
+	ctx context.Context,
+	actor admin.Actor,
+	permission admin.Permission,
+	realmID, id admin.ID,
+) (admin.Membership, error) {
+	if err := s.authorizer.Authorize(ctx, actor, permission, admin.RealmResource(realmID)); err != nil {
+		return admin.Membership{}, err
+	}
+
+	membership, err := s.repo.GetMembership(ctx, realmID, id)
+	if err != nil {
+		return admin.Membership{}, err
+	}
+
+	return membership, nil
+}
+
+// authorize checks that the actor can manage the memberships of the realm of the
+// membership and assign it the given roles.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *MembershipService) authorize(
+	ctx context.Context,
+	actor admin.Actor,
+	membership admin.Membership,
+	roles ...admin.SystemRole,
+) error {
+	resource := admin.RealmResource(membership.RealmID)
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionMembershipsWrite, resource); err != nil {
+		return err
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersRolesWrite, resource); err != nil {
+		return err
+	}
+
+	return s.authorizeRoles(ctx, actor, membership, roles...)
+}
+
+// authorizeRoles checks that the actor holds every permission of the given roles
+// on the member in the realm of the membership, so that it cannot grant permissions
+// it does not hold itself, nor manage a membership more privileged than itself. The
+// membership roles are never system-wide.
+func (s *MembershipService) authorizeRoles(
+	ctx context.Context,
+	actor admin.Actor,
+	membership admin.Membership,
+	roles ...admin.SystemRole,
+) error {
+	resource := admin.OwnedResource(membership.RealmID, membership.UserID)
+
+	for _, role := range roles {
+		for _, permission := range role.Permissions() {
+			if err := s.authorizer.Authorize(ctx, actor, permission, resource); err != nil {
+				return domain.NewAccessDeniedError("actor %s cannot manage memberships with role %s", actor, role)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMembershipService_GetMemberships(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+
+	tests := map[string]struct {
+		actor      admin.Actor
+		wantResult bool
+		wantError  error
+	}{
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager": {
+			actor:      admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			wantResult: true,
+		},
+		"manager-wrongRealmID": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "wrongRealmID"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-repoError": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			wantError: domain.StoreError{},
+		},
+		"admin": {
+			actor:      admin.Actor{Role: admin.SystemRoleAdmin},
+			wantResult: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockMembershipRepository()
+
+			if errors.Is(test.wantError, domain.StoreError{}) {
+				repo.forcedError = domain.NewStoreError("forcedError")
+			}
+
+			svc := NewMembershipService(repo, newMockUserRepository(), newMockRealmRepository(), newTestAuthorizer(), nil)
+
+			res, err := svc.GetMemberships(context.Background(), test.actor, realmID, admin.ListOptions{})
+
+			if test.wantResult {
+				require.Len(t, res.Items, 1)
+			}
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMembershipService_CreateMembership(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID}
+	membership := admin.Membership{RealmID: realmID, UserID: "u1", UserRealmID: "a2", Role: admin.SystemRoleManager}
+
+	tests := map[string]struct {
+		actor       admin.Actor
+		membership  admin.Membership
+		member      bool
+		unknownUser bool
+		wantError   error
+	}{
+		"manager": {
+			actor:      manager,
+			membership: membership,
+		},
+		"manager-wrongRealmID": {
+			actor:      admin.Actor{Role: admin.SystemRoleManager, RealmID: "a2"},
+			membership: membership,
+			wantError:  domain.AccessDeniedError{},
+		},
+		"user": {
+			actor:      admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u2"},
+			membership: membership,
+			wantError:  domain.AccessDeniedError{},
+		},
+		"adminRole": {
+			actor:      manager,
+			membership: admin.Membership{RealmID: realmID, UserID: "u1", UserRealmID: "a2", Role: admin.SystemRoleAdmin},
+			wantError:  domain.ValidationError{},
+		},
+		"systemAuditorRole": {
+			actor:      manager,
+			membership: admin.Membership{RealmID: realmID, UserID: "u1", UserRealmID: "a2", Role: admin.SystemRoleSystemAuditor},
+			wantError:  domain.ValidationError{},
+		},
+		"realmAuditorRole": {
+			actor:      manager,
+			membership: admin.Membership{RealmID: realmID, UserID: "u1", UserRealmID: "a2", Role: admin.SystemRoleRealmAuditor},
+		},
+		"ownRealm": {
+			actor:      manager,
+			membership: admin.Membership{RealmID: realmID, UserID: "u1", UserRealmID: realmID, Role: admin.SystemRoleUser},
+			wantError:  domain.ValidationError{},
+		},
+		"unknownUser": {
+			actor:       manager,
+			membership:  membership,
+			unknownUser: true,
+			wantError:   domain.ValidationError{},
+		},
+		"alreadyMember": {
+			actor:      manager,
+			membership: membership,
+			member:     true,
+			wantError:  domain.ConflictError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockMembershipRepository()
+			repo.notMember = !test.member
+			userRepo := newMockUserRepository()
+
+			if test.unknownUser {
+				userRepo.forcedError = domain.NewNotFoundError("user not found")
+			}
+
+			svc := NewMembershipService(repo, userRepo, newMockRealmRepository(), newTestAuthorizer(), newMockIDGenerator())
+
+			created, err := svc.CreateMembership(context.Background(), test.actor, test.membership)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, admin.ID("1"), created.ID)
+			}
+		})
+	}
+}
+
+func TestMembershipService_UpdateMembership(t *testing.T) {
+	t.Parallel()
+
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"}
+	svc := NewMembershipService(newMockMembershipRepository(), newMockUserRepository(), newMockRealmRepository(),
+		newTestAuthorizer(), nil)
+
+	updated, err := svc.UpdateMembership(context.Background(), manager, admin.Membership{
+		ID:          "m1",
+		RealmID:     "a1",
+		UserID:      "u2",
+		UserRealmID: "a3",
+		Role:        admin.SystemRoleManager,
+		Version:     4,
+	})
+	require.NoError(t, err)
+	require.Equal(t, admin.ID("u1"), updated.UserID)
+	require.Equal(t, admin.ID("a2"), updated.UserRealmID)
+	require.Equal(t, admin.SystemRoleManager, updated.Role)
+	require.Equal(t, admin.Version(5), updated.Version)
+
+	_, err = svc.UpdateMembership(context.Background(), manager, admin.Membership{
+		ID:      "m1",
+		RealmID: "a1",
+		Role:    admin.SystemRoleAdmin,
+	})
+	require.ErrorAs(t, err, &domain.ValidationError{})
+}
+
+func TestMembershipService_roles(t *testing.T) {
+	t.Parallel()
+
+	// a custom role that can manage the memberships, and holds the permissions of the
+	// user role but not those of the manager role
+	membershipAdmin := admin.Role{Name: "membershipAdmin", Permissions: []admin.Permission{
+		admin.PermissionMembershipsRead, admin.PermissionMembershipsWrite, admin.PermissionUsersRolesWrite,
+		admin.PermissionUsersRead, admin.PermissionUsersWrite, admin.PermissionUsersKeysRead, admin.PermissionUsersKeysWrite,
+	}}
+	custom := admin.Actor{UserID: "u9", RealmID: "a1", Role: admin.SystemRoleUser}
+	manager := admin.Actor{UserID: "u8", RealmID: "a1", Role: admin.SystemRoleManager}
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		stored    admin.SystemRole
+		role      admin.SystemRole
+		wantError error
+	}{
+		"custom-user": {
+			actor:  custom,
+			stored: admin.SystemRoleUser,
+			role:   admin.SystemRoleUser,
+		},
+		"custom-grantManager": {
+			actor:     custom,
+			stored:    admin.SystemRoleUser,
+			role:      admin.SystemRoleManager,
+			wantError: domain.AccessDeniedError{},
+		},
+		"custom-storedManager": {
+			actor:     custom,
+			stored:    admin.SystemRoleManager,
+			role:      admin.SystemRoleUser,
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-grantManager": {
+			actor:  manager,
+			stored: admin.SystemRoleManager,
+			role:   admin.SystemRoleManager,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockMembershipRepository()
+			repo.notMember = true
+			repo.role = test.stored
+			userRepo := newMockUserRepository()
+			userRepo.roles = []string{"membershipAdmin"}
+			realmRepo := newMockRealmRepository()
+			realmRepo.roles = []admin.Role{membershipAdmin}
+			svc := NewMembershipService(repo, userRepo, realmRepo,
+				NewAuthorizer(userRepo, realmRepo, newMockGroupRepository(), newMockRuleRepository(), newMockRuleEngine()),
+				newMockIDGenerator())
+
+			membership := admin.Membership{ID: "m1", RealmID: "a1", UserID: "u1", UserRealmID: "a2", Role: test.role}
+
+			_, createErr := svc.CreateMembership(context.Background(), test.actor, membership)
+			_, updateErr := svc.UpdateMembership(context.Background(), test.actor, membership)
+			deleteErr := svc.DeleteMembership(context.Background(), test.actor, "a1", "m1", 1)
+
+			if test.wantError == nil {
+				require.NoError(t, createErr)
+				require.NoError(t, updateErr)
+				require.NoError(t, deleteErr)
+
+				return
+			}
+
+			// the creation only grants the new role, the deletion only removes the stored one
+			if test.role != admin.SystemRoleUser {
+				require.ErrorAs(t, createErr, &test.wantError)
+			} else {
+				require.NoError(t, createErr)
+			}
+
+			if test.stored != admin.SystemRoleUser {
+				require.ErrorAs(t, deleteErr, &test.wantError)
+			} else {
+				require.NoError(t, deleteErr)
+			}
+
+			require.ErrorAs(t, updateErr, &test.wantError)
+		})
+	}
+}
+
+type mockMembershipRepository struct {
+	notMember   bool
+	role        admin.SystemRole
+	forcedError error
+}
+
+// ensure mockMembershipRepository implements admin.MembershipRepository.
+var _ admin.MembershipRepository = (*mockMembershipRepository)(nil)
+
+func newMockMembershipRepository() *mockMembershipRepository {
+	return &mockMembershipRepository{}
+}
+
+func (r *mockMembershipRepository) GetMemberships(
+	_ context.Context,
+	realmID admin.ID,
+	_ admin.ListOptions,
+) (admin.Page[admin.Membership], error) {
+	if realmID == "" {
+		return admin.Page[admin.Membership]{}, errors.New("test-precondition: empty realmID")
+	}
+
+	return admin.Page[admin.Membership]{Items: []admin.Membership{r.mockMembership()}, Total: 1}, r.forcedError
+}
+
+func (r *mockMembershipRepository) GetMembership(_ context.Context, realmID, id admin.ID) (admin.Membership, error) {
+	if realmID == "" {
+		return admin.Membership{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return admin.Membership{}, errors.New("test-precondition: empty id")
+	}
+
+	return r.mockMembership(), r.forcedError
+}
+
+func (r *mockMembershipRepository) GetUserMembership(_ context.Context, realmID, userID admin.ID) (admin.Membership, error) {
+	if realmID == "" {
+		return admin.Membership{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if userID == "" {
+		return admin.Membership{}, errors.New("test-precondition: empty userID")
+	}
+
+	if r.notMember {
+		return admin.Membership{}, domain.NewNotFoundError("membership not found")
+	}
+
+	return r.mockMembership(), r.forcedError
+}
+
+func (r *mockMembershipRepository) GetUserMemberships(_ context.Context, userID admin.ID) ([]admin.Membership, error) {
+	if userID == "" {
+		return nil, errors.New("test-precondition: empty userID")
+	}
+
+	return []admin.Membership{r.mockMembership()}, r.forcedError
+}
+
+func (r *mockMembershipRepository) CreateMembership(_ context.Context, membership admin.Membership) error {
+	if (reflect.DeepEqual(membership, admin.Membership{})) {
+		return errors.New("test-precondition: empty membership")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockMembershipRepository) UpdateMembership(_ context.Context, membership admin.Membership) error {
+	if (reflect.DeepEqual(membership, admin.Membership{})) {
+		return errors.New("test-precondition: empty membership")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockMembershipRepository) DeleteMembership(_ context.Context, realmID, id admin.ID, version admin.Version, deletedAt time.Time) error {
+	if version < admin.FirstVersion {
+		return errors.New("test-precondition: no version")
+	}
+
+	if deletedAt.IsZero() {
+		return errors.New("test-precondition: no deletion time")
+	}
+
+	if realmID == "" {
+		return errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return errors.New("test-precondition: empty id")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockMembershipRepository) RestoreMembership(_ context.Context, realmID, id admin.ID) (time.Time, error) {
+	if realmID == "" {
+		return time.Time{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return time.Time{}, errors.New("test-precondition: empty id")
+	}
+
+	return mockDeletedAt(), r.forcedError
+}
+
+func (r *mockMembershipRepository) DeleteUserMemberships(_ context.Context, userID admin.ID, deletedAt time.Time) error {
+	if userID == "" {
+		return errors.New("test-precondition: empty userID")
+	}
+
+	if deletedAt.IsZero() {
+		return errors.New("test-precondition: no deletion time")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockMembershipRepository) RestoreUserMemberships(_ context.Context, userID admin.ID, deletedAt time.Time) error {
+	if userID == "" {
+		return errors.New("test-precondition: empty userID")
+	}
+
+	if !deletedAt.Equal(mockDeletedAt()) {
+		return errors.New("test-precondition: unexpected deletion time")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockMembershipRepository) mockMembership() admin.Membership {
+	return admin.Membership{
+		ID:          "m1",
+		RealmID:     "a1",
+		UserID:      "u1",
+		UserRealmID: "a2",
+		Role:        cmp.Or(r.role, admin.SystemRoleUser),
+	}
+}
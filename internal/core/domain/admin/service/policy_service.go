@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// PolicyService is a service for managing the authorization policies of the realms.
+//
+// It implements the service.PolicyService interface.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type PolicyService struct {
+	repo       admin.PolicyRepository
+	authorizer admin.Authorizer
+	idgen      domain.IDGenerator
+}
+
+// NewPolicyService returns a new PolicyService instance.
+func NewPolicyService(
+	repo admin.PolicyRepository,
+	authorizer admin.Authorizer,
+	idgen domain.IDGenerator,
+) *PolicyService {
+	return &PolicyService{
+		repo:       repo,
+		authorizer: authorizer,
+		idgen:      idgen,
+	}
+}
+
+// Ensure service implements the service.PolicyService interface.
+var _ admin.PolicyService = (*PolicyService)(nil)
+
+// GetPolicies implements the service.PolicyService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *PolicyService) GetPolicies(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	options admin.ListOptions,
+) (admin.Page[admin.Policy], error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionPoliciesRead, admin.RealmResource(realmID)); err != nil {
+		return admin.Page[admin.Policy]{}, err
+	}
+
+	options, err := validateListOptions(options, admin.PolicySortFields)
+	if err != nil {
+		return admin.Page[admin.Policy]{}, err
+	}
+
+	page, err := s.repo.GetPolicies(ctx, realmID, options)
+	if err != nil {
+		return admin.Page[admin.Policy]{}, err
+	}
+
+	return page, nil
+}
+
+// GetPolicy implements the service.PolicyService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *PolicyService) GetPolicy(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.Policy, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionPoliciesRead, admin.RealmResource(realmID)); err != nil {
+		return admin.Policy{}, err
+	}
+
+	policy, err := s.repo.GetPolicy(ctx, realmID, id)
+	if err != nil {
+		return admin.Policy{}, err
+	}
+
+	return policy, nil
+}
+
+// CreatePolicy implements the service.PolicyService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *PolicyService) CreatePolicy(
+	ctx context.Context,
+	actor admin.Actor,
+	policy admin.Policy,
+) (admin.Policy, error) {
+	policy, err := validatePolicy(policy)
+	if err != nil {
+		return admin.Policy{}, err
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionPoliciesWrite, admin.RealmResource(policy.RealmID)); err != nil {
+		return admin.Policy{}, err
+	}
+
+	policy.ID = admin.ID(s.idgen.GenerateID())
+	policy.Version = admin.FirstVersion
+
+	if err := s.repo.CreatePolicy(ctx, policy); err != nil {
+		return admin.Policy{}, err
+	}
+
+	return policy, nil
+}
+
+// UpdatePolicy implements the service.PolicyService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *PolicyService) UpdatePolicy(
+	ctx context.Context,
+	actor admin.Actor,
+	policy admin.Policy,
+) (admin.Policy, error) {
+	policy, err := validatePolicy(policy)
+	if err != nil {
+		return admin.Policy{}, err
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionPoliciesWrite, admin.RealmResource(policy.RealmID)); err != nil {
+		return admin.Policy{}, err
+	}
+
+	if err := s.repo.UpdatePolicy(ctx, policy); err != nil {
+		return admin.Policy{}, err
+	}
+
+	policy.Version = policy.Version.Next()
+
+	return policy, nil
+}
+
+// DeletePolicy implements the service.PolicyService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *PolicyService) DeletePolicy(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+	version admin.Version,
+) error {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionPoliciesWrite, admin.RealmResource(realmID)); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeletePolicy(ctx, realmID, id, version, time.Now()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestorePolicy implements the service.PolicyService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *PolicyService) RestorePolicy(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.Policy, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionPoliciesWrite, admin.RealmResource(realmID)); err != nil {
+		return admin.Policy{}, err
+	}
+
+	if _, err := s.repo.RestorePolicy(ctx, realmID, id); err != nil {
+		return admin.Policy{}, err
+	}
+
+	return s.repo.GetPolicy(ctx, realmID, id)
+}
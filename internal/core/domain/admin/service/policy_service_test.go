@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyService_GetPolicies(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+
+	tests := map[string]struct {
+		actor      admin.Actor
+		wantResult bool
+		wantError  error
+	}{
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager": {
+			actor:      admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			wantResult: true,
+		},
+		"manager-wrongRealmID": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "wrongRealmID"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-repoError": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			wantError: domain.StoreError{},
+		},
+		"admin": {
+			actor:      admin.Actor{Role: admin.SystemRoleAdmin},
+			wantResult: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockPolicyRepository()
+
+			if errors.Is(test.wantError, domain.StoreError{}) {
+				repo.forcedError = domain.NewStoreError("forcedError")
+			}
+
+			svc := NewPolicyService(repo, newTestAuthorizer(), nil)
+
+			res, err := svc.GetPolicies(context.Background(), test.actor, realmID, admin.ListOptions{})
+
+			if test.wantResult {
+				require.Len(t, res.Items, 1)
+			}
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPolicyService_CreatePolicy(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID}
+	policy := admin.Policy{
+		RealmID:   realmID,
+		Code:      "invoices-read",
+		Effect:    admin.PolicyEffectAllow,
+		Actions:   []string{"invoices:read"},
+		Resources: []string{"invoices/*"},
+	}
+
+	withPolicy := func(modify func(policy *admin.Policy)) admin.Policy {
+		p := policy
+		modify(&p)
+
+		return p
+	}
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		policy    admin.Policy
+		wantError error
+	}{
+		"manager": {
+			actor:  manager,
+			policy: policy,
+		},
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			policy:    policy,
+			wantError: domain.AccessDeniedError{},
+		},
+		"noEffect": {
+			actor:     manager,
+			policy:    withPolicy(func(p *admin.Policy) { p.Effect = admin.PolicyEffectNone }),
+			wantError: domain.ValidationError{},
+		},
+		"noActions": {
+			actor:     manager,
+			policy:    withPolicy(func(p *admin.Policy) { p.Actions = nil }),
+			wantError: domain.ValidationError{},
+		},
+		"invalidResource": {
+			actor:     manager,
+			policy:    withPolicy(func(p *admin.Policy) { p.Resources = []string{"invoices/*/lines"} }),
+			wantError: domain.ValidationError{},
+		},
+		"emptyRole": {
+			actor:     manager,
+			policy:    withPolicy(func(p *admin.Policy) { p.Roles = []string{""} }),
+			wantError: domain.ValidationError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := NewPolicyService(newMockPolicyRepository(), newTestAuthorizer(), newMockIDGenerator())
+
+			created, err := svc.CreatePolicy(context.Background(), test.actor, test.policy)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, admin.ID("1"), created.ID)
+			}
+		})
+	}
+}
+
+type mockPolicyRepository struct {
+	policies    []admin.Policy
+	forcedError error
+}
+
+// ensure mockPolicyRepository implements admin.PolicyRepository.
+var _ admin.PolicyRepository = (*mockPolicyRepository)(nil)
+
+func newMockPolicyRepository() *mockPolicyRepository {
+	return &mockPolicyRepository{}
+}
+
+func (r *mockPolicyRepository) GetPolicies(
+	_ context.Context,
+	realmID admin.ID,
+	_ admin.ListOptions,
+) (admin.Page[admin.Policy], error) {
+	if realmID == "" {
+		return admin.Page[admin.Policy]{}, errors.New("test-precondition: empty realmID")
+	}
+
+	policies := r.policies
+
+	if policies == nil {
+		policies = []admin.Policy{r.mockPolicy()}
+	}
+
+	return admin.Page[admin.Policy]{Items: policies, Total: len(policies)}, r.forcedError
+}
+
+func (r *mockPolicyRepository) GetPolicy(_ context.Context, realmID, id admin.ID) (admin.Policy, error) {
+	if realmID == "" {
+		return admin.Policy{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return admin.Policy{}, errors.New("test-precondition: empty id")
+	}
+
+	return r.mockPolicy(), r.forcedError
+}
+
+func (r *mockPolicyRepository) CreatePolicy(_ context.Context, policy admin.Policy) error {
+	if (reflect.DeepEqual(policy, admin.Policy{})) {
+		return errors.New("test-precondition: empty policy")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockPolicyRepository) UpdatePolicy(_ context.Context, policy admin.Policy) error {
+	if (reflect.DeepEqual(policy, admin.Policy{})) {
+		return errors.New("test-precondition: empty policy")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockPolicyRepository) DeletePolicy(_ context.Context, realmID, id admin.ID, version admin.Version, deletedAt time.Time) error {
+	if version < admin.FirstVersion {
+		return errors.New("test-precondition: no version")
+	}
+
+	if deletedAt.IsZero() {
+		return errors.New("test-precondition: no deletion time")
+	}
+
+	if realmID == "" {
+		return errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return errors.New("test-precondition: empty id")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockPolicyRepository) RestorePolicy(_ context.Context, realmID, id admin.ID) (time.Time, error) {
+	if realmID == "" {
+		return time.Time{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return time.Time{}, errors.New("test-precondition: empty id")
+	}
+
+	return mockDeletedAt(), r.forcedError
+}
+
+func (r *mockPolicyRepository) mockPolicy() admin.Policy {
+	return admin.Policy{
+		ID:        "p1",
+		RealmID:   "a1",
+		Code:      "mockPolicy",
+		Effect:    admin.PolicyEffectAllow,
+		Actions:   []string{"*"},
+		Resources: []string{"*"},
+	}
+}
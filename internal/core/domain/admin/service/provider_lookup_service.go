@@ -43,12 +43,12 @@ func (s *ProviderLookupService) LookupProvider(
 		return admin.Provider{}, domain.NewBadRequestError("provider code must not be empty")
 	}
 
-	providers, err := s.providerService.GetProviders(ctx, s.admin)
+	providers, err := s.providerService.GetProviders(ctx, s.admin, admin.ListOptions{})
 	if err != nil {
 		return admin.Provider{}, err
 	}
 
-	provider, found := s.findProvider(providers, providerCode)
+	provider, found := s.findProvider(providers.Items, providerCode)
 	if !found {
 		return admin.Provider{}, domain.NewNotFoundError("provider %s not found", providerCode)
 	}
@@ -56,6 +56,16 @@ func (s *ProviderLookupService) LookupProvider(
 	return provider, nil
 }
 
+// LookupProviderByID implements the service.ProviderLookupService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *ProviderLookupService) LookupProviderByID(
+	ctx context.Context,
+	id admin.ID,
+) (admin.Provider, error) {
+	return s.providerService.GetProvider(ctx, s.admin, id)
+}
+
 func (s *ProviderLookupService) findProvider(providers []admin.Provider, code string) (admin.Provider, bool) {
 	for _, provider := range providers {
 		if provider.Code == code && provider.Enabled {
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -14,18 +15,21 @@ import (
 // We do not wrap the errors returned by the repository because they are already
 // packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
 type ProviderService struct {
-	repo  admin.ProviderRepository
-	idgen domain.IDGenerator
+	repo       admin.ProviderRepository
+	authorizer admin.Authorizer
+	idgen      domain.IDGenerator
 }
 
 // NewProviderService returns a new ProviderService instance.
 func NewProviderService(
 	repo admin.ProviderRepository,
+	authorizer admin.Authorizer,
 	idgen domain.IDGenerator,
 ) *ProviderService {
 	return &ProviderService{
-		repo:  repo,
-		idgen: idgen,
+		repo:       repo,
+		authorizer: authorizer,
+		idgen:      idgen,
 	}
 }
 
@@ -38,24 +42,23 @@ var _ admin.ProviderService = (*ProviderService)(nil)
 func (s *ProviderService) GetProviders(
 	ctx context.Context,
 	actor admin.Actor,
-) ([]admin.Provider, error) {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return nil, domain.NewAccessDeniedError("user %s cannot get providers", actor.UserID)
-	case admin.SystemRoleManager:
-		return nil, domain.NewAccessDeniedError("manager %s cannot get providers", actor.UserID)
-	case admin.SystemRoleAdmin:
-		providers, err := s.repo.GetProviders(ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		return providers, nil
-	case admin.SystemRoleNone:
-		return nil, domain.NewAccessDeniedError("anonymous user cannot get providers")
-	default:
-		return nil, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	options admin.ListOptions,
+) (admin.Page[admin.Provider], error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionProvidersRead, admin.SystemResource()); err != nil {
+		return admin.Page[admin.Provider]{}, err
 	}
+
+	options, err := validateListOptions(options, admin.ProviderSortFields)
+	if err != nil {
+		return admin.Page[admin.Provider]{}, err
+	}
+
+	page, err := s.repo.GetProviders(ctx, options)
+	if err != nil {
+		return admin.Page[admin.Provider]{}, err
+	}
+
+	return page, nil
 }
 
 // GetProvider implements the service.ProviderService interface.
@@ -66,23 +69,16 @@ func (s *ProviderService) GetProvider(
 	actor admin.Actor,
 	id admin.ID,
 ) (admin.Provider, error) {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.Provider{}, domain.NewAccessDeniedError("user %s cannot get provider %s", actor.UserID, id)
-	case admin.SystemRoleManager:
-		return admin.Provider{}, domain.NewAccessDeniedError("manager %s cannot get provider %s", actor.UserID, id)
-	case admin.SystemRoleAdmin:
-		user, err := s.repo.GetProvider(ctx, id)
-		if err != nil {
-			return admin.Provider{}, err
-		}
-
-		return user, nil
-	case admin.SystemRoleNone:
-		return admin.Provider{}, domain.NewAccessDeniedError("anonymous user cannot get provider %s", id)
-	default:
-		return admin.Provider{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionProvidersRead, admin.SystemResource()); err != nil {
+		return admin.Provider{}, err
+	}
+
+	provider, err := s.repo.GetProvider(ctx, id)
+	if err != nil {
+		return admin.Provider{}, err
 	}
+
+	return provider, nil
 }
 
 // CreateProvider implements the service.ProviderService interface.
@@ -98,24 +94,18 @@ func (s *ProviderService) CreateProvider(
 		return admin.Provider{}, err
 	}
 
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.Provider{}, domain.NewAccessDeniedError("user %s cannot create provider", actor.UserID)
-	case admin.SystemRoleManager:
-		return admin.Provider{}, domain.NewAccessDeniedError("manager %s cannot create provider", actor.UserID)
-	case admin.SystemRoleAdmin:
-		provider.ID = admin.ID(s.idgen.GenerateID())
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionProvidersWrite, admin.SystemResource()); err != nil {
+		return admin.Provider{}, err
+	}
 
-		if err := s.repo.CreateProvider(ctx, provider); err != nil {
-			return admin.Provider{}, err
-		}
+	provider.ID = admin.ID(s.idgen.GenerateID())
+	provider.Version = admin.FirstVersion
 
-		return provider, nil
-	case admin.SystemRoleNone:
-		return admin.Provider{}, domain.NewAccessDeniedError("anonymous user cannot create provider")
-	default:
-		return admin.Provider{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	if err := s.repo.CreateProvider(ctx, provider); err != nil {
+		return admin.Provider{}, err
 	}
+
+	return provider, nil
 }
 
 // UpdateProvider implements the service.ProviderService interface.
@@ -131,22 +121,17 @@ func (s *ProviderService) UpdateProvider(
 		return admin.Provider{}, err
 	}
 
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.Provider{}, domain.NewAccessDeniedError("user %s cannot update provider %s", actor.UserID, provider.ID)
-	case admin.SystemRoleManager:
-		return admin.Provider{}, domain.NewAccessDeniedError("manager %s cannot update provider %s", actor.UserID, provider.ID)
-	case admin.SystemRoleAdmin:
-		if err := s.repo.UpdateProvider(ctx, provider); err != nil {
-			return admin.Provider{}, err
-		}
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionProvidersWrite, admin.SystemResource()); err != nil {
+		return admin.Provider{}, err
+	}
 
-		return provider, nil
-	case admin.SystemRoleNone:
-		return admin.Provider{}, domain.NewAccessDeniedError("anonymous user cannot update provider %s", provider.ID)
-	default:
-		return admin.Provider{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	if err := s.repo.UpdateProvider(ctx, provider); err != nil {
+		return admin.Provider{}, err
 	}
+
+	provider.Version = provider.Version.Next()
+
+	return provider, nil
 }
 
 // DeleteProvider implements the service.ProviderService interface.
@@ -156,21 +141,34 @@ func (s *ProviderService) DeleteProvider(
 	ctx context.Context,
 	actor admin.Actor,
 	id admin.ID,
+	version admin.Version,
 ) error {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return domain.NewAccessDeniedError("user %s cannot delete provider %s", actor.UserID, id)
-	case admin.SystemRoleManager:
-		return domain.NewAccessDeniedError("manager %s cannot delete provider %s", actor.UserID, id)
-	case admin.SystemRoleAdmin:
-		if err := s.repo.DeleteProvider(ctx, id); err != nil {
-			return err
-		}
-
-		return nil
-	case admin.SystemRoleNone:
-		return domain.NewAccessDeniedError("anonymous user cannot delete provider %s", id)
-	default:
-		return domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionProvidersWrite, admin.SystemResource()); err != nil {
+		return err
 	}
+
+	if err := s.repo.DeleteProvider(ctx, id, version, time.Now()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestoreProvider implements the service.ProviderService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *ProviderService) RestoreProvider(
+	ctx context.Context,
+	actor admin.Actor,
+	id admin.ID,
+) (admin.Provider, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionProvidersWrite, admin.SystemResource()); err != nil {
+		return admin.Provider{}, err
+	}
+
+	if _, err := s.repo.RestoreProvider(ctx, id); err != nil {
+		return admin.Provider{}, err
+	}
+
+	return s.repo.GetProvider(ctx, id)
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -37,6 +38,14 @@ func TestProviderService_GetProviders(t *testing.T) {
 			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
 			wantError: domain.StoreError{},
 		},
+		"systemAuditor": {
+			actor:      admin.Actor{Role: admin.SystemRoleSystemAuditor},
+			wantResult: true,
+		},
+		"realmAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
 		"none": {
 			actor:     admin.Actor{Role: admin.SystemRoleNone},
 			wantError: domain.AccessDeniedError{},
@@ -48,7 +57,7 @@ func TestProviderService_GetProviders(t *testing.T) {
 	}
 
 	repo := newMockProviderRepository()
-	svc := NewProviderService(repo, nil)
+	svc := NewProviderService(repo, newTestAuthorizer(), nil)
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -58,10 +67,10 @@ func TestProviderService_GetProviders(t *testing.T) {
 		}
 
 		t.Run(name, func(t *testing.T) {
-			res, err := svc.GetProviders(context.Background(), test.actor)
+			res, err := svc.GetProviders(context.Background(), test.actor, admin.ListOptions{})
 
 			if test.wantResult {
-				require.Len(t, res, 1)
+				require.Len(t, res.Items, 1)
 			}
 
 			if test.wantError != nil {
@@ -111,7 +120,7 @@ func TestProviderService_GetProvider(t *testing.T) {
 	}
 
 	repo := newMockProviderRepository()
-	svc := NewProviderService(repo, nil)
+	svc := NewProviderService(repo, newTestAuthorizer(), nil)
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -162,6 +171,10 @@ func TestProviderService_CreateProvider(t *testing.T) {
 			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
 			wantError: domain.StoreError{},
 		},
+		"systemAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleSystemAuditor},
+			wantError: domain.AccessDeniedError{},
+		},
 		"none": {
 			actor:     admin.Actor{Role: admin.SystemRoleNone},
 			wantError: domain.AccessDeniedError{},
@@ -173,7 +186,7 @@ func TestProviderService_CreateProvider(t *testing.T) {
 	}
 
 	repo := newMockProviderRepository()
-	svc := NewProviderService(repo, newMockIDGenerator())
+	svc := NewProviderService(repo, newTestAuthorizer(), newMockIDGenerator())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -242,7 +255,7 @@ func TestProviderService_UpdateProvider(t *testing.T) {
 	}
 
 	repo := newMockProviderRepository()
-	svc := NewProviderService(repo, nil)
+	svc := NewProviderService(repo, newTestAuthorizer(), nil)
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -311,7 +324,7 @@ func TestProviderService_DeleteProvider(t *testing.T) {
 	}
 
 	repo := newMockProviderRepository()
-	svc := NewProviderService(repo, nil)
+	svc := NewProviderService(repo, newTestAuthorizer(), nil)
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -321,7 +334,7 @@ func TestProviderService_DeleteProvider(t *testing.T) {
 		}
 
 		t.Run(name, func(t *testing.T) {
-			err := svc.DeleteProvider(context.Background(), test.actor, userID)
+			err := svc.DeleteProvider(context.Background(), test.actor, userID, 1)
 
 			if test.wantResult {
 				require.NoError(t, err)
@@ -347,8 +360,11 @@ func newMockProviderRepository() *mockProviderRepository {
 	return &mockProviderRepository{}
 }
 
-func (r *mockProviderRepository) GetProviders(_ context.Context) ([]admin.Provider, error) {
-	return []admin.Provider{r.mockProvider()}, r.forcedError
+func (r *mockProviderRepository) GetProviders(
+	_ context.Context,
+	_ admin.ListOptions,
+) (admin.Page[admin.Provider], error) {
+	return admin.Page[admin.Provider]{Items: []admin.Provider{r.mockProvider()}, Total: 1}, r.forcedError
 }
 
 func (r *mockProviderRepository) GetProvider(_ context.Context, id admin.ID) (admin.Provider, error) {
@@ -375,7 +391,15 @@ func (r *mockProviderRepository) UpdateProvider(_ context.Context, user admin.Pr
 	return r.forcedError
 }
 
-func (r *mockProviderRepository) DeleteProvider(_ context.Context, id admin.ID) error {
+func (r *mockProviderRepository) DeleteProvider(_ context.Context, id admin.ID, version admin.Version, deletedAt time.Time) error {
+	if version < admin.FirstVersion {
+		return errors.New("test-precondition: no version")
+	}
+
+	if deletedAt.IsZero() {
+		return errors.New("test-precondition: no deletion time")
+	}
+
 	if id == "" {
 		return errors.New("test-precondition: empty id")
 	}
@@ -383,6 +407,14 @@ func (r *mockProviderRepository) DeleteProvider(_ context.Context, id admin.ID)
 	return r.forcedError
 }
 
+func (r *mockProviderRepository) RestoreProvider(_ context.Context, id admin.ID) (time.Time, error) {
+	if id == "" {
+		return time.Time{}, errors.New("test-precondition: empty id")
+	}
+
+	return mockDeletedAt(), r.forcedError
+}
+
 func (r *mockProviderRepository) mockProvider() admin.Provider {
 	return admin.Provider{
 		ID:   "u1",
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// PurgeService provides a service for purging the deleted entities.
+//
+// It implements the admin.PurgeService interface.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type PurgeService struct {
+	deletionRepo admin.DeletionRepository
+}
+
+// NewPurgeService returns a new PurgeService instance.
+func NewPurgeService(deletionRepo admin.DeletionRepository) *PurgeService {
+	return &PurgeService{deletionRepo: deletionRepo}
+}
+
+// Ensure service implements the admin.PurgeService interface.
+var _ admin.PurgeService = (*PurgeService)(nil)
+
+// PurgeDeleted implements the admin.PurgeService interface.
+//
+// The entities deleted before the given time can no longer be restored.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *PurgeService) PurgeDeleted(
+	ctx context.Context,
+	before time.Time,
+) (int, error) {
+	return s.deletionRepo.PurgeDeleted(ctx, before)
+}
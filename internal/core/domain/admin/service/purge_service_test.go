@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeService_PurgeDeleted(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		forcedError error
+		wantPurged  int
+		wantError   bool
+	}{
+		"purged": {
+			wantPurged: 3,
+		},
+		"repoError": {
+			forcedError: errors.New("forcedError"),
+			wantError:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockDeletionRepository()
+			repo.forcedError = test.forcedError
+
+			svc := NewPurgeService(repo)
+
+			purged, err := svc.PurgeDeleted(context.Background(), time.Now())
+
+			if test.wantError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wantPurged, purged)
+		})
+	}
+}
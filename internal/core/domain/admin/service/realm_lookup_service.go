@@ -42,12 +42,12 @@ func (s *RealmLookupService) LookupRealm(
 		return admin.Realm{}, domain.NewBadRequestError("realm code must not be empty")
 	}
 
-	realms, err := s.realmService.GetRealms(ctx, s.admin)
+	realms, err := s.realmService.GetRealms(ctx, s.admin, admin.ListOptions{})
 	if err != nil {
 		return admin.Realm{}, err
 	}
 
-	realm, found := s.findProvider(realms, realmCode)
+	realm, found := s.findProvider(realms.Items, realmCode)
 	if !found {
 		return admin.Realm{}, domain.NewNotFoundError("realm %s not found", realmCode)
 	}
@@ -55,6 +55,16 @@ func (s *RealmLookupService) LookupRealm(
 	return realm, nil
 }
 
+// LookupRealmByID implements the service.RealmLookupService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *RealmLookupService) LookupRealmByID(
+	ctx context.Context,
+	id admin.ID,
+) (admin.Realm, error) {
+	return s.realmService.GetRealm(ctx, s.admin, id)
+}
+
 func (s *RealmLookupService) findProvider(realms []admin.Realm, code string) (admin.Realm, bool) {
 	for _, realm := range realms {
 		if realm.Code == code && realm.Enabled {
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -9,23 +10,31 @@ import (
 
 // RealmService is a service for managing realms.
 //
+// The content of a realm is deleted and restored with the realm.
+//
 // It implements the service.RealmService interface.
 //
 // We do not wrap the errors returned by the repository because they are already
 // packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
 type RealmService struct {
-	repo  admin.RealmRepository
-	idgen domain.IDGenerator
+	repo         admin.RealmRepository
+	deletionRepo admin.DeletionRepository
+	authorizer   admin.Authorizer
+	idgen        domain.IDGenerator
 }
 
 // NewRealmService returns a new RealmService instance.
 func NewRealmService(
 	repo admin.RealmRepository,
+	deletionRepo admin.DeletionRepository,
+	authorizer admin.Authorizer,
 	idgen domain.IDGenerator,
 ) *RealmService {
 	return &RealmService{
-		repo:  repo,
-		idgen: idgen,
+		repo:         repo,
+		deletionRepo: deletionRepo,
+		authorizer:   authorizer,
+		idgen:        idgen,
 	}
 }
 
@@ -33,34 +42,43 @@ func NewRealmService(
 var _ admin.RealmService = (*RealmService)(nil)
 
 // GetRealms implements the service.RealmService interface.
+// Actors that can read only their own realm get just that realm, on a single page.
 //
 //nolint:wrapcheck // see comment in the header
 func (s *RealmService) GetRealms(
 	ctx context.Context,
 	actor admin.Actor,
-) ([]admin.Realm, error) {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return nil, domain.NewAccessDeniedError("user %s cannot get realms", actor.UserID)
-	case admin.SystemRoleManager:
-		realm, err := s.repo.GetRealm(ctx, actor.RealmID)
+	options admin.ListOptions,
+) (admin.Page[admin.Realm], error) {
+	err := s.authorizer.Authorize(ctx, actor, admin.PermissionRealmsRead, admin.SystemResource())
+	if err == nil {
+		options, err := validateListOptions(options, admin.RealmSortFields)
 		if err != nil {
-			return nil, err
+			return admin.Page[admin.Realm]{}, err
 		}
 
-		return []admin.Realm{realm}, nil
-	case admin.SystemRoleAdmin:
-		realms, err := s.repo.GetRealms(ctx)
+		page, err := s.repo.GetRealms(ctx, options)
 		if err != nil {
-			return nil, err
+			return admin.Page[admin.Realm]{}, err
 		}
 
-		return realms, nil
-	case admin.SystemRoleNone:
-		return nil, domain.NewAccessDeniedError("anonymous user cannot get realms")
-	default:
-		return nil, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+		return page, nil
+	}
+
+	if !domain.IsAccessDeniedError(err) {
+		return admin.Page[admin.Realm]{}, err
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRealmsRead, admin.RealmResource(actor.RealmID)); err != nil {
+		return admin.Page[admin.Realm]{}, err
+	}
+
+	realm, err := s.repo.GetRealm(ctx, actor.RealmID)
+	if err != nil {
+		return admin.Page[admin.Realm]{}, err
 	}
+
+	return admin.Page[admin.Realm]{Items: []admin.Realm{realm}, Total: 1}, nil
 }
 
 // GetRealm implements the service.RealmService interface.
@@ -71,22 +89,11 @@ func (s *RealmService) GetRealm(
 	actor admin.Actor,
 	id admin.ID,
 ) (admin.Realm, error) {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.Realm{}, domain.NewAccessDeniedError("user %s cannot get realm %s", actor.UserID, id)
-	case admin.SystemRoleManager:
-		if actor.RealmID != id {
-			return admin.Realm{}, domain.NewAccessDeniedError("manager %s cannot get realm %s", actor.UserID, id)
-		}
-
-		return s.repo.GetRealm(ctx, id)
-	case admin.SystemRoleAdmin:
-		return s.repo.GetRealm(ctx, id)
-	case admin.SystemRoleNone:
-		return admin.Realm{}, domain.NewAccessDeniedError("anonymous user cannot get realm %s", id)
-	default:
-		return admin.Realm{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRealmsRead, admin.RealmResource(id)); err != nil {
+		return admin.Realm{}, err
 	}
+
+	return s.repo.GetRealm(ctx, id)
 }
 
 // CreateRealm implements the service.RealmService interface.
@@ -102,24 +109,18 @@ func (s *RealmService) CreateRealm(
 		return admin.Realm{}, err
 	}
 
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.Realm{}, domain.NewAccessDeniedError("user %s cannot create realm", actor.UserID)
-	case admin.SystemRoleManager:
-		return admin.Realm{}, domain.NewAccessDeniedError("manager %s cannot create realm", actor.UserID)
-	case admin.SystemRoleAdmin:
-		realm.ID = admin.ID(s.idgen.GenerateID())
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRealmsCreate, admin.SystemResource()); err != nil {
+		return admin.Realm{}, err
+	}
 
-		if err := s.repo.CreateRealm(ctx, realm); err != nil {
-			return admin.Realm{}, err
-		}
+	realm.ID = admin.ID(s.idgen.GenerateID())
+	realm.Version = admin.FirstVersion
 
-		return realm, nil
-	case admin.SystemRoleNone:
-		return admin.Realm{}, domain.NewAccessDeniedError("anonymous user cannot create realm")
-	default:
-		return admin.Realm{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	if err := s.repo.CreateRealm(ctx, realm); err != nil {
+		return admin.Realm{}, err
 	}
+
+	return realm, nil
 }
 
 // UpdateRealm implements the service.RealmService interface.
@@ -135,50 +136,78 @@ func (s *RealmService) UpdateRealm(
 		return admin.Realm{}, err
 	}
 
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.Realm{}, domain.NewAccessDeniedError("user %s cannot update realm %s", actor.UserID, realm.ID)
-	case admin.SystemRoleManager:
-		if actor.RealmID != realm.ID {
-			return admin.Realm{}, domain.NewAccessDeniedError("manager %s cannot update realm %s", actor.UserID, realm.ID)
-		}
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRealmsWrite, admin.RealmResource(realm.ID)); err != nil {
+		return admin.Realm{}, err
+	}
+
+	if err := s.repo.UpdateRealm(ctx, realm); err != nil {
+		return admin.Realm{}, err
+	}
+
+	realm.Version = realm.Version.Next()
+
+	return realm, nil
+}
 
-		if err := s.repo.UpdateRealm(ctx, realm); err != nil {
-			return admin.Realm{}, err
+// DeleteRealm implements the service.RealmService interface.
+// A dry run checks the version of the realm, so that it reports the content the
+// deletion of the same version would delete.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *RealmService) DeleteRealm(
+	ctx context.Context,
+	actor admin.Actor,
+	id admin.ID,
+	version admin.Version,
+	dryRun bool,
+) (admin.RealmContent, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRealmsDelete, admin.RealmResource(id)); err != nil {
+		return admin.RealmContent{}, err
+	}
+
+	if dryRun {
+		realm, err := s.repo.GetRealm(ctx, id)
+		if err != nil {
+			return admin.RealmContent{}, err
 		}
 
-		return realm, nil
-	case admin.SystemRoleAdmin:
-		if err := s.repo.UpdateRealm(ctx, realm); err != nil {
-			return admin.Realm{}, err
+		if realm.Version != version {
+			return admin.RealmContent{}, domain.NewStaleVersionError("realm %v was changed since version %d", id, version)
 		}
 
-		return realm, nil
-	case admin.SystemRoleNone:
-		return admin.Realm{}, domain.NewAccessDeniedError("anonymous user cannot update realm %s", realm.ID)
-	default:
-		return admin.Realm{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+		return s.deletionRepo.CountRealmContent(ctx, id)
+	}
+
+	deletedAt := time.Now()
+
+	if err := s.repo.DeleteRealm(ctx, id, version, deletedAt); err != nil {
+		return admin.RealmContent{}, err
 	}
+
+	return s.deletionRepo.DeleteRealmContent(ctx, id, deletedAt)
 }
 
-// DeleteRealm implements the service.RealmService interface.
+// RestoreRealm implements the service.RealmService interface.
+// The content deleted with the realm is restored with it.
 //
 //nolint:wrapcheck // see comment in the header
-func (s *RealmService) DeleteRealm(
+func (s *RealmService) RestoreRealm(
 	ctx context.Context,
 	actor admin.Actor,
 	id admin.ID,
-) error {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return domain.NewAccessDeniedError("user %s cannot delete realm %s", actor.UserID, id)
-	case admin.SystemRoleManager:
-		return domain.NewAccessDeniedError("manager %s cannot delete realm %s", actor.UserID, id)
-	case admin.SystemRoleAdmin:
-		return s.repo.DeleteRealm(ctx, id)
-	case admin.SystemRoleNone:
-		return domain.NewAccessDeniedError("anonymous user cannot delete realm %s", id)
-	default:
-		return domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+) (admin.Realm, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRealmsDelete, admin.RealmResource(id)); err != nil {
+		return admin.Realm{}, err
 	}
+
+	deletedAt, err := s.repo.RestoreRealm(ctx, id)
+	if err != nil {
+		return admin.Realm{}, err
+	}
+
+	if _, err := s.deletionRepo.RestoreRealmContent(ctx, id, deletedAt); err != nil {
+		return admin.Realm{}, err
+	}
+
+	return s.repo.GetRealm(ctx, id)
 }
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -40,6 +42,14 @@ func TestRealmService_GetRealms(t *testing.T) {
 			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
 			wantError: domain.StoreError{},
 		},
+		"systemAuditor": {
+			actor:      admin.Actor{Role: admin.SystemRoleSystemAuditor, RealmID: "other"},
+			wantResult: true,
+		},
+		"realmAuditor": {
+			actor:      admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: realmID},
+			wantResult: true,
+		},
 		"none": {
 			actor:     admin.Actor{Role: admin.SystemRoleNone},
 			wantError: domain.AccessDeniedError{},
@@ -51,7 +61,7 @@ func TestRealmService_GetRealms(t *testing.T) {
 	}
 
 	repo := newMockRealmRepository()
-	svc := NewRealmService(repo, nil)
+	svc := NewRealmService(repo, newMockDeletionRepository(), newTestAuthorizer(), nil)
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -61,10 +71,10 @@ func TestRealmService_GetRealms(t *testing.T) {
 		}
 
 		t.Run(name, func(t *testing.T) {
-			res, err := svc.GetRealms(context.Background(), test.actor)
+			res, err := svc.GetRealms(context.Background(), test.actor, admin.ListOptions{})
 
 			if test.wantResult {
-				require.Len(t, res, 1)
+				require.Len(t, res.Items, 1)
 			}
 
 			if test.wantError != nil {
@@ -121,7 +131,7 @@ func TestRealmService_GetRealm(t *testing.T) {
 	}
 
 	repo := newMockRealmRepository()
-	svc := NewRealmService(repo, nil)
+	svc := NewRealmService(repo, newMockDeletionRepository(), newTestAuthorizer(), nil)
 
 	id := realmID
 
@@ -185,7 +195,7 @@ func TestRealmService_CreateRealm(t *testing.T) {
 	}
 
 	repo := newMockRealmRepository()
-	svc := NewRealmService(repo, newMockIDGenerator())
+	svc := NewRealmService(repo, newMockDeletionRepository(), newTestAuthorizer(), newMockIDGenerator())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -203,6 +213,7 @@ func TestRealmService_CreateRealm(t *testing.T) {
 			if test.wantResult {
 				require.NotEmpty(t, res)
 				require.NotEmpty(t, res.ID)
+				require.Equal(t, admin.FirstVersion, res.Version)
 			}
 
 			if test.wantError != nil {
@@ -214,6 +225,89 @@ func TestRealmService_CreateRealm(t *testing.T) {
 	}
 }
 
+func TestRealmService_CreateRealm_invalidRedirectURIs(t *testing.T) {
+	t.Parallel()
+
+	svc := NewRealmService(newMockRealmRepository(), newMockDeletionRepository(), newTestAuthorizer(), newMockIDGenerator())
+	actor := admin.Actor{Role: admin.SystemRoleAdmin}
+
+	for _, uri := range []string{
+		"",
+		"/relative",
+		"https://user@app.somedomain.com/",
+		"https://app.*.somedomain.com/",
+		"https://app.somedomain.com/*/console",
+	} {
+		t.Run(uri, func(t *testing.T) {
+			_, err := svc.CreateRealm(context.Background(), actor, admin.Realm{
+				Code:         "code",
+				Name:         "name",
+				RedirectURIs: []string{uri},
+			})
+
+			require.ErrorAs(t, err, &domain.ValidationError{})
+		})
+	}
+}
+
+func TestRealmService_CreateRealm_invalidRoles(t *testing.T) {
+	t.Parallel()
+
+	svc := NewRealmService(newMockRealmRepository(), newMockDeletionRepository(), newTestAuthorizer(), newMockIDGenerator())
+	actor := admin.Actor{Role: admin.SystemRoleAdmin}
+
+	tests := map[string][]admin.Role{
+		"invalidName":         {{Name: "Auditor"}},
+		"systemRoleName":      {{Name: "manager"}},
+		"duplicateName":       {{Name: "auditor"}, {Name: "auditor"}},
+		"unknownPermission":   {{Name: "auditor", Permissions: []admin.Permission{"users.delete"}}},
+		"nonRealmPermission":  {{Name: "auditor", Permissions: []admin.Permission{admin.PermissionProvidersWrite}}},
+		"daemonRealmsGranted": {{Name: "auditor", Permissions: []admin.Permission{admin.PermissionDaemonsRealmsWrite}}},
+	}
+
+	for name, roles := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.CreateRealm(context.Background(), actor, admin.Realm{
+				Code:  "code",
+				Name:  "name",
+				Roles: roles,
+			})
+
+			require.ErrorAs(t, err, &domain.ValidationError{})
+		})
+	}
+}
+
+func TestRealmService_CreateRealm_invalidUserAttributes(t *testing.T) {
+	t.Parallel()
+
+	svc := NewRealmService(newMockRealmRepository(), newMockDeletionRepository(), newTestAuthorizer(), newMockIDGenerator())
+	actor := admin.Actor{Role: admin.SystemRoleAdmin}
+
+	tests := map[string][]admin.AttributeDefinition{
+		"invalidName":    {{Name: "1st", Type: admin.AttributeTypeString}},
+		"dottedName":     {{Name: "cost.centre", Type: admin.AttributeTypeString}},
+		"duplicateName":  {{Name: "level", Type: admin.AttributeTypeNumber}, {Name: "level", Type: admin.AttributeTypeString}},
+		"missingType":    {{Name: "level"}},
+		"unknownType":    {{Name: "level", Type: "date"}},
+		"enumNoValues":   {{Name: "costCentre", Type: admin.AttributeTypeEnum}},
+		"stringValues":   {{Name: "costCentre", Type: admin.AttributeTypeString, Values: []string{"sales"}}},
+		"emptyEnumValue": {{Name: "costCentre", Type: admin.AttributeTypeEnum, Values: []string{"sales", " "}}},
+	}
+
+	for name, schema := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.CreateRealm(context.Background(), actor, admin.Realm{
+				Code:           "code",
+				Name:           "name",
+				UserAttributes: schema,
+			})
+
+			require.ErrorAs(t, err, &domain.ValidationError{})
+		})
+	}
+}
+
 func TestRealmService_UpdateRealm(t *testing.T) {
 	t.Parallel()
 
@@ -247,6 +341,14 @@ func TestRealmService_UpdateRealm(t *testing.T) {
 			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
 			wantError: domain.StoreError{},
 		},
+		"systemAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleSystemAuditor},
+			wantError: domain.AccessDeniedError{},
+		},
+		"realmAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
 		"none": {
 			actor:     admin.Actor{Role: admin.SystemRoleNone},
 			wantError: domain.AccessDeniedError{},
@@ -258,7 +360,7 @@ func TestRealmService_UpdateRealm(t *testing.T) {
 	}
 
 	repo := newMockRealmRepository()
-	svc := NewRealmService(repo, nil)
+	svc := NewRealmService(repo, newMockDeletionRepository(), newTestAuthorizer(), nil)
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -269,15 +371,17 @@ func TestRealmService_UpdateRealm(t *testing.T) {
 
 		t.Run(name, func(t *testing.T) {
 			realm := admin.Realm{
-				ID:   realmID,
-				Code: "newCode",
-				Name: "newName",
+				ID:      realmID,
+				Code:    "newCode",
+				Name:    "newName",
+				Version: 2,
 			}
 
 			res, err := svc.UpdateRealm(context.Background(), test.actor, realm)
 
 			if test.wantResult {
 				require.NotEmpty(t, res)
+				require.Equal(t, admin.Version(3), res.Version)
 			}
 
 			if test.wantError != nil {
@@ -296,35 +400,54 @@ func TestRealmService_DeleteRealm(t *testing.T) {
 
 	tests := map[string]struct {
 		actor     admin.Actor
+		version   admin.Version
+		dryRun    bool
 		wantError error
 	}{
 		"user": {
 			actor:     admin.Actor{Role: admin.SystemRoleUser},
+			version:   1,
 			wantError: domain.AccessDeniedError{},
 		},
 		"manager": {
 			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			version:   1,
 			wantError: domain.AccessDeniedError{},
 		},
 		"admin": {
-			actor: admin.Actor{Role: admin.SystemRoleAdmin},
+			actor:   admin.Actor{Role: admin.SystemRoleAdmin},
+			version: 1,
+		},
+		"admin-dryRun": {
+			actor:   admin.Actor{Role: admin.SystemRoleAdmin},
+			version: 1,
+			dryRun:  true,
+		},
+		"admin-dryRun-staleVersion": {
+			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
+			version:   2,
+			dryRun:    true,
+			wantError: domain.ConflictError{},
 		},
 		"admin-repoError": {
 			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
+			version:   1,
 			wantError: domain.StoreError{},
 		},
 		"none": {
 			actor:     admin.Actor{Role: admin.SystemRoleNone},
+			version:   1,
 			wantError: domain.AccessDeniedError{},
 		},
 		"unknown": {
 			actor:     admin.Actor{Role: "unknown"},
+			version:   1,
 			wantError: domain.AccessDeniedError{},
 		},
 	}
 
 	repo := newMockRealmRepository()
-	svc := NewRealmService(repo, nil)
+	svc := NewRealmService(repo, newMockDeletionRepository(), newTestAuthorizer(), nil)
 
 	id := realmID
 
@@ -336,19 +459,68 @@ func TestRealmService_DeleteRealm(t *testing.T) {
 		}
 
 		t.Run(name, func(t *testing.T) {
-			err := svc.DeleteRealm(context.Background(), test.actor, id)
+			content, err := svc.DeleteRealm(context.Background(), test.actor, id, test.version, test.dryRun)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, newMockDeletionRepository().mockContent(), content)
+			}
+		})
+	}
+}
+
+func TestRealmService_RestoreRealm(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("1")
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		wantError error
+	}{
+		"manager": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
+		"admin": {
+			actor: admin.Actor{Role: admin.SystemRoleAdmin},
+		},
+		"admin-repoError": {
+			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
+			wantError: domain.StoreError{},
+		},
+	}
+
+	repo := newMockRealmRepository()
+	svc := NewRealmService(repo, newMockDeletionRepository(), newTestAuthorizer(), nil)
+
+	for name, test := range tests {
+		if errors.Is(test.wantError, domain.StoreError{}) {
+			repo.forcedError = errors.New("forcedError")
+		} else {
+			repo.forcedError = nil
+		}
+
+		t.Run(name, func(t *testing.T) {
+			realm, err := svc.RestoreRealm(context.Background(), test.actor, realmID)
 
 			if test.wantError != nil {
 				require.ErrorAs(t, err, &test.wantError)
 			} else {
 				require.NoError(t, err)
+				require.Equal(t, realmID, realm.ID)
 			}
 		})
 	}
 }
 
 type mockRealmRepository struct {
-	forcedError error
+	apiKeyMaxLifetime time.Duration
+	roles             []admin.Role
+	userAttributes    []admin.AttributeDefinition
+	forcedError       error
 }
 
 // ensure mockRealmRepository implements admin.RealmRepository.
@@ -358,8 +530,11 @@ func newMockRealmRepository() *mockRealmRepository {
 	return &mockRealmRepository{}
 }
 
-func (r *mockRealmRepository) GetRealms(_ context.Context) ([]admin.Realm, error) {
-	return []admin.Realm{r.mockRealm()}, r.forcedError
+func (r *mockRealmRepository) GetRealms(
+	_ context.Context,
+	_ admin.ListOptions,
+) (admin.Page[admin.Realm], error) {
+	return admin.Page[admin.Realm]{Items: []admin.Realm{r.mockRealm()}, Total: 1}, r.forcedError
 }
 
 func (r *mockRealmRepository) GetRealm(_ context.Context, id admin.ID) (admin.Realm, error) {
@@ -371,7 +546,7 @@ func (r *mockRealmRepository) GetRealm(_ context.Context, id admin.ID) (admin.Re
 }
 
 func (r *mockRealmRepository) CreateRealm(_ context.Context, realm admin.Realm) error {
-	if (realm == admin.Realm{}) {
+	if (reflect.DeepEqual(realm, admin.Realm{})) {
 		return errors.New("test-precondition: empty realm")
 	}
 
@@ -379,14 +554,22 @@ func (r *mockRealmRepository) CreateRealm(_ context.Context, realm admin.Realm)
 }
 
 func (r *mockRealmRepository) UpdateRealm(_ context.Context, realm admin.Realm) error {
-	if (realm == admin.Realm{}) {
+	if (reflect.DeepEqual(realm, admin.Realm{})) {
 		return errors.New("test-precondition: empty realm")
 	}
 
 	return r.forcedError
 }
 
-func (r *mockRealmRepository) DeleteRealm(_ context.Context, id admin.ID) error {
+func (r *mockRealmRepository) DeleteRealm(_ context.Context, id admin.ID, version admin.Version, deletedAt time.Time) error {
+	if version < admin.FirstVersion {
+		return errors.New("test-precondition: no version")
+	}
+
+	if deletedAt.IsZero() {
+		return errors.New("test-precondition: no deletion time")
+	}
+
 	if id == "" {
 		return errors.New("test-precondition: empty id")
 	}
@@ -394,9 +577,84 @@ func (r *mockRealmRepository) DeleteRealm(_ context.Context, id admin.ID) error
 	return r.forcedError
 }
 
+func (r *mockRealmRepository) RestoreRealm(_ context.Context, id admin.ID) (time.Time, error) {
+	if id == "" {
+		return time.Time{}, errors.New("test-precondition: empty id")
+	}
+
+	return mockDeletedAt(), r.forcedError
+}
+
 func (r *mockRealmRepository) mockRealm() admin.Realm {
 	return admin.Realm{
-		ID:   "1",
-		Name: "mockRealm",
+		ID:                "1",
+		Name:              "mockRealm",
+		Version:           admin.FirstVersion,
+		APIKeyMaxLifetime: r.apiKeyMaxLifetime,
+		Roles:             r.roles,
+		UserAttributes:    r.userAttributes,
+	}
+}
+
+type mockDeletionRepository struct {
+	forcedError error
+}
+
+// ensure mockDeletionRepository implements admin.DeletionRepository.
+var _ admin.DeletionRepository = (*mockDeletionRepository)(nil)
+
+func newMockDeletionRepository() *mockDeletionRepository {
+	return &mockDeletionRepository{}
+}
+
+func (r *mockDeletionRepository) CountRealmContent(_ context.Context, realmID admin.ID) (admin.RealmContent, error) {
+	if realmID == "" {
+		return admin.RealmContent{}, errors.New("test-precondition: empty realmID")
+	}
+
+	return r.mockContent(), r.forcedError
+}
+
+func (r *mockDeletionRepository) DeleteRealmContent(
+	_ context.Context,
+	realmID admin.ID,
+	deletedAt time.Time,
+) (admin.RealmContent, error) {
+	if realmID == "" {
+		return admin.RealmContent{}, errors.New("test-precondition: empty realmID")
 	}
+
+	if deletedAt.IsZero() {
+		return admin.RealmContent{}, errors.New("test-precondition: no deletion time")
+	}
+
+	return r.mockContent(), r.forcedError
+}
+
+func (r *mockDeletionRepository) RestoreRealmContent(
+	_ context.Context,
+	realmID admin.ID,
+	deletedAt time.Time,
+) (admin.RealmContent, error) {
+	if realmID == "" {
+		return admin.RealmContent{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if !deletedAt.Equal(mockDeletedAt()) {
+		return admin.RealmContent{}, errors.New("test-precondition: unexpected deletion time")
+	}
+
+	return r.mockContent(), r.forcedError
+}
+
+func (r *mockDeletionRepository) PurgeDeleted(_ context.Context, before time.Time) (int, error) {
+	if before.IsZero() {
+		return 0, errors.New("test-precondition: no purge time")
+	}
+
+	return 3, r.forcedError
+}
+
+func (r *mockDeletionRepository) mockContent() admin.RealmContent {
+	return admin.RealmContent{Users: 2, Daemons: 1, Groups: 1, Memberships: 3, Policies: 1, Rules: 1}
 }
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// checkRoles checks that the custom roles assigned to a user or a group are defined
+// in the realm. The roles already assigned are not checked again, so that removing
+// a role from the realm does not block the updates of its holders.
+//
+//nolint:wrapcheck // the repository returns domain errors
+func checkRoles(
+	ctx context.Context,
+	realmRepo admin.RealmRepository,
+	realmID admin.ID,
+	roles, current []string,
+) error {
+	added := slices.DeleteFunc(slices.Clone(roles), func(name string) bool {
+		return slices.Contains(current, name)
+	})
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	realm, err := realmRepo.GetRealm(ctx, realmID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range added {
+		if _, found := realm.FindRole(name); !found {
+			return domain.NewValidationError("role %s is not defined in realm %s", name, realmID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// RuleService is a service for managing the access rules of the realms.
+//
+// It implements the service.RuleService interface.
+//
+// The expressions are compiled when the rules are saved, so that invalid rules
+// are rejected instead of denying every operation they are bound to.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type RuleService struct {
+	repo       admin.RuleRepository
+	engine     admin.RuleEngine
+	authorizer admin.Authorizer
+	idgen      domain.IDGenerator
+}
+
+// NewRuleService returns a new RuleService instance.
+func NewRuleService(
+	repo admin.RuleRepository,
+	engine admin.RuleEngine,
+	authorizer admin.Authorizer,
+	idgen domain.IDGenerator,
+) *RuleService {
+	return &RuleService{
+		repo:       repo,
+		engine:     engine,
+		authorizer: authorizer,
+		idgen:      idgen,
+	}
+}
+
+// Ensure service implements the service.RuleService interface.
+var _ admin.RuleService = (*RuleService)(nil)
+
+// GetRules implements the service.RuleService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *RuleService) GetRules(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	options admin.ListOptions,
+) (admin.Page[admin.AccessRule], error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRulesRead, admin.RealmResource(realmID)); err != nil {
+		return admin.Page[admin.AccessRule]{}, err
+	}
+
+	options, err := validateListOptions(options, admin.RuleSortFields)
+	if err != nil {
+		return admin.Page[admin.AccessRule]{}, err
+	}
+
+	page, err := s.repo.GetRules(ctx, realmID, options)
+	if err != nil {
+		return admin.Page[admin.AccessRule]{}, err
+	}
+
+	return page, nil
+}
+
+// GetRule implements the service.RuleService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *RuleService) GetRule(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.AccessRule, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRulesRead, admin.RealmResource(realmID)); err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	rule, err := s.repo.GetRule(ctx, realmID, id)
+	if err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	return rule, nil
+}
+
+// CreateRule implements the service.RuleService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *RuleService) CreateRule(
+	ctx context.Context,
+	actor admin.Actor,
+	rule admin.AccessRule,
+) (admin.AccessRule, error) {
+	rule, err := validateRule(rule)
+	if err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRulesWrite, admin.RealmResource(rule.RealmID)); err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	if err := s.engine.Compile(rule.Expression); err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	rule.ID = admin.ID(s.idgen.GenerateID())
+	rule.Version = admin.FirstVersion
+
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	return rule, nil
+}
+
+// UpdateRule implements the service.RuleService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *RuleService) UpdateRule(
+	ctx context.Context,
+	actor admin.Actor,
+	rule admin.AccessRule,
+) (admin.AccessRule, error) {
+	rule, err := validateRule(rule)
+	if err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRulesWrite, admin.RealmResource(rule.RealmID)); err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	if err := s.engine.Compile(rule.Expression); err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	rule.Version = rule.Version.Next()
+
+	return rule, nil
+}
+
+// DeleteRule implements the service.RuleService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *RuleService) DeleteRule(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+	version admin.Version,
+) error {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRulesWrite, admin.RealmResource(realmID)); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteRule(ctx, realmID, id, version, time.Now()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestoreRule implements the service.RuleService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *RuleService) RestoreRule(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.AccessRule, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRulesWrite, admin.RealmResource(realmID)); err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	if _, err := s.repo.RestoreRule(ctx, realmID, id); err != nil {
+		return admin.AccessRule{}, err
+	}
+
+	return s.repo.GetRule(ctx, realmID, id)
+}
+
+// DryRunRule implements the service.RuleService interface.
+// Expressions that cannot be compiled or evaluated are reported as validation errors.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *RuleService) DryRunRule(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	expression string,
+	input admin.RuleInput,
+) (bool, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionRulesWrite, admin.RealmResource(realmID)); err != nil {
+		return false, err
+	}
+
+	if err := s.engine.Compile(expression); err != nil {
+		return false, err
+	}
+
+	allowed, err := s.engine.Evaluate(expression, input)
+	if err != nil {
+		return false, domain.NewValidationError("failed to evaluate expression: %v", err)
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleService_GetRules(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+
+	tests := map[string]struct {
+		actor      admin.Actor
+		wantResult bool
+		wantError  error
+	}{
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager": {
+			actor:      admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			wantResult: true,
+		},
+		"manager-wrongRealmID": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "wrongRealmID"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-repoError": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			wantError: domain.StoreError{},
+		},
+		"admin": {
+			actor:      admin.Actor{Role: admin.SystemRoleAdmin},
+			wantResult: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockRuleRepository()
+			repo.rules = []admin.AccessRule{repo.mockRule()}
+
+			if errors.Is(test.wantError, domain.StoreError{}) {
+				repo.forcedError = domain.NewStoreError("forcedError")
+			}
+
+			svc := NewRuleService(repo, newMockRuleEngine(), newTestAuthorizer(), nil)
+
+			res, err := svc.GetRules(context.Background(), test.actor, realmID, admin.ListOptions{})
+
+			if test.wantResult {
+				require.Len(t, res.Items, 1)
+			}
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRuleService_CreateRule(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID}
+	rule := admin.AccessRule{
+		RealmID:     realmID,
+		Code:        "same-department",
+		Enabled:     true,
+		Permissions: []admin.Permission{admin.PermissionUsersWrite},
+		Expression:  "true",
+	}
+
+	withRule := func(modify func(rule *admin.AccessRule)) admin.AccessRule {
+		r := rule
+		modify(&r)
+
+		return r
+	}
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		rule      admin.AccessRule
+		wantError error
+	}{
+		"manager": {
+			actor: manager,
+			rule:  rule,
+		},
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			rule:      rule,
+			wantError: domain.AccessDeniedError{},
+		},
+		"noPermissions": {
+			actor:     manager,
+			rule:      withRule(func(r *admin.AccessRule) { r.Permissions = nil }),
+			wantError: domain.ValidationError{},
+		},
+		"readPermission": {
+			actor:     manager,
+			rule:      withRule(func(r *admin.AccessRule) { r.Permissions = []admin.Permission{admin.PermissionUsersRead} }),
+			wantError: domain.ValidationError{},
+		},
+		"noExpression": {
+			actor:     manager,
+			rule:      withRule(func(r *admin.AccessRule) { r.Expression = " " }),
+			wantError: domain.ValidationError{},
+		},
+		"invalidExpression": {
+			actor:     manager,
+			rule:      withRule(func(r *admin.AccessRule) { r.Expression = "invalid" }),
+			wantError: domain.ValidationError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := NewRuleService(newMockRuleRepository(), newMockRuleEngine(), newTestAuthorizer(), newMockIDGenerator())
+
+			created, err := svc.CreateRule(context.Background(), test.actor, test.rule)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, admin.ID("1"), created.ID)
+			}
+		})
+	}
+}
+
+func TestRuleService_DryRunRule(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID}
+
+	tests := map[string]struct {
+		actor       admin.Actor
+		expression  string
+		wantAllowed bool
+		wantError   error
+	}{
+		"allow": {
+			actor:       manager,
+			expression:  "true",
+			wantAllowed: true,
+		},
+		"deny": {
+			actor:      manager,
+			expression: "false",
+		},
+		"invalidExpression": {
+			actor:      manager,
+			expression: "invalid",
+			wantError:  domain.ValidationError{},
+		},
+		"evaluationError": {
+			actor:      manager,
+			expression: "error",
+			wantError:  domain.ValidationError{},
+		},
+		"user": {
+			actor:      admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			expression: "true",
+			wantError:  domain.AccessDeniedError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := NewRuleService(newMockRuleRepository(), newMockRuleEngine(), newTestAuthorizer(), nil)
+
+			allowed, err := svc.DryRunRule(context.Background(), test.actor, realmID, test.expression, admin.RuleInput{})
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.wantAllowed, allowed)
+			}
+		})
+	}
+}
+
+type mockRuleRepository struct {
+	rules       []admin.AccessRule
+	forcedError error
+}
+
+// ensure mockRuleRepository implements admin.RuleRepository.
+var _ admin.RuleRepository = (*mockRuleRepository)(nil)
+
+func newMockRuleRepository() *mockRuleRepository {
+	return &mockRuleRepository{}
+}
+
+func (r *mockRuleRepository) GetRules(
+	_ context.Context,
+	realmID admin.ID,
+	_ admin.ListOptions,
+) (admin.Page[admin.AccessRule], error) {
+	if realmID == "" {
+		return admin.Page[admin.AccessRule]{}, errors.New("test-precondition: empty realmID")
+	}
+
+	return admin.Page[admin.AccessRule]{Items: r.rules, Total: len(r.rules)}, r.forcedError
+}
+
+func (r *mockRuleRepository) GetRule(_ context.Context, realmID, id admin.ID) (admin.AccessRule, error) {
+	if realmID == "" {
+		return admin.AccessRule{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return admin.AccessRule{}, errors.New("test-precondition: empty id")
+	}
+
+	return r.mockRule(), r.forcedError
+}
+
+func (r *mockRuleRepository) CreateRule(_ context.Context, rule admin.AccessRule) error {
+	if (reflect.DeepEqual(rule, admin.AccessRule{})) {
+		return errors.New("test-precondition: empty rule")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockRuleRepository) UpdateRule(_ context.Context, rule admin.AccessRule) error {
+	if (reflect.DeepEqual(rule, admin.AccessRule{})) {
+		return errors.New("test-precondition: empty rule")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockRuleRepository) DeleteRule(_ context.Context, realmID, id admin.ID, version admin.Version, deletedAt time.Time) error {
+	if version < admin.FirstVersion {
+		return errors.New("test-precondition: no version")
+	}
+
+	if deletedAt.IsZero() {
+		return errors.New("test-precondition: no deletion time")
+	}
+
+	if realmID == "" {
+		return errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return errors.New("test-precondition: empty id")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockRuleRepository) RestoreRule(_ context.Context, realmID, id admin.ID) (time.Time, error) {
+	if realmID == "" {
+		return time.Time{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return time.Time{}, errors.New("test-precondition: empty id")
+	}
+
+	return mockDeletedAt(), r.forcedError
+}
+
+func (r *mockRuleRepository) mockRule() admin.AccessRule {
+	return admin.AccessRule{
+		ID:          "r1",
+		RealmID:     "a1",
+		Code:        "mockRule",
+		Enabled:     true,
+		Permissions: []admin.Permission{admin.PermissionUsersWrite},
+		Expression:  "true",
+	}
+}
+
+// mockRuleEngine evaluates the expressions "true" and "false" to their value.
+// The expression "error" fails to evaluate, any other expression fails to compile.
+type mockRuleEngine struct {
+	lastInput admin.RuleInput
+}
+
+// ensure mockRuleEngine implements admin.RuleEngine.
+var _ admin.RuleEngine = (*mockRuleEngine)(nil)
+
+func newMockRuleEngine() *mockRuleEngine {
+	return &mockRuleEngine{}
+}
+
+func (e *mockRuleEngine) Compile(expression string) error {
+	switch expression {
+	case "true", "false", "error":
+		return nil
+	default:
+		return domain.NewValidationError("invalid expression: %s", expression)
+	}
+}
+
+func (e *mockRuleEngine) Evaluate(expression string, input admin.RuleInput) (bool, error) {
+	e.lastInput = input
+
+	switch expression {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, errors.New("evaluation failed")
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// Limits of the search hits.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchService is a service for searching the users and the daemons.
+//
+// It implements the service.SearchService interface.
+//
+// The users are only searched if the actor can read them, and the daemons if
+// the actor can read them, so that a delegated manager only finds what it manages.
+// Searching all the realms requires the read permissions on the whole system.
+//
+// We do not wrap the errors returned by the repository because they are already
+// packed as domain errors. Therefore, we disable the wrapcheck linter for these calls.
+type SearchService struct {
+	userRepo   admin.UserRepository
+	daemonRepo admin.DaemonRepository
+	authorizer admin.Authorizer
+}
+
+// NewSearchService returns a new SearchService instance.
+func NewSearchService(
+	userRepo admin.UserRepository,
+	daemonRepo admin.DaemonRepository,
+	authorizer admin.Authorizer,
+) *SearchService {
+	return &SearchService{
+		userRepo:   userRepo,
+		daemonRepo: daemonRepo,
+		authorizer: authorizer,
+	}
+}
+
+// Ensure service implements the service.SearchService interface.
+var _ admin.SearchService = (*SearchService)(nil)
+
+// Search implements the service.SearchService interface.
+// A zero limit returns defaultSearchLimit hits.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *SearchService) Search(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	text string,
+	limit int,
+) ([]admin.SearchHit, error) {
+	text = strings.TrimSpace(text)
+
+	if err := checkEmpty("search text", text); err != nil {
+		return nil, err
+	}
+
+	if limit < 0 || limit > maxSearchLimit {
+		return nil, domain.NewValidationError("limit must be between 1 and %d", maxSearchLimit)
+	}
+
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+
+	resource := admin.RealmResource(realmID)
+
+	if realmID == "" {
+		resource = admin.SystemResource()
+	}
+
+	searchUsers, err := s.permits(ctx, actor, admin.PermissionUsersRead, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	searchDaemons, err := s.permits(ctx, actor, admin.PermissionDaemonsRead, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if !searchUsers && !searchDaemons {
+		return nil, domain.NewAccessDeniedError("actor %s is not allowed to search", actor)
+	}
+
+	hits := make([]admin.SearchHit, 0)
+
+	if searchUsers {
+		users, err := s.userRepo.SearchUsers(ctx, realmID, text, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		hits = append(hits, users...)
+	}
+
+	if searchDaemons {
+		daemons, err := s.daemonRepo.SearchDaemons(ctx, realmID, text, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		hits = append(hits, daemons...)
+	}
+
+	slices.SortStableFunc(hits, func(a, b admin.SearchHit) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, nil
+}
+
+// permits returns true if the actor has the permission on the resource.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *SearchService) permits(
+	ctx context.Context,
+	actor admin.Actor,
+	permission admin.Permission,
+	resource admin.Resource,
+) (bool, error) {
+	err := s.authorizer.Authorize(ctx, actor, permission, resource)
+	if err == nil {
+		return true, nil
+	}
+
+	if domain.IsAccessDeniedError(err) {
+		return false, nil
+	}
+
+	return false, err
+}
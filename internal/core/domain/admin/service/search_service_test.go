@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchService_Search(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		realmID   admin.ID
+		text      string
+		limit     int
+		wantIDs   []admin.ID
+		wantError error
+	}{
+		"manager": {
+			actor:   admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			realmID: realmID,
+			text:    "mock",
+			wantIDs: []admin.ID{"d1", "u1"},
+		},
+		"manager-limit": {
+			actor:   admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			realmID: realmID,
+			text:    "mock",
+			limit:   1,
+			wantIDs: []admin.ID{"d1"},
+		},
+		"manager-wrongRealmID": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "wrongRealmID"},
+			realmID:   realmID,
+			text:      "mock",
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-allRealms": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			text:      "mock",
+			wantError: domain.AccessDeniedError{},
+		},
+		"userManager": {
+			actor:   admin.Actor{Role: admin.SystemRoleUserManager, RealmID: realmID},
+			realmID: realmID,
+			text:    "mock",
+			wantIDs: []admin.ID{"u1"},
+		},
+		"daemonManager": {
+			actor:   admin.Actor{Role: admin.SystemRoleDaemonManager, RealmID: realmID},
+			realmID: realmID,
+			text:    "mock",
+			wantIDs: []admin.ID{"d1"},
+		},
+		"systemAuditor-allRealms": {
+			actor:   admin.Actor{Role: admin.SystemRoleSystemAuditor},
+			text:    "mock",
+			wantIDs: []admin.ID{"d1", "u1"},
+		},
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: "u1"},
+			realmID:   realmID,
+			text:      "mock",
+			wantError: domain.AccessDeniedError{},
+		},
+		"emptyText": {
+			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
+			realmID:   realmID,
+			text:      "  ",
+			wantError: domain.ValidationError{},
+		},
+		"limitTooLarge": {
+			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
+			realmID:   realmID,
+			text:      "mock",
+			limit:     maxSearchLimit + 1,
+			wantError: domain.ValidationError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := NewSearchService(newMockUserRepository(), newMockDaemonRepository(), newTestAuthorizer())
+
+			hits, err := svc.Search(context.Background(), test.actor, test.realmID, test.text, test.limit)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			ids := make([]admin.ID, len(hits))
+
+			for i, hit := range hits {
+				ids[i] = hit.ID
+			}
+
+			require.Equal(t, test.wantIDs, ids)
+		})
+	}
+}
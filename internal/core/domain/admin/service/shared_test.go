@@ -1,6 +1,11 @@
 package service
 
-import "github.com/energimind/identity-server/internal/core/domain"
+import (
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
 
 type mockIDGenerator struct{}
 
@@ -14,3 +19,65 @@ var _ domain.IDGenerator = (*mockIDGenerator)(nil)
 func (m mockIDGenerator) GenerateID() string {
 	return "1"
 }
+
+type mockKeyGenerator struct{}
+
+func newMockKeyGenerator() *mockKeyGenerator {
+	return &mockKeyGenerator{}
+}
+
+// ensure mockKeyGenerator implements domain.IDGenerator.
+var _ domain.IDGenerator = (*mockKeyGenerator)(nil)
+
+func (m mockKeyGenerator) GenerateID() string {
+	return "0123456789abcdefABCDEF0123456789"
+}
+
+type mockKeyHasher struct{}
+
+func newMockKeyHasher() *mockKeyHasher {
+	return &mockKeyHasher{}
+}
+
+// ensure mockKeyHasher implements domain.KeyHasher.
+var _ domain.KeyHasher = (*mockKeyHasher)(nil)
+
+func (m mockKeyHasher) HashKey(key string) string {
+	return "hash:" + key
+}
+
+type mockAPIKeyUsageRecorder struct {
+	usages []admin.APIKeyUsage
+}
+
+func newMockAPIKeyUsageRecorder() *mockAPIKeyUsageRecorder {
+	return &mockAPIKeyUsageRecorder{}
+}
+
+// ensure mockAPIKeyUsageRecorder implements admin.APIKeyUsageRecorder.
+var _ admin.APIKeyUsageRecorder = (*mockAPIKeyUsageRecorder)(nil)
+
+func (m *mockAPIKeyUsageRecorder) RecordAPIKeyUsage(usage admin.APIKeyUsage) {
+	m.usages = append(m.usages, usage)
+}
+
+func ownAPIKeys(realmID, ownerID admin.ID, apiKeys []admin.APIKey) []admin.OwnedAPIKey {
+	owned := make([]admin.OwnedAPIKey, len(apiKeys))
+
+	for i, apiKey := range apiKeys {
+		owned[i] = admin.OwnedAPIKey{RealmID: realmID, OwnerID: ownerID, APIKey: apiKey}
+	}
+
+	return owned
+}
+
+// newTestAuthorizer returns an authorizer for actors without custom roles.
+func newTestAuthorizer() *Authorizer {
+	return NewAuthorizer(newMockUserRepository(), newMockRealmRepository(), newMockGroupRepository(),
+		newMockRuleRepository(), newMockRuleEngine())
+}
+
+// mockDeletedAt returns the time the entities of the mock repositories were deleted at.
+func mockDeletedAt() time.Time {
+	return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+}
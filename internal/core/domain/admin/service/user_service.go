@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -22,18 +25,36 @@ var adminActor = admin.Actor{Role: admin.SystemRoleAdmin}
 // Some methods are reported as to complex by the linter. We disable the linter for
 // these methods, because they are not too complex, but just have a lot of error handling.
 type UserService struct {
-	repo  admin.UserRepository
-	idgen domain.IDGenerator
+	repo           admin.UserRepository
+	realmRepo      admin.RealmRepository
+	groupRepo      admin.GroupRepository
+	membershipRepo admin.MembershipRepository
+	authorizer     admin.Authorizer
+	idgen          domain.IDGenerator
+	keygen         domain.IDGenerator
+	hasher         domain.KeyHasher
 }
 
 // NewUserService returns a new UserService instance.
 func NewUserService(
 	repo admin.UserRepository,
+	realmRepo admin.RealmRepository,
+	groupRepo admin.GroupRepository,
+	membershipRepo admin.MembershipRepository,
+	authorizer admin.Authorizer,
 	idgen domain.IDGenerator,
+	keygen domain.IDGenerator,
+	hasher domain.KeyHasher,
 ) *UserService {
 	return &UserService{
-		repo:  repo,
-		idgen: idgen,
+		repo:           repo,
+		realmRepo:      realmRepo,
+		groupRepo:      groupRepo,
+		membershipRepo: membershipRepo,
+		authorizer:     authorizer,
+		idgen:          idgen,
+		keygen:         keygen,
+		hasher:         hasher,
 	}
 }
 
@@ -43,90 +64,55 @@ var _ admin.UserService = (*UserService)(nil)
 // Ensure service implements the admin.UserFinder interface.
 var _ admin.UserFinder = (*UserService)(nil)
 
+// Ensure service implements the admin.UserImpersonator interface.
+var _ admin.UserImpersonator = (*UserService)(nil)
+
 // GetUsers implements the service.UserService interface.
+// The attribute values of the filter given as strings are parsed according to
+// the attribute schema of the realm.
 //
 //nolint:wrapcheck // see comment in the header
 func (s *UserService) GetUsers(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID admin.ID,
-) ([]admin.User, error) {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return nil, domain.NewAccessDeniedError("user %s cannot get users", actor.UserID)
-	case admin.SystemRoleManager:
-		if actor.RealmID != realmID {
-			return nil, domain.NewAccessDeniedError("manager %s cannot get users for realm %s", actor.UserID, realmID)
-		}
+	filter admin.UserFilter,
+	options admin.ListOptions,
+) (admin.Page[admin.User], error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersRead, admin.RealmResource(realmID)); err != nil {
+		return admin.Page[admin.User]{}, err
+	}
 
-		users, err := s.repo.GetUsers(ctx, realmID)
-		if err != nil {
-			return nil, err
-		}
+	options, err := validateListOptions(options, admin.UserSortFields)
+	if err != nil {
+		return admin.Page[admin.User]{}, err
+	}
 
-		return users, nil
-	case admin.SystemRoleAdmin:
-		users, err := s.repo.GetUsers(ctx, realmID)
-		if err != nil {
-			return nil, err
-		}
+	filter, err = validateUserFilter(filter)
+	if err != nil {
+		return admin.Page[admin.User]{}, err
+	}
 
-		return users, nil
-	case admin.SystemRoleNone:
-		return nil, domain.NewAccessDeniedError("anonymous user cannot get users")
-	default:
-		return nil, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	filter, err = s.resolveFilter(ctx, realmID, filter)
+	if err != nil {
+		return admin.Page[admin.User]{}, err
+	}
+
+	page, err := s.repo.GetUsers(ctx, realmID, filter, options)
+	if err != nil {
+		return admin.Page[admin.User]{}, err
 	}
+
+	return page, nil
 }
 
 // GetUser implements the service.UserService interface.
-//
-//nolint:wrapcheck,cyclop // see comment in the header
 func (s *UserService) GetUser(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, id admin.ID,
 ) (admin.User, error) {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		if actor.RealmID != realmID {
-			return admin.User{}, domain.NewAccessDeniedError("user %s cannot get user %s", actor.UserID, id)
-		}
-
-		// user can only get itself
-		if actor.UserID != id {
-			return admin.User{}, domain.NewAccessDeniedError("user %s cannot get user %s", actor.UserID, id)
-		}
-
-		user, err := s.repo.GetUser(ctx, realmID, id)
-		if err != nil {
-			return admin.User{}, err
-		}
-
-		return user, nil
-	case admin.SystemRoleManager:
-		if actor.RealmID != realmID {
-			return admin.User{}, domain.NewAccessDeniedError("manager %s cannot get user %s", actor.UserID, id)
-		}
-
-		user, err := s.repo.GetUser(ctx, realmID, id)
-		if err != nil {
-			return admin.User{}, err
-		}
-
-		return user, nil
-	case admin.SystemRoleAdmin:
-		user, err := s.repo.GetUser(ctx, realmID, id)
-		if err != nil {
-			return admin.User{}, err
-		}
-
-		return user, nil
-	case admin.SystemRoleNone:
-		return admin.User{}, domain.NewAccessDeniedError("anonymous user cannot get user %s", id)
-	default:
-		return admin.User{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
-	}
+	return s.getUser(ctx, actor, admin.PermissionUsersRead, realmID, id)
 }
 
 // CreateUser implements the service.UserService interface.
@@ -137,127 +123,122 @@ func (s *UserService) CreateUser(
 	actor admin.Actor,
 	user admin.User,
 ) (admin.User, error) {
-	user, err := validateUser(user)
+	user, err := s.prepareCreate(ctx, actor, user)
 	if err != nil {
 		return admin.User{}, err
 	}
 
-	create := func() (admin.User, error) {
-		if err := s.checkUserExists(ctx, user.RealmID, user.BindID); err != nil {
-			return admin.User{}, err
-		}
-
-		user.ID = admin.ID(s.idgen.GenerateID())
-
-		if err := s.repo.CreateUser(ctx, user); err != nil {
-			return admin.User{}, err
-		}
-
-		return user, nil
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return admin.User{}, err
 	}
 
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.User{}, domain.NewAccessDeniedError("user %s cannot create user", actor.UserID)
-	case admin.SystemRoleManager:
-		if actor.RealmID != user.RealmID {
-			return admin.User{}, domain.NewAccessDeniedError("manager %s cannot create user", actor.UserID)
-		}
-
-		return create()
-	case admin.SystemRoleAdmin:
-		return create()
-	case admin.SystemRoleNone:
-		return admin.User{}, domain.NewAccessDeniedError("anonymous user cannot create user")
-	default:
-		return admin.User{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
-	}
+	return user, nil
 }
 
 // UpdateUser implements the service.UserService interface.
+// Changing the roles of the user requires the permission to assign roles.
 //
-//nolint:wrapcheck,cyclop // see comment in the header
+//nolint:wrapcheck // see comment in the header
 func (s *UserService) UpdateUser(
 	ctx context.Context,
 	actor admin.Actor,
 	user admin.User,
 ) (admin.User, error) {
-	user, err := validateUser(user)
+	user, err := s.prepareUpdate(ctx, actor, user)
 	if err != nil {
 		return admin.User{}, err
 	}
 
-	update := func() (admin.User, error) {
-		if err := s.checkAnotherUserExists(ctx, user.RealmID, user.BindID, user.ID); err != nil {
-			return admin.User{}, err
-		}
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return admin.User{}, err
+	}
 
-		if err := s.repo.UpdateUser(ctx, user); err != nil {
-			return admin.User{}, err
-		}
+	user.Version = user.Version.Next()
+
+	return user, nil
+}
 
-		return user, nil
+// DeleteUser implements the service.UserService interface.
+// The user is also removed from the groups it is a member of.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) DeleteUser(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+	version admin.Version,
+) error {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersWrite, admin.RealmResource(realmID)); err != nil {
+		return err
 	}
 
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		if actor.RealmID != user.RealmID {
-			return admin.User{}, domain.NewAccessDeniedError("user %s cannot update user %s", actor.UserID, user.ID)
-		}
+	stored, err := s.repo.GetUser(ctx, realmID, id)
+	if err != nil {
+		return err
+	}
 
-		// user can only update itself
-		if actor.UserID != user.ID {
-			return admin.User{}, domain.NewAccessDeniedError("user %s cannot update user %s", actor.UserID, user.ID)
-		}
+	if err := s.authorizeCurrentRole(ctx, actor, stored); err != nil {
+		return err
+	}
 
-		return update()
-	case admin.SystemRoleManager:
-		if actor.RealmID != user.RealmID {
-			return admin.User{}, domain.NewAccessDeniedError("manager %s cannot update user %s", actor.UserID, user.ID)
-		}
+	if err := s.enforce(ctx, actor, admin.RuleOperationDelete, stored); err != nil {
+		return err
+	}
 
-		return update()
-	case admin.SystemRoleAdmin:
-		return update()
-	case admin.SystemRoleNone:
-		return admin.User{}, domain.NewAccessDeniedError("anonymous user cannot update user %s", user.ID)
-	default:
-		return admin.User{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	deletedAt := time.Now()
+
+	if err := s.repo.DeleteUser(ctx, realmID, id, version, deletedAt); err != nil {
+		return err
+	}
+
+	if err := s.groupRepo.RemoveMember(ctx, realmID, id); err != nil {
+		return err
+	}
+
+	if err := s.membershipRepo.DeleteUserMemberships(ctx, id, deletedAt); err != nil {
+		return err
 	}
+
+	return nil
 }
 
-// DeleteUser implements the service.UserService interface.
+// RestoreUser implements the service.UserService interface.
+// The access rules are checked as for the creation of the user. The memberships
+// deleted with the user are restored with it, but not its membership of groups.
 //
 //nolint:wrapcheck // see comment in the header
-func (s *UserService) DeleteUser(
+func (s *UserService) RestoreUser(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, id admin.ID,
-) error {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return domain.NewAccessDeniedError("user %s cannot delete user %s", actor.UserID, id)
-	case admin.SystemRoleManager:
-		if actor.RealmID != realmID {
-			return domain.NewAccessDeniedError("manager %s cannot delete user %s", actor.UserID, id)
-		}
+) (admin.User, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersWrite, admin.RealmResource(realmID)); err != nil {
+		return admin.User{}, err
+	}
 
-		if err := s.repo.DeleteUser(ctx, realmID, id); err != nil {
-			return err
-		}
+	deleted, err := s.repo.GetDeletedUser(ctx, realmID, id)
+	if err != nil {
+		return admin.User{}, err
+	}
 
-		return nil
-	case admin.SystemRoleAdmin:
-		if err := s.repo.DeleteUser(ctx, realmID, id); err != nil {
-			return err
-		}
+	if err := s.enforce(ctx, actor, admin.RuleOperationCreate, deleted); err != nil {
+		return admin.User{}, err
+	}
 
-		return nil
-	case admin.SystemRoleNone:
-		return domain.NewAccessDeniedError("anonymous user cannot delete user %s", id)
-	default:
-		return domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	if err := s.checkUserExists(ctx, realmID, deleted.BindID); err != nil {
+		return admin.User{}, err
 	}
+
+	deletedAt, err := s.repo.RestoreUser(ctx, realmID, id)
+	if err != nil {
+		return admin.User{}, err
+	}
+
+	if err := s.membershipRepo.RestoreUserMemberships(ctx, id, deletedAt); err != nil {
+		return admin.User{}, err
+	}
+
+	return s.repo.GetUser(ctx, realmID, id)
 }
 
 // GetAPIKeys implements the service.UserService interface.
@@ -265,13 +246,14 @@ func (s *UserService) GetAPIKeys(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, userID admin.ID,
+	filter admin.APIKeyFilter,
 ) ([]admin.APIKey, error) {
-	user, err := s.GetUser(ctx, actor, realmID, userID)
+	user, err := s.getUser(ctx, actor, admin.PermissionUsersKeysRead, realmID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return user.APIKeys, nil
+	return filter.Filter(user.APIKeys), nil
 }
 
 // GetAPIKey implements the service.UserService interface.
@@ -280,7 +262,7 @@ func (s *UserService) GetAPIKey(
 	actor admin.Actor,
 	realmID, userID, id admin.ID,
 ) (admin.APIKey, error) {
-	user, err := s.GetUser(ctx, actor, realmID, userID)
+	user, err := s.getUser(ctx, actor, admin.PermissionUsersKeysRead, realmID, userID)
 	if err != nil {
 		return admin.APIKey{}, err
 	}
@@ -303,25 +285,31 @@ func (s *UserService) CreateAPIKey(
 	realmID, userID admin.ID,
 	apiKey admin.APIKey,
 ) (admin.APIKey, error) {
-	apiKey, err := validateAPIKey(apiKey)
+	user, err := s.getUser(ctx, actor, admin.PermissionUsersKeysWrite, realmID, userID)
 	if err != nil {
 		return admin.APIKey{}, err
 	}
 
-	user, err := s.GetUser(ctx, actor, realmID, userID)
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
 	if err != nil {
 		return admin.APIKey{}, err
 	}
 
-	apiKey.ID = admin.ID(s.idgen.GenerateID())
+	apiKey, err = issueAPIKey(apiKey, realm, s.idgen, s.keygen, time.Now())
+	if err != nil {
+		return admin.APIKey{}, err
+	}
 
-	user.APIKeys = append(user.APIKeys, apiKey)
+	sealed := sealAPIKey(apiKey, s.hasher)
+
+	user.APIKeys = append(user.APIKeys, sealed)
 
 	if uErr := s.repo.UpdateUser(ctx, user); uErr != nil {
 		return admin.APIKey{}, uErr
 	}
 
-	return apiKey, nil
+	// the plain text key is returned only once, in response to the creation
+	return revealAPIKey(sealed, apiKey.Key), nil
 }
 
 // UpdateAPIKey implements the service.UserService interface.
@@ -331,20 +319,42 @@ func (s *UserService) UpdateAPIKey(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, userID, id admin.ID,
+	version admin.Version,
 	apiKey admin.APIKey,
 ) (admin.APIKey, error) {
-	apiKey, err := validateAPIKey(apiKey)
+	apiKey, err := validateAPIKeyUpdate(apiKey)
+	if err != nil {
+		return admin.APIKey{}, err
+	}
+
+	user, err := s.getUser(ctx, actor, admin.PermissionUsersKeysWrite, realmID, userID)
 	if err != nil {
 		return admin.APIKey{}, err
 	}
 
-	user, err := s.GetUser(ctx, actor, realmID, userID)
+	user.Version = version
+
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
 	if err != nil {
 		return admin.APIKey{}, err
 	}
 
 	for i, ak := range user.APIKeys {
 		if ak.ID == id {
+			apiKey.ID = ak.ID
+			apiKey.Prefix = ak.Prefix
+			apiKey.Hash = ak.Hash
+			apiKey.CreatedAt = ak.CreatedAt
+			apiKey.LastUsedAt = ak.LastUsedAt
+			apiKey.LastUsedIP = ak.LastUsedIP
+			apiKey.UsageDays = ak.UsageDays
+			apiKey.RetiredAt = ak.RetiredAt
+
+			apiKey, err = applyAPIKeyPolicy(apiKey, realm)
+			if err != nil {
+				return admin.APIKey{}, err
+			}
+
 			user.APIKeys[i] = apiKey
 
 			if uErr := s.repo.UpdateUser(ctx, user); uErr != nil {
@@ -358,6 +368,82 @@ func (s *UserService) UpdateAPIKey(
 	return admin.APIKey{}, domain.NewNotFoundError("API key %s not found", id)
 }
 
+// RotateAPIKey implements the service.UserService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) RotateAPIKey(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, userID, id admin.ID,
+	version admin.Version,
+	successor admin.APIKey,
+	gracePeriod time.Duration,
+) (admin.APIKey, error) {
+	if err := checkGracePeriod(gracePeriod); err != nil {
+		return admin.APIKey{}, err
+	}
+
+	user, err := s.getUser(ctx, actor, admin.PermissionUsersKeysWrite, realmID, userID)
+	if err != nil {
+		return admin.APIKey{}, err
+	}
+
+	user.Version = version
+
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
+	if err != nil {
+		return admin.APIKey{}, err
+	}
+
+	now := time.Now()
+
+	for i, predecessor := range user.APIKeys {
+		if predecessor.ID != id {
+			continue
+		}
+
+		if !predecessor.Enabled || predecessor.IsExpired(now) {
+			return admin.APIKey{}, domain.NewValidationError("API key %s is not active", id)
+		}
+
+		if successor.Name == "" {
+			successor.Name = predecessor.Name
+			successor.Description = predecessor.Description
+		}
+
+		// a successor without scopes would be unrestricted
+		if len(successor.Scopes) == 0 {
+			successor.Scopes = predecessor.Scopes
+		}
+
+		// a successor without CIDR ranges would be usable from everywhere
+		if len(successor.AllowedCIDRs) == 0 {
+			successor.AllowedCIDRs = predecessor.AllowedCIDRs
+		}
+
+		successor.Enabled = true
+
+		successor, err = issueAPIKey(successor, realm, s.idgen, s.keygen, now)
+		if err != nil {
+			return admin.APIKey{}, err
+		}
+
+		sealed := sealAPIKey(successor, s.hasher)
+
+		user.APIKeys[i] = retireAPIKey(predecessor, gracePeriod, now)
+		user.APIKeys = append(user.APIKeys, sealed)
+
+		if uErr := s.repo.UpdateUser(ctx, user); uErr != nil {
+			return admin.APIKey{}, uErr
+		}
+
+		// the plain text key is returned only once, in response to the creation
+		return revealAPIKey(sealed, successor.Key), nil
+	}
+
+	return admin.APIKey{}, domain.NewNotFoundError("API key %s not found", id)
+}
+
 // DeleteAPIKey implements the service.UserService interface.
 //
 //nolint:wrapcheck // see comment in the header
@@ -365,12 +451,15 @@ func (s *UserService) DeleteAPIKey(
 	ctx context.Context,
 	actor admin.Actor,
 	realmID, userID, id admin.ID,
+	version admin.Version,
 ) error {
-	user, err := s.GetUser(ctx, actor, realmID, userID)
+	user, err := s.getUser(ctx, actor, admin.PermissionUsersKeysWrite, realmID, userID)
 	if err != nil {
 		return err
 	}
 
+	user.Version = version
+
 	for i, apiKey := range user.APIKeys {
 		if apiKey.ID == id {
 			user.APIKeys = append(user.APIKeys[:i], user.APIKeys[i+1:]...)
@@ -386,6 +475,150 @@ func (s *UserService) DeleteAPIKey(
 	return domain.NewNotFoundError("API key %s not found", id)
 }
 
+// GetEffectiveRoles implements the service.UserService interface.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) GetEffectiveRoles(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, userID admin.ID,
+) (admin.EffectiveRoles, error) {
+	user, err := s.getUser(ctx, actor, admin.PermissionUsersRead, realmID, userID)
+	if err != nil {
+		return admin.EffectiveRoles{}, err
+	}
+
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
+	if err != nil {
+		return admin.EffectiveRoles{}, err
+	}
+
+	groups, err := s.groupRepo.GetMemberGroups(ctx, realmID, userID)
+	if err != nil {
+		return admin.EffectiveRoles{}, err
+	}
+
+	return admin.ResolveRoles(realm, user, groups), nil
+}
+
+// ImportUsers implements the service.UserService interface.
+// Each row is created or updated like a single user, so that the same permissions,
+// validation and access rules apply. A row failing does not stop the import. An
+// updated user only changes the fields given by its row. The API keys of the rows
+// are ignored, as they are managed by the API key methods.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) ImportUsers(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	rows []admin.UserImportRow,
+	dryRun bool,
+) (admin.UserImportReport, error) {
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersWrite, admin.RealmResource(realmID)); err != nil {
+		return admin.UserImportReport{}, err
+	}
+
+	if len(rows) > admin.MaxImportRows {
+		return admin.UserImportReport{}, domain.NewValidationError("import exceeds %d rows", admin.MaxImportRows)
+	}
+
+	report := admin.UserImportReport{DryRun: dryRun, Results: make([]admin.UserImportResult, 0, len(rows))}
+
+	// lines of the bind IDs imported so far
+	lines := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		report.Add(s.importUser(ctx, actor, realmID, row, dryRun, lines))
+	}
+
+	return report, nil
+}
+
+// ExportUsers implements the service.UserService interface.
+// The users are read page by page, like the listing, so that they can be streamed.
+// Their API keys are exported without secrets, and only if the actor can read them.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) ExportUsers(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	filter admin.UserFilter,
+	export func(admin.User) error,
+) error {
+	withKeys := true
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersKeysRead, admin.RealmResource(realmID)); err != nil {
+		if !domain.IsAccessDeniedError(err) {
+			return err
+		}
+
+		withKeys = false
+	}
+
+	options := admin.ListOptions{Limit: maxListLimit}
+
+	for {
+		page, err := s.GetUsers(ctx, actor, realmID, filter, options)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range page.Items {
+			user.APIKeys = exportAPIKeys(user.APIKeys, withKeys)
+
+			if err := export(user); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+
+		options.Cursor = page.NextCursor
+	}
+}
+
+// ImpersonateUser implements the admin.UserImpersonator interface.
+// Admins cannot be impersonated, and an impersonated actor cannot impersonate
+// another user.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) ImpersonateUser(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID, id admin.ID,
+) (admin.User, error) {
+	if actor.IsImpersonated() {
+		return admin.User{}, domain.NewAccessDeniedError("actor %s is already impersonating a user", actor)
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersImpersonate, admin.RealmResource(realmID)); err != nil {
+		return admin.User{}, err
+	}
+
+	user, err := s.repo.GetUser(ctx, realmID, id)
+	if err != nil {
+		return admin.User{}, err
+	}
+
+	if user.ID == actor.UserID && user.RealmID == actor.RealmID {
+		return admin.User{}, domain.NewValidationError("actor %s cannot impersonate itself", actor)
+	}
+
+	if user.Role == admin.SystemRoleAdmin {
+		return admin.User{}, domain.NewAccessDeniedError("admin %s cannot be impersonated", user.ID)
+	}
+
+	if !user.Enabled {
+		return admin.User{}, domain.NewValidationError("user %s is disabled", user.ID)
+	}
+
+	return user, nil
+}
+
 // GetUserByBindID implements the admin.UserFinder interface.
 //
 //nolint:wrapcheck // see comment in the header
@@ -395,32 +628,367 @@ func (s *UserService) GetUserByBindID(
 	realmID admin.ID,
 	bindID string,
 ) (admin.User, error) {
-	switch actor.Role {
-	case admin.SystemRoleUser:
-		return admin.User{}, domain.NewAccessDeniedError("user %s cannot get user by bindID", actor.UserID)
-	case admin.SystemRoleManager:
-		if actor.RealmID != realmID {
-			return admin.User{}, domain.NewAccessDeniedError("manager %s cannot get user by bindID", actor.UserID)
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersRead, admin.RealmResource(realmID)); err != nil {
+		return admin.User{}, err
+	}
+
+	user, err := s.repo.GetUserByBindID(ctx, realmID, bindID)
+	if err != nil {
+		return admin.User{}, err
+	}
+
+	return user, nil
+}
+
+// getUser authorizes the permission on the user and returns the user.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) getUser(
+	ctx context.Context,
+	actor admin.Actor,
+	permission admin.Permission,
+	realmID, id admin.ID,
+) (admin.User, error) {
+	if err := s.authorizer.Authorize(ctx, actor, permission, admin.OwnedResource(realmID, id)); err != nil {
+		return admin.User{}, err
+	}
+
+	user, err := s.repo.GetUser(ctx, realmID, id)
+	if err != nil {
+		return admin.User{}, err
+	}
+
+	return user, nil
+}
+
+// prepareCreate validates and authorizes the creation of the user, and returns the
+// user to store.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) prepareCreate(
+	ctx context.Context,
+	actor admin.Actor,
+	user admin.User,
+) (admin.User, error) {
+	user, err := s.validateUser(ctx, user)
+	if err != nil {
+		return admin.User{}, err
+	}
+
+	if user.Role == admin.SystemRoleNone {
+		return admin.User{}, domain.NewValidationError("role must not be empty")
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersWrite, admin.RealmResource(user.RealmID)); err != nil {
+		return admin.User{}, err
+	}
+
+	if err := s.authorizeRoles(ctx, actor, user, admin.User{}); err != nil {
+		return admin.User{}, err
+	}
+
+	if err := checkRoles(ctx, s.realmRepo, user.RealmID, user.Roles, nil); err != nil {
+		return admin.User{}, err
+	}
+
+	if len(user.APIKeys) > 0 && actor.IsDeniedByImpersonation(admin.PermissionUsersKeysWrite) {
+		return admin.User{}, domain.NewAccessDeniedError("impersonated actor %s cannot create API keys", actor)
+	}
+
+	user.APIKeys, err = s.sealNewAPIKeys(ctx, user.RealmID, user.APIKeys)
+	if err != nil {
+		return admin.User{}, err
+	}
+
+	if err := s.checkUserExists(ctx, user.RealmID, user.BindID); err != nil {
+		return admin.User{}, err
+	}
+
+	user.ID = admin.ID(s.idgen.GenerateID())
+	user.Version = admin.FirstVersion
+	user.CreatedAt = time.Now()
+
+	if err := s.enforce(ctx, actor, admin.RuleOperationCreate, user); err != nil {
+		return admin.User{}, err
+	}
+
+	return user, nil
+}
+
+// prepareUpdate validates and authorizes the update of the user, and returns the
+// user to store.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) prepareUpdate(
+	ctx context.Context,
+	actor admin.Actor,
+	user admin.User,
+) (admin.User, error) {
+	user, err := s.validateUser(ctx, user)
+	if err != nil {
+		return admin.User{}, err
+	}
+
+	stored, err := s.getUser(ctx, actor, admin.PermissionUsersWrite, user.RealmID, user.ID)
+	if err != nil {
+		return admin.User{}, err
+	}
+
+	if err := s.authorizeCurrentRole(ctx, actor, stored); err != nil {
+		return admin.User{}, err
+	}
+
+	if err := s.authorizeRoles(ctx, actor, user, stored); err != nil {
+		return admin.User{}, err
+	}
+
+	if err := checkRoles(ctx, s.realmRepo, user.RealmID, user.Roles, stored.Roles); err != nil {
+		return admin.User{}, err
+	}
+
+	if err := s.checkAnotherUserExists(ctx, user.RealmID, user.BindID, user.ID); err != nil {
+		return admin.User{}, err
+	}
+
+	// API keys are managed by the API key methods only
+	user.APIKeys = stored.APIKeys
+	user.CreatedAt = stored.CreatedAt
+
+	if err := s.enforceUpdate(ctx, actor, stored, user); err != nil {
+		return admin.User{}, err
+	}
+
+	return user, nil
+}
+
+// importUser creates or updates the user of the row, and returns the outcome.
+// The user with the bind ID of the row is updated if it exists, and created if not.
+// A bind ID imported on an earlier line fails the row, as the import would otherwise
+// depend on the order of the rows.
+func (s *UserService) importUser(
+	ctx context.Context,
+	actor admin.Actor,
+	realmID admin.ID,
+	row admin.UserImportRow,
+	dryRun bool,
+	lines map[string]int,
+) admin.UserImportResult {
+	user := row.User
+	user.BindID = strings.TrimSpace(user.BindID)
+	user.RealmID = realmID
+	user.APIKeys = nil
+
+	result := admin.UserImportResult{Line: row.Line, BindID: user.BindID, Action: admin.ImportActionFail}
+
+	if row.Err != nil {
+		result.Err = row.Err
+
+		return result
+	}
+
+	if line, found := lines[user.BindID]; found && user.BindID != "" {
+		result.Err = domain.NewConflictError("bindID %s is already imported on line %d", user.BindID, line)
+
+		return result
+	}
+
+	lines[user.BindID] = row.Line
+
+	stored, err := s.repo.GetUserByBindID(ctx, realmID, user.BindID)
+
+	switch {
+	case err == nil:
+		user = row.MergeInto(stored)
+
+		result.ID, result.Err = s.importUpdate(ctx, actor, user, dryRun)
+		result.Action = admin.ImportActionUpdate
+	case domain.IsNotFoundError(err):
+		result.ID, result.Err = s.importCreate(ctx, actor, user, dryRun)
+		result.Action = admin.ImportActionCreate
+	default:
+		result.Err = err
+	}
+
+	if result.Err != nil {
+		result.Action = admin.ImportActionFail
+	}
+
+	return result
+}
+
+// importCreate creates the imported user unless it is a dry run, and returns its ID.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) importCreate(ctx context.Context, actor admin.Actor, user admin.User, dryRun bool) (admin.ID, error) {
+	user, err := s.prepareCreate(ctx, actor, user)
+	if err != nil {
+		return "", err
+	}
+
+	if dryRun {
+		return "", nil
+	}
+
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return "", err
+	}
+
+	return user.ID, nil
+}
+
+// importUpdate updates the imported user unless it is a dry run, and returns its ID.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) importUpdate(ctx context.Context, actor admin.Actor, user admin.User, dryRun bool) (admin.ID, error) {
+	user, err := s.prepareUpdate(ctx, actor, user)
+	if err != nil {
+		return "", err
+	}
+
+	if !dryRun {
+		if err := s.repo.UpdateUser(ctx, user); err != nil {
+			return "", err
 		}
+	}
 
-		user, err := s.repo.GetUserByBindID(ctx, realmID, bindID)
-		if err != nil {
-			return admin.User{}, err
+	return user.ID, nil
+}
+
+// authorizeRoles checks that the actor can assign the roles of the user, if they
+// differ from the current ones. The system-wide roles are not confined to the realm
+// of the user, so granting them requires the permission on the whole system. An
+// actor can only grant a system role whose permissions it holds itself.
+func (s *UserService) authorizeRoles(
+	ctx context.Context,
+	actor admin.Actor,
+	user, current admin.User,
+) error {
+	if user.Role == current.Role && slices.Equal(user.Roles, current.Roles) {
+		return nil
+	}
+
+	resource := admin.RealmResource(user.RealmID)
+
+	if user.Role.IsSystemWide() && user.Role != current.Role {
+		resource = admin.SystemResource()
+	}
+
+	if err := s.authorizer.Authorize(ctx, actor, admin.PermissionUsersRolesWrite, resource); err != nil {
+		return err
+	}
+
+	if user.Role == current.Role {
+		return nil
+	}
+
+	// the actor cannot grant permissions it does not hold itself
+	for _, permission := range user.Role.Permissions() {
+		if err := s.authorizer.Authorize(ctx, actor, permission, resource); err != nil {
+			return err
 		}
+	}
 
-		return user, nil
-	case admin.SystemRoleAdmin:
-		user, err := s.repo.GetUserByBindID(ctx, realmID, bindID)
-		if err != nil {
-			return admin.User{}, err
+	return nil
+}
+
+// authorizeCurrentRole checks that the actor holds every permission of the current
+// system role of the user, so that an actor cannot update or delete a user more
+// privileged than itself. The permissions of a system-wide role are checked on the
+// whole system.
+//
+// The permissions an impersonated actor holds are those of the user it acts as,
+// including the ones denied during the impersonation.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) authorizeCurrentRole(ctx context.Context, actor admin.Actor, user admin.User) error {
+	resource := admin.OwnedResource(user.RealmID, user.ID)
+
+	if user.Role.IsSystemWide() {
+		resource = admin.SystemResource()
+	}
+
+	holder := actor
+	holder.ImpersonatorID = ""
+	holder.ImpersonatorRealmID = ""
+
+	for _, permission := range user.Role.Permissions() {
+		if err := s.authorizer.Authorize(ctx, holder, permission, resource); err != nil {
+			return domain.NewAccessDeniedError("actor %s cannot manage user %s with role %s",
+				actor, user.ID, user.Role)
 		}
+	}
 
-		return user, nil
-	case admin.SystemRoleNone:
-		return admin.User{}, domain.NewAccessDeniedError("anonymous user cannot get user by bindID")
-	default:
-		return admin.User{}, domain.NewAccessDeniedError("unknown actor role %s", actor.Role)
+	return nil
+}
+
+// enforce checks the access rules of the realm of the user.
+func (s *UserService) enforce(
+	ctx context.Context,
+	actor admin.Actor,
+	operation admin.RuleOperation,
+	user admin.User,
+) error {
+	return s.authorizer.Enforce(ctx, actor, admin.PermissionUsersWrite, operation, admin.UserTarget(user))
+}
+
+// enforceUpdate checks the access rules for the update of the stored user. The rules
+// must allow the update of both the stored and the updated user, so that a user cannot
+// be moved out of the reach of the rules.
+func (s *UserService) enforceUpdate(ctx context.Context, actor admin.Actor, stored, user admin.User) error {
+	if err := s.enforce(ctx, actor, admin.RuleOperationUpdate, stored); err != nil {
+		return err
+	}
+
+	return s.enforce(ctx, actor, admin.RuleOperationUpdate, user)
+}
+
+// validateUser validates the user against the attribute schema of its realm.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) validateUser(ctx context.Context, user admin.User) (admin.User, error) {
+	realm, err := s.realmRepo.GetRealm(ctx, user.RealmID)
+	if err != nil {
+		return admin.User{}, err
+	}
+
+	return validateUser(user, realm.UserAttributes)
+}
+
+// resolveFilter checks the attributes of the filter against the attribute schema
+// of the realm and converts the values given as strings to their attribute type.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) resolveFilter(ctx context.Context, realmID admin.ID, filter admin.UserFilter) (admin.UserFilter, error) {
+	if len(filter.Attributes) == 0 {
+		return filter, nil
+	}
+
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
+	if err != nil {
+		return admin.UserFilter{}, err
 	}
+
+	attributes := make(map[string]any, len(filter.Attributes))
+
+	for name, value := range filter.Attributes {
+		definition, found := admin.FindAttribute(realm.UserAttributes, name)
+		if !found {
+			return admin.UserFilter{}, domain.NewValidationError("unknown attribute: %s", name)
+		}
+
+		if str, ok := value.(string); ok {
+			value, err = definition.ParseValue(str)
+			if err != nil {
+				return admin.UserFilter{}, err
+			}
+		}
+
+		attributes[name] = value
+	}
+
+	filter.Attributes = attributes
+
+	return filter, nil
 }
 
 // checkUserExists checks if a user with the given bindID already exists.
@@ -472,6 +1040,15 @@ func (s *UserService) CreateUserSys(
 	return s.CreateUser(ctx, adminActor, user)
 }
 
+// GetUserSys gets a user in the system.
+// This method is not exposed in the API. It does not include acting user checks.
+func (s *UserService) GetUserSys(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (admin.User, error) {
+	return s.GetUser(ctx, adminActor, realmID, id)
+}
+
 // GetUserByBindIDSys gets a user by bind ID in the system.
 // This method is not exposed in the API. It does not include acting user checks.
 func (s *UserService) GetUserByBindIDSys(
@@ -481,3 +1058,23 @@ func (s *UserService) GetUserByBindIDSys(
 ) (admin.User, error) {
 	return s.GetUserByBindID(ctx, adminActor, realmID, bindID)
 }
+
+// sealNewAPIKeys issues and seals the API keys supplied with a new user.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *UserService) sealNewAPIKeys(
+	ctx context.Context,
+	realmID admin.ID,
+	apiKeys []admin.APIKey,
+) ([]admin.APIKey, error) {
+	if len(apiKeys) == 0 {
+		return nil, nil
+	}
+
+	realm, err := s.realmRepo.GetRealm(ctx, realmID)
+	if err != nil {
+		return nil, err
+	}
+
+	return sealNewAPIKeys(apiKeys, realm, s.idgen, s.keygen, s.hasher, time.Now())
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -45,6 +47,22 @@ func TestUserService_GetUsers(t *testing.T) {
 			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
 			wantError: domain.StoreError{},
 		},
+		"systemAuditor": {
+			actor:      admin.Actor{Role: admin.SystemRoleSystemAuditor, RealmID: "wrongRealmID"},
+			wantResult: true,
+		},
+		"realmAuditor": {
+			actor:      admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: realmID},
+			wantResult: true,
+		},
+		"userManager": {
+			actor:      admin.Actor{Role: admin.SystemRoleUserManager, RealmID: realmID},
+			wantResult: true,
+		},
+		"daemonManager": {
+			actor:     admin.Actor{Role: admin.SystemRoleDaemonManager, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
 		"none": {
 			actor:     admin.Actor{Role: admin.SystemRoleNone},
 			wantError: domain.AccessDeniedError{},
@@ -56,7 +74,7 @@ func TestUserService_GetUsers(t *testing.T) {
 	}
 
 	repo := newMockUserRepository()
-	svc := NewUserService(repo, nil)
+	svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -66,10 +84,10 @@ func TestUserService_GetUsers(t *testing.T) {
 		}
 
 		t.Run(name, func(t *testing.T) {
-			res, err := svc.GetUsers(context.Background(), test.actor, realmID)
+			res, err := svc.GetUsers(context.Background(), test.actor, realmID, admin.UserFilter{}, admin.ListOptions{})
 
 			if test.wantResult {
-				require.Len(t, res, 1)
+				require.Len(t, res.Items, 1)
 			}
 
 			if test.wantError != nil {
@@ -139,7 +157,7 @@ func TestUserService_GetUser(t *testing.T) {
 	}
 
 	repo := newMockUserRepository()
-	svc := NewUserService(repo, nil)
+	svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -204,6 +222,22 @@ func TestUserService_CreateUser(t *testing.T) {
 			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
 			wantError: domain.StoreError{},
 		},
+		"systemAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleSystemAuditor},
+			wantError: domain.AccessDeniedError{},
+		},
+		"realmAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
+		"userManager": {
+			actor:      admin.Actor{Role: admin.SystemRoleUserManager, RealmID: realmID},
+			wantResult: true,
+		},
+		"daemonManager": {
+			actor:     admin.Actor{Role: admin.SystemRoleDaemonManager, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
 		"none": {
 			actor:     admin.Actor{Role: admin.SystemRoleNone},
 			wantError: domain.AccessDeniedError{},
@@ -215,7 +249,7 @@ func TestUserService_CreateUser(t *testing.T) {
 	}
 
 	repo := newMockUserRepository()
-	svc := NewUserService(repo, newMockIDGenerator())
+	svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(), newTestAuthorizer(), newMockIDGenerator(), newMockKeyGenerator(), newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -232,6 +266,7 @@ func TestUserService_CreateUser(t *testing.T) {
 				BindID:   "bindID",
 				Username: "testUser",
 				Email:    "email@domain.com",
+				Role:     admin.SystemRoleUser,
 			}
 
 			res, err := svc.CreateUser(context.Background(), test.actor, user)
@@ -308,7 +343,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 	}
 
 	repo := newMockUserRepository()
-	svc := NewUserService(repo, nil)
+	svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -387,7 +422,7 @@ func TestUserService_DeleteUser(t *testing.T) {
 	}
 
 	repo := newMockUserRepository()
-	svc := NewUserService(repo, nil)
+	svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
 
 	for name, test := range tests {
 		if errors.Is(test.wantError, domain.StoreError{}) {
@@ -397,7 +432,7 @@ func TestUserService_DeleteUser(t *testing.T) {
 		}
 
 		t.Run(name, func(t *testing.T) {
-			err := svc.DeleteUser(context.Background(), test.actor, realmID, userID)
+			err := svc.DeleteUser(context.Background(), test.actor, realmID, userID, 1)
 
 			if test.wantError != nil {
 				require.ErrorAs(t, err, &test.wantError)
@@ -412,98 +447,1400 @@ func TestUserService_DeleteUser(t *testing.T) {
 	}
 }
 
-type mockUserRepository struct {
-	userExists  bool
-	forcedError error
-}
-
-// ensure mockUserRepository implements admin.UserRepository.
-var _ admin.UserRepository = (*mockUserRepository)(nil)
+func TestUserService_RestoreUser(t *testing.T) {
+	t.Parallel()
 
-func newMockUserRepository() *mockUserRepository {
-	return &mockUserRepository{}
-}
+	userID := admin.ID("u1")
+	realmID := admin.ID("a1")
 
-func (r *mockUserRepository) GetUsers(_ context.Context, realmID admin.ID) ([]admin.User, error) {
-	if realmID == "" {
-		return nil, errors.New("test-precondition: empty realmID")
+	tests := map[string]struct {
+		actor      admin.Actor
+		userExists bool
+		wantError  error
+	}{
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID, UserID: userID},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager": {
+			actor: admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+		},
+		"manager-wrongRealmID": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "wrongRealmID"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"admin": {
+			actor: admin.Actor{Role: admin.SystemRoleAdmin},
+		},
+		"admin-bindIDTaken": {
+			actor:      admin.Actor{Role: admin.SystemRoleAdmin},
+			userExists: true,
+			wantError:  domain.ConflictError{},
+		},
+		"admin-repoError": {
+			actor:     admin.Actor{Role: admin.SystemRoleAdmin},
+			wantError: domain.StoreError{},
+		},
 	}
 
-	return []admin.User{r.mockUser()}, r.forcedError
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockUserRepository()
+			repo.userExists = test.userExists
+
+			if errors.Is(test.wantError, domain.StoreError{}) {
+				repo.forcedError = errors.New("forcedError")
+			}
+
+			svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+				newTestAuthorizer(), nil, nil, newMockKeyHasher())
+
+			user, err := svc.RestoreUser(context.Background(), test.actor, realmID, userID)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, userID, user.ID)
+		})
+	}
 }
 
-func (r *mockUserRepository) GetUser(_ context.Context, realmID, id admin.ID) (admin.User, error) {
-	if realmID == "" {
-		return admin.User{}, errors.New("test-precondition: empty realmID")
+func TestUserService_ImportUsers(t *testing.T) {
+	t.Parallel()
+
+	realmID := admin.ID("a1")
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID}
+	row := admin.UserImportRow{
+		Line:   2,
+		User:   admin.User{BindID: "b1", Username: "user1", Email: "user1@domain.com", Role: admin.SystemRoleUser},
+		Fields: []admin.UserField{admin.UserFieldUsername, admin.UserFieldEmail, admin.UserFieldRole},
 	}
 
-	if id == "" {
-		return admin.User{}, errors.New("test-precondition: empty id")
+	tests := map[string]struct {
+		actor       admin.Actor
+		rows        []admin.UserImportRow
+		userExists  bool
+		dryRun      bool
+		forcedError bool
+		wantActions []admin.ImportAction
+		wantID      admin.ID
+		wantError   error
+	}{
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID},
+			rows:      []admin.UserImportRow{row},
+			wantError: domain.AccessDeniedError{},
+		},
+		"realmAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: realmID},
+			rows:      []admin.UserImportRow{row},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-wrongRealmID": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "wrongRealmID"},
+			rows:      []admin.UserImportRow{row},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-create": {
+			actor:       manager,
+			rows:        []admin.UserImportRow{row},
+			wantActions: []admin.ImportAction{admin.ImportActionCreate},
+			wantID:      "1",
+		},
+		"manager-update": {
+			actor:       manager,
+			rows:        []admin.UserImportRow{row},
+			userExists:  true,
+			wantActions: []admin.ImportAction{admin.ImportActionUpdate},
+			wantID:      "u1",
+		},
+		"manager-dryRunCreate": {
+			actor:       manager,
+			rows:        []admin.UserImportRow{row},
+			dryRun:      true,
+			wantActions: []admin.ImportAction{admin.ImportActionCreate},
+		},
+		"manager-dryRunUpdate": {
+			actor:       manager,
+			rows:        []admin.UserImportRow{row},
+			userExists:  true,
+			dryRun:      true,
+			wantActions: []admin.ImportAction{admin.ImportActionUpdate},
+			wantID:      "u1",
+		},
+		"manager-rowErrors": {
+			actor: manager,
+			rows: []admin.UserImportRow{
+				{Line: 1, Err: domain.NewValidationError("invalid JSON")},
+				{Line: 2, User: admin.User{BindID: "b1", Email: "user1@domain.com", Role: admin.SystemRoleUser}},
+				{Line: 3, User: admin.User{BindID: "b2", Username: "user2", Email: "user2@domain.com", Role: admin.SystemRoleUser}},
+				{Line: 4, User: admin.User{BindID: " b2 ", Username: "user2", Email: "user2@domain.com", Role: admin.SystemRoleUser}},
+				{Line: 5, User: admin.User{BindID: "b3", Username: "user3", Email: "user3@domain.com"}},
+				{Line: 6, User: admin.User{BindID: "b4", Username: "user4", Email: "user4@domain.com", Role: "superuser"}},
+			},
+			wantActions: []admin.ImportAction{
+				admin.ImportActionFail, admin.ImportActionFail, admin.ImportActionCreate, admin.ImportActionFail,
+				admin.ImportActionFail, admin.ImportActionFail,
+			},
+		},
+		"manager-repoError": {
+			actor:       manager,
+			rows:        []admin.UserImportRow{row},
+			forcedError: true,
+			wantActions: []admin.ImportAction{admin.ImportActionFail},
+		},
+		"manager-tooManyRows": {
+			actor:     manager,
+			rows:      make([]admin.UserImportRow, admin.MaxImportRows+1),
+			wantError: domain.ValidationError{},
+		},
+		"userManager": {
+			actor:       admin.Actor{Role: admin.SystemRoleUserManager, RealmID: realmID},
+			rows:        []admin.UserImportRow{row},
+			wantActions: []admin.ImportAction{admin.ImportActionCreate},
+			wantID:      "1",
+		},
+		"admin": {
+			actor:       admin.Actor{Role: admin.SystemRoleAdmin},
+			rows:        []admin.UserImportRow{row},
+			wantActions: []admin.ImportAction{admin.ImportActionCreate},
+			wantID:      "1",
+		},
 	}
 
-	return r.mockUser(), r.forcedError
-}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-func (r *mockUserRepository) CreateUser(_ context.Context, user admin.User) error {
-	if (reflect.DeepEqual(user, admin.User{})) {
-		return errors.New("test-precondition: empty user")
-	}
+			repo := newMockUserRepository()
+			repo.userExists = test.userExists
 
-	return r.forcedError
-}
+			if test.forcedError {
+				repo.forcedError = errors.New("forcedError")
+			}
 
-func (r *mockUserRepository) UpdateUser(_ context.Context, user admin.User) error {
-	if (reflect.DeepEqual(user, admin.User{})) {
-		return errors.New("test-precondition: empty user")
-	}
+			svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+				newTestAuthorizer(), newMockIDGenerator(), newMockKeyGenerator(), newMockKeyHasher())
 
-	return r.forcedError
-}
+			report, err := svc.ImportUsers(context.Background(), test.actor, realmID, test.rows, test.dryRun)
 
-func (r *mockUserRepository) DeleteUser(_ context.Context, realmID, id admin.ID) error {
-	if realmID == "" {
-		return errors.New("test-precondition: empty realmID")
-	}
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
 
-	if id == "" {
-		return errors.New("test-precondition: empty id")
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.dryRun, report.DryRun)
+			require.Len(t, report.Results, len(test.wantActions))
+
+			counts := map[admin.ImportAction]int{}
+
+			for i, result := range report.Results {
+				require.Equal(t, test.rows[i].Line, result.Line)
+				require.Equal(t, test.wantActions[i], result.Action)
+				require.Equal(t, result.Action == admin.ImportActionFail, result.Err != nil)
+
+				counts[result.Action]++
+			}
+
+			require.Equal(t, counts[admin.ImportActionCreate], report.Created)
+			require.Equal(t, counts[admin.ImportActionUpdate], report.Updated)
+			require.Equal(t, counts[admin.ImportActionFail], report.Failed)
+			require.Equal(t, test.wantID, report.Results[0].ID)
+
+			if test.dryRun || !test.userExists {
+				require.Empty(t, repo.updatedUser.ID)
+			} else {
+				require.Equal(t, admin.ID("u1"), repo.updatedUser.ID)
+			}
+		})
 	}
+}
 
-	return r.forcedError
+func TestUserService_ImportUsers_merge(t *testing.T) {
+	t.Parallel()
+
+	repo := newMockUserRepository()
+	repo.userExists = true
+	repo.role = admin.SystemRoleUser
+	repo.roles = []string{"editor"}
+	svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+		newTestAuthorizer(), newMockIDGenerator(), newMockKeyGenerator(), newMockKeyHasher())
+
+	rows := []admin.UserImportRow{{
+		Line:   2,
+		User:   admin.User{BindID: "b1", DisplayName: "User One", Enabled: false},
+		Fields: []admin.UserField{admin.UserFieldDisplayName, admin.UserFieldEnabled},
+	}}
+
+	report, err := svc.ImportUsers(context.Background(), admin.Actor{Role: admin.SystemRoleAdmin}, "a1", rows, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Updated, report.Results)
+
+	// the fields missing from the row keep their stored values
+	updated := repo.updatedUser
+
+	require.Equal(t, admin.ID("u1"), updated.ID)
+	require.Equal(t, "b1", updated.BindID)
+	require.Equal(t, "mockUser", updated.Username)
+	require.Equal(t, "mockUser@domain.com", updated.Email)
+	require.Equal(t, admin.SystemRoleUser, updated.Role)
+	require.Equal(t, []string{"editor"}, updated.Roles)
+	require.Equal(t, "User One", updated.DisplayName)
+	require.False(t, updated.Enabled)
 }
 
-func (r *mockUserRepository) GetUserByBindID(_ context.Context, realmID admin.ID, bindID string) (admin.User, error) {
-	if realmID == "" {
-		return admin.User{}, errors.New("test-precondition: empty realmID")
-	}
+func TestUserService_ExportUsers(t *testing.T) {
+	t.Parallel()
 
-	if bindID == "" {
-		return admin.User{}, errors.New("test-precondition: empty bindID")
+	realmID := admin.ID("a1")
+
+	tests := map[string]struct {
+		actor       admin.Actor
+		forcedError bool
+		exportError error
+		wantError   error
+	}{
+		"user": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager": {
+			actor: admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+		},
+		"manager-wrongRealmID": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "wrongRealmID"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-repoError": {
+			actor:       admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			forcedError: true,
+			wantError:   domain.StoreError{},
+		},
+		"manager-exportError": {
+			actor:       admin.Actor{Role: admin.SystemRoleManager, RealmID: realmID},
+			exportError: domain.NewBadRequestError("exportError"),
+			wantError:   domain.BadRequestError{},
+		},
+		"realmAuditor": {
+			actor: admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: realmID},
+		},
+		"daemonManager": {
+			actor:     admin.Actor{Role: admin.SystemRoleDaemonManager, RealmID: realmID},
+			wantError: domain.AccessDeniedError{},
+		},
 	}
 
-	if !r.userExists {
-		return admin.User{}, domain.NewNotFoundError("user not found")
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockUserRepository()
+			repo.apiKeys = []admin.APIKey{{ID: "k1", Name: "key1", Prefix: "pre", Hash: "hash"}}
+
+			if test.forcedError {
+				repo.forcedError = errors.New("forcedError")
+			}
+
+			svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+				newTestAuthorizer(), nil, nil, newMockKeyHasher())
+
+			var users []admin.User
+
+			err := svc.ExportUsers(context.Background(), test.actor, realmID, admin.UserFilter{}, func(user admin.User) error {
+				users = append(users, user)
+
+				return test.exportError
+			})
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, users, 1)
+			require.Equal(t, maxListLimit, repo.options.Limit)
+			require.Equal(t, []admin.APIKey{{ID: "k1", Name: "key1", Prefix: "pre"}}, users[0].APIKeys)
+		})
 	}
+}
 
-	return r.mockUser(), r.forcedError
+func TestUserService_CreateAPIKey(t *testing.T) {
+	t.Parallel()
+
+	repo := newMockUserRepository()
+	realmRepo := newMockRealmRepository()
+	svc := NewUserService(repo, realmRepo, newMockGroupRepository(), newMockMembershipRepository(), newTestAuthorizer(), newMockIDGenerator(), newMockKeyGenerator(), newMockKeyHasher())
+	actor := admin.Actor{Role: admin.SystemRoleAdmin}
+	minted := admin.FormatAPIKey("1", "0123456789abcdefABCDEF0123456789")
+
+	t.Run("sealed", func(t *testing.T) {
+		apiKey := admin.APIKey{Name: "key", Enabled: true}
+
+		res, err := svc.CreateAPIKey(context.Background(), actor, "a1", "u1", apiKey)
+		require.NoError(t, err)
+
+		// the minted plain text key is returned once
+		require.Equal(t, minted, res.Key)
+		require.Equal(t, "01234567", res.Prefix)
+
+		// but never persisted
+		require.Len(t, repo.updatedUser.APIKeys, 1)
+
+		stored := repo.updatedUser.APIKeys[0]
+
+		require.NotZero(t, stored.CreatedAt)
+		require.Zero(t, stored.ExpiresAt)
+
+		stored.CreatedAt = time.Time{}
+
+		require.Equal(t, admin.APIKey{
+			ID:      "1",
+			Name:    "key",
+			Enabled: true,
+			Prefix:  "01234567",
+			Hash:    "hash:" + minted,
+		}, stored)
+	})
+
+	t.Run("suppliedKey", func(t *testing.T) {
+		apiKey := admin.APIKey{Name: "key", Key: admin.FormatAPIKey("1", "fedcba9876543210FEDCBA9876543210")}
+
+		_, err := svc.CreateAPIKey(context.Background(), actor, "a1", "u1", apiKey)
+		require.ErrorAs(t, err, &domain.ValidationError{})
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		apiKey := admin.APIKey{Name: "key", ExpiresAt: time.Now().Add(-time.Hour)}
+
+		_, err := svc.CreateAPIKey(context.Background(), actor, "a1", "u1", apiKey)
+		require.ErrorAs(t, err, &domain.ValidationError{})
+	})
+
+	t.Run("scopes", func(t *testing.T) {
+		apiKey := admin.APIKey{Name: "key", Scopes: []string{admin.ScopeSessionsRead}}
+
+		res, err := svc.CreateAPIKey(context.Background(), actor, "a1", "u1", apiKey)
+		require.NoError(t, err)
+		require.Equal(t, []string{admin.ScopeSessionsRead}, res.Scopes)
+	})
+
+	t.Run("undefinedScope", func(t *testing.T) {
+		apiKey := admin.APIKey{Name: "key", Scopes: []string{"reports:read"}}
+
+		_, err := svc.CreateAPIKey(context.Background(), actor, "a1", "u1", apiKey)
+		require.ErrorAs(t, err, &domain.ValidationError{})
+	})
+
+	t.Run("malformedScope", func(t *testing.T) {
+		apiKey := admin.APIKey{Name: "key", Scopes: []string{"Reports"}}
+
+		_, err := svc.CreateAPIKey(context.Background(), actor, "a1", "u1", apiKey)
+		require.ErrorAs(t, err, &domain.ValidationError{})
+	})
+
+	t.Run("maxLifetime-default", func(t *testing.T) {
+		realmRepo.apiKeyMaxLifetime = 24 * time.Hour
+		defer func() { realmRepo.apiKeyMaxLifetime = 0 }()
+
+		apiKey := admin.APIKey{Name: "key"}
+
+		res, err := svc.CreateAPIKey(context.Background(), actor, "a1", "u1", apiKey)
+		require.NoError(t, err)
+		require.Equal(t, res.CreatedAt.Add(24*time.Hour), res.ExpiresAt)
+	})
+
+	t.Run("maxLifetime-exceeded", func(t *testing.T) {
+		realmRepo.apiKeyMaxLifetime = 24 * time.Hour
+		defer func() { realmRepo.apiKeyMaxLifetime = 0 }()
+
+		apiKey := admin.APIKey{Name: "key", ExpiresAt: time.Now().Add(48 * time.Hour)}
+
+		_, err := svc.CreateAPIKey(context.Background(), actor, "a1", "u1", apiKey)
+		require.ErrorAs(t, err, &domain.ValidationError{})
+	})
 }
 
-func (r *mockUserRepository) GetAPIKey(_ context.Context, realmID admin.ID, key string) (admin.APIKey, error) {
-	if realmID == "" {
-		return admin.APIKey{}, errors.New("test-precondition: empty realmID")
+func TestUserService_RotateAPIKey(t *testing.T) {
+	t.Parallel()
+
+	actor := admin.Actor{Role: admin.SystemRoleAdmin}
+	predecessor := admin.APIKey{
+		ID:      "k1",
+		Name:    "key",
+		Enabled: true,
+		Prefix:  "oldkey01",
+		Hash:    "hash:old",
+		Scopes:  []string{admin.ScopeSessionsRead},
 	}
 
-	if key == "" {
-		return admin.APIKey{}, errors.New("test-precondition: empty key")
+	tests := map[string]struct {
+		predecessor admin.APIKey
+		keyID       admin.ID
+		gracePeriod time.Duration
+		wantError   error
+	}{
+		"rotated": {
+			predecessor: predecessor,
+			keyID:       "k1",
+			gracePeriod: time.Hour,
+		},
+		"notFound": {
+			predecessor: predecessor,
+			keyID:       "k2",
+			wantError:   domain.NotFoundError{},
+		},
+		"disabled": {
+			predecessor: admin.APIKey{ID: "k1", Name: "key", Enabled: false},
+			keyID:       "k1",
+			wantError:   domain.ValidationError{},
+		},
+		"negativeGracePeriod": {
+			predecessor: predecessor,
+			keyID:       "k1",
+			gracePeriod: -time.Hour,
+			wantError:   domain.ValidationError{},
+		},
 	}
 
-	return admin.APIKey{}, r.forcedError
-}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-func (r *mockUserRepository) mockUser() admin.User {
-	return admin.User{
-		ID:       "u1",
-		RealmID:  "a1",
-		Username: "mockUser",
+			repo := &mockUserRepository{apiKeys: []admin.APIKey{test.predecessor}}
+			svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(), newTestAuthorizer(), newMockIDGenerator(), newMockKeyGenerator(), newMockKeyHasher())
+			successor := admin.APIKey{}
+
+			before := time.Now()
+
+			res, err := svc.RotateAPIKey(context.Background(), actor, "a1", "u1", test.keyID, 3, successor, test.gracePeriod)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			minted := admin.FormatAPIKey("1", "0123456789abcdefABCDEF0123456789")
+
+			require.Equal(t, minted, res.Key)
+			require.Equal(t, "key", res.Name)
+			require.Equal(t, []string{admin.ScopeSessionsRead}, res.Scopes)
+			require.True(t, res.Enabled)
+
+			require.Equal(t, admin.Version(3), repo.updatedUser.Version)
+			require.Len(t, repo.updatedUser.APIKeys, 2)
+
+			retired := repo.updatedUser.APIKeys[0]
+
+			require.True(t, retired.Enabled)
+			require.True(t, retired.IsRetired())
+			require.False(t, retired.ExpiresAt.Before(before.Add(test.gracePeriod)))
+			require.False(t, retired.ExpiresAt.After(time.Now().Add(test.gracePeriod)))
+			require.Equal(t, "hash:"+minted, repo.updatedUser.APIKeys[1].Hash)
+		})
 	}
 }
+
+func TestUserService_UpdateUser_roles(t *testing.T) {
+	t.Parallel()
+
+	auditor := admin.Role{Name: "auditor", Permissions: []admin.Permission{admin.PermissionUsersRead}}
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		role      admin.SystemRole
+		roles     []string
+		wantError error
+	}{
+		"user-unchanged": {
+			actor: admin.Actor{Role: admin.SystemRoleUser, RealmID: "a1", UserID: "u1"},
+		},
+		"user-grantsItselfRole": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: "a1", UserID: "u1"},
+			role:      admin.SystemRoleManager,
+			wantError: domain.AccessDeniedError{},
+		},
+		"user-grantsItselfCustomRole": {
+			actor:     admin.Actor{Role: admin.SystemRoleUser, RealmID: "a1", UserID: "u1"},
+			roles:     []string{"auditor"},
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-grantsManager": {
+			actor: admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"},
+			role:  admin.SystemRoleManager,
+		},
+		"manager-grantsCustomRole": {
+			actor: admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"},
+			roles: []string{"auditor"},
+		},
+		"manager-grantsAdmin": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"},
+			role:      admin.SystemRoleAdmin,
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-undefinedRole": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"},
+			roles:     []string{"undefined"},
+			wantError: domain.ValidationError{},
+		},
+		"admin-grantsAdmin": {
+			actor: admin.Actor{Role: admin.SystemRoleAdmin},
+			role:  admin.SystemRoleAdmin,
+		},
+		"manager-grantsSystemAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"},
+			role:      admin.SystemRoleSystemAuditor,
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-grantsRealmAuditor": {
+			actor: admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1"},
+			role:  admin.SystemRoleRealmAuditor,
+		},
+		"userManager-grantsUser": {
+			actor: admin.Actor{Role: admin.SystemRoleUserManager, RealmID: "a1"},
+			role:  admin.SystemRoleUser,
+		},
+		"userManager-grantsUserManager": {
+			actor: admin.Actor{Role: admin.SystemRoleUserManager, RealmID: "a1"},
+			role:  admin.SystemRoleUserManager,
+		},
+		"userManager-grantsManager": {
+			actor:     admin.Actor{Role: admin.SystemRoleUserManager, RealmID: "a1"},
+			role:      admin.SystemRoleManager,
+			wantError: domain.AccessDeniedError{},
+		},
+		"userManager-grantsDaemonManager": {
+			actor:     admin.Actor{Role: admin.SystemRoleUserManager, RealmID: "a1"},
+			role:      admin.SystemRoleDaemonManager,
+			wantError: domain.AccessDeniedError{},
+		},
+		"realmAuditor-grantsRealmAuditor": {
+			actor:     admin.Actor{Role: admin.SystemRoleRealmAuditor, RealmID: "a1"},
+			role:      admin.SystemRoleRealmAuditor,
+			wantError: domain.AccessDeniedError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			realmRepo := newMockRealmRepository()
+			realmRepo.roles = []admin.Role{auditor}
+			svc := NewUserService(newMockUserRepository(), realmRepo, newMockGroupRepository(), newMockMembershipRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
+			user := admin.User{
+				ID:       "u1",
+				RealmID:  "a1",
+				BindID:   "bindID",
+				Username: "username",
+				Email:    "mail@domain.com",
+				Role:     test.role,
+				Roles:    test.roles,
+			}
+
+			_, err := svc.UpdateUser(context.Background(), test.actor, user)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserService_UpdateUser_accessRules(t *testing.T) {
+	t.Parallel()
+
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1", UserID: "u9"}
+
+	ruleRepo := newMockRuleRepository()
+	ruleRepo.rules = []admin.AccessRule{{
+		RealmID:     "a1",
+		Code:        "deny-all",
+		Enabled:     true,
+		Permissions: []admin.Permission{admin.PermissionUsersWrite},
+		Expression:  "false",
+	}}
+	userRepo := newMockUserRepository()
+	authorizer := NewAuthorizer(userRepo, newMockRealmRepository(), newMockGroupRepository(), ruleRepo, newMockRuleEngine())
+	svc := NewUserService(userRepo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+		authorizer, nil, nil, nil)
+
+	_, err := svc.UpdateUser(context.Background(), manager, admin.User{
+		ID:       "u1",
+		RealmID:  "a1",
+		BindID:   "u1",
+		Username: "user1",
+		Email:    "user1@example.com",
+	})
+	require.ErrorAs(t, err, &domain.AccessDeniedError{})
+
+	err = svc.DeleteUser(context.Background(), manager, "a1", "u1", 1)
+	require.ErrorAs(t, err, &domain.AccessDeniedError{})
+}
+
+func TestUserService_UpdateUser_accessRulesOnStoredUser(t *testing.T) {
+	t.Parallel()
+
+	// the manager may only manage the users of the research cost centre
+	manager := admin.Actor{Role: admin.SystemRoleManager, RealmID: "a1", UserID: "u9"}
+
+	tests := map[string]struct {
+		storedCostCentre string
+		costCentre       string
+		wantError        error
+	}{
+		"ownCostCentre": {
+			storedCostCentre: "research",
+			costCentre:       "research",
+		},
+		"moveIntoOtherCostCentre": {
+			storedCostCentre: "research",
+			costCentre:       "sales",
+			wantError:        domain.AccessDeniedError{},
+		},
+		"moveOutOfOtherCostCentre": {
+			storedCostCentre: "sales",
+			costCentre:       "research",
+			wantError:        domain.AccessDeniedError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ruleRepo := newMockRuleRepository()
+			ruleRepo.rules = []admin.AccessRule{{
+				RealmID:     "a1",
+				Code:        "research-only",
+				Enabled:     true,
+				Permissions: []admin.Permission{admin.PermissionUsersWrite},
+				Expression:  "research",
+			}}
+			userRepo := newMockUserRepository()
+			userRepo.attributes = map[string]any{"employeeNumber": "e1", "costCentre": test.storedCostCentre}
+			realmRepo := newMockRealmRepository()
+			realmRepo.userAttributes = testAttributeSchema
+			authorizer := NewAuthorizer(userRepo, realmRepo, newMockGroupRepository(), ruleRepo, costCentreRuleEngine{})
+			svc := NewUserService(userRepo, realmRepo, newMockGroupRepository(), newMockMembershipRepository(),
+				authorizer, nil, nil, nil)
+
+			_, err := svc.UpdateUser(context.Background(), manager, admin.User{
+				ID:         "u1",
+				RealmID:    "a1",
+				BindID:     "u1",
+				Username:   "user1",
+				Email:      "user1@example.com",
+				Attributes: map[string]any{"employeeNumber": "e1", "costCentre": test.costCentre},
+			})
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+// costCentreRuleEngine allows the targets whose cost centre is the expression.
+type costCentreRuleEngine struct{}
+
+func (costCentreRuleEngine) Compile(_ string) error {
+	return nil
+}
+
+func (costCentreRuleEngine) Evaluate(expression string, input admin.RuleInput) (bool, error) {
+	custom, _ := input.Target["custom"].(map[string]any)
+
+	return custom["costCentre"] == expression, nil
+}
+
+func TestUserService_impersonatedActor(t *testing.T) {
+	t.Parallel()
+
+	impersonated := admin.Actor{
+		UserID:              "u2",
+		RealmID:             "a1",
+		Role:                admin.SystemRoleManager,
+		ImpersonatorID:      "admin1",
+		ImpersonatorRealmID: "a0",
+	}
+	ctx := context.Background()
+
+	repo := newMockUserRepository()
+	repo.role = admin.SystemRoleUser
+	repo.apiKeys = []admin.APIKey{{ID: "k1", Name: "key", Enabled: true}}
+	svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+		newTestAuthorizer(), newMockIDGenerator(), newMockKeyGenerator(), newMockKeyHasher())
+
+	user := admin.User{
+		ID:       "u1",
+		RealmID:  "a1",
+		BindID:   "bindID",
+		Username: "user1",
+		Email:    "user1@example.com",
+		Role:     admin.SystemRoleUser,
+	}
+
+	t.Run("updateUser", func(t *testing.T) {
+		_, err := svc.UpdateUser(ctx, impersonated, user)
+		require.NoError(t, err)
+	})
+
+	t.Run("updateUser-roleChange", func(t *testing.T) {
+		promoted := user
+		promoted.Role = admin.SystemRoleManager
+
+		_, err := svc.UpdateUser(ctx, impersonated, promoted)
+		require.ErrorAs(t, err, &domain.AccessDeniedError{})
+	})
+
+	t.Run("createUser-apiKeys", func(t *testing.T) {
+		created := user
+		created.ID = ""
+		created.APIKeys = []admin.APIKey{{Name: "key"}}
+
+		_, err := svc.CreateUser(ctx, impersonated, created)
+		require.ErrorAs(t, err, &domain.AccessDeniedError{})
+	})
+
+	t.Run("createAPIKey", func(t *testing.T) {
+		_, err := svc.CreateAPIKey(ctx, impersonated, "a1", "u1", admin.APIKey{Name: "key"})
+		require.ErrorAs(t, err, &domain.AccessDeniedError{})
+	})
+
+	t.Run("updateAPIKey", func(t *testing.T) {
+		_, err := svc.UpdateAPIKey(ctx, impersonated, "a1", "u1", "k1", 1, admin.APIKey{Name: "key"})
+		require.ErrorAs(t, err, &domain.AccessDeniedError{})
+	})
+
+	t.Run("rotateAPIKey", func(t *testing.T) {
+		_, err := svc.RotateAPIKey(ctx, impersonated, "a1", "u1", "k1", 1, admin.APIKey{}, 0)
+		require.ErrorAs(t, err, &domain.AccessDeniedError{})
+	})
+
+	t.Run("deleteAPIKey", func(t *testing.T) {
+		err := svc.DeleteAPIKey(ctx, impersonated, "a1", "u1", "k1", 1)
+		require.ErrorAs(t, err, &domain.AccessDeniedError{})
+	})
+}
+
+func TestUserService_currentRole(t *testing.T) {
+	t.Parallel()
+
+	userManager := admin.Actor{UserID: "m1", RealmID: "a1", Role: admin.SystemRoleUserManager}
+	manager := admin.Actor{UserID: "m2", RealmID: "a1", Role: admin.SystemRoleManager}
+	admin1 := admin.Actor{UserID: "admin1", RealmID: "a0", Role: admin.SystemRoleAdmin}
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		role      admin.SystemRole
+		wantError error
+	}{
+		"userManager-targetUser": {
+			actor: userManager,
+			role:  admin.SystemRoleUser,
+		},
+		"userManager-targetManager": {
+			actor:     userManager,
+			role:      admin.SystemRoleManager,
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-targetManager": {
+			actor: manager,
+			role:  admin.SystemRoleManager,
+		},
+		"manager-targetSystemAuditor": {
+			actor:     manager,
+			role:      admin.SystemRoleSystemAuditor,
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager-targetAdmin": {
+			actor:     manager,
+			role:      admin.SystemRoleAdmin,
+			wantError: domain.AccessDeniedError{},
+		},
+		"admin-targetAdmin": {
+			actor: admin1,
+			role:  admin.SystemRoleAdmin,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockUserRepository()
+			repo.role = test.role
+			svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+				newTestAuthorizer(), nil, nil, newMockKeyHasher())
+
+			user := admin.User{
+				ID:       "u1",
+				RealmID:  "a1",
+				BindID:   "bindID",
+				Username: "user1",
+				Email:    "user1@example.com",
+				Role:     test.role,
+			}
+
+			_, updateErr := svc.UpdateUser(context.Background(), test.actor, user)
+			deleteErr := svc.DeleteUser(context.Background(), test.actor, "a1", "u1", 1)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, updateErr, &test.wantError)
+				require.ErrorAs(t, deleteErr, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, updateErr)
+			require.NoError(t, deleteErr)
+		})
+	}
+}
+
+type mockUserRepository struct {
+	userExists  bool
+	disabled    bool
+	role        admin.SystemRole
+	roles       []string
+	attributes  map[string]any
+	apiKeys     []admin.APIKey
+	updatedUser admin.User
+	filter      admin.UserFilter
+	options     admin.ListOptions
+	usages      []admin.APIKeyUsage
+	forcedError error
+}
+
+// ensure mockUserRepository implements admin.UserRepository.
+var _ admin.UserRepository = (*mockUserRepository)(nil)
+
+func newMockUserRepository() *mockUserRepository {
+	return &mockUserRepository{}
+}
+
+func (r *mockUserRepository) GetUsers(
+	_ context.Context,
+	realmID admin.ID,
+	filter admin.UserFilter,
+	options admin.ListOptions,
+) (admin.Page[admin.User], error) {
+	if realmID == "" {
+		return admin.Page[admin.User]{}, errors.New("test-precondition: empty realmID")
+	}
+
+	r.filter = filter
+	r.options = options
+
+	return admin.Page[admin.User]{Items: []admin.User{r.mockUser()}, Total: 1}, r.forcedError
+}
+
+func (r *mockUserRepository) GetUser(_ context.Context, realmID, id admin.ID) (admin.User, error) {
+	if realmID == "" {
+		return admin.User{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return admin.User{}, errors.New("test-precondition: empty id")
+	}
+
+	return r.mockUser(), r.forcedError
+}
+
+func (r *mockUserRepository) CreateUser(_ context.Context, user admin.User) error {
+	if (reflect.DeepEqual(user, admin.User{})) {
+		return errors.New("test-precondition: empty user")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockUserRepository) UpdateUser(_ context.Context, user admin.User) error {
+	if (reflect.DeepEqual(user, admin.User{})) {
+		return errors.New("test-precondition: empty user")
+	}
+
+	r.updatedUser = user
+
+	return r.forcedError
+}
+
+func (r *mockUserRepository) DeleteUser(_ context.Context, realmID, id admin.ID, version admin.Version, deletedAt time.Time) error {
+	if version < admin.FirstVersion {
+		return errors.New("test-precondition: no version")
+	}
+
+	if deletedAt.IsZero() {
+		return errors.New("test-precondition: no deletion time")
+	}
+
+	if realmID == "" {
+		return errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return errors.New("test-precondition: empty id")
+	}
+
+	return r.forcedError
+}
+
+func (r *mockUserRepository) RestoreUser(_ context.Context, realmID, id admin.ID) (time.Time, error) {
+	if realmID == "" {
+		return time.Time{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return time.Time{}, errors.New("test-precondition: empty id")
+	}
+
+	return mockDeletedAt(), r.forcedError
+}
+
+func (r *mockUserRepository) GetDeletedUser(_ context.Context, realmID, id admin.ID) (admin.User, error) {
+	if realmID == "" {
+		return admin.User{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if id == "" {
+		return admin.User{}, errors.New("test-precondition: empty id")
+	}
+
+	user := r.mockUser()
+	user.BindID = "mockBindID"
+
+	return user, r.forcedError
+}
+
+func (r *mockUserRepository) SearchUsers(_ context.Context, realmID admin.ID, text string, limit int) ([]admin.SearchHit, error) {
+	if text == "" || limit <= 0 {
+		return nil, errors.New("test-precondition: empty text or limit")
+	}
+
+	hit := admin.SearchHit{Kind: admin.PrincipalKindUser, RealmID: realmID, ID: "u1", Name: "mockUser", Score: 1.5}
+
+	return []admin.SearchHit{hit}, r.forcedError
+}
+
+func (r *mockUserRepository) GetUserByBindID(_ context.Context, realmID admin.ID, bindID string) (admin.User, error) {
+	if realmID == "" {
+		return admin.User{}, errors.New("test-precondition: empty realmID")
+	}
+
+	if bindID == "" {
+		return admin.User{}, errors.New("test-precondition: empty bindID")
+	}
+
+	if !r.userExists {
+		return admin.User{}, domain.NewNotFoundError("user not found")
+	}
+
+	user := r.mockUser()
+	user.BindID = bindID
+	user.Email = "mockUser@domain.com"
+
+	return user, r.forcedError
+}
+
+func (r *mockUserRepository) GetAPIKeysByPrefix(_ context.Context, realmID admin.ID, prefix string) ([]admin.OwnedAPIKey, error) {
+	if realmID == "" {
+		return nil, errors.New("test-precondition: empty realmID")
+	}
+
+	if prefix == "" {
+		return nil, errors.New("test-precondition: empty prefix")
+	}
+
+	return ownAPIKeys(realmID, "u1", r.apiKeys), r.forcedError
+}
+
+func (r *mockUserRepository) GetAPIKeysExpiringBefore(_ context.Context, before time.Time) ([]admin.OwnedAPIKey, error) {
+	if before.IsZero() {
+		return nil, errors.New("test-precondition: zero time")
+	}
+
+	return ownAPIKeys("r1", "u1", r.apiKeys), r.forcedError
+}
+
+func (r *mockUserRepository) DisableExpiredAPIKeys(_ context.Context, now time.Time) (int, error) {
+	if now.IsZero() {
+		return 0, errors.New("test-precondition: zero time")
+	}
+
+	return 0, r.forcedError
+}
+
+func (r *mockUserRepository) RecordAPIKeyUsage(_ context.Context, usages []admin.APIKeyUsage) error {
+	if r.forcedError != nil {
+		return r.forcedError
+	}
+
+	r.usages = append(r.usages, usages...)
+
+	return nil
+}
+
+func (r *mockUserRepository) mockUser() admin.User {
+	return admin.User{
+		ID:         "u1",
+		RealmID:    "a1",
+		Username:   "mockUser",
+		Enabled:    !r.disabled,
+		Role:       r.role,
+		Roles:      r.roles,
+		Attributes: r.attributes,
+		APIKeys:    slices.Clone(r.apiKeys),
+		CreatedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestUserService_ImpersonateUser(t *testing.T) {
+	t.Parallel()
+
+	admin1 := admin.Actor{UserID: "admin1", RealmID: "a0", Role: admin.SystemRoleAdmin}
+	impersonated := admin.Actor{UserID: "u2", RealmID: "a1", Role: admin.SystemRoleAdmin, ImpersonatorID: "admin1", ImpersonatorRealmID: "a0"}
+
+	tests := map[string]struct {
+		actor     admin.Actor
+		role      admin.SystemRole
+		disabled  bool
+		repoError bool
+		wantError error
+	}{
+		"admin": {
+			actor: admin1,
+			role:  admin.SystemRoleManager,
+		},
+		"admin-self": {
+			actor:     admin.Actor{UserID: "u1", RealmID: "a1", Role: admin.SystemRoleAdmin},
+			wantError: domain.ValidationError{},
+		},
+		"admin-targetAdmin": {
+			actor:     admin1,
+			role:      admin.SystemRoleAdmin,
+			wantError: domain.AccessDeniedError{},
+		},
+		"admin-targetDisabled": {
+			actor:     admin1,
+			role:      admin.SystemRoleUser,
+			disabled:  true,
+			wantError: domain.ValidationError{},
+		},
+		"admin-repoError": {
+			actor:     admin1,
+			repoError: true,
+			wantError: domain.StoreError{},
+		},
+		"admin-impersonated": {
+			actor:     impersonated,
+			role:      admin.SystemRoleUser,
+			wantError: domain.AccessDeniedError{},
+		},
+		"manager": {
+			actor:     admin.Actor{UserID: "m1", RealmID: "a1", Role: admin.SystemRoleManager},
+			role:      admin.SystemRoleUser,
+			wantError: domain.AccessDeniedError{},
+		},
+		"user": {
+			actor:     admin.Actor{UserID: "u2", RealmID: "a1", Role: admin.SystemRoleUser},
+			role:      admin.SystemRoleUser,
+			wantError: domain.AccessDeniedError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockUserRepository()
+			repo.role = test.role
+			repo.disabled = test.disabled
+
+			if test.repoError {
+				repo.forcedError = domain.NewStoreError("forcedError")
+			}
+
+			svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+				newTestAuthorizer(), nil, nil, newMockKeyHasher())
+
+			user, err := svc.ImpersonateUser(context.Background(), test.actor, "a1", "u1")
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, admin.ID("u1"), user.ID)
+			}
+		})
+	}
+}
+
+//nolint:gochecknoglobals // test fixture
+var testAttributeSchema = []admin.AttributeDefinition{
+	{Name: "employeeNumber", Type: admin.AttributeTypeString, Required: true},
+	{Name: "level", Type: admin.AttributeTypeNumber},
+	{Name: "contractor", Type: admin.AttributeTypeBool},
+	{Name: "costCentre", Type: admin.AttributeTypeEnum, Values: []string{"sales", "research"}},
+	{Name: "skills", Type: admin.AttributeTypeList},
+}
+
+func TestUserService_CreateUser_attributes(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		attributes map[string]any
+		want       map[string]any
+		wantError  error
+	}{
+		"valid": {
+			attributes: map[string]any{
+				"employeeNumber": "E-1",
+				"level":          3,
+				"contractor":     false,
+				"costCentre":     "sales",
+				"skills":         []any{"go", "sql"},
+			},
+			want: map[string]any{
+				"employeeNumber": "E-1",
+				"level":          3.0,
+				"contractor":     false,
+				"costCentre":     "sales",
+				"skills":         []string{"go", "sql"},
+			},
+		},
+		"requiredOnly": {
+			attributes: map[string]any{"employeeNumber": "E-1"},
+			want:       map[string]any{"employeeNumber": "E-1"},
+		},
+		"missingRequired": {
+			attributes: map[string]any{"level": 3.0},
+			wantError:  domain.ValidationError{},
+		},
+		"unknown": {
+			attributes: map[string]any{"employeeNumber": "E-1", "shoeSize": 44.0},
+			wantError:  domain.ValidationError{},
+		},
+		"wrongType": {
+			attributes: map[string]any{"employeeNumber": "E-1", "level": "three"},
+			wantError:  domain.ValidationError{},
+		},
+		"enumNotAllowed": {
+			attributes: map[string]any{"employeeNumber": "E-1", "costCentre": "legal"},
+			wantError:  domain.ValidationError{},
+		},
+		"listOfNumbers": {
+			attributes: map[string]any{"employeeNumber": "E-1", "skills": []any{1.0}},
+			wantError:  domain.ValidationError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			realmRepo := newMockRealmRepository()
+			realmRepo.userAttributes = testAttributeSchema
+			svc := NewUserService(newMockUserRepository(), realmRepo, newMockGroupRepository(), newMockMembershipRepository(),
+				newTestAuthorizer(), newMockIDGenerator(), newMockKeyGenerator(), newMockKeyHasher())
+
+			user, err := svc.CreateUser(context.Background(), admin.Actor{Role: admin.SystemRoleAdmin}, admin.User{
+				RealmID:    "a1",
+				BindID:     "bindID",
+				Username:   "username",
+				Email:      "mail@domain.com",
+				Role:       admin.SystemRoleUser,
+				Attributes: test.attributes,
+			})
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.want, user.Attributes)
+			}
+		})
+	}
+}
+
+func TestUserService_GetUsers_attributes(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		attributes map[string]any
+		want       map[string]any
+		wantError  error
+	}{
+		"none": {},
+		"typed": {
+			attributes: map[string]any{"level": "3", "contractor": "true", "costCentre": "sales", "skills": "go"},
+			want:       map[string]any{"level": 3.0, "contractor": true, "costCentre": "sales", "skills": "go"},
+		},
+		"unknown": {
+			attributes: map[string]any{"shoeSize": "44"},
+			wantError:  domain.ValidationError{},
+		},
+		"invalidNumber": {
+			attributes: map[string]any{"level": "three"},
+			wantError:  domain.ValidationError{},
+		},
+		"invalidBool": {
+			attributes: map[string]any{"contractor": "maybe"},
+			wantError:  domain.ValidationError{},
+		},
+		"enumNotAllowed": {
+			attributes: map[string]any{"costCentre": "legal"},
+			wantError:  domain.ValidationError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockUserRepository()
+			realmRepo := newMockRealmRepository()
+			realmRepo.userAttributes = testAttributeSchema
+			svc := NewUserService(repo, realmRepo, newMockGroupRepository(), newMockMembershipRepository(),
+				newTestAuthorizer(), nil, nil, newMockKeyHasher())
+
+			_, err := svc.GetUsers(context.Background(), admin.Actor{Role: admin.SystemRoleAdmin}, "a1",
+				admin.UserFilter{Attributes: test.attributes}, admin.ListOptions{})
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.want, repo.filter.Attributes)
+			}
+		})
+	}
+}
+
+func TestUserService_GetUsers_listOptions(t *testing.T) {
+	t.Parallel()
+
+	enabled := true
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		filter      admin.UserFilter
+		options     admin.ListOptions
+		wantOptions admin.ListOptions
+		wantError   error
+	}{
+		"defaults": {
+			wantOptions: admin.ListOptions{SortBy: admin.SortByUsername},
+		},
+		"cursorWithoutLimit": {
+			options:     admin.ListOptions{Cursor: "c"},
+			wantOptions: admin.ListOptions{Cursor: "c", SortBy: admin.SortByUsername, Limit: defaultListLimit},
+		},
+		"sorted": {
+			filter:      admin.UserFilter{Enabled: &enabled, Role: admin.SystemRoleManager, EmailPrefix: "j"},
+			options:     admin.ListOptions{Cursor: "c", SortBy: admin.SortByCreatedAt, Descending: true, Limit: 10},
+			wantOptions: admin.ListOptions{Cursor: "c", SortBy: admin.SortByCreatedAt, Descending: true, Limit: 10},
+		},
+		"invalidSortField": {
+			options:   admin.ListOptions{SortBy: admin.SortByCode},
+			wantError: domain.ValidationError{},
+		},
+		"limitTooLarge": {
+			options:   admin.ListOptions{Limit: maxListLimit + 1},
+			wantError: domain.ValidationError{},
+		},
+		"invalidRole": {
+			filter:    admin.UserFilter{Role: "unknown"},
+			wantError: domain.ValidationError{},
+		},
+		"emptyCreationRange": {
+			filter:    admin.UserFilter{CreatedAfter: day, CreatedBefore: day},
+			wantError: domain.ValidationError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newMockUserRepository()
+			svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+				newTestAuthorizer(), nil, nil, newMockKeyHasher())
+
+			page, err := svc.GetUsers(context.Background(), admin.Actor{Role: admin.SystemRoleAdmin}, "a1",
+				test.filter, test.options)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, 1, page.Total)
+				require.Equal(t, test.filter, repo.filter)
+				require.Equal(t, test.wantOptions, repo.options)
+			}
+		})
+	}
+}
+
+func TestUserService_createdAt(t *testing.T) {
+	t.Parallel()
+
+	repo := newMockUserRepository()
+	svc := NewUserService(repo, newMockRealmRepository(), newMockGroupRepository(), newMockMembershipRepository(),
+		newTestAuthorizer(), newMockIDGenerator(), newMockKeyGenerator(), newMockKeyHasher())
+	actor := admin.Actor{Role: admin.SystemRoleAdmin}
+	user := admin.User{RealmID: "a1", BindID: "bindID", Username: "username", Email: "mail@domain.com", Role: admin.SystemRoleUser}
+
+	created, err := svc.CreateUser(context.Background(), actor, user)
+
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), created.CreatedAt, time.Minute)
+
+	user.ID = "u1"
+	user.CreatedAt = time.Now()
+
+	updated, err := svc.UpdateUser(context.Background(), actor, user)
+
+	require.NoError(t, err)
+	require.Equal(t, repo.mockUser().CreatedAt, updated.CreatedAt)
+}
+
+func TestUserService_GetEffectiveRoles(t *testing.T) {
+	t.Parallel()
+
+	realmRepo := newMockRealmRepository()
+	realmRepo.roles = []admin.Role{{Name: "auditor", Permissions: []admin.Permission{admin.PermissionGroupsRead}}}
+	groupRepo := newMockGroupRepository()
+	groupRepo.roles = []string{"auditor"}
+	svc := NewUserService(newMockUserRepository(), realmRepo, groupRepo, newMockMembershipRepository(), newTestAuthorizer(), nil, nil, newMockKeyHasher())
+
+	self := admin.Actor{Role: admin.SystemRoleUser, RealmID: "a1", UserID: "u1"}
+
+	effective, err := svc.GetEffectiveRoles(context.Background(), self, "a1", "u1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"auditor"}, effective.Roles)
+	require.Equal(t, []admin.ID{"g1"}, effective.Groups)
+	require.Contains(t, effective.Permissions, admin.PermissionGroupsRead)
+
+	other := admin.Actor{Role: admin.SystemRoleUser, RealmID: "a1", UserID: "u2"}
+
+	_, err = svc.GetEffectiveRoles(context.Background(), other, "a1", "u1")
+	require.ErrorAs(t, err, &domain.AccessDeniedError{})
+}
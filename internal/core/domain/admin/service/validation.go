@@ -1,8 +1,10 @@
 package service
 
 import (
+	"slices"
 	"strings"
 
+	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
 )
 
@@ -18,9 +20,139 @@ func validateRealm(realm admin.Realm) (admin.Realm, error) {
 		return realm, err
 	}
 
+	if realm.APIKeyMaxLifetime < 0 {
+		return realm, domain.NewValidationError("API key max lifetime cannot be negative")
+	}
+
+	// the slices are trimmed in place, so they must not be shared with the caller
+	realm.RedirectURIs = slices.Clone(realm.RedirectURIs)
+	realm.APIKeyScopes = slices.Clone(realm.APIKeyScopes)
+
+	for i, uri := range realm.RedirectURIs {
+		realm.RedirectURIs[i] = strings.TrimSpace(uri)
+
+		if err := checkRedirectURI(realm.RedirectURIs[i]); err != nil {
+			return realm, err
+		}
+	}
+
+	for i, scope := range realm.APIKeyScopes {
+		realm.APIKeyScopes[i] = strings.TrimSpace(scope)
+
+		if err := checkScope(realm.APIKeyScopes[i]); err != nil {
+			return realm, err
+		}
+	}
+
+	roles, err := validateRoles(realm.Roles)
+	if err != nil {
+		return realm, err
+	}
+
+	realm.Roles = roles
+
+	schema, err := validateAttributeSchema(realm.UserAttributes)
+	if err != nil {
+		return realm, err
+	}
+
+	realm.UserAttributes = schema
+
 	return realm, nil
 }
 
+func validateAttributeSchema(schema []admin.AttributeDefinition) ([]admin.AttributeDefinition, error) {
+	names := make(map[string]bool, len(schema))
+	schema = slices.Clone(schema)
+
+	for i, definition := range schema {
+		definition.Name = strings.TrimSpace(definition.Name)
+
+		if err := checkAttributeKey(definition.Name); err != nil {
+			return nil, err
+		}
+
+		if names[definition.Name] {
+			return nil, domain.NewValidationError("attribute %s is defined more than once", definition.Name)
+		}
+
+		names[definition.Name] = true
+
+		if !slices.Contains(admin.AllAttributeTypes, definition.Type) || definition.Type == admin.AttributeTypeNone {
+			return nil, domain.NewValidationError("invalid attribute type: %s", definition.Type)
+		}
+
+		switch definition.Type {
+		case admin.AttributeTypeEnum:
+			if len(definition.Values) == 0 {
+				return nil, domain.NewValidationError("enum attribute %s must have values", definition.Name)
+			}
+		case admin.AttributeTypeList:
+		default:
+			if len(definition.Values) > 0 {
+				return nil, domain.NewValidationError("attribute %s of type %s cannot have values", definition.Name, definition.Type)
+			}
+		}
+
+		values := make([]string, 0, len(definition.Values))
+
+		for _, value := range definition.Values {
+			value = strings.TrimSpace(value)
+
+			if err := checkEmpty("attribute value", value); err != nil {
+				return nil, err
+			}
+
+			// the order of the values is kept for presentation purposes
+			if !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+
+		if len(values) > 0 {
+			definition.Values = values
+		}
+
+		schema[i] = definition
+	}
+
+	return schema, nil
+}
+
+func validateRoles(roles []admin.Role) ([]admin.Role, error) {
+	names := make(map[string]bool, len(roles))
+	roles = slices.Clone(roles)
+
+	for i, role := range roles {
+		role.Name = strings.TrimSpace(role.Name)
+
+		if err := checkRoleName(role.Name); err != nil {
+			return nil, err
+		}
+
+		if names[role.Name] {
+			return nil, domain.NewValidationError("role %s is defined more than once", role.Name)
+		}
+
+		names[role.Name] = true
+
+		for _, permission := range role.Permissions {
+			if !admin.IsRealmPermission(permission) {
+				return nil, domain.NewValidationError("permission %s cannot be granted by a realm role", permission)
+			}
+		}
+
+		role.Permissions = slices.Clone(role.Permissions)
+
+		slices.Sort(role.Permissions)
+
+		role.Permissions = slices.Compact(role.Permissions)
+		roles[i] = role
+	}
+
+	return roles, nil
+}
+
 func validateDaemon(daemon admin.Daemon) (admin.Daemon, error) {
 	daemon.Name = strings.TrimSpace(daemon.Name)
 	daemon.Code = strings.TrimSpace(daemon.Code)
@@ -33,9 +165,148 @@ func validateDaemon(daemon admin.Daemon) (admin.Daemon, error) {
 		return admin.Daemon{}, err
 	}
 
+	for _, realmID := range daemon.AllowedRealms {
+		if realmID == "" {
+			return admin.Daemon{}, domain.NewValidationError("allowed realm cannot be empty")
+		}
+	}
+
+	// the own realm is always allowed
+	daemon.AllowedRealms = slices.DeleteFunc(slices.Clone(daemon.AllowedRealms), func(realmID admin.ID) bool {
+		return realmID == daemon.RealmID
+	})
+
+	slices.Sort(daemon.AllowedRealms)
+
+	daemon.AllowedRealms = slices.Compact(daemon.AllowedRealms)
+
+	cidrs, err := validateCIDRs(daemon.AllowedCIDRs)
+	if err != nil {
+		return admin.Daemon{}, err
+	}
+
+	daemon.AllowedCIDRs = cidrs
+
 	return daemon, nil
 }
 
+func validateGroup(group admin.Group) (admin.Group, error) {
+	group.Name = strings.TrimSpace(group.Name)
+	group.Code = strings.TrimSpace(group.Code)
+
+	if err := checkName(group.Name); err != nil {
+		return group, err
+	}
+
+	if err := checkCode(group.Code); err != nil {
+		return group, err
+	}
+
+	group.Roles = slices.Clone(group.Roles)
+
+	for i, name := range group.Roles {
+		group.Roles[i] = strings.TrimSpace(name)
+
+		if err := checkEmpty("role", group.Roles[i]); err != nil {
+			return group, err
+		}
+	}
+
+	slices.Sort(group.Roles)
+
+	group.Roles = slices.Compact(group.Roles)
+
+	for key := range group.Attributes {
+		if err := checkAttributeKey(key); err != nil {
+			return group, err
+		}
+	}
+
+	return group, nil
+}
+
+func validateMembership(membership admin.Membership) (admin.Membership, error) {
+	if err := checkEmpty("userID", membership.UserID.String()); err != nil {
+		return membership, err
+	}
+
+	if err := checkEmpty("userRealmID", membership.UserRealmID.String()); err != nil {
+		return membership, err
+	}
+
+	if membership.UserRealmID == membership.RealmID {
+		return membership, domain.NewValidationError("user %s already belongs to realm %s", membership.UserID, membership.RealmID)
+	}
+
+	// memberships grant access to a single realm, so the system-wide roles are excluded
+	if membership.Role != admin.SystemRoleUser && !membership.Role.IsRealmWide() {
+		return membership, domain.NewValidationError("invalid membership role: %s", membership.Role)
+	}
+
+	return membership, nil
+}
+
+func validatePolicy(policy admin.Policy) (admin.Policy, error) {
+	policy.Code = strings.TrimSpace(policy.Code)
+
+	if err := checkCode(policy.Code); err != nil {
+		return policy, err
+	}
+
+	if policy.Effect != admin.PolicyEffectAllow && policy.Effect != admin.PolicyEffectDeny {
+		return policy, domain.NewValidationError("invalid policy effect: %s", policy.Effect)
+	}
+
+	if len(policy.Actions) == 0 || len(policy.Resources) == 0 {
+		return policy, domain.NewValidationError("policy %s must have actions and resources", policy.Code)
+	}
+
+	for _, pattern := range slices.Concat(policy.Actions, policy.Resources) {
+		if !admin.IsValidPattern(pattern) {
+			return policy, domain.NewValidationError("invalid policy pattern: %q", pattern)
+		}
+	}
+
+	for _, subject := range slices.Concat(policy.Roles, policy.Groups) {
+		if err := checkEmpty("policy subject", subject); err != nil {
+			return policy, err
+		}
+	}
+
+	return policy, nil
+}
+
+func validateRule(rule admin.AccessRule) (admin.AccessRule, error) {
+	rule.Code = strings.TrimSpace(rule.Code)
+	rule.Expression = strings.TrimSpace(rule.Expression)
+
+	if err := checkCode(rule.Code); err != nil {
+		return rule, err
+	}
+
+	if err := checkEmpty("expression", rule.Expression); err != nil {
+		return rule, err
+	}
+
+	if len(rule.Permissions) == 0 {
+		return rule, domain.NewValidationError("rule %s must have permissions", rule.Code)
+	}
+
+	for _, permission := range rule.Permissions {
+		if !admin.IsRulePermission(permission) {
+			return rule, domain.NewValidationError("rules cannot be bound to permission %s", permission)
+		}
+	}
+
+	rule.Permissions = slices.Clone(rule.Permissions)
+
+	slices.Sort(rule.Permissions)
+
+	rule.Permissions = slices.Compact(rule.Permissions)
+
+	return rule, nil
+}
+
 func validateProvider(provider admin.Provider) (admin.Provider, error) {
 	provider.Name = strings.TrimSpace(provider.Name)
 	provider.Code = strings.TrimSpace(provider.Code)
@@ -51,7 +322,7 @@ func validateProvider(provider admin.Provider) (admin.Provider, error) {
 	return provider, nil
 }
 
-func validateUser(user admin.User) (admin.User, error) {
+func validateUser(user admin.User, schema []admin.AttributeDefinition) (admin.User, error) {
 	user.BindID = strings.TrimSpace(user.BindID)
 	user.Username = strings.TrimSpace(user.Username)
 	user.Email = strings.TrimSpace(user.Email)
@@ -69,9 +340,70 @@ func validateUser(user admin.User) (admin.User, error) {
 		return user, err
 	}
 
+	if !admin.IsBuiltinRole(string(user.Role)) {
+		return user, domain.NewValidationError("invalid role: %s", user.Role)
+	}
+
+	user.Roles = slices.Clone(user.Roles)
+
+	for i, name := range user.Roles {
+		user.Roles[i] = strings.TrimSpace(name)
+
+		if err := checkEmpty("role", user.Roles[i]); err != nil {
+			return user, err
+		}
+	}
+
+	slices.Sort(user.Roles)
+
+	user.Roles = slices.Compact(user.Roles)
+
+	attributes, err := validateUserAttributes(user.Attributes, schema)
+	if err != nil {
+		return user, err
+	}
+
+	user.Attributes = attributes
+
 	return user, nil
 }
 
+// validateUserAttributes checks the attributes against the schema of the realm
+// and returns a copy holding the values in their canonical types.
+func validateUserAttributes(attributes map[string]any, schema []admin.AttributeDefinition) (map[string]any, error) {
+	for name := range attributes {
+		if _, ok := admin.FindAttribute(schema, name); !ok {
+			return nil, domain.NewValidationError("unknown attribute: %s", name)
+		}
+	}
+
+	var normalized map[string]any
+
+	for _, definition := range schema {
+		value, ok := attributes[definition.Name]
+		if !ok || value == nil {
+			if definition.Required {
+				return nil, domain.NewValidationError("attribute %s is required", definition.Name)
+			}
+
+			continue
+		}
+
+		value, err := definition.NormalizeValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if normalized == nil {
+			normalized = make(map[string]any, len(attributes))
+		}
+
+		normalized[definition.Name] = value
+	}
+
+	return normalized, nil
+}
+
 func validateAPIKey(apiKey admin.APIKey) (admin.APIKey, error) {
 	apiKey.Name = strings.TrimSpace(apiKey.Name)
 	apiKey.Key = strings.TrimSpace(apiKey.Key)
@@ -80,5 +412,112 @@ func validateAPIKey(apiKey admin.APIKey) (admin.APIKey, error) {
 		return apiKey, err
 	}
 
+	return validateAPIKeyRestrictions(apiKey)
+}
+
+func validateAPIKeyUpdate(apiKey admin.APIKey) (admin.APIKey, error) {
+	apiKey.Name = strings.TrimSpace(apiKey.Name)
+
+	// the key itself cannot be changed after creation
+	apiKey.Key = ""
+
+	if err := checkName(apiKey.Name); err != nil {
+		return apiKey, err
+	}
+
+	return validateAPIKeyRestrictions(apiKey)
+}
+
+func validateAPIKeyRestrictions(apiKey admin.APIKey) (admin.APIKey, error) {
+	apiKey.Scopes = slices.Clone(apiKey.Scopes)
+
+	for i, scope := range apiKey.Scopes {
+		apiKey.Scopes[i] = strings.TrimSpace(scope)
+
+		if err := checkScope(apiKey.Scopes[i]); err != nil {
+			return apiKey, err
+		}
+	}
+
+	cidrs, err := validateCIDRs(apiKey.AllowedCIDRs)
+	if err != nil {
+		return apiKey, err
+	}
+
+	apiKey.AllowedCIDRs = cidrs
+
 	return apiKey, nil
 }
+
+// validateCIDRs returns the canonical, sorted and deduplicated copy of the CIDR ranges.
+func validateCIDRs(cidrs []string) ([]string, error) {
+	if len(cidrs) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, len(cidrs))
+
+	for i, cidr := range cidrs {
+		n, err := normalizeCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		normalized[i] = n
+	}
+
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized), nil
+}
+
+// Pagination limits of the listings.
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// validateListOptions defaults the sort field to the first of the given fields.
+// Without a cursor nor a limit, the listing returns all the items as it did before
+// the pagination; the limit of the following pages defaults to defaultListLimit.
+func validateListOptions(options admin.ListOptions, sortFields []string) (admin.ListOptions, error) {
+	options.SortBy = strings.TrimSpace(options.SortBy)
+
+	if options.SortBy == "" {
+		options.SortBy = sortFields[0]
+	}
+
+	if !slices.Contains(sortFields, options.SortBy) {
+		return options, domain.NewValidationError("invalid sort field: %s", options.SortBy)
+	}
+
+	if options.Limit < 0 || options.Limit > maxListLimit {
+		return options, domain.NewValidationError("limit must be between 1 and %d", maxListLimit)
+	}
+
+	if options.Limit == 0 && options.Cursor != "" {
+		options.Limit = defaultListLimit
+	}
+
+	return options, nil
+}
+
+func validateUserFilter(filter admin.UserFilter) (admin.UserFilter, error) {
+	if filter.Role != admin.SystemRoleNone && !admin.IsBuiltinRole(string(filter.Role)) {
+		return filter, domain.NewValidationError("invalid role: %s", filter.Role)
+	}
+
+	if err := checkCreationRange(filter.CreatedAfter, filter.CreatedBefore); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func validateDaemonFilter(filter admin.DaemonFilter) (admin.DaemonFilter, error) {
+	if err := checkCreationRange(filter.CreatedAfter, filter.CreatedBefore); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
@@ -2,13 +2,24 @@ package service
 
 import (
 	"net/mail"
+	"net/netip"
+	"net/url"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
 )
 
 var codeRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]*$`)
 
+var scopeRegex = regexp.MustCompile(`^[a-z][a-z0-9_.-]*:[a-z][a-z0-9_.-]*$`)
+
+var roleNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_.-]*$`)
+
+var attributeKeyRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
 func checkEmpty(name, value string) error {
 	if value == "" {
 		return domain.NewValidationError("%s cannot be empty", name)
@@ -33,6 +44,34 @@ func checkCode(code string) error {
 	return nil
 }
 
+func checkScope(scope string) error {
+	if !scopeRegex.MatchString(scope) {
+		return domain.NewValidationError("invalid scope %q, expected resource:action", scope)
+	}
+
+	return nil
+}
+
+func checkRoleName(name string) error {
+	if !roleNameRegex.MatchString(name) {
+		return domain.NewValidationError("invalid role name %q", name)
+	}
+
+	if admin.IsBuiltinRole(name) {
+		return domain.NewValidationError("role name %s is reserved for a system role", name)
+	}
+
+	return nil
+}
+
+func checkAttributeKey(key string) error {
+	if !attributeKeyRegex.MatchString(key) {
+		return domain.NewValidationError("invalid attribute name %q", key)
+	}
+
+	return nil
+}
+
 func checkEmail(email string) error {
 	if err := checkEmpty("email", email); err != nil {
 		return err
@@ -45,3 +84,55 @@ func checkEmail(email string) error {
 
 	return nil
 }
+
+func checkRedirectURI(uri string) error {
+	if err := checkEmpty("redirect URI", uri); err != nil {
+		return err
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil || !parsed.IsAbs() || parsed.Host == "" {
+		return domain.NewValidationError("redirect URI %s must be an absolute URI", uri)
+	}
+
+	if parsed.User != nil {
+		return domain.NewValidationError("redirect URI %s must not contain user information", uri)
+	}
+
+	if host := parsed.Host; strings.Contains(host, "*") && (strings.Count(host, "*") > 1 || !strings.HasPrefix(host, "*.")) {
+		return domain.NewValidationError("redirect URI %s may only use a wildcard as the leftmost host label", uri)
+	}
+
+	if i := strings.Index(parsed.Path, "*"); i >= 0 && i != len(parsed.Path)-1 {
+		return domain.NewValidationError("redirect URI %s may only use a wildcard at the end of the path", uri)
+	}
+
+	return nil
+}
+
+func checkCreationRange(after, before time.Time) error {
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		return domain.NewValidationError("createdAfter must be before createdBefore")
+	}
+
+	return nil
+}
+
+// normalizeCIDR parses a CIDR range and returns it in its canonical form.
+// A single IP address is accepted as a range of one address.
+func normalizeCIDR(cidr string) (string, error) {
+	cidr = strings.TrimSpace(cidr)
+
+	if addr, err := netip.ParseAddr(cidr); err == nil {
+		addr = addr.Unmap()
+
+		return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+	}
+
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return "", domain.NewValidationError("invalid CIDR range %q", cidr)
+	}
+
+	return prefix.Masked().String(), nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateRealm(t *testing.T) {
+	t.Parallel()
+
+	redirectURIs := []string{" https://app.domain.com/home "}
+	scopes := []string{" sessions:read "}
+
+	realm, err := validateRealm(admin.Realm{
+		Name:         "Realm 1",
+		Code:         "realm1",
+		RedirectURIs: redirectURIs,
+		APIKeyScopes: scopes,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://app.domain.com/home"}, realm.RedirectURIs)
+	require.Equal(t, []string{"sessions:read"}, realm.APIKeyScopes)
+
+	// the slices of the caller are left untouched
+	require.Equal(t, []string{" https://app.domain.com/home "}, redirectURIs)
+	require.Equal(t, []string{" sessions:read "}, scopes)
+}
+
+func Test_validateAPIKeyRestrictions(t *testing.T) {
+	t.Parallel()
+
+	scopes := []string{" sessions:read "}
+
+	apiKey, err := validateAPIKeyRestrictions(admin.APIKey{Scopes: scopes})
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"sessions:read"}, apiKey.Scopes)
+
+	// the slice of the caller is left untouched
+	require.Equal(t, []string{" sessions:read "}, scopes)
+}
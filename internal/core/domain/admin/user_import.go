@@ -0,0 +1,108 @@
+package admin
+
+// MaxImportRows limits the number of rows of an import.
+const MaxImportRows = 10000
+
+// UserImportRow represents a user read from an import, with the line it was read
+// from. Err is set if the line could not be read, in which case the row is
+// reported as failed.
+//
+// Fields lists the user fields given by the row. An imported user updating a stored
+// one only changes these fields, and the others keep their stored values.
+type UserImportRow struct {
+	Line   int
+	User   User
+	Fields []UserField
+	Err    error
+}
+
+// UserField represents a field of a user an import can set. The fields are named
+// like in the API.
+type UserField string
+
+// User fields.
+const (
+	UserFieldUsername    UserField = "username"
+	UserFieldEmail       UserField = "email"
+	UserFieldDisplayName UserField = "displayName"
+	UserFieldDescription UserField = "description"
+	UserFieldEnabled     UserField = "enabled"
+	UserFieldRole        UserField = "role"
+	UserFieldRoles       UserField = "roles"
+	UserFieldAttributes  UserField = "attributes"
+)
+
+// MergeInto returns the stored user with the fields of the row set to the values of
+// the imported user.
+func (r UserImportRow) MergeInto(stored User) User {
+	user := stored
+
+	for _, field := range r.Fields {
+		switch field {
+		case UserFieldUsername:
+			user.Username = r.User.Username
+		case UserFieldEmail:
+			user.Email = r.User.Email
+		case UserFieldDisplayName:
+			user.DisplayName = r.User.DisplayName
+		case UserFieldDescription:
+			user.Description = r.User.Description
+		case UserFieldEnabled:
+			user.Enabled = r.User.Enabled
+		case UserFieldRole:
+			user.Role = r.User.Role
+		case UserFieldRoles:
+			user.Roles = r.User.Roles
+		case UserFieldAttributes:
+			user.Attributes = r.User.Attributes
+		}
+	}
+
+	return user
+}
+
+// ImportAction represents the outcome of importing a user.
+type ImportAction string
+
+// Import actions.
+const (
+	ImportActionCreate ImportAction = "create"
+	ImportActionUpdate ImportAction = "update"
+	ImportActionFail   ImportAction = "fail"
+)
+
+// UserImportResult represents the outcome of importing a row.
+// ID is the ID of the user created or updated. It is empty for the users a dry run
+// would create. Err is set if the row failed.
+type UserImportResult struct {
+	Line   int
+	BindID string
+	ID     ID
+	Action ImportAction
+	Err    error
+}
+
+// UserImportReport represents the outcome of an import, with a result per row in
+// the order of the rows. A dry run reports what the import would do, without
+// changing any user.
+type UserImportReport struct {
+	DryRun  bool
+	Created int
+	Updated int
+	Failed  int
+	Results []UserImportResult
+}
+
+// Add adds the result of a row to the report.
+func (r *UserImportReport) Add(result UserImportResult) {
+	switch result.Action {
+	case ImportActionCreate:
+		r.Created++
+	case ImportActionUpdate:
+		r.Updated++
+	case ImportActionFail:
+		r.Failed++
+	}
+
+	r.Results = append(r.Results, result)
+}
@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserImportRow_MergeInto(t *testing.T) {
+	t.Parallel()
+
+	stored := User{
+		ID:          "u1",
+		RealmID:     "r1",
+		BindID:      "b1",
+		Username:    "user1",
+		Email:       "user1@domain.com",
+		DisplayName: "User One",
+		Enabled:     true,
+		Role:        SystemRoleManager,
+		Roles:       []string{"editor"},
+		Attributes:  map[string]any{"level": 3},
+		Version:     4,
+	}
+	imported := User{
+		BindID:      "b1",
+		DisplayName: "User 1",
+		Role:        SystemRoleUser,
+	}
+
+	tests := map[string]struct {
+		fields []UserField
+		want   func(user User) User
+	}{
+		"noFields": {
+			want: func(user User) User { return user },
+		},
+		"someFields": {
+			fields: []UserField{UserFieldDisplayName, UserFieldRole},
+			want: func(user User) User {
+				user.DisplayName = "User 1"
+				user.Role = SystemRoleUser
+
+				return user
+			},
+		},
+		"clearedFields": {
+			fields: []UserField{UserFieldEnabled, UserFieldRoles, UserFieldAttributes},
+			want: func(user User) User {
+				user.Enabled = false
+				user.Roles = nil
+				user.Attributes = nil
+
+				return user
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			row := UserImportRow{Line: 2, User: imported, Fields: test.fields}
+
+			require.Equal(t, test.want(stored), row.MergeInto(stored))
+		})
+	}
+}
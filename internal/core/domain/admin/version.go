@@ -0,0 +1,18 @@
+package admin
+
+// Version represents the version of a stored entity. A new entity starts at the
+// first version, and every change increments it, so that a change based on an
+// older version can be rejected instead of overwriting the changes made since.
+//
+// The repositories update or delete an entity only if it is stored with the version
+// of the change, and an update stores the next version. Otherwise, they return a
+// stale domain.ConflictError.
+type Version int
+
+// FirstVersion is the version of a new entity.
+const FirstVersion Version = 1
+
+// Next returns the version following the version.
+func (v Version) Next() Version {
+	return v + 1
+}
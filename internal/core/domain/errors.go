@@ -3,6 +3,7 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 // BadRequestError is the error returned when a request is invalid.
@@ -39,6 +40,13 @@ func (e AccessDeniedError) Error() string {
 	return e.Message
 }
 
+// IsAccessDeniedError returns true if the error is an AccessDeniedError.
+func IsAccessDeniedError(err error) bool {
+	var accessDeniedError AccessDeniedError
+
+	return errors.As(err, &accessDeniedError)
+}
+
 // NotFoundError is the error returned when an object is not found.
 type NotFoundError struct {
 	Message string
@@ -81,8 +89,11 @@ func (e ValidationError) Error() string {
 }
 
 // ConflictError is the error returned when an object conflicts with another object.
+// Stale is true if the object conflicts with its own stored version instead, because
+// it was changed since the version the change is based on.
 type ConflictError struct {
 	Message string
+	Stale   bool
 }
 
 // NewConflictError returns a new ConflictError.
@@ -92,11 +103,37 @@ func NewConflictError(format string, args ...any) ConflictError {
 	}
 }
 
+// NewStaleVersionError returns a new ConflictError for a change based on a stale version.
+func NewStaleVersionError(format string, args ...any) ConflictError {
+	return ConflictError{
+		Message: fmt.Sprintf(format, args...),
+		Stale:   true,
+	}
+}
+
 // Error returns the error message.
 func (e ConflictError) Error() string {
 	return e.Message
 }
 
+// PreconditionRequiredError is the error returned when a change does not tell the
+// version it is based on.
+type PreconditionRequiredError struct {
+	Message string
+}
+
+// NewPreconditionRequiredError returns a new PreconditionRequiredError.
+func NewPreconditionRequiredError(format string, args ...any) PreconditionRequiredError {
+	return PreconditionRequiredError{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Error returns the error message.
+func (e PreconditionRequiredError) Error() string {
+	return e.Message
+}
+
 // StoreError is the error returned when an error occurs while storing an object.
 type StoreError struct {
 	Message string
@@ -165,3 +202,55 @@ func NewUnauthorizedError(format string, args ...any) UnauthorizedError {
 func (e UnauthorizedError) Error() string {
 	return e.Message
 }
+
+// NetworkDeniedError is the error returned when a request comes from a network
+// that is not allowed to use the credentials.
+type NetworkDeniedError struct {
+	Message string
+}
+
+// NewNetworkDeniedError returns a new NetworkDeniedError.
+func NewNetworkDeniedError(format string, args ...any) NetworkDeniedError {
+	return NetworkDeniedError{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Error returns the error message.
+func (e NetworkDeniedError) Error() string {
+	return e.Message
+}
+
+// IsNetworkDeniedError returns true if the error is a NetworkDeniedError.
+func IsNetworkDeniedError(err error) bool {
+	var networkDeniedError NetworkDeniedError
+
+	return errors.As(err, &networkDeniedError)
+}
+
+// RateLimitedError is the error returned when a client exceeds the rate limit.
+// RetryAfter is the time the client should wait before retrying.
+type RateLimitedError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+// NewRateLimitedError returns a new RateLimitedError.
+func NewRateLimitedError(retryAfter time.Duration, format string, args ...any) RateLimitedError {
+	return RateLimitedError{
+		Message:    fmt.Sprintf(format, args...),
+		RetryAfter: retryAfter,
+	}
+}
+
+// Error returns the error message.
+func (e RateLimitedError) Error() string {
+	return e.Message
+}
+
+// IsRateLimitedError returns true if the error is a RateLimitedError.
+func IsRateLimitedError(err error) bool {
+	var rateLimitedError RateLimitedError
+
+	return errors.As(err, &rateLimitedError)
+}
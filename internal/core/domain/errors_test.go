@@ -2,6 +2,7 @@ package domain
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -20,7 +21,14 @@ func TestErrors(t *testing.T) {
 	tester(t, NewValidationError("test:%d", 42), ValidationError{})
 	tester(t, NewStoreError("test:%d", 42), StoreError{})
 	tester(t, NewConflictError("test:%d", 42), ConflictError{})
+	tester(t, NewStaleVersionError("test:%d", 42), ConflictError{})
+	tester(t, NewPreconditionRequiredError("test:%d", 42), PreconditionRequiredError{})
 	tester(t, NewGatewayError("test:%d", 42), GatewayError{})
 	tester(t, NewSessionError("test:%d", 42), SessionError{})
 	tester(t, NewUnauthorizedError("test:%d", 42), UnauthorizedError{})
+	tester(t, NewNetworkDeniedError("test:%d", 42), NetworkDeniedError{})
+	tester(t, NewRateLimitedError(time.Second, "test:%d", 42), RateLimitedError{})
+
+	require.False(t, NewConflictError("test").Stale)
+	require.True(t, NewStaleVersionError("test").Stale)
 }
@@ -0,0 +1,7 @@
+package domain
+
+// KeyHasher is an interface for hashing secret keys.
+// The same key must always produce the same hash.
+type KeyHasher interface {
+	HashKey(key string) string
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimiter is an interface for limiting the rate of hits per key.
+//
+// Allow counts a hit of the key and returns zero if the hit is within the limit
+// of hits per window. Otherwise, it returns the time to wait before the next hit
+// would be allowed.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (time.Duration, error)
+}
@@ -7,9 +7,13 @@ type Session struct {
 }
 
 // Header is a struct that contains session header information.
+//
+// ReturnTo is the validated URI the user should be sent to after login.
+// It is empty if no return URI was requested when the login link was created.
 type Header struct {
 	SessionID string
 	RealmID   string
+	ReturnTo  string
 }
 
 // User is a struct that contains user information.
@@ -9,9 +9,11 @@ import (
 // Service is a service that handles sessions.
 type Service interface {
 	// Link returns a link to the provider's login page.
-	Link(ctx context.Context, realmCode, providerCode, action string) (string, error)
+	// The returnTo URI is optional. If set, it must be allowed by the realm's redirect URIs.
+	Link(ctx context.Context, realmCode, providerCode, action, returnTo string) (string, error)
 
 	// Login completes the login/signup process and returns the session ID.
+	// A locked out user is rejected with a rate limited error before the session is completed.
 	Login(ctx context.Context, code, state string) (string, error)
 
 	// Session returns the session associated with the session ID.
@@ -23,6 +25,14 @@ type Service interface {
 	// Logout logs out the session associated with the session ID.
 	Logout(ctx context.Context, sessionID string) error
 
-	// VerifyAPIKey verifies the API key.
-	VerifyAPIKey(ctx context.Context, realmID admin.ID, apiKey string) error
+	// VerifyAPIKey verifies the API key and returns the principal owning it.
+	// The API key must grant all the given scopes. The client IP is recorded
+	// as the last usage of the API key. Unknown API keys count towards the lockout
+	// of the key prefix and the client IP.
+	VerifyAPIKey(
+		ctx context.Context,
+		realmID admin.ID,
+		apiKey, clientIP string,
+		scopes ...string,
+	) (admin.APIKeyPrincipal, error)
 }
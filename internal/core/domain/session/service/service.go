@@ -27,6 +27,7 @@ type Service struct {
 	realmFinder    admin.RealmLookupService
 	providerFinder admin.ProviderLookupService
 	apiKeyFinder   admin.APIKeyLookupService
+	lockoutGuard   admin.LockoutGuard
 	idGenerator    domain.IDGenerator
 	sessionCache   domain.Cache
 }
@@ -36,6 +37,7 @@ func NewService(
 	realmFinder admin.RealmLookupService,
 	providerFinder admin.ProviderLookupService,
 	apiKeyFinder admin.APIKeyLookupService,
+	lockoutGuard admin.LockoutGuard,
 	idgen domain.IDGenerator,
 	cache domain.Cache,
 ) *Service {
@@ -43,6 +45,7 @@ func NewService(
 		realmFinder:    realmFinder,
 		providerFinder: providerFinder,
 		apiKeyFinder:   apiKeyFinder,
+		lockoutGuard:   lockoutGuard,
 		idGenerator:    idgen,
 		sessionCache:   cache,
 	}
@@ -54,7 +57,7 @@ var _ session.Service = (*Service)(nil)
 // Link implements the session.Service interface.
 //
 //nolint:wrapcheck // see comment in the header
-func (s *Service) Link(ctx context.Context, realmCode, providerCode, action string) (string, error) {
+func (s *Service) Link(ctx context.Context, realmCode, providerCode, action, returnTo string) (string, error) {
 	const defaultAction = "login"
 
 	realm, err := s.realmFinder.LookupRealm(ctx, realmCode)
@@ -62,6 +65,10 @@ func (s *Service) Link(ctx context.Context, realmCode, providerCode, action stri
 		return "", err
 	}
 
+	if returnTo != "" && !realm.IsRedirectAllowed(returnTo) {
+		return "", domain.NewBadRequestError("return URI %s is not allowed for realm %s", returnTo, realmCode)
+	}
+
 	provider, err := s.providerFinder.LookupProvider(ctx, providerCode)
 	if err != nil {
 		return "", err
@@ -76,7 +83,7 @@ func (s *Service) Link(ctx context.Context, realmCode, providerCode, action stri
 	}
 
 	// save oauthCfg in the session
-	us := newUserSession(realm.ID.String(), oauthCfg)
+	us := newUserSession(realm.ID.String(), provider.ID.String(), returnTo, oauthCfg)
 
 	if pErr := s.sessionCache.Put(ctx, sessionID, us, sessionTTL); pErr != nil {
 		return "", pErr
@@ -132,6 +139,15 @@ func (s *Service) Login(ctx context.Context, code, state string) (string, error)
 
 	user := toIdentityUser(ui)
 
+	// the session of a locked out user is not completed
+	userKey := admin.LockoutKey{Kind: admin.LockoutKindUser, RealmID: admin.ID(us.RealmID), Subject: user.BindID}
+
+	if lErr := s.lockoutGuard.CheckLockout(ctx, userKey); lErr != nil {
+		s.silentlyDeleteSession(ctx, sessionID)
+
+		return "", lErr
+	}
+
 	us.updateToken(token)
 	us.updateUser(user)
 
@@ -159,6 +175,7 @@ func (s *Service) Session(ctx context.Context, sessionID string) (session.Sessio
 		Header: session.Header{
 			SessionID: sessionID,
 			RealmID:   us.RealmID,
+			ReturnTo:  us.ReturnTo,
 		},
 		User: us.User,
 	}, nil
@@ -233,10 +250,37 @@ func (s *Service) Logout(ctx context.Context, sessionID string) error {
 // VerifyAPIKey implements the session.Service interface.
 //
 //nolint:wrapcheck // see comment in the header
-func (s *Service) VerifyAPIKey(ctx context.Context, realmID admin.ID, apiKey string) error {
-	_, err := s.apiKeyFinder.LookupAPIKey(ctx, realmID, apiKey)
+func (s *Service) VerifyAPIKey(
+	ctx context.Context,
+	realmID admin.ID,
+	apiKey, clientIP string,
+	scopes ...string,
+) (admin.APIKeyPrincipal, error) {
+	// unknown API keys count as failed authentications of the key prefix and the client IP
+	lockoutKeys := []admin.LockoutKey{
+		{Kind: admin.LockoutKindAPIKeyPrefix, RealmID: realmID, Subject: admin.APIKeyPrefix(apiKey)},
+		{Kind: admin.LockoutKindClientIP, Subject: clientIP},
+	}
 
-	return err
+	if err := s.lockoutGuard.CheckLockout(ctx, lockoutKeys...); err != nil {
+		return admin.APIKeyPrincipal{}, err
+	}
+
+	principal, err := s.apiKeyFinder.LookupAPIKey(ctx, realmID, apiKey, clientIP)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			s.lockoutGuard.RecordFailure(ctx, lockoutKeys...)
+		}
+
+		return admin.APIKeyPrincipal{}, err
+	}
+
+	if !principal.APIKey.HasScopes(scopes...) {
+		return admin.APIKeyPrincipal{}, domain.NewAccessDeniedError("API key %s does not grant scopes %v",
+			principal.APIKey.ID, scopes)
+	}
+
+	return principal, nil
 }
 
 //nolint:wrapcheck // see comment in the header
@@ -252,9 +296,40 @@ func (s *Service) findUserSession(ctx context.Context, sessionID string) (userSe
 		return userSession{}, domain.NewNotFoundError("invalid session ID: %s", sessionID)
 	}
 
+	if err := s.checkSessionOrigin(ctx, us); err != nil {
+		if domain.IsNotFoundError(err) {
+			s.silentlyDeleteSession(ctx, sessionID)
+
+			return userSession{}, domain.NewNotFoundError("invalid session ID: %s: %v", sessionID, err)
+		}
+
+		return userSession{}, err
+	}
+
 	return us, nil
 }
 
+// checkSessionOrigin checks that the realm and the provider of the session still
+// exist. The sessions of a deleted realm or provider are invalidated on their next
+// use, since the cache cannot enumerate them.
+//
+//nolint:wrapcheck // see comment in the header
+func (s *Service) checkSessionOrigin(ctx context.Context, us userSession) error {
+	if _, err := s.realmFinder.LookupRealmByID(ctx, admin.ID(us.RealmID)); err != nil {
+		return err
+	}
+
+	if us.ProviderID == "" {
+		return nil
+	}
+
+	if _, err := s.providerFinder.LookupProviderByID(ctx, admin.ID(us.ProviderID)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Service) sessionProvider(session userSession) (oauth.Provider, error) { //nolint:ireturn
 	provider, err := providers.NewProvider(session.Config)
 	if err != nil {
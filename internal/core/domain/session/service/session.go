@@ -8,19 +8,25 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// userSession is the state of a session kept in the cache.
+// The sessions started before the provider was recorded have no provider ID.
 type userSession struct {
-	RealmID   string        `json:"realmId"`
-	Config    *oauth.Config `json:"config"`
-	Token     *oauth2.Token `json:"token,omitempty"`
-	User      session.User  `json:"user,omitempty"`
-	Timestamp time.Time     `json:"timestamp"`
+	RealmID    string        `json:"realmId"`
+	ProviderID string        `json:"providerId,omitempty"`
+	ReturnTo   string        `json:"returnTo,omitempty"`
+	Config     *oauth.Config `json:"config"`
+	Token      *oauth2.Token `json:"token,omitempty"`
+	User       session.User  `json:"user,omitempty"`
+	Timestamp  time.Time     `json:"timestamp"`
 }
 
-func newUserSession(realmID string, config *oauth.Config) *userSession {
+func newUserSession(realmID, providerID, returnTo string, config *oauth.Config) *userSession {
 	return &userSession{
-		RealmID:   realmID,
-		Config:    config,
-		Timestamp: time.Now(),
+		RealmID:    realmID,
+		ProviderID: providerID,
+		ReturnTo:   returnTo,
+		Config:     config,
+		Timestamp:  time.Now(),
 	}
 }
 
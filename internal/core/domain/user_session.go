@@ -1,11 +1,25 @@
 package domain
 
+import "time"
+
 // UserSession is a struct that contains user session information.
+//
+// RealmID is the realm the user signed in to, which is the realm of the user.
+// ActiveRealmID is the realm the user switched to, if any. The user must be a
+// member of the active realm.
+//
+// ImpersonatorID, ImpersonatorRealmID and ImpersonatorRole identify the admin who
+// signed in and acts as the user until ImpersonationExpiresAt, if any.
 type UserSession struct {
-	SessionID string
-	RealmID   string
-	UserID    string
-	UserRole  string
+	SessionID              string
+	RealmID                string
+	UserID                 string
+	UserRole               string
+	ActiveRealmID          string
+	ImpersonatorID         string
+	ImpersonatorRealmID    string
+	ImpersonatorRole       string
+	ImpersonationExpiresAt time.Time
 }
 
 // NewUserSession creates a new UserSession with the given parameters.
@@ -17,3 +31,43 @@ func NewUserSession(sessionID, realmID, userID, userRole string) UserSession {
 		UserRole:  userRole,
 	}
 }
+
+// InActiveRealm returns true if the user switched to a realm other than its own.
+func (us UserSession) InActiveRealm() bool {
+	return us.ActiveRealmID != "" && us.ActiveRealmID != us.RealmID
+}
+
+// IsImpersonated returns true if an admin acts as the user of the session.
+func (us UserSession) IsImpersonated() bool {
+	return us.ImpersonatorID != ""
+}
+
+// ImpersonationExpired returns true if the impersonation has expired at the given time.
+func (us UserSession) ImpersonationExpired(now time.Time) bool {
+	return us.IsImpersonated() && !now.Before(us.ImpersonationExpiresAt)
+}
+
+// Impersonate returns the session acting as the given user until expiresAt.
+// The current user is recorded as the impersonator; the active realm is reset.
+func (us UserSession) Impersonate(realmID, userID, userRole string, expiresAt time.Time) UserSession {
+	return UserSession{
+		SessionID:              us.SessionID,
+		RealmID:                realmID,
+		UserID:                 userID,
+		UserRole:               userRole,
+		ImpersonatorID:         us.UserID,
+		ImpersonatorRealmID:    us.RealmID,
+		ImpersonatorRole:       us.UserRole,
+		ImpersonationExpiresAt: expiresAt,
+	}
+}
+
+// EndImpersonation returns the session of the impersonator.
+// The session is returned unchanged if it is not impersonated.
+func (us UserSession) EndImpersonation() UserSession {
+	if !us.IsImpersonated() {
+		return us
+	}
+
+	return NewUserSession(us.SessionID, us.ImpersonatorRealmID, us.ImpersonatorID, us.ImpersonatorRole)
+}
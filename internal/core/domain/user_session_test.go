@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserSession_Impersonate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	us := NewUserSession("s1", "r0", "admin1", "admin")
+	us.ActiveRealmID = "r2"
+
+	impersonated := us.Impersonate("r1", "u1", "user", now.Add(time.Hour))
+
+	require.Equal(t, UserSession{
+		SessionID:              "s1",
+		RealmID:                "r1",
+		UserID:                 "u1",
+		UserRole:               "user",
+		ImpersonatorID:         "admin1",
+		ImpersonatorRealmID:    "r0",
+		ImpersonatorRole:       "admin",
+		ImpersonationExpiresAt: now.Add(time.Hour),
+	}, impersonated)
+	require.True(t, impersonated.IsImpersonated())
+	require.False(t, impersonated.ImpersonationExpired(now))
+	require.True(t, impersonated.ImpersonationExpired(now.Add(time.Hour)))
+
+	require.Equal(t, NewUserSession("s1", "r0", "admin1", "admin"), impersonated.EndImpersonation())
+
+	require.False(t, us.IsImpersonated())
+	require.False(t, us.ImpersonationExpired(now))
+	require.Equal(t, us, us.EndImpersonation())
+}
@@ -0,0 +1,2 @@
+// Package keygen provides a generator for random secret keys.
+package keygen
@@ -0,0 +1,51 @@
+package keygen
+
+import (
+	"crypto/rand"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+)
+
+// alphabet is the base62 alphabet of the generated keys.
+const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// keyLength is the length of the generated keys. 32 base62 characters
+// carry about 190 bits of entropy.
+const keyLength = 32
+
+// maxByte is the largest multiple of the alphabet size that fits in a byte.
+// Random bytes above it are discarded to avoid a modulo bias.
+const maxByte = 256 - 256%len(alphabet)
+
+// Generator generates secret keys from a cryptographically secure random source.
+//
+// It implements the domain.IDGenerator interface.
+type Generator struct{}
+
+// Ensure Generator implements the domain.IDGenerator interface.
+var _ domain.IDGenerator = (*Generator)(nil)
+
+// NewGenerator returns a new Generator instance.
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// GenerateID implements the domain.IDGenerator interface.
+// It returns a random base62 string of 32 characters.
+func (g *Generator) GenerateID() string {
+	key := make([]byte, 0, keyLength)
+	buf := make([]byte, keyLength)
+
+	for len(key) < keyLength {
+		// crypto/rand.Read never returns an error on supported platforms
+		_, _ = rand.Read(buf)
+
+		for _, b := range buf {
+			if int(b) < maxByte && len(key) < keyLength {
+				key = append(key, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+
+	return string(key)
+}
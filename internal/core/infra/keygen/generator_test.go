@@ -0,0 +1,31 @@
+package keygen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GenerateID(t *testing.T) {
+	t.Parallel()
+
+	g := NewGenerator()
+
+	generated := make(map[string]struct{})
+
+	for range 200 {
+		key := g.GenerateID()
+
+		require.Len(t, key, keyLength)
+
+		for _, r := range key {
+			require.True(t, strings.ContainsRune(alphabet, r), "unexpected character %q", r)
+		}
+
+		_, found := generated[key]
+		require.False(t, found, "duplicated key %s", key)
+
+		generated[key] = struct{}{}
+	}
+}
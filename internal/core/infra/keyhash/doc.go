@@ -0,0 +1,2 @@
+// Package keyhash implements keyed hashing of secret keys.
+package keyhash
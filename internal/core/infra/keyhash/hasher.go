@@ -0,0 +1,40 @@
+package keyhash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+)
+
+// Hasher hashes keys with HMAC-SHA256 using a server-side secret.
+//
+// It implements the domain.KeyHasher interface.
+type Hasher struct {
+	secret []byte
+}
+
+// Ensure Hasher implements the domain.KeyHasher interface.
+var _ domain.KeyHasher = (*Hasher)(nil)
+
+// NewHasher returns a new Hasher instance. The secret must not be empty.
+func NewHasher(secret string) (*Hasher, error) {
+	if secret == "" {
+		return nil, errors.New("missing key hash secret")
+	}
+
+	return &Hasher{secret: []byte(secret)}, nil
+}
+
+// HashKey implements the domain.KeyHasher interface.
+// It returns the hex encoded HMAC-SHA256 of the key.
+func (h *Hasher) HashKey(key string) string {
+	mac := hmac.New(sha256.New, h.secret)
+
+	// hash.Hash never returns an error on write
+	_, _ = mac.Write([]byte(key))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,32 @@
+package keyhash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHasher(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewHasher("")
+	require.Error(t, err)
+
+	h, err := NewHasher("secret")
+	require.NoError(t, err)
+	require.NotNil(t, h)
+}
+
+func TestHasher_HashKey(t *testing.T) {
+	t.Parallel()
+
+	h1, _ := NewHasher("secret1")
+	h2, _ := NewHasher("secret2")
+
+	hash := h1.HashKey("key")
+
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, h1.HashKey("key"))
+	require.NotEqual(t, hash, h1.HashKey("key2"))
+	require.NotEqual(t, hash, h2.HashKey("key"))
+}
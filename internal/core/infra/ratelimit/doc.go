@@ -0,0 +1,2 @@
+// Package ratelimit implements a sliding window rate limiter.
+package ratelimit
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/go-kit/slog"
+	"github.com/energimind/identity-server/internal/core/domain"
+)
+
+// Store is an interface for counting hits in fixed windows.
+//
+// IncrementWindow increments the counter of the key in the window with the given
+// index and returns the counters of the previous and of the current window.
+// The counters must be kept for at least two windows.
+type Store interface {
+	IncrementWindow(ctx context.Context, key string, index int64, window time.Duration) (int64, int64, error)
+}
+
+// Limiter is a sliding window rate limiter.
+//
+// It implements the domain.RateLimiter interface.
+//
+// The hits are counted in fixed windows by the store. The sliding window count is
+// approximated by weighting the count of the previous window with the part of it
+// that still overlaps the sliding window. If the store fails, the hits are counted
+// in memory instead, so that the limits stay in effect per instance.
+type Limiter struct {
+	store    Store
+	fallback *MemoryStore
+	now      func() time.Time
+}
+
+// Ensure Limiter implements the domain.RateLimiter interface.
+var _ domain.RateLimiter = (*Limiter)(nil)
+
+// NewLimiter returns a new Limiter instance counting the hits in the given store.
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{
+		store:    store,
+		fallback: NewMemoryStore(),
+		now:      time.Now,
+	}
+}
+
+// Allow implements the domain.RateLimiter interface.
+// Hits are not limited if the limit or the window is not positive.
+func (l *Limiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (time.Duration, error) {
+	if limit <= 0 || window <= 0 {
+		return 0, nil
+	}
+
+	now := l.now()
+	index := now.UnixNano() / int64(window)
+	elapsed := time.Duration(now.UnixNano() - index*int64(window))
+
+	previous, current, err := l.store.IncrementWindow(ctx, key, index, window)
+	if err != nil {
+		slog.Warn().Err(err).Str("key", key).Msg("Rate limit store failed, counting in memory")
+
+		previous, current, err = l.fallback.IncrementWindow(ctx, key, index, window)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return retryAfter(previous, current, int64(limit), window, elapsed), nil
+}
+
+// retryAfter returns zero if the approximated count of the sliding window is within
+// the limit. Otherwise, it returns the time until the next hit would be allowed.
+// The current count includes the hit being checked.
+func retryAfter(previous, current, limit int64, window, elapsed time.Duration) time.Duration {
+	overlap := 1 - float64(elapsed)/float64(window)
+
+	if float64(previous)*overlap+float64(current) <= float64(limit) {
+		return 0
+	}
+
+	// the next hit fits into the current window once enough of the previous window
+	// has slid out of the sliding window
+	if current+1 <= limit {
+		wait := float64(window)*(1-float64(limit-current-1)/float64(previous)) - float64(elapsed)
+
+		return max(time.Duration(wait), time.Millisecond)
+	}
+
+	// otherwise, the next hit must wait for the next window, where the current
+	// window becomes the previous one
+	wait := float64(window)*(1-float64(max(limit-1, 0))/float64(current)) + float64(window-elapsed)
+
+	return max(time.Duration(wait), time.Millisecond)
+}
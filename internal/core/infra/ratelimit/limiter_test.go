@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		store Store
+	}{
+		"store":    {store: NewMemoryStore()},
+		"fallback": {store: failingStore{}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			limiter := NewLimiter(test.store)
+			start := time.Unix(0, 0)
+			limiter.now = func() time.Time { return start }
+
+			for range 3 {
+				retryAfter, err := limiter.Allow(context.Background(), "key", 3, time.Minute)
+				require.NoError(t, err)
+				require.Zero(t, retryAfter)
+			}
+
+			retryAfter, err := limiter.Allow(context.Background(), "key", 3, time.Minute)
+			require.NoError(t, err)
+			require.Positive(t, retryAfter)
+
+			// other keys are not affected
+			retryAfter, err = limiter.Allow(context.Background(), "other", 3, time.Minute)
+			require.NoError(t, err)
+			require.Zero(t, retryAfter)
+		})
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	t.Parallel()
+
+	const window = time.Minute
+
+	tests := map[string]struct {
+		previous int64
+		current  int64
+		limit    int64
+		elapsed  time.Duration
+		want     time.Duration
+	}{
+		"withinLimit": {
+			previous: 0,
+			current:  3,
+			limit:    3,
+			want:     0,
+		},
+		"previousSlidOut": {
+			previous: 10,
+			current:  2,
+			limit:    3,
+			elapsed:  55 * time.Second,
+			want:     0,
+		},
+		"previousOverlaps": {
+			previous: 6,
+			current:  2,
+			limit:    4,
+			elapsed:  30 * time.Second,
+			want:     20 * time.Second,
+		},
+		"currentExceeded": {
+			previous: 0,
+			current:  4,
+			limit:    3,
+			elapsed:  30 * time.Second,
+			want:     60 * time.Second,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.want, retryAfter(test.previous, test.current, test.limit, window, test.elapsed))
+		})
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) IncrementWindow(context.Context, string, int64, time.Duration) (int64, int64, error) {
+	return 0, 0, errors.New("store failed")
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the interval of removing stale counters from the memory store.
+const sweepInterval = time.Minute
+
+// MemoryStore counts hits in memory. The counts are local to the process.
+//
+// It implements the Store interface.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+type memoryCounter struct {
+	index    int64
+	previous int64
+	current  int64
+	expires  time.Time
+}
+
+// Ensure MemoryStore implements the Store interface.
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns a new MemoryStore instance.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*memoryCounter),
+	}
+}
+
+// IncrementWindow implements the Store interface.
+func (s *MemoryStore) IncrementWindow(_ context.Context, key string, index int64, window time.Duration) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	s.sweep(now)
+
+	counter, found := s.counters[key]
+	if !found {
+		counter = &memoryCounter{index: index}
+		s.counters[key] = counter
+	}
+
+	switch {
+	case counter.index == index-1:
+		counter.previous, counter.current = counter.current, 0
+	case counter.index != index:
+		counter.previous, counter.current = 0, 0
+	}
+
+	counter.index = index
+	counter.current++
+	counter.expires = now.Add(2 * window)
+
+	return counter.previous, counter.current, nil
+}
+
+// sweep removes the expired counters. It runs at most once per sweep interval.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	s.lastSweep = now
+
+	for key, counter := range s.counters {
+		if now.After(counter.expires) {
+			delete(s.counters, key)
+		}
+	}
+}
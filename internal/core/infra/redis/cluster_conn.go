@@ -60,3 +60,7 @@ func (c *clusterConnection) get(ctx context.Context, key string) *redis.StringCm
 func (c *clusterConnection) delete(ctx context.Context, key string) *redis.IntCmd {
 	return c.cluster.Del(ctx, key)
 }
+
+func (c *clusterConnection) run(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd {
+	return script.Run(ctx, c.cluster, keys, args...)
+}
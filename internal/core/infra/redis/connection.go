@@ -24,4 +24,5 @@ type connection interface {
 	set(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd
 	get(ctx context.Context, key string) *redis.StringCmd
 	delete(ctx context.Context, key string) *redis.IntCmd
+	run(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd
 }
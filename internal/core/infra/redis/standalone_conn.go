@@ -59,3 +59,7 @@ func (c *standaloneConnection) get(ctx context.Context, key string) *redis.Strin
 func (c *standaloneConnection) delete(ctx context.Context, key string) *redis.IntCmd {
 	return c.client.Del(ctx, key)
 }
+
+func (c *standaloneConnection) run(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd {
+	return script.Run(ctx, c.client, keys, args...)
+}
@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrementWindowScript increments the counter of the current window and returns
+// the counters of the previous and of the current window. The counter expires
+// after two windows, when it cannot be the previous window anymore.
+var incrementWindowScript = redis.NewScript(`
+local current = redis.call("INCR", KEYS[2])
+if current == 1 then
+	redis.call("PEXPIRE", KEYS[2], ARGV[1])
+end
+local previous = tonumber(redis.call("GET", KEYS[1]) or "0")
+return {previous, current}
+`)
+
+// IncrementWindow increments the hit counter of the key in the window with the given index.
+// It returns the counters of the previous and of the current window.
+//
+// It implements the ratelimit.Store interface.
+func (c *Cache) IncrementWindow(ctx context.Context, key string, index int64, window time.Duration) (int64, int64, error) {
+	// the hash tag keeps both windows of a key in the same cluster slot
+	keys := []string{
+		c.fqn(fmt.Sprintf("ratelimit.{%s}.%d", key, index-1)),
+		c.fqn(fmt.Sprintf("ratelimit.{%s}.%d", key, index)),
+	}
+
+	counters, err := c.conn.run(ctx, incrementWindowScript, keys, (2 * window).Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, NewCacheError("failed to increment window counter: %v", err)
+	}
+
+	if len(counters) != 2 { //nolint:mnd
+		return 0, 0, NewCacheError("unexpected window counter reply: %v", counters)
+	}
+
+	return counters[0], counters[1], nil
+}
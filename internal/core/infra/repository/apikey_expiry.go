@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// expiringAPIKeyFilter returns a filter matching enabled API keys that have an
+// expiration time not after the given time. API keys without an expiration time
+// are stored with the zero time and never match.
+func expiringAPIKeyFilter(before time.Time) bson.M {
+	return bson.M{
+		"enabled":   true,
+		"expiresAt": bson.M{"$gt": time.Time{}, "$lte": before},
+	}
+}
+
+// getAPIKeysExpiringBefore returns the enabled API keys of the given collection
+// that expire not after the given time.
+func getAPIKeysExpiringBefore(
+	ctx context.Context,
+	coll *mongo.Collection,
+	before time.Time,
+) ([]admin.OwnedAPIKey, error) {
+	qFilter := live(bson.M{"apiKeys": bson.M{"$elemMatch": expiringAPIKeyFilter(before)}})
+
+	qCursor, err := coll.Find(ctx, qFilter)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to find expiring API keys: %v", err)
+	}
+
+	owners := make([]apiKeyOwner, 0)
+
+	if err := qCursor.All(ctx, &owners); err != nil {
+		return nil, domain.NewStoreError("failed to get expiring API keys: %v", err)
+	}
+
+	expiring := make([]admin.OwnedAPIKey, 0, len(owners))
+
+	for _, owner := range owners {
+		for _, dbKey := range owner.APIKeys {
+			apiKey := fromAPIKey(dbKey)
+
+			if apiKey.Enabled && !apiKey.ExpiresAt.IsZero() && !apiKey.ExpiresAt.After(before) {
+				expiring = append(expiring, admin.OwnedAPIKey{
+					RealmID: fromID(owner.RealmID),
+					OwnerID: fromID(owner.ID),
+					APIKey:  apiKey,
+				})
+			}
+		}
+	}
+
+	return expiring, nil
+}
+
+// disableExpiredAPIKeys disables the expired API keys of the given collection.
+// It returns the number of users or daemons that had expired API keys.
+//
+// The versions of the users or daemons are incremented, so that the changes based
+// on a version with enabled API keys do not enable them again.
+func disableExpiredAPIKeys(ctx context.Context, coll *mongo.Collection, now time.Time) (int, error) {
+	const expired = "expired"
+
+	qFilter := live(bson.M{"apiKeys": bson.M{"$elemMatch": expiringAPIKeyFilter(now)}})
+	qUpdate := bson.M{
+		"$set": bson.M{"apiKeys.$[" + expired + "].enabled": false},
+		"$inc": bson.M{"version": 1},
+	}
+	qOptions := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []any{bson.M{
+			expired + ".enabled":   true,
+			expired + ".expiresAt": bson.M{"$gt": time.Time{}, "$lte": now},
+		}},
+	})
+
+	result, err := coll.UpdateMany(ctx, qFilter, qUpdate, qOptions)
+	if err != nil {
+		return 0, domain.NewStoreError("failed to disable expired API keys: %v", err)
+	}
+
+	return int(result.ModifiedCount), nil
+}
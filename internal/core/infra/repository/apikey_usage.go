@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recordAPIKeyUsage adds the usage to the API keys of the given collection in a single
+// unordered bulk write. Usages of API keys not found in the collection are ignored.
+// The last used time and IP are only replaced by a more recent usage, so that
+// out of order writes from several instances do not move them backwards.
+//
+// The count is added to the day of the usage, and the days before the usage window
+// are dropped from the key.
+func recordAPIKeyUsage(ctx context.Context, coll *mongo.Collection, usages []admin.APIKeyUsage) error {
+	if len(usages) == 0 {
+		return nil
+	}
+
+	const used = "used"
+
+	models := make([]mongo.WriteModel, 0, 2*len(usages))
+
+	for _, usage := range usages {
+		qFilter := bson.M{"apiKeys.id": toID(usage.APIKeyID)}
+		qCount := addUsageDay(usage.APIKeyID, usage.LastUsedAt, usage.Count)
+		qLastUsed := bson.M{"$set": bson.M{
+			"apiKeys.$[" + used + "].lastUsedAt": usage.LastUsedAt,
+			"apiKeys.$[" + used + "].lastUsedIp": usage.LastUsedIP,
+		}}
+		qArrayFilters := options.ArrayFilters{
+			Filters: []any{bson.M{
+				used + ".id":         toID(usage.APIKeyID),
+				used + ".lastUsedAt": bson.M{"$not": bson.M{"$gte": usage.LastUsedAt}},
+			}},
+		}
+
+		models = append(models,
+			mongo.NewUpdateOneModel().SetFilter(qFilter).SetUpdate(qCount),
+			mongo.NewUpdateOneModel().SetFilter(qFilter).SetUpdate(qLastUsed).SetArrayFilters(qArrayFilters),
+		)
+	}
+
+	if _, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return domain.NewStoreError("failed to record API key usage: %v", err)
+	}
+
+	return nil
+}
+
+// addUsageDay returns the update pipeline that adds the count to the usage of the day
+// of the given time of the API key, and drops the days before the usage window.
+func addUsageDay(id admin.ID, usedAt time.Time, count int64) bson.A {
+	day, oldest := admin.UsageDay(usedAt), admin.OldestUsageDay(usedAt)
+	qUsageDays := bson.M{"$ifNull": bson.A{"$$key.usageDays", bson.M{}}}
+
+	qKept := bson.M{"$filter": bson.M{
+		"input": bson.M{"$objectToArray": qUsageDays},
+		"as":    "day",
+		"cond": bson.M{"$and": bson.A{
+			bson.M{"$gte": bson.A{"$$day.k", oldest}},
+			bson.M{"$ne": bson.A{"$$day.k", day}},
+		}},
+	}}
+	qDayCount := bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{bson.M{"$getField": bson.M{"field": bson.M{"$literal": day}, "input": qUsageDays}}, 0}},
+		count,
+	}}
+	qAdded := bson.M{"$arrayToObject": bson.M{"$concatArrays": bson.A{qKept, bson.A{bson.M{"k": day, "v": qDayCount}}}}}
+
+	qAPIKeys := bson.M{"$map": bson.M{
+		"input": "$apiKeys",
+		"as":    "key",
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{"$$key.id", toID(id)}},
+			bson.M{"$mergeObjects": bson.A{"$$key", bson.M{"usageDays": qAdded}}},
+			"$$key",
+		}},
+	}}
+
+	return bson.A{bson.M{"$set": bson.M{"apiKeys": qAPIKeys}}}
+}
+
+// keepAPIKeyUsage returns the update pipeline that sets the fields of the document
+// while keeping the stored usage of its API keys. The usage is only written by
+// recordAPIKeyUsage: a document read before a usage was recorded must not reset it
+// when it is written back.
+//
+// The values are set as literals, so that strings starting with a dollar sign are
+// not taken as field paths.
+func keepAPIKeyUsage(doc any) (bson.A, error) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to encode document: %v", err)
+	}
+
+	fields := bson.M{}
+
+	if err := bson.Unmarshal(data, &fields); err != nil {
+		return nil, domain.NewStoreError("failed to decode document: %v", err)
+	}
+
+	apiKeys, found := fields["apiKeys"]
+	if !found {
+		apiKeys = bson.A{}
+	}
+
+	delete(fields, "apiKeys")
+
+	qSet := make(bson.M, len(fields))
+
+	for name, value := range fields {
+		qSet[name] = bson.M{"$literal": value}
+	}
+
+	qStored := bson.M{"$arrayElemAt": bson.A{
+		bson.M{"$filter": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$apiKeys", bson.A{}}},
+			"as":    "stored",
+			"cond":  bson.M{"$eq": bson.A{"$$stored.id", "$$key.id"}},
+		}},
+		0,
+	}}
+	qUsage := bson.M{
+		"lastUsedAt": bson.M{"$ifNull": bson.A{"$$stored.lastUsedAt", "$$key.lastUsedAt"}},
+		"lastUsedIp": bson.M{"$ifNull": bson.A{"$$stored.lastUsedIp", "$$key.lastUsedIp"}},
+		"usageDays":  bson.M{"$ifNull": bson.A{"$$stored.usageDays", "$$key.usageDays"}},
+	}
+	qAPIKeys := bson.M{"$map": bson.M{
+		"input": bson.M{"$literal": apiKeys},
+		"as":    "key",
+		"in": bson.M{"$let": bson.M{
+			"vars": bson.M{"stored": qStored},
+			"in":   bson.M{"$mergeObjects": bson.A{"$$key", qUsage}},
+		}},
+	}}
+
+	return bson.A{bson.M{"$set": bson.M{"apiKeys": qAPIKeys}}, bson.M{"$set": qSet}}, nil
+}
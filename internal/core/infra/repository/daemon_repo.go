@@ -3,11 +3,13 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // DaemonRepository is a MongoDB implementation of DaemonRepository.
@@ -27,21 +29,25 @@ var _ admin.DaemonRepository = (*DaemonRepository)(nil)
 func (r *DaemonRepository) GetDaemons(
 	ctx context.Context,
 	realmID admin.ID,
-) ([]admin.Daemon, error) {
+	filter admin.DaemonFilter,
+	listOptions admin.ListOptions,
+) (admin.Page[admin.Daemon], error) {
 	coll := r.db.Collection("daemons")
-	qFilter := bson.M{"realmId": realmID}
+	qFilter := live(bson.M{"realmId": realmID})
 
-	qCursor, err := coll.Find(ctx, qFilter)
-	if err != nil {
-		return nil, domain.NewStoreError("failed to find daemons: %v", err)
+	if filter.Enabled != nil {
+		qFilter["enabled"] = *filter.Enabled
 	}
 
-	daemons, err := drainCursor[dbDaemon](ctx, qCursor, fromDaemon)
-	if err != nil {
-		return nil, domain.NewStoreError("failed to get daemons: %v", err)
+	if filter.CodePrefix != "" {
+		qFilter["code"] = matchPrefix(filter.CodePrefix)
 	}
 
-	return daemons, nil
+	if !filter.CreatedAfter.IsZero() || !filter.CreatedBefore.IsZero() {
+		qFilter["createdAt"] = matchRange(filter.CreatedAfter, filter.CreatedBefore)
+	}
+
+	return findPage(ctx, coll, "daemons", qFilter, listOptions, fromDaemon)
 }
 
 // GetDaemon implements the admin.DaemonRepository interface.
@@ -50,7 +56,7 @@ func (r *DaemonRepository) GetDaemon(
 	realmID, id admin.ID,
 ) (admin.Daemon, error) {
 	coll := r.db.Collection("daemons")
-	qFilter := bson.M{"id": id, "realmId": realmID}
+	qFilter := live(bson.M{"id": id, "realmId": realmID})
 	daemon := dbDaemon{}
 
 	if err := coll.FindOne(ctx, qFilter).Decode(&daemon); err != nil {
@@ -84,16 +90,23 @@ func (r *DaemonRepository) UpdateDaemon(
 	daemon admin.Daemon,
 ) error {
 	coll := r.db.Collection("daemons")
-	qFilter := bson.M{"id": daemon.ID}
-	qUpdate := bson.M{"$set": toDaemon(daemon)}
+	qFilter := live(bson.M{"id": daemon.ID})
+	version := daemon.Version
+
+	daemon.Version = version.Next()
 
-	result, err := coll.UpdateOne(ctx, qFilter, qUpdate)
+	qUpdate, err := keepAPIKeyUsage(toDaemon(daemon))
+	if err != nil {
+		return err
+	}
+
+	result, err := coll.UpdateOne(ctx, withVersion(qFilter, version), qUpdate)
 	if err != nil {
 		return domain.NewStoreError("failed to update daemon: %v", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return domain.NewNotFoundError("daemon %v not found", daemon.ID)
+		return missingOrStale(ctx, coll, qFilter, "daemon", daemon.ID, version)
 	}
 
 	return nil
@@ -103,54 +116,149 @@ func (r *DaemonRepository) UpdateDaemon(
 func (r *DaemonRepository) DeleteDaemon(
 	ctx context.Context,
 	realmID, id admin.ID,
+	version admin.Version,
+	deletedAt time.Time,
 ) error {
 	coll := r.db.Collection("daemons")
 	qFilter := bson.M{"id": id, "realmId": realmID}
 
-	result, err := coll.DeleteOne(ctx, qFilter)
-	if err != nil {
-		return domain.NewStoreError("failed to delete daemon: %v", err)
-	}
+	return softDelete(ctx, coll, qFilter, "daemon", id, version, deletedAt)
+}
 
-	if result.DeletedCount == 0 {
-		return domain.NewNotFoundError("daemon %v not found", id)
-	}
+// RestoreDaemon implements the admin.DaemonRepository interface.
+func (r *DaemonRepository) RestoreDaemon(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (time.Time, error) {
+	coll := r.db.Collection("daemons")
+	qFilter := bson.M{"id": id, "realmId": realmID}
 
-	return nil
+	return restore(ctx, coll, qFilter, "daemon", id)
+}
+
+// GetDeletedDaemon implements the admin.DaemonRepository interface.
+func (r *DaemonRepository) GetDeletedDaemon(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (admin.Daemon, error) {
+	coll := r.db.Collection("daemons")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return findDeleted(ctx, coll, qFilter, "daemon", id, fromDaemon)
+}
+
+// SearchDaemons implements the admin.DaemonRepository interface.
+func (r *DaemonRepository) SearchDaemons(
+	ctx context.Context,
+	realmID admin.ID,
+	text string,
+	limit int,
+) ([]admin.SearchHit, error) {
+	return searchText(ctx, r.db.Collection("daemons"), "daemons", toID(realmID), text, daemonSearchFields, limit, fromDaemonHit)
 }
 
-// GetAPIKey implements the admin.UserRepository interface.
+// GetAPIKeysByPrefix implements the admin.DaemonRepository interface.
 //
-// This method takes in account the enabled field of the user and the API key.
-func (r *DaemonRepository) GetAPIKey(
+// This method takes in account the enabled field of the daemon and the API key.
+func (r *DaemonRepository) GetAPIKeysByPrefix(
 	ctx context.Context,
 	realmID admin.ID,
-	key string,
-) (admin.APIKey, error) {
+	prefix string,
+) ([]admin.OwnedAPIKey, error) {
 	coll := r.db.Collection("daemons")
-	qFilter := bson.M{
+	qFilter := live(bson.M{
 		"realmId": realmID,
 		"enabled": true,
 		"apiKeys": bson.M{"$elemMatch": bson.M{
-			"key":     key,
+			"prefix":  prefix,
 			"enabled": true,
 		}},
-	}
-	daemon := dbDaemon{}
+	})
 
-	if err := coll.FindOne(ctx, qFilter).Decode(&daemon); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return admin.APIKey{}, domain.NewNotFoundError("API key not found")
-		}
+	qCursor, err := coll.Find(ctx, qFilter)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to find API keys: %v", err)
+	}
 
-		return admin.APIKey{}, domain.NewStoreError("failed to API api key: %v", err)
+	daemons, err := drainCursor[dbDaemon](ctx, qCursor, fromDaemon)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to get API keys: %v", err)
 	}
 
-	for _, apiKey := range daemon.APIKeys {
-		if apiKey.Key == key {
-			return fromAPIKey(apiKey), nil
+	apiKeys := make([]admin.OwnedAPIKey, 0, len(daemons))
+
+	for _, daemon := range daemons {
+		for _, apiKey := range daemon.APIKeys {
+			if apiKey.Prefix == prefix && apiKey.Enabled {
+				apiKeys = append(apiKeys, admin.OwnedAPIKey{
+					RealmID: daemon.RealmID,
+					OwnerID: daemon.ID,
+					APIKey:  apiKey,
+				})
+			}
 		}
 	}
 
-	return admin.APIKey{}, domain.NewNotFoundError("API key not found")
+	return apiKeys, nil
+}
+
+// GetAPIKeysExpiringBefore implements the admin.DaemonRepository interface.
+func (r *DaemonRepository) GetAPIKeysExpiringBefore(
+	ctx context.Context,
+	before time.Time,
+) ([]admin.OwnedAPIKey, error) {
+	return getAPIKeysExpiringBefore(ctx, r.db.Collection("daemons"), before)
+}
+
+// DisableExpiredAPIKeys implements the admin.DaemonRepository interface.
+func (r *DaemonRepository) DisableExpiredAPIKeys(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+	return disableExpiredAPIKeys(ctx, r.db.Collection("daemons"), now)
+}
+
+// RecordAPIKeyUsage implements the admin.DaemonRepository interface.
+func (r *DaemonRepository) RecordAPIKeyUsage(
+	ctx context.Context,
+	usages []admin.APIKeyUsage,
+) error {
+	return recordAPIKeyUsage(ctx, r.db.Collection("daemons"), usages)
+}
+
+// daemonSearchFields are the fields of the daemons searched by prefix. They are weighted
+// like in the text index, which ranks the codes first.
+//
+//nolint:gochecknoglobals // read-only list
+var daemonSearchFields = []searchField{
+	{name: "code", weight: 10, value: func(hit admin.SearchHit) string { return hit.Name }},
+	{name: "name", weight: 5, value: func(hit admin.SearchHit) string { return hit.DisplayName }},
+}
+
+// EnsureDaemonIndexes creates the indexes of the daemons collection.
+// They support the sort orders of the daemon listings of a realm and the text
+// search of the daemons, which ranks the codes first.
+func EnsureDaemonIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("daemons")
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "code", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "id", Value: 1}}},
+		{
+			Keys: bson.D{
+				{Key: "code", Value: "text"},
+				{Key: "name", Value: "text"},
+				{Key: "description", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("search").
+				SetDefaultLanguage("none").
+				SetWeights(searchWeights(daemonSearchFields)),
+		},
+	})
+	if err != nil {
+		return domain.NewStoreError("failed to create daemon indexes: %v", err)
+	}
+
+	return nil
 }
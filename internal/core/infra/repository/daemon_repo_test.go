@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/energimind/go-kit/testutil/crud"
 	"github.com/energimind/identity-server/internal/core/domain"
@@ -23,7 +24,9 @@ func TestDaemonRepository_CRUD(t *testing.T) {
 	crud.RunTests(t, crud.Setup[admin.Daemon, admin.ID]{
 		RepoOps: crud.RepoOps[admin.Daemon, admin.ID]{
 			GetAll: func(ctx context.Context) ([]admin.Daemon, error) {
-				return repo.GetDaemons(ctx, realmID)
+				page, err := repo.GetDaemons(ctx, realmID, admin.DaemonFilter{}, admin.ListOptions{SortBy: admin.SortByCode})
+
+				return page.Items, err
 			},
 			GetByID: func(ctx context.Context, id admin.ID) (admin.Daemon, error) {
 				return repo.GetDaemon(ctx, realmID, id)
@@ -32,26 +35,33 @@ func TestDaemonRepository_CRUD(t *testing.T) {
 				return repo.CreateDaemon(ctx, user)
 			},
 			Update: func(ctx context.Context, user admin.Daemon) error {
+				// the modified entity holds the version stored by the update
+				user.Version = admin.FirstVersion
+
 				return repo.UpdateDaemon(ctx, user)
 			},
 			Delete: func(ctx context.Context, id admin.ID) error {
-				return repo.DeleteDaemon(ctx, realmID, id)
+				return repo.DeleteDaemon(ctx, realmID, id, admin.FirstVersion.Next(), time.Now())
 			},
 		},
 		EntityOps: crud.EntityOps[admin.Daemon, admin.ID]{
 			NewEntity: func(key int) admin.Daemon {
 				return admin.Daemon{
-					ID:          admin.ID(strconv.Itoa(key)),
-					RealmID:     realmID,
-					Code:        "daemon",
-					Name:        "Daemon",
-					Description: "Daemon description",
-					Enabled:     true,
-					APIKeys:     []admin.APIKey{{}},
+					ID:            admin.ID(strconv.Itoa(key)),
+					RealmID:       realmID,
+					Code:          "daemon",
+					Name:          "Daemon",
+					Description:   "Daemon description",
+					Enabled:       true,
+					APIKeys:       []admin.APIKey{{}},
+					AllowedRealms: []admin.ID{"realm2"},
+					AllowedCIDRs:  []string{"10.0.0.0/8"},
+					Version:       admin.FirstVersion,
 				}
 			},
 			ModifyEntity: func(user admin.Daemon) admin.Daemon {
 				user.Name = "Daemon 2"
+				user.Version = user.Version.Next()
 
 				return user
 			},
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// deletableCollections lists the collections of the entities deleted softly.
+//
+//nolint:gochecknoglobals // read-only list
+var deletableCollections = []string{
+	"realms", "providers", "users", "daemons", "groups", "memberships", "policies", "rules",
+}
+
+// DeletionRepository is a MongoDB implementation of admin.DeletionRepository.
+//
+// The content of a realm is deleted and restored one collection at a time. If it
+// fails halfway, the realm can be restored and deleted again.
+type DeletionRepository struct {
+	db *mongo.Database
+}
+
+// NewDeletionRepository creates a new MongoDB deletion repository.
+func NewDeletionRepository(db *mongo.Database) *DeletionRepository {
+	return &DeletionRepository{db: db}
+}
+
+// Ensure repository implements the admin.DeletionRepository interface.
+var _ admin.DeletionRepository = (*DeletionRepository)(nil)
+
+// CountRealmContent implements the admin.DeletionRepository interface.
+func (r *DeletionRepository) CountRealmContent(
+	ctx context.Context,
+	realmID admin.ID,
+) (admin.RealmContent, error) {
+	return r.forRealmContent(realmID, func(coll *mongo.Collection, qFilter bson.M) (int, error) {
+		count, err := coll.CountDocuments(ctx, live(qFilter))
+		if err != nil {
+			return 0, domain.NewStoreError("failed to count %s: %v", coll.Name(), err)
+		}
+
+		return int(count), nil
+	})
+}
+
+// DeleteRealmContent implements the admin.DeletionRepository interface.
+func (r *DeletionRepository) DeleteRealmContent(
+	ctx context.Context,
+	realmID admin.ID,
+	deletedAt time.Time,
+) (admin.RealmContent, error) {
+	return r.forRealmContent(realmID, func(coll *mongo.Collection, qFilter bson.M) (int, error) {
+		return softDeleteMany(ctx, coll, qFilter, deletedAt)
+	})
+}
+
+// RestoreRealmContent implements the admin.DeletionRepository interface.
+func (r *DeletionRepository) RestoreRealmContent(
+	ctx context.Context,
+	realmID admin.ID,
+	deletedAt time.Time,
+) (admin.RealmContent, error) {
+	return r.forRealmContent(realmID, func(coll *mongo.Collection, qFilter bson.M) (int, error) {
+		return restoreMany(ctx, coll, qFilter, deletedAt)
+	})
+}
+
+// PurgeDeleted implements the admin.DeletionRepository interface.
+func (r *DeletionRepository) PurgeDeleted(
+	ctx context.Context,
+	before time.Time,
+) (int, error) {
+	qFilter := bson.M{"deletedAt": bson.M{"$lt": before}}
+	purged := 0
+
+	for _, name := range deletableCollections {
+		result, err := r.db.Collection(name).DeleteMany(ctx, qFilter)
+		if err != nil {
+			return purged, domain.NewStoreError("failed to purge %s: %v", name, err)
+		}
+
+		purged += int(result.DeletedCount)
+	}
+
+	return purged, nil
+}
+
+// forRealmContent applies the operation to the documents of each collection that
+// belong to the realm, and returns the numbers the operation returned.
+func (r *DeletionRepository) forRealmContent(
+	realmID admin.ID,
+	operation func(coll *mongo.Collection, qFilter bson.M) (int, error),
+) (admin.RealmContent, error) {
+	qRealm := bson.M{"realmId": realmID}
+	qMemberships := bson.M{"$or": bson.A{qRealm, bson.M{"userRealmId": realmID}}}
+	content := admin.RealmContent{}
+
+	parts := []struct {
+		name    string
+		qFilter bson.M
+		count   *int
+	}{
+		{"users", qRealm, &content.Users},
+		{"daemons", qRealm, &content.Daemons},
+		{"groups", qRealm, &content.Groups},
+		{"memberships", qMemberships, &content.Memberships},
+		{"policies", qRealm, &content.Policies},
+		{"rules", qRealm, &content.Rules},
+	}
+
+	for _, part := range parts {
+		count, err := operation(r.db.Collection(part.name), part.qFilter)
+		if err != nil {
+			return admin.RealmContent{}, err
+		}
+
+		*part.count = count
+	}
+
+	return content, nil
+}
@@ -0,0 +1,66 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeletionRepository(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	ctx := context.Background()
+	repo := repository.NewDeletionRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	groupRepo := repository.NewGroupRepository(db)
+
+	require.NoError(t, userRepo.CreateUser(ctx, admin.User{ID: "u1", RealmID: "r1", Version: admin.FirstVersion, Username: "user1"}))
+	require.NoError(t, userRepo.CreateUser(ctx, admin.User{ID: "u2", RealmID: "r1", Version: admin.FirstVersion, Username: "user2"}))
+	require.NoError(t, userRepo.CreateUser(ctx, admin.User{ID: "u3", RealmID: "r2", Version: admin.FirstVersion, Username: "user3"}))
+	require.NoError(t, groupRepo.CreateGroup(ctx, admin.Group{ID: "g1", RealmID: "r1", Version: admin.FirstVersion, Name: "group1"}))
+
+	content, err := repo.CountRealmContent(ctx, "r1")
+	require.NoError(t, err)
+	require.Equal(t, admin.RealmContent{Users: 2, Groups: 1}, content)
+
+	// u2 is deleted on its own before the realm, so it is not restored with the realm
+	earlier := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	require.NoError(t, userRepo.DeleteUser(ctx, "r1", "u2", admin.FirstVersion, earlier))
+
+	deletedAt := time.Now().Truncate(time.Millisecond)
+
+	content, err = repo.DeleteRealmContent(ctx, "r1", deletedAt)
+	require.NoError(t, err)
+	require.Equal(t, admin.RealmContent{Users: 1, Groups: 1}, content)
+
+	_, err = userRepo.GetUser(ctx, "r1", "u1")
+	require.ErrorAs(t, err, &domain.NotFoundError{})
+
+	content, err = repo.RestoreRealmContent(ctx, "r1", deletedAt)
+	require.NoError(t, err)
+	require.Equal(t, admin.RealmContent{Users: 1, Groups: 1}, content)
+
+	_, err = userRepo.GetUser(ctx, "r1", "u1")
+	require.NoError(t, err)
+
+	_, err = userRepo.GetUser(ctx, "r1", "u2")
+	require.ErrorAs(t, err, &domain.NotFoundError{})
+
+	purged, err := repo.PurgeDeleted(ctx, deletedAt)
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+
+	_, err = userRepo.RestoreUser(ctx, "r1", "u2")
+	require.ErrorAs(t, err, &domain.NotFoundError{})
+
+	_, err = userRepo.GetUser(ctx, "r2", "u3")
+	require.NoError(t, err)
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// live returns a copy of the filter that matches only the documents not deleted.
+// The deleted documents have a deletion time, while the other documents have none.
+func live(qFilter bson.M) bson.M {
+	qLive := maps.Clone(qFilter)
+	qLive["deletedAt"] = nil
+
+	return qLive
+}
+
+// deleted returns a copy of the filter that matches only the deleted documents.
+func deleted(qFilter bson.M) bson.M {
+	qDeleted := maps.Clone(qFilter)
+	qDeleted["deletedAt"] = bson.M{"$ne": nil}
+
+	return qDeleted
+}
+
+// softDelete marks the given version of the document as deleted at the given time.
+// The version is incremented, so that the changes based on the deleted version are
+// rejected once the document is restored.
+//
+// The filter identifies the document regardless of its version. The name of the
+// document is used in the error messages.
+func softDelete(
+	ctx context.Context,
+	coll *mongo.Collection,
+	qFilter bson.M,
+	name string,
+	id admin.ID,
+	version admin.Version,
+	deletedAt time.Time,
+) error {
+	qLive := live(qFilter)
+	qUpdate := bson.M{"$set": bson.M{"deletedAt": deletedAt}, "$inc": bson.M{"version": 1}}
+
+	result, err := coll.UpdateOne(ctx, withVersion(qLive, version), qUpdate)
+	if err != nil {
+		return domain.NewStoreError("failed to delete %s: %v", name, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return missingOrStale(ctx, coll, qLive, name, id, version)
+	}
+
+	return nil
+}
+
+// restore clears the deletion of the deleted document and returns the time it was
+// deleted at. The version is incremented, as for any other change. A document that
+// would duplicate a live one is not restored.
+//
+// The name of the document is used in the error messages.
+func restore(
+	ctx context.Context,
+	coll *mongo.Collection,
+	qFilter bson.M,
+	name string,
+	id admin.ID,
+) (time.Time, error) {
+	qUpdate := bson.M{"$unset": bson.M{"deletedAt": ""}, "$inc": bson.M{"version": 1}}
+	qOptions := options.FindOneAndUpdate().SetProjection(bson.M{"deletedAt": 1})
+	deletion := dbDeletion{}
+
+	if err := coll.FindOneAndUpdate(ctx, deleted(qFilter), qUpdate, qOptions).Decode(&deletion); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return time.Time{}, domain.NewNotFoundError("deleted %s %v not found", name, id)
+		}
+
+		if mongo.IsDuplicateKeyError(err) {
+			return time.Time{}, domain.NewConflictError("deleted %s %v conflicts with a live %s", name, id, name)
+		}
+
+		return time.Time{}, domain.NewStoreError("failed to restore %s: %v", name, err)
+	}
+
+	return deletion.DeletedAt, nil
+}
+
+// findDeleted finds the deleted document matching the filter.
+//
+// The name of the document is used in the error messages.
+func findDeleted[T, M any](
+	ctx context.Context,
+	coll *mongo.Collection,
+	qFilter bson.M,
+	name string,
+	id admin.ID,
+	mapper func(T) M,
+) (M, error) {
+	var doc T
+
+	if err := coll.FindOne(ctx, deleted(qFilter)).Decode(&doc); err != nil {
+		var empty M
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return empty, domain.NewNotFoundError("deleted %s %v not found", name, id)
+		}
+
+		return empty, domain.NewStoreError("failed to get deleted %s: %v", name, err)
+	}
+
+	return mapper(doc), nil
+}
+
+// softDeleteMany marks the documents matching the filter as deleted at the given
+// time, and returns their number. The documents already deleted keep their
+// deletion time.
+func softDeleteMany(ctx context.Context, coll *mongo.Collection, qFilter bson.M, deletedAt time.Time) (int, error) {
+	qUpdate := bson.M{"$set": bson.M{"deletedAt": deletedAt}, "$inc": bson.M{"version": 1}}
+
+	result, err := coll.UpdateMany(ctx, live(qFilter), qUpdate)
+	if err != nil {
+		return 0, domain.NewStoreError("failed to delete %s: %v", coll.Name(), err)
+	}
+
+	return int(result.ModifiedCount), nil
+}
+
+// restoreMany restores the documents matching the filter deleted at the given time,
+// and returns their number.
+func restoreMany(ctx context.Context, coll *mongo.Collection, qFilter bson.M, deletedAt time.Time) (int, error) {
+	qDeleted := maps.Clone(qFilter)
+	qDeleted["deletedAt"] = deletedAt
+	qUpdate := bson.M{"$unset": bson.M{"deletedAt": ""}, "$inc": bson.M{"version": 1}}
+
+	result, err := coll.UpdateMany(ctx, qDeleted, qUpdate)
+	if err != nil {
+		return 0, domain.NewStoreError("failed to restore %s: %v", coll.Name(), err)
+	}
+
+	return int(result.ModifiedCount), nil
+}
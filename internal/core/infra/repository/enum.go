@@ -10,6 +10,32 @@ const (
 	dbSystemRoleUser
 	dbSystemRoleManager
 	dbSystemRoleAdmin
+	dbSystemRoleSystemAuditor
+	dbSystemRoleRealmAuditor
+	dbSystemRoleUserManager
+	dbSystemRoleDaemonManager
+)
+
+const (
+	dbLockoutKindNone dbLockoutKind = iota
+	dbLockoutKindAPIKeyPrefix
+	dbLockoutKindUser
+	dbLockoutKindClientIP
+)
+
+const (
+	dbPolicyEffectNone dbPolicyEffect = iota
+	dbPolicyEffectAllow
+	dbPolicyEffectDeny
+)
+
+const (
+	dbAttributeTypeNone dbAttributeType = iota
+	dbAttributeTypeString
+	dbAttributeTypeNumber
+	dbAttributeTypeBool
+	dbAttributeTypeEnum
+	dbAttributeTypeList
 )
 
 // All enums. Used for testing purposes to validate that all enum values are
@@ -18,9 +44,26 @@ const (
 //nolint:gochecknoglobals,unused
 var (
 	allProviderTypes = []dbProviderType{dbProviderTypeNone, dbProviderTypeGoogle}
-	allSystemRoles   = []dbSystemRole{dbSystemRoleNone, dbSystemRoleUser, dbSystemRoleManager, dbSystemRoleAdmin}
+	allSystemRoles   = []dbSystemRole{
+		dbSystemRoleNone, dbSystemRoleUser, dbSystemRoleManager, dbSystemRoleAdmin,
+		dbSystemRoleSystemAuditor, dbSystemRoleRealmAuditor, dbSystemRoleUserManager, dbSystemRoleDaemonManager,
+	}
+	allLockoutKinds = []dbLockoutKind{
+		dbLockoutKindNone, dbLockoutKindAPIKeyPrefix, dbLockoutKindUser, dbLockoutKindClientIP,
+	}
+	allPolicyEffects  = []dbPolicyEffect{dbPolicyEffectNone, dbPolicyEffectAllow, dbPolicyEffectDeny}
+	allAttributeTypes = []dbAttributeType{
+		dbAttributeTypeNone, dbAttributeTypeString, dbAttributeTypeNumber,
+		dbAttributeTypeBool, dbAttributeTypeEnum, dbAttributeTypeList,
+	}
 )
 
 type dbProviderType int
 
 type dbSystemRole int
+
+type dbLockoutKind int
+
+type dbPolicyEffect int
+
+type dbAttributeType int
@@ -36,6 +36,14 @@ func toSystemRole(r admin.SystemRole) dbSystemRole {
 		return dbSystemRoleManager
 	case admin.SystemRoleAdmin:
 		return dbSystemRoleAdmin
+	case admin.SystemRoleSystemAuditor:
+		return dbSystemRoleSystemAuditor
+	case admin.SystemRoleRealmAuditor:
+		return dbSystemRoleRealmAuditor
+	case admin.SystemRoleUserManager:
+		return dbSystemRoleUserManager
+	case admin.SystemRoleDaemonManager:
+		return dbSystemRoleDaemonManager
 	default:
 		return dbSystemRoleNone
 	}
@@ -51,7 +59,109 @@ func fromSystemRole(r dbSystemRole) admin.SystemRole {
 		return admin.SystemRoleManager
 	case dbSystemRoleAdmin:
 		return admin.SystemRoleAdmin
+	case dbSystemRoleSystemAuditor:
+		return admin.SystemRoleSystemAuditor
+	case dbSystemRoleRealmAuditor:
+		return admin.SystemRoleRealmAuditor
+	case dbSystemRoleUserManager:
+		return admin.SystemRoleUserManager
+	case dbSystemRoleDaemonManager:
+		return admin.SystemRoleDaemonManager
 	default:
 		return admin.SystemRoleNone
 	}
 }
+
+func toLockoutKind(k admin.LockoutKind) dbLockoutKind {
+	switch k {
+	case admin.LockoutKindNone:
+		return dbLockoutKindNone
+	case admin.LockoutKindAPIKeyPrefix:
+		return dbLockoutKindAPIKeyPrefix
+	case admin.LockoutKindUser:
+		return dbLockoutKindUser
+	case admin.LockoutKindClientIP:
+		return dbLockoutKindClientIP
+	default:
+		return dbLockoutKindNone
+	}
+}
+
+func fromLockoutKind(k dbLockoutKind) admin.LockoutKind {
+	switch k {
+	case dbLockoutKindNone:
+		return admin.LockoutKindNone
+	case dbLockoutKindAPIKeyPrefix:
+		return admin.LockoutKindAPIKeyPrefix
+	case dbLockoutKindUser:
+		return admin.LockoutKindUser
+	case dbLockoutKindClientIP:
+		return admin.LockoutKindClientIP
+	default:
+		return admin.LockoutKindNone
+	}
+}
+
+func toPolicyEffect(e admin.PolicyEffect) dbPolicyEffect {
+	switch e {
+	case admin.PolicyEffectNone:
+		return dbPolicyEffectNone
+	case admin.PolicyEffectAllow:
+		return dbPolicyEffectAllow
+	case admin.PolicyEffectDeny:
+		return dbPolicyEffectDeny
+	default:
+		return dbPolicyEffectNone
+	}
+}
+
+func fromPolicyEffect(e dbPolicyEffect) admin.PolicyEffect {
+	switch e {
+	case dbPolicyEffectNone:
+		return admin.PolicyEffectNone
+	case dbPolicyEffectAllow:
+		return admin.PolicyEffectAllow
+	case dbPolicyEffectDeny:
+		return admin.PolicyEffectDeny
+	default:
+		return admin.PolicyEffectNone
+	}
+}
+
+func toAttributeType(t admin.AttributeType) dbAttributeType {
+	switch t {
+	case admin.AttributeTypeNone:
+		return dbAttributeTypeNone
+	case admin.AttributeTypeString:
+		return dbAttributeTypeString
+	case admin.AttributeTypeNumber:
+		return dbAttributeTypeNumber
+	case admin.AttributeTypeBool:
+		return dbAttributeTypeBool
+	case admin.AttributeTypeEnum:
+		return dbAttributeTypeEnum
+	case admin.AttributeTypeList:
+		return dbAttributeTypeList
+	default:
+		return dbAttributeTypeNone
+	}
+}
+
+func fromAttributeType(t dbAttributeType) admin.AttributeType {
+	switch t {
+	case dbAttributeTypeNone:
+		return admin.AttributeTypeNone
+	case dbAttributeTypeString:
+		return admin.AttributeTypeString
+	case dbAttributeTypeNumber:
+		return admin.AttributeTypeNumber
+	case dbAttributeTypeBool:
+		return admin.AttributeTypeBool
+	case dbAttributeTypeEnum:
+		return admin.AttributeTypeEnum
+	case dbAttributeTypeList:
+		return admin.AttributeTypeList
+	default:
+		return admin.AttributeTypeNone
+	}
+}
@@ -13,15 +13,27 @@ func Test_allEnumValuesAreMapped(t *testing.T) {
 
 	mapping.CheckAllEnumValuesAreMapped(t, admin.AllProviderTypes, allProviderTypes, toProviderType)
 	mapping.CheckAllEnumValuesAreMapped(t, admin.AllSystemRoles, allSystemRoles, toSystemRole)
+	mapping.CheckAllEnumValuesAreMapped(t, admin.AllLockoutKinds, allLockoutKinds, toLockoutKind)
+	mapping.CheckAllEnumValuesAreMapped(t, admin.AllPolicyEffects, allPolicyEffects, toPolicyEffect)
+	mapping.CheckAllEnumValuesAreMapped(t, admin.AllAttributeTypes, allAttributeTypes, toAttributeType)
 
 	mapping.CheckAllEnumValuesAreMapped(t, allProviderTypes, admin.AllProviderTypes, fromProviderType)
 	mapping.CheckAllEnumValuesAreMapped(t, allSystemRoles, admin.AllSystemRoles, fromSystemRole)
+	mapping.CheckAllEnumValuesAreMapped(t, allLockoutKinds, admin.AllLockoutKinds, fromLockoutKind)
+	mapping.CheckAllEnumValuesAreMapped(t, allPolicyEffects, admin.AllPolicyEffects, fromPolicyEffect)
+	mapping.CheckAllEnumValuesAreMapped(t, allAttributeTypes, admin.AllAttributeTypes, fromAttributeType)
 }
 
 func Test_enumMapperDefaultsOnInvalidEnum(t *testing.T) {
 	require.Equal(t, dbProviderTypeNone, toProviderType("invalid"))
 	require.Equal(t, dbSystemRoleNone, toSystemRole("invalid"))
+	require.Equal(t, dbLockoutKindNone, toLockoutKind("invalid"))
+	require.Equal(t, dbPolicyEffectNone, toPolicyEffect("invalid"))
+	require.Equal(t, dbAttributeTypeNone, toAttributeType("invalid"))
 
 	require.Equal(t, admin.ProviderTypeNone, fromProviderType(dbProviderType(-1)))
 	require.Equal(t, admin.SystemRoleNone, fromSystemRole(dbSystemRole(-1)))
+	require.Equal(t, admin.LockoutKindNone, fromLockoutKind(dbLockoutKind(-1)))
+	require.Equal(t, admin.PolicyEffectNone, fromPolicyEffect(dbPolicyEffect(-1)))
+	require.Equal(t, admin.AttributeTypeNone, fromAttributeType(dbAttributeType(-1)))
 }
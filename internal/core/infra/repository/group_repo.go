@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GroupRepository is a MongoDB implementation of GroupRepository.
+//
+// The members are stored in the group document. The membership methods
+// update them atomically, without replacing the group.
+type GroupRepository struct {
+	db *mongo.Database
+}
+
+// NewGroupRepository creates a new MongoDB group repository.
+func NewGroupRepository(db *mongo.Database) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+// Ensure repository implements the admin.GroupRepository interface.
+var _ admin.GroupRepository = (*GroupRepository)(nil)
+
+// GetGroups implements the admin.GroupRepository interface.
+func (r *GroupRepository) GetGroups(
+	ctx context.Context,
+	realmID admin.ID,
+	listOptions admin.ListOptions,
+) (admin.Page[admin.Group], error) {
+	coll := r.db.Collection("groups")
+	qFilter := live(bson.M{"realmId": realmID})
+
+	return findPage(ctx, coll, "groups", qFilter, listOptions, fromGroup)
+}
+
+// GetGroup implements the admin.GroupRepository interface.
+func (r *GroupRepository) GetGroup(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (admin.Group, error) {
+	coll := r.db.Collection("groups")
+	qFilter := live(bson.M{"id": id, "realmId": realmID})
+	group := dbGroup{}
+
+	if err := coll.FindOne(ctx, qFilter).Decode(&group); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return admin.Group{}, domain.NewNotFoundError("group %v not found", id)
+		}
+
+		return admin.Group{}, domain.NewStoreError("failed to get group: %v", err)
+	}
+
+	return fromGroup(group), nil
+}
+
+// CreateGroup implements the admin.GroupRepository interface.
+func (r *GroupRepository) CreateGroup(
+	ctx context.Context,
+	group admin.Group,
+) error {
+	coll := r.db.Collection("groups")
+
+	if _, err := coll.InsertOne(ctx, toGroup(group)); err != nil {
+		return domain.NewStoreError("failed to create group: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateGroup implements the admin.GroupRepository interface.
+func (r *GroupRepository) UpdateGroup(
+	ctx context.Context,
+	group admin.Group,
+) error {
+	coll := r.db.Collection("groups")
+	qFilter := live(bson.M{"id": group.ID, "realmId": group.RealmID})
+	version := group.Version
+
+	group.Version = version.Next()
+
+	qUpdate := bson.M{"$set": toGroup(group)}
+
+	result, err := coll.UpdateOne(ctx, withVersion(qFilter, version), qUpdate)
+	if err != nil {
+		return domain.NewStoreError("failed to update group: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return missingOrStale(ctx, coll, qFilter, "group", group.ID, version)
+	}
+
+	return nil
+}
+
+// DeleteGroup implements the admin.GroupRepository interface.
+func (r *GroupRepository) DeleteGroup(
+	ctx context.Context,
+	realmID, id admin.ID,
+	version admin.Version,
+	deletedAt time.Time,
+) error {
+	coll := r.db.Collection("groups")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return softDelete(ctx, coll, qFilter, "group", id, version, deletedAt)
+}
+
+// RestoreGroup implements the admin.GroupRepository interface.
+func (r *GroupRepository) RestoreGroup(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (time.Time, error) {
+	coll := r.db.Collection("groups")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return restore(ctx, coll, qFilter, "group", id)
+}
+
+// GetDeletedGroup implements the admin.GroupRepository interface.
+func (r *GroupRepository) GetDeletedGroup(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (admin.Group, error) {
+	coll := r.db.Collection("groups")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return findDeleted(ctx, coll, qFilter, "group", id, fromGroup)
+}
+
+// GetMemberGroups implements the admin.GroupRepository interface.
+func (r *GroupRepository) GetMemberGroups(
+	ctx context.Context,
+	realmID, userID admin.ID,
+) ([]admin.Group, error) {
+	return r.findGroups(ctx, bson.M{"realmId": realmID, "members": userID})
+}
+
+// AddGroupMember implements the admin.GroupRepository interface.
+func (r *GroupRepository) AddGroupMember(
+	ctx context.Context,
+	realmID, id, userID admin.ID,
+) error {
+	return r.updateMembers(ctx, realmID, id, bson.M{"$addToSet": bson.M{"members": userID}})
+}
+
+// RemoveGroupMember implements the admin.GroupRepository interface.
+func (r *GroupRepository) RemoveGroupMember(
+	ctx context.Context,
+	realmID, id, userID admin.ID,
+) error {
+	return r.updateMembers(ctx, realmID, id, bson.M{"$pull": bson.M{"members": userID}})
+}
+
+// RemoveMember implements the admin.GroupRepository interface.
+func (r *GroupRepository) RemoveMember(
+	ctx context.Context,
+	realmID, userID admin.ID,
+) error {
+	coll := r.db.Collection("groups")
+	qFilter := bson.M{"realmId": realmID, "members": userID}
+	qUpdate := bson.M{"$pull": bson.M{"members": userID}, "$inc": bson.M{"version": 1}}
+
+	if _, err := coll.UpdateMany(ctx, qFilter, qUpdate); err != nil {
+		return domain.NewStoreError("failed to remove group member: %v", err)
+	}
+
+	return nil
+}
+
+func (r *GroupRepository) findGroups(ctx context.Context, qFilter bson.M) ([]admin.Group, error) {
+	coll := r.db.Collection("groups")
+
+	qCursor, err := coll.Find(ctx, live(qFilter))
+	if err != nil {
+		return nil, domain.NewStoreError("failed to find groups: %v", err)
+	}
+
+	groups, err := drainCursor[dbGroup](ctx, qCursor, fromGroup)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to get groups: %v", err)
+	}
+
+	return groups, nil
+}
+
+// updateMembers applies the update of the members to the group. The version of
+// the group is incremented, so that the changes based on a previous version do
+// not overwrite the members.
+func (r *GroupRepository) updateMembers(ctx context.Context, realmID, id admin.ID, qUpdate bson.M) error {
+	coll := r.db.Collection("groups")
+	qFilter := live(bson.M{"id": id, "realmId": realmID})
+	qUpdate["$inc"] = bson.M{"version": 1}
+
+	result, err := coll.UpdateOne(ctx, qFilter, qUpdate)
+	if err != nil {
+		return domain.NewStoreError("failed to update group members: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.NewNotFoundError("group %v not found", id)
+	}
+
+	return nil
+}
+
+// EnsureGroupIndexes creates the indexes of the groups collection.
+// The groups of a member are looked up on every session request. The groups of a
+// realm are listed sorted by code.
+func EnsureGroupIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("groups")
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "code", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "members", Value: 1}}},
+	})
+	if err != nil {
+		return domain.NewStoreError("failed to create group indexes: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package repository_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/energimind/go-kit/testutil/crud"
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupRepository_CRUD(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewGroupRepository(db)
+	realmID := admin.ID("1")
+
+	crud.RunTests(t, crud.Setup[admin.Group, admin.ID]{
+		RepoOps: crud.RepoOps[admin.Group, admin.ID]{
+			GetAll: func(ctx context.Context) ([]admin.Group, error) {
+				page, err := repo.GetGroups(ctx, realmID, admin.ListOptions{})
+
+				return page.Items, err
+			},
+			GetByID: func(ctx context.Context, id admin.ID) (admin.Group, error) {
+				return repo.GetGroup(ctx, realmID, id)
+			},
+			Create: func(ctx context.Context, group admin.Group) error {
+				return repo.CreateGroup(ctx, group)
+			},
+			Update: func(ctx context.Context, group admin.Group) error {
+				// the modified entity holds the version stored by the update
+				group.Version = admin.FirstVersion
+
+				return repo.UpdateGroup(ctx, group)
+			},
+			Delete: func(ctx context.Context, id admin.ID) error {
+				return repo.DeleteGroup(ctx, realmID, id, admin.FirstVersion.Next(), time.Now())
+			},
+		},
+		EntityOps: crud.EntityOps[admin.Group, admin.ID]{
+			NewEntity: func(key int) admin.Group {
+				return admin.Group{
+					ID:          admin.ID(strconv.Itoa(key)),
+					RealmID:     realmID,
+					Code:        "group",
+					Name:        "Group",
+					Description: "Group description",
+					Roles:       []string{"auditor"},
+					Attributes:  map[string]string{"department": "sales"},
+					Members:     []admin.ID{"user1"},
+					Version:     admin.FirstVersion,
+				}
+			},
+			ModifyEntity: func(group admin.Group) admin.Group {
+				group.Name = "Group 2"
+				group.Version = group.Version.Next()
+
+				return group
+			},
+			UnboundEntity: func() admin.Group {
+				return admin.Group{ID: ""}
+			},
+			ExtractKey: func(group admin.Group) admin.ID {
+				return group.ID
+			},
+			MissingKey: func() admin.ID {
+				return "missing"
+			},
+		},
+		NotFoundErr: func() any {
+			return domain.NotFoundError{}
+		},
+	})
+}
+
+func TestGroupRepository_members(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	ctx := context.Background()
+	repo := repository.NewGroupRepository(db)
+	realmID := admin.ID("1")
+
+	require.NoError(t, repository.EnsureGroupIndexes(ctx, db))
+	require.NoError(t, repo.CreateGroup(ctx, admin.Group{ID: "g1", RealmID: realmID, Code: "g1"}))
+	require.NoError(t, repo.CreateGroup(ctx, admin.Group{ID: "g2", RealmID: realmID, Code: "g2"}))
+
+	require.NoError(t, repo.AddGroupMember(ctx, realmID, "g1", "u1"))
+	require.NoError(t, repo.AddGroupMember(ctx, realmID, "g1", "u1"))
+	require.NoError(t, repo.AddGroupMember(ctx, realmID, "g2", "u1"))
+	require.ErrorAs(t, repo.AddGroupMember(ctx, realmID, "missing", "u1"), &domain.NotFoundError{})
+
+	groups, err := repo.GetMemberGroups(ctx, realmID, "u1")
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	require.Equal(t, []admin.ID{"u1"}, groups[0].Members)
+
+	require.NoError(t, repo.RemoveGroupMember(ctx, realmID, "g1", "u1"))
+
+	groups, err = repo.GetMemberGroups(ctx, realmID, "u1")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+
+	require.NoError(t, repo.RemoveMember(ctx, realmID, "u1"))
+
+	groups, err = repo.GetMemberGroups(ctx, realmID, "u1")
+	require.NoError(t, err)
+	require.Empty(t, groups)
+}
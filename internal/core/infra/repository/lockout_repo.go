@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LockoutRepository is a MongoDB implementation of admin.LockoutRepository.
+type LockoutRepository struct {
+	db *mongo.Database
+}
+
+// NewLockoutRepository creates a new MongoDB lockout repository.
+func NewLockoutRepository(db *mongo.Database) *LockoutRepository {
+	return &LockoutRepository{db: db}
+}
+
+// Ensure repository implements the admin.LockoutRepository interface.
+var _ admin.LockoutRepository = (*LockoutRepository)(nil)
+
+// GetLockouts implements the admin.LockoutRepository interface.
+func (r *LockoutRepository) GetLockouts(
+	ctx context.Context,
+	now time.Time,
+	listOptions admin.ListOptions,
+) (admin.Page[admin.Lockout], error) {
+	coll := r.db.Collection("lockouts")
+	qFilter := bson.M{"expiresAt": bson.M{"$gt": now}}
+
+	return findPage(ctx, coll, "lockouts", qFilter, listOptions, fromLockout)
+}
+
+// GetLockout implements the admin.LockoutRepository interface.
+func (r *LockoutRepository) GetLockout(
+	ctx context.Context,
+	id admin.ID,
+) (admin.Lockout, error) {
+	coll := r.db.Collection("lockouts")
+	qFilter := bson.M{"id": id}
+	lockout := dbLockout{}
+
+	if err := coll.FindOne(ctx, qFilter).Decode(&lockout); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return admin.Lockout{}, domain.NewNotFoundError("lockout %v not found", id)
+		}
+
+		return admin.Lockout{}, domain.NewStoreError("failed to get lockout: %v", err)
+	}
+
+	return fromLockout(lockout), nil
+}
+
+// AddLockoutFailure implements the admin.LockoutRepository interface.
+//
+// The failure is counted with an atomic increment, so that concurrent failures are
+// never lost. The failures and the lockouts of a subject that has been quiet during
+// the window are reset first; concurrent resets are harmless, as a reset never
+// matches once a failure has been counted.
+func (r *LockoutRepository) AddLockoutFailure(
+	ctx context.Context,
+	key admin.LockoutKey,
+	now time.Time,
+	window time.Duration,
+) (admin.Lockout, error) {
+	coll := r.db.Collection("lockouts")
+	id := key.ID()
+	quietBefore := now.Add(-window)
+
+	qReset := bson.M{
+		"id":            id,
+		"lastFailureAt": bson.M{"$lt": quietBefore},
+		"lockedUntil":   bson.M{"$lt": quietBefore},
+	}
+
+	if _, err := coll.UpdateOne(ctx, qReset, bson.M{"$set": bson.M{"failures": 0, "lockouts": 0}}); err != nil {
+		return admin.Lockout{}, domain.NewStoreError("failed to reset lockout: %v", err)
+	}
+
+	qFilter := bson.M{"id": id}
+	qUpdate := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"lastFailureAt": now},
+		"$max": bson.M{"expiresAt": now.Add(window)},
+		"$setOnInsert": bson.M{
+			"kind":        toLockoutKind(key.Kind),
+			"realmId":     toID(key.RealmID),
+			"subject":     key.Subject,
+			"lockouts":    0,
+			"lockedUntil": time.Time{},
+		},
+	}
+	qOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	lockout := dbLockout{}
+
+	if err := coll.FindOneAndUpdate(ctx, qFilter, qUpdate, qOptions).Decode(&lockout); err != nil {
+		return admin.Lockout{}, domain.NewStoreError("failed to add lockout failure: %v", err)
+	}
+
+	return fromLockout(lockout), nil
+}
+
+// LockOut implements the admin.LockoutRepository interface.
+func (r *LockoutRepository) LockOut(
+	ctx context.Context,
+	lockout admin.Lockout,
+	threshold int,
+) (bool, error) {
+	coll := r.db.Collection("lockouts")
+	qFilter := bson.M{
+		"id":       lockout.ID,
+		"lockouts": lockout.Lockouts - 1,
+		"failures": bson.M{"$gte": threshold},
+	}
+	qUpdate := bson.M{"$set": bson.M{
+		"failures":    lockout.Failures,
+		"lockouts":    lockout.Lockouts,
+		"lockedUntil": lockout.LockedUntil,
+		"expiresAt":   lockout.ExpiresAt,
+	}}
+
+	result, err := coll.UpdateOne(ctx, qFilter, qUpdate)
+	if err != nil {
+		return false, domain.NewStoreError("failed to lock out: %v", err)
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// DeleteLockout implements the admin.LockoutRepository interface.
+func (r *LockoutRepository) DeleteLockout(
+	ctx context.Context,
+	id admin.ID,
+) error {
+	coll := r.db.Collection("lockouts")
+	qFilter := bson.M{"id": id}
+
+	result, err := coll.DeleteOne(ctx, qFilter)
+	if err != nil {
+		return domain.NewStoreError("failed to delete lockout: %v", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.NewNotFoundError("lockout %v not found", id)
+	}
+
+	return nil
+}
+
+// EnsureLockoutIndexes creates the indexes of the lockouts collection.
+// The expired lockouts are removed by MongoDB using a TTL index. The lockouts are
+// listed sorted by their last failure by default.
+func EnsureLockoutIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("lockouts")
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "lastFailureAt", Value: 1}, {Key: "id", Value: 1}}},
+	})
+	if err != nil {
+		return domain.NewStoreError("failed to create lockout indexes: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutRepository(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewLockoutRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC().Round(time.Millisecond)
+
+	require.NoError(t, repository.EnsureLockoutIndexes(ctx, db))
+
+	key := admin.LockoutKey{Kind: admin.LockoutKindClientIP, Subject: "10.0.0.1"}
+
+	_, err := repo.AddLockoutFailure(ctx, key, now.Add(-time.Minute), time.Hour)
+	require.NoError(t, err)
+
+	lockout, err := repo.AddLockoutFailure(ctx, key, now, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, admin.Lockout{
+		ID:            key.ID(),
+		Kind:          admin.LockoutKindClientIP,
+		Subject:       "10.0.0.1",
+		Failures:      2,
+		LastFailureAt: now,
+		LockedUntil:   time.Time{},
+		ExpiresAt:     now.Add(time.Hour),
+	}, lockout)
+
+	locked := lockout
+	locked.Failures = 0
+	locked.Lockouts = 1
+	locked.LockedUntil = now.Add(time.Minute)
+	locked.ExpiresAt = now.Add(time.Hour + time.Minute)
+
+	// the failures do not reach the threshold
+	lockedOut, err := repo.LockOut(ctx, locked, 3)
+	require.NoError(t, err)
+	require.False(t, lockedOut)
+
+	lockedOut, err = repo.LockOut(ctx, locked, 2)
+	require.NoError(t, err)
+	require.True(t, lockedOut)
+
+	// the subject has already been locked out
+	lockedOut, err = repo.LockOut(ctx, locked, 0)
+	require.NoError(t, err)
+	require.False(t, lockedOut)
+
+	stored, err := repo.GetLockout(ctx, key.ID())
+	require.NoError(t, err)
+	require.Equal(t, locked, stored)
+
+	// the failures and the lockouts are forgotten after a quiet window
+	later := locked.LockedUntil.Add(time.Hour + time.Second)
+
+	lockout, err = repo.AddLockoutFailure(ctx, key, later, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 1, lockout.Failures)
+	require.Zero(t, lockout.Lockouts)
+	require.Equal(t, later.Add(time.Hour), lockout.ExpiresAt)
+
+	locked = lockout
+
+	active, err := repo.GetLockouts(ctx, later, admin.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []admin.Lockout{locked}, active.Items)
+
+	// the expired lockouts are not listed, even before they are removed
+	active, err = repo.GetLockouts(ctx, locked.ExpiresAt, admin.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, active.Items)
+
+	require.NoError(t, repo.DeleteLockout(ctx, key.ID()))
+
+	_, err = repo.GetLockout(ctx, key.ID())
+	require.ErrorAs(t, err, &domain.NotFoundError{})
+
+	err = repo.DeleteLockout(ctx, key.ID())
+	require.ErrorAs(t, err, &domain.NotFoundError{})
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MembershipRepository is a MongoDB implementation of admin.MembershipRepository.
+type MembershipRepository struct {
+	db *mongo.Database
+}
+
+// NewMembershipRepository creates a new MongoDB membership repository.
+func NewMembershipRepository(db *mongo.Database) *MembershipRepository {
+	return &MembershipRepository{db: db}
+}
+
+// Ensure repository implements the admin.MembershipRepository interface.
+var _ admin.MembershipRepository = (*MembershipRepository)(nil)
+
+// GetMemberships implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) GetMemberships(
+	ctx context.Context,
+	realmID admin.ID,
+	listOptions admin.ListOptions,
+) (admin.Page[admin.Membership], error) {
+	coll := r.db.Collection("memberships")
+	qFilter := live(bson.M{"realmId": realmID})
+
+	return findPage(ctx, coll, "memberships", qFilter, listOptions, fromMembership)
+}
+
+// GetMembership implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) GetMembership(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (admin.Membership, error) {
+	return r.findMembership(ctx, bson.M{"id": id, "realmId": realmID})
+}
+
+// GetUserMembership implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) GetUserMembership(
+	ctx context.Context,
+	realmID, userID admin.ID,
+) (admin.Membership, error) {
+	return r.findMembership(ctx, bson.M{"realmId": realmID, "userId": userID})
+}
+
+// GetUserMemberships implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) GetUserMemberships(
+	ctx context.Context,
+	userID admin.ID,
+) ([]admin.Membership, error) {
+	return r.findMemberships(ctx, bson.M{"userId": userID})
+}
+
+// CreateMembership implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) CreateMembership(
+	ctx context.Context,
+	membership admin.Membership,
+) error {
+	coll := r.db.Collection("memberships")
+
+	if _, err := coll.InsertOne(ctx, toMembership(membership)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.NewConflictError("user %v is already a member of realm %v",
+				membership.UserID, membership.RealmID)
+		}
+
+		return domain.NewStoreError("failed to create membership: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateMembership implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) UpdateMembership(
+	ctx context.Context,
+	membership admin.Membership,
+) error {
+	coll := r.db.Collection("memberships")
+	qFilter := live(bson.M{"id": membership.ID, "realmId": membership.RealmID})
+	version := membership.Version
+
+	membership.Version = version.Next()
+
+	qUpdate := bson.M{"$set": toMembership(membership)}
+
+	result, err := coll.UpdateOne(ctx, withVersion(qFilter, version), qUpdate)
+	if err != nil {
+		return domain.NewStoreError("failed to update membership: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return missingOrStale(ctx, coll, qFilter, "membership", membership.ID, version)
+	}
+
+	return nil
+}
+
+// DeleteMembership implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) DeleteMembership(
+	ctx context.Context,
+	realmID, id admin.ID,
+	version admin.Version,
+	deletedAt time.Time,
+) error {
+	coll := r.db.Collection("memberships")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return softDelete(ctx, coll, qFilter, "membership", id, version, deletedAt)
+}
+
+// RestoreMembership implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) RestoreMembership(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (time.Time, error) {
+	coll := r.db.Collection("memberships")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return restore(ctx, coll, qFilter, "membership", id)
+}
+
+// DeleteUserMemberships implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) DeleteUserMemberships(
+	ctx context.Context,
+	userID admin.ID,
+	deletedAt time.Time,
+) error {
+	coll := r.db.Collection("memberships")
+
+	if _, err := softDeleteMany(ctx, coll, bson.M{"userId": userID}, deletedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestoreUserMemberships implements the admin.MembershipRepository interface.
+func (r *MembershipRepository) RestoreUserMemberships(
+	ctx context.Context,
+	userID admin.ID,
+	deletedAt time.Time,
+) error {
+	coll := r.db.Collection("memberships")
+
+	if _, err := restoreMany(ctx, coll, bson.M{"userId": userID}, deletedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *MembershipRepository) findMembership(ctx context.Context, qFilter bson.M) (admin.Membership, error) {
+	coll := r.db.Collection("memberships")
+	membership := dbMembership{}
+
+	if err := coll.FindOne(ctx, live(qFilter)).Decode(&membership); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return admin.Membership{}, domain.NewNotFoundError("membership not found")
+		}
+
+		return admin.Membership{}, domain.NewStoreError("failed to get membership: %v", err)
+	}
+
+	return fromMembership(membership), nil
+}
+
+func (r *MembershipRepository) findMemberships(ctx context.Context, qFilter bson.M) ([]admin.Membership, error) {
+	coll := r.db.Collection("memberships")
+
+	qCursor, err := coll.Find(ctx, live(qFilter))
+	if err != nil {
+		return nil, domain.NewStoreError("failed to find memberships: %v", err)
+	}
+
+	memberships, err := drainCursor[dbMembership](ctx, qCursor, fromMembership)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to get memberships: %v", err)
+	}
+
+	return memberships, nil
+}
+
+// EnsureMembershipIndexes creates the indexes of the memberships collection.
+// A user has at most one live membership in a realm. The deletion time is part of
+// the unique key, so that the deleted memberships do not prevent a new one.
+func EnsureMembershipIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("memberships")
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "realmId", Value: 1}, {Key: "userId", Value: 1}, {Key: "deletedAt", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	})
+	if err != nil {
+		return domain.NewStoreError("failed to create membership indexes: %v", err)
+	}
+
+	return dropIndex(ctx, coll, legacyMembershipIndex)
+}
+
+// legacyMembershipIndex is the former unique index of the memberships, which also
+// covered the deleted memberships.
+const legacyMembershipIndex = "realmId_1_userId_1"
+
+// dropIndex drops the index of the collection if it exists.
+func dropIndex(ctx context.Context, coll *mongo.Collection, name string) error {
+	specs, err := coll.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return domain.NewStoreError("failed to list %s indexes: %v", coll.Name(), err)
+	}
+
+	for _, spec := range specs {
+		if spec.Name != name {
+			continue
+		}
+
+		if _, err := coll.Indexes().DropOne(ctx, name); err != nil {
+			return domain.NewStoreError("failed to drop %s index %s: %v", coll.Name(), name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package repository_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/energimind/go-kit/testutil/crud"
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMembershipRepository_CRUD(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewMembershipRepository(db)
+	realmID := admin.ID("1")
+
+	crud.RunTests(t, crud.Setup[admin.Membership, admin.ID]{
+		RepoOps: crud.RepoOps[admin.Membership, admin.ID]{
+			GetAll: func(ctx context.Context) ([]admin.Membership, error) {
+				page, err := repo.GetMemberships(ctx, realmID, admin.ListOptions{})
+
+				return page.Items, err
+			},
+			GetByID: func(ctx context.Context, id admin.ID) (admin.Membership, error) {
+				return repo.GetMembership(ctx, realmID, id)
+			},
+			Create: func(ctx context.Context, membership admin.Membership) error {
+				return repo.CreateMembership(ctx, membership)
+			},
+			Update: func(ctx context.Context, membership admin.Membership) error {
+				// the modified entity holds the version stored by the update
+				membership.Version = admin.FirstVersion
+
+				return repo.UpdateMembership(ctx, membership)
+			},
+			Delete: func(ctx context.Context, id admin.ID) error {
+				return repo.DeleteMembership(ctx, realmID, id, admin.FirstVersion.Next(), time.Now())
+			},
+		},
+		EntityOps: crud.EntityOps[admin.Membership, admin.ID]{
+			NewEntity: func(key int) admin.Membership {
+				return admin.Membership{
+					ID:          admin.ID(strconv.Itoa(key)),
+					RealmID:     realmID,
+					UserID:      admin.ID("user" + strconv.Itoa(key)),
+					UserRealmID: "2",
+					Role:        admin.SystemRoleUser,
+					Version:     admin.FirstVersion,
+				}
+			},
+			ModifyEntity: func(membership admin.Membership) admin.Membership {
+				membership.Role = admin.SystemRoleManager
+				membership.Version = membership.Version.Next()
+
+				return membership
+			},
+			UnboundEntity: func() admin.Membership {
+				return admin.Membership{ID: ""}
+			},
+			ExtractKey: func(membership admin.Membership) admin.ID {
+				return membership.ID
+			},
+			MissingKey: func() admin.ID {
+				return "missing"
+			},
+		},
+		NotFoundErr: func() any {
+			return domain.NotFoundError{}
+		},
+	})
+}
+
+func TestMembershipRepository_userMemberships(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	ctx := context.Background()
+	repo := repository.NewMembershipRepository(db)
+
+	require.NoError(t, repository.EnsureMembershipIndexes(ctx, db))
+
+	m1 := admin.Membership{ID: "m1", RealmID: "1", UserID: "u1", UserRealmID: "3", Role: admin.SystemRoleUser}
+	m2 := admin.Membership{ID: "m2", RealmID: "2", UserID: "u1", UserRealmID: "3", Role: admin.SystemRoleManager}
+
+	require.NoError(t, repo.CreateMembership(ctx, m1))
+	require.NoError(t, repo.CreateMembership(ctx, m2))
+
+	duplicate := m1
+	duplicate.ID = "m3"
+	require.ErrorAs(t, repo.CreateMembership(ctx, duplicate), &domain.ConflictError{})
+
+	membership, err := repo.GetUserMembership(ctx, "2", "u1")
+	require.NoError(t, err)
+	require.Equal(t, m2, membership)
+
+	memberships, err := repo.GetUserMemberships(ctx, "u1")
+	require.NoError(t, err)
+	require.Len(t, memberships, 2)
+
+	deletedAt := time.Now().Truncate(time.Millisecond)
+
+	require.NoError(t, repo.DeleteUserMemberships(ctx, "u1", deletedAt))
+
+	_, err = repo.GetUserMembership(ctx, "2", "u1")
+	require.ErrorAs(t, err, &domain.NotFoundError{})
+
+	require.NoError(t, repo.RestoreUserMemberships(ctx, "u1", deletedAt))
+
+	memberships, err = repo.GetUserMemberships(ctx, "u1")
+	require.NoError(t, err)
+	require.Len(t, memberships, 2)
+
+}
+
+func TestMembershipRepository_recreate(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	ctx := context.Background()
+	repo := repository.NewMembershipRepository(db)
+
+	require.NoError(t, repository.EnsureMembershipIndexes(ctx, db))
+
+	m1 := admin.Membership{
+		ID: "m1", RealmID: "1", UserID: "u1", UserRealmID: "3", Role: admin.SystemRoleUser, Version: admin.FirstVersion,
+	}
+	m2 := admin.Membership{
+		ID: "m2", RealmID: "1", UserID: "u1", UserRealmID: "3", Role: admin.SystemRoleManager, Version: admin.FirstVersion,
+	}
+
+	require.NoError(t, repo.CreateMembership(ctx, m1))
+	require.NoError(t, repo.DeleteMembership(ctx, "1", "m1", m1.Version, time.Now()))
+
+	// the deleted membership does not prevent a new one
+	require.NoError(t, repo.CreateMembership(ctx, m2))
+
+	membership, err := repo.GetUserMembership(ctx, "1", "u1")
+	require.NoError(t, err)
+	require.Equal(t, m2, membership)
+
+	// nor a new one once removed with its user
+	require.NoError(t, repo.DeleteUserMemberships(ctx, "u1", time.Now().Add(time.Millisecond)))
+
+	m3 := m1
+	m3.ID = "m3"
+	require.NoError(t, repo.CreateMembership(ctx, m3))
+
+	// a deleted membership is not restored over a live one
+	_, err = repo.RestoreMembership(ctx, "1", "m1")
+	require.ErrorAs(t, err, &domain.ConflictError{})
+
+	require.NoError(t, repo.DeleteMembership(ctx, "1", "m3", m3.Version, time.Now().Add(2*time.Millisecond)))
+
+	_, err = repo.RestoreMembership(ctx, "1", "m1")
+	require.NoError(t, err)
+}
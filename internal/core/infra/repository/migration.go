@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// apiKeyOwner is the part of a user or a daemon document that holds API keys.
+type apiKeyOwner struct {
+	ID      string     `bson:"id"`
+	RealmID string     `bson:"realmId"`
+	APIKeys []dbAPIKey `bson:"apiKeys"`
+}
+
+// MigrateAPIKeys replaces the plain text API keys stored in the users and daemons
+// collections with their public prefix and keyed hash.
+//
+// The migration is idempotent. It returns the number of migrated API keys.
+func MigrateAPIKeys(ctx context.Context, db *mongo.Database, hasher domain.KeyHasher) (int, error) {
+	migrated := 0
+
+	for _, collName := range []string{"users", "daemons"} {
+		count, err := migrateAPIKeys(ctx, db.Collection(collName), hasher)
+		if err != nil {
+			return migrated, domain.NewStoreError("failed to migrate API keys in %s: %v", collName, err)
+		}
+
+		migrated += count
+	}
+
+	return migrated, nil
+}
+
+//nolint:wrapcheck // errors are wrapped by the caller
+func migrateAPIKeys(ctx context.Context, coll *mongo.Collection, hasher domain.KeyHasher) (int, error) {
+	qFilter := bson.M{"apiKeys.key": bson.M{"$exists": true, "$ne": ""}}
+
+	qCursor, err := coll.Find(ctx, qFilter)
+	if err != nil {
+		return 0, err
+	}
+
+	owners := make([]apiKeyOwner, 0)
+
+	if err := qCursor.All(ctx, &owners); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+
+	for _, owner := range owners {
+		for i, apiKey := range owner.APIKeys {
+			if apiKey.Key == "" {
+				continue
+			}
+
+			owner.APIKeys[i].Prefix = admin.APIKeyPrefix(apiKey.Key)
+			owner.APIKeys[i].Hash = hasher.HashKey(apiKey.Key)
+			owner.APIKeys[i].Key = ""
+
+			migrated++
+		}
+
+		qUpdate := bson.M{"$set": bson.M{"apiKeys": owner.APIKeys}}
+
+		if _, err := coll.UpdateOne(ctx, bson.M{"id": owner.ID}, qUpdate); err != nil {
+			return migrated, err
+		}
+	}
+
+	return migrated, nil
+}
+
+// MigrateCreationTimes sets the zero creation time on the users and daemons created
+// before it was recorded, so that the listings sorted by creation time can page
+// through all of them. The zero time is not exposed by the API.
+//
+// The migration is idempotent. It returns the number of migrated documents.
+func MigrateCreationTimes(ctx context.Context, db *mongo.Database) (int, error) {
+	migrated := 0
+
+	for _, collName := range []string{"users", "daemons"} {
+		qFilter := bson.M{"createdAt": bson.M{"$exists": false}}
+		qUpdate := bson.M{"$set": bson.M{"createdAt": time.Time{}}}
+
+		result, err := db.Collection(collName).UpdateMany(ctx, qFilter, qUpdate)
+		if err != nil {
+			return migrated, domain.NewStoreError("failed to migrate creation times in %s: %v", collName, err)
+		}
+
+		migrated += int(result.ModifiedCount)
+	}
+
+	return migrated, nil
+}
+
+// MigrateSearchTerms sets the search terms of the users stored before they were
+// recorded, so that the text search matches their custom attributes.
+//
+// The migration is idempotent. It returns the number of migrated users.
+func MigrateSearchTerms(ctx context.Context, db *mongo.Database) (int, error) {
+	coll := db.Collection("users")
+	qFilter := bson.M{"searchTerms": bson.M{"$exists": false}}
+
+	qCursor, err := coll.Find(ctx, qFilter)
+	if err != nil {
+		return 0, domain.NewStoreError("failed to find users without search terms: %v", err)
+	}
+
+	users, err := drainCursor[dbUser](ctx, qCursor, fromUser)
+	if err != nil {
+		return 0, domain.NewStoreError("failed to get users without search terms: %v", err)
+	}
+
+	for i, user := range users {
+		qUpdate := bson.M{"$set": bson.M{"searchTerms": toSearchTerms(user.Attributes)}}
+
+		if _, err := coll.UpdateOne(ctx, bson.M{"id": user.ID}, qUpdate); err != nil {
+			return i, domain.NewStoreError("failed to migrate search terms: %v", err)
+		}
+	}
+
+	return len(users), nil
+}
+
+// MigrateVersions sets the first version on the admin entities stored before they
+// were versioned, so that they can be updated and deleted with their entity tag.
+//
+// The migration is idempotent. It returns the number of migrated documents.
+func MigrateVersions(ctx context.Context, db *mongo.Database) (int, error) {
+	migrated := 0
+
+	collNames := []string{"realms", "providers", "users", "daemons", "groups", "memberships", "policies", "rules"}
+
+	for _, collName := range collNames {
+		qFilter := bson.M{"version": bson.M{"$exists": false}}
+		qUpdate := bson.M{"$set": bson.M{"version": int(admin.FirstVersion)}}
+
+		result, err := db.Collection(collName).UpdateMany(ctx, qFilter, qUpdate)
+		if err != nil {
+			return migrated, domain.NewStoreError("failed to migrate versions in %s: %v", collName, err)
+		}
+
+		migrated += int(result.ModifiedCount)
+	}
+
+	return migrated, nil
+}
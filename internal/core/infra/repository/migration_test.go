@@ -0,0 +1,164 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/repository"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type reverseHasher struct{}
+
+func (reverseHasher) HashKey(key string) string {
+	runes := []rune(key)
+
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
+
+func TestMigrateAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	ctx := context.Background()
+
+	_, err := db.Collection("users").InsertOne(ctx, bson.M{
+		"id":      "u1",
+		"realmId": "r1",
+		"enabled": true,
+		"apiKeys": bson.A{bson.M{"id": "k1", "enabled": true, "key": "0123456789abcdef"}},
+	})
+	require.NoError(t, err)
+
+	_, err = db.Collection("daemons").InsertOne(ctx, bson.M{
+		"id":      "d1",
+		"realmId": "r1",
+		"enabled": true,
+		"apiKeys": bson.A{bson.M{"id": "k2", "enabled": true, "key": "fedcba9876543210"}},
+	})
+	require.NoError(t, err)
+
+	migrated, err := repository.MigrateAPIKeys(ctx, db, reverseHasher{})
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+
+	// the migration is idempotent
+	migrated, err = repository.MigrateAPIKeys(ctx, db, reverseHasher{})
+	require.NoError(t, err)
+	require.Equal(t, 0, migrated)
+
+	userKeys, err := repository.NewUserRepository(db).GetAPIKeysByPrefix(ctx, "r1", "01234567")
+	require.NoError(t, err)
+	require.Equal(t, []admin.OwnedAPIKey{{
+		RealmID: "r1",
+		OwnerID: "u1",
+		APIKey: admin.APIKey{
+			ID:      "k1",
+			Enabled: true,
+			Prefix:  "01234567",
+			Hash:    "fedcba9876543210",
+		},
+	}}, userKeys)
+
+	daemonKeys, err := repository.NewDaemonRepository(db).GetAPIKeysByPrefix(ctx, "r1", "fedcba98")
+	require.NoError(t, err)
+	require.Len(t, daemonKeys, 1)
+	require.Equal(t, admin.ID("d1"), daemonKeys[0].OwnerID)
+	require.Empty(t, daemonKeys[0].APIKey.Key)
+	require.Equal(t, "01234567"+"89abcdef", daemonKeys[0].APIKey.Hash)
+}
+
+func TestMigrateCreationTimes(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	ctx := context.Background()
+
+	_, err := db.Collection("users").InsertOne(ctx, bson.M{"id": "u1", "realmId": "r1", "username": "legacy"})
+	require.NoError(t, err)
+
+	_, err = db.Collection("daemons").InsertOne(ctx, bson.M{"id": "d1", "realmId": "r1", "code": "legacy"})
+	require.NoError(t, err)
+
+	migrated, err := repository.MigrateCreationTimes(ctx, db)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+
+	page, err := repository.NewUserRepository(db).GetUsers(ctx, "r1", admin.UserFilter{},
+		admin.ListOptions{SortBy: admin.SortByCreatedAt, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.True(t, page.Items[0].CreatedAt.IsZero())
+
+	migrated, err = repository.MigrateCreationTimes(ctx, db)
+	require.NoError(t, err)
+	require.Zero(t, migrated)
+}
+
+func TestMigrateSearchTerms(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	ctx := context.Background()
+
+	require.NoError(t, repository.EnsureUserIndexes(ctx, db))
+
+	_, err := db.Collection("users").InsertOne(ctx, bson.M{
+		"id": "u1", "realmId": "r1", "username": "legacy", "attributes": bson.M{"team": "ops"},
+	})
+	require.NoError(t, err)
+
+	migrated, err := repository.MigrateSearchTerms(ctx, db)
+	require.NoError(t, err)
+	require.Equal(t, 1, migrated)
+
+	hits, err := repository.NewUserRepository(db).SearchUsers(ctx, "r1", "ops", 10)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+
+	migrated, err = repository.MigrateSearchTerms(ctx, db)
+	require.NoError(t, err)
+	require.Zero(t, migrated)
+}
+
+func TestMigrateVersions(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	ctx := context.Background()
+
+	_, err := db.Collection("realms").InsertOne(ctx, bson.M{"id": "r1", "code": "legacy"})
+	require.NoError(t, err)
+
+	_, err = db.Collection("rules").InsertOne(ctx, bson.M{"id": "p1", "realmId": "r1", "code": "legacy"})
+	require.NoError(t, err)
+
+	migrated, err := repository.MigrateVersions(ctx, db)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+
+	realm, err := repository.NewRealmRepository(db).GetRealm(ctx, "r1")
+	require.NoError(t, err)
+	require.Equal(t, admin.FirstVersion, realm.Version)
+
+	require.NoError(t, repository.NewRuleRepository(db).DeleteRule(ctx, "r1", "p1", admin.FirstVersion, time.Now()))
+
+	migrated, err = repository.MigrateVersions(ctx, db)
+	require.NoError(t, err)
+	require.Zero(t, migrated)
+}
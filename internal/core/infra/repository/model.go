@@ -4,11 +4,33 @@ import "time"
 
 // dbRealm is the database model for a realm.
 type dbRealm struct {
-	ID          string `bson:"id"`
-	Code        string `bson:"code"`
-	Name        string `bson:"name,omitempty"`
-	Description string `bson:"description,omitempty"`
-	Enabled     bool   `bson:"enabled"`
+	ID                string                  `bson:"id"`
+	Code              string                  `bson:"code"`
+	Name              string                  `bson:"name,omitempty"`
+	Description       string                  `bson:"description,omitempty"`
+	Enabled           bool                    `bson:"enabled"`
+	RedirectURIs      []string                `bson:"redirectUris,omitempty"`
+	APIKeyMaxLifetime time.Duration           `bson:"apiKeyMaxLifetime,omitempty"`
+	APIKeyScopes      []string                `bson:"apiKeyScopes,omitempty"`
+	Roles             []dbRole                `bson:"roles,omitempty"`
+	UserAttributes    []dbAttributeDefinition `bson:"userAttributes,omitempty"`
+	Version           int                     `bson:"version"`
+}
+
+// dbRole is the database model for a custom role of a realm.
+type dbRole struct {
+	Name        string   `bson:"name"`
+	Description string   `bson:"description,omitempty"`
+	Permissions []string `bson:"permissions,omitempty"`
+}
+
+// dbAttributeDefinition is the database model for the definition of a custom user attribute.
+type dbAttributeDefinition struct {
+	Name        string          `bson:"name"`
+	Description string          `bson:"description,omitempty"`
+	Type        dbAttributeType `bson:"type"`
+	Required    bool            `bson:"required,omitempty"`
+	Values      []string        `bson:"values,omitempty"`
 }
 
 // dbProvider is the database model for an authentication provider.
@@ -22,39 +44,148 @@ type dbProvider struct {
 	ClientID     string         `bson:"clientId"`
 	ClientSecret string         `bson:"clientSecret"`
 	RedirectURL  string         `bson:"redirectUrl"`
+	Version      int            `bson:"version"`
 }
 
 // dbUser is the database model for a user.
 type dbUser struct {
+	ID          string         `bson:"id"`
+	RealmID     string         `bson:"realmId"`
+	BindID      string         `bson:"bindId"`
+	Username    string         `bson:"username"`
+	Email       string         `bson:"email"`
+	DisplayName string         `bson:"displayName"`
+	Description string         `bson:"description,omitempty"`
+	Enabled     bool           `bson:"enabled"`
+	Role        dbSystemRole   `bson:"role"`
+	Roles       []string       `bson:"roles,omitempty"`
+	Attributes  map[string]any `bson:"attributes,omitempty"`
+	APIKeys     []dbAPIKey     `bson:"apiKeys,omitempty"`
+	CreatedAt   time.Time      `bson:"createdAt"`
+	Version     int            `bson:"version"`
+}
+
+// dbSearchableUser is the database model for a user as it is written. The text
+// index cannot reach into the custom attributes, so their string values are
+// copied to the search terms.
+type dbSearchableUser struct {
+	User        dbUser   `bson:",inline"`
+	SearchTerms []string `bson:"searchTerms"`
+}
+
+// dbUserHit is the database model for a user matching a text search.
+type dbUserHit struct {
+	User  dbUser  `bson:",inline"`
+	Score float64 `bson:"score"`
+}
+
+// dbDaemonHit is the database model for a daemon matching a text search.
+type dbDaemonHit struct {
+	Daemon dbDaemon `bson:",inline"`
+	Score  float64  `bson:"score"`
+}
+
+// dbDaemon is the database model for a daemon.
+type dbDaemon struct {
+	ID            string     `bson:"id"`
+	RealmID       string     `bson:"realmId"`
+	Code          string     `bson:"code"`
+	Name          string     `bson:"name,omitempty"`
+	Description   string     `bson:"description,omitempty"`
+	Enabled       bool       `bson:"enabled"`
+	APIKeys       []dbAPIKey `bson:"apiKeys,omitempty"`
+	AllowedRealms []string   `bson:"allowedRealms,omitempty"`
+	AllowedCIDRs  []string   `bson:"allowedCidrs,omitempty"`
+	CreatedAt     time.Time  `bson:"createdAt"`
+	Version       int        `bson:"version"`
+}
+
+// dbGroup is the database model for a group of users.
+type dbGroup struct {
+	ID          string            `bson:"id"`
+	RealmID     string            `bson:"realmId"`
+	Code        string            `bson:"code"`
+	Name        string            `bson:"name,omitempty"`
+	Description string            `bson:"description,omitempty"`
+	Roles       []string          `bson:"roles,omitempty"`
+	Attributes  map[string]string `bson:"attributes,omitempty"`
+	Members     []string          `bson:"members,omitempty"`
+	Version     int               `bson:"version"`
+}
+
+// dbMembership is the database model for a membership of a user in a realm.
+type dbMembership struct {
 	ID          string       `bson:"id"`
 	RealmID     string       `bson:"realmId"`
-	BindID      string       `bson:"bindId"`
-	Username    string       `bson:"username"`
-	Email       string       `bson:"email"`
-	DisplayName string       `bson:"displayName"`
-	Description string       `bson:"description,omitempty"`
-	Enabled     bool         `bson:"enabled"`
+	UserID      string       `bson:"userId"`
+	UserRealmID string       `bson:"userRealmId"`
 	Role        dbSystemRole `bson:"role"`
-	APIKeys     []dbAPIKey   `bson:"apiKeys,omitempty"`
+	Version     int          `bson:"version"`
 }
 
-// dbDaemon is the database model for a daemon.
-type dbDaemon struct {
-	ID          string     `bson:"id"`
-	RealmID     string     `bson:"realmId"`
-	Code        string     `bson:"code"`
-	Name        string     `bson:"name,omitempty"`
-	Description string     `bson:"description,omitempty"`
-	Enabled     bool       `bson:"enabled"`
-	APIKeys     []dbAPIKey `bson:"apiKeys,omitempty"`
+// dbPolicy is the database model for an authorization policy.
+type dbPolicy struct {
+	ID          string         `bson:"id"`
+	RealmID     string         `bson:"realmId"`
+	Code        string         `bson:"code"`
+	Description string         `bson:"description,omitempty"`
+	Effect      dbPolicyEffect `bson:"effect"`
+	Actions     []string       `bson:"actions"`
+	Resources   []string       `bson:"resources"`
+	Roles       []string       `bson:"roles,omitempty"`
+	Groups      []string       `bson:"groups,omitempty"`
+	Principals  []string       `bson:"principals,omitempty"`
+	Version     int            `bson:"version"`
+}
+
+// dbRule is the database model for an access rule.
+type dbRule struct {
+	ID          string   `bson:"id"`
+	RealmID     string   `bson:"realmId"`
+	Code        string   `bson:"code"`
+	Description string   `bson:"description,omitempty"`
+	Enabled     bool     `bson:"enabled"`
+	Permissions []string `bson:"permissions"`
+	Expression  string   `bson:"expression"`
+	Version     int      `bson:"version"`
 }
 
 // dbAPIKey is the database model for an API key.
+//
+// The Key field only holds legacy plain text keys. They are replaced
+// by the prefix and the hash during the API key migration.
 type dbAPIKey struct {
-	ID          string    `bson:"id"`
-	Name        string    `bson:"name,omitempty"`
-	Description string    `bson:"description,omitempty"`
-	Enabled     bool      `bson:"enabled"`
-	Key         string    `bson:"key"`
-	ExpiresAt   time.Time `bson:"expiresAt"`
+	ID           string           `bson:"id"`
+	Name         string           `bson:"name,omitempty"`
+	Description  string           `bson:"description,omitempty"`
+	Enabled      bool             `bson:"enabled"`
+	Key          string           `bson:"key,omitempty"`
+	Prefix       string           `bson:"prefix"`
+	Hash         string           `bson:"hash"`
+	CreatedAt    time.Time        `bson:"createdAt"`
+	ExpiresAt    time.Time        `bson:"expiresAt"`
+	Scopes       []string         `bson:"scopes,omitempty"`
+	LastUsedAt   time.Time        `bson:"lastUsedAt"`
+	LastUsedIP   string           `bson:"lastUsedIp,omitempty"`
+	UsageDays    map[string]int64 `bson:"usageDays,omitempty"`
+	AllowedCIDRs []string         `bson:"allowedCidrs,omitempty"`
+	RetiredAt    time.Time        `bson:"retiredAt,omitempty"`
+}
+
+// dbLockout is the database model for a lockout.
+type dbLockout struct {
+	ID            string        `bson:"id"`
+	Kind          dbLockoutKind `bson:"kind"`
+	RealmID       string        `bson:"realmId,omitempty"`
+	Subject       string        `bson:"subject"`
+	Failures      int           `bson:"failures"`
+	Lockouts      int           `bson:"lockouts"`
+	LastFailureAt time.Time     `bson:"lastFailureAt"`
+	LockedUntil   time.Time     `bson:"lockedUntil"`
+	ExpiresAt     time.Time     `bson:"expiresAt"`
+}
+
+// dbDeletion is the database model for the deletion time of a deleted document.
+type dbDeletion struct {
+	DeletedAt time.Time `bson:"deletedAt"`
 }
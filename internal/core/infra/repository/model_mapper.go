@@ -1,6 +1,11 @@
 package repository
 
-import "github.com/energimind/identity-server/internal/core/domain/admin"
+import (
+	"slices"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 func toID(id admin.ID) string {
 	return id.String()
@@ -10,23 +15,79 @@ func fromID(id string) admin.ID {
 	return admin.ID(id)
 }
 
+func toPermission(permission admin.Permission) string {
+	return string(permission)
+}
+
+func fromPermission(permission string) admin.Permission {
+	return admin.Permission(permission)
+}
+
 func toRealm(realm admin.Realm) dbRealm {
 	return dbRealm{
-		ID:          toID(realm.ID),
-		Code:        realm.Code,
-		Name:        realm.Name,
-		Description: realm.Description,
-		Enabled:     realm.Enabled,
+		ID:                toID(realm.ID),
+		Code:              realm.Code,
+		Name:              realm.Name,
+		Description:       realm.Description,
+		Enabled:           realm.Enabled,
+		RedirectURIs:      realm.RedirectURIs,
+		APIKeyMaxLifetime: realm.APIKeyMaxLifetime,
+		APIKeyScopes:      realm.APIKeyScopes,
+		Roles:             mapSlice(realm.Roles, toRole),
+		UserAttributes:    mapSlice(realm.UserAttributes, toAttributeDefinition),
+		Version:           int(realm.Version),
 	}
 }
 
 func fromRealm(realm dbRealm) admin.Realm {
 	return admin.Realm{
-		ID:          fromID(realm.ID),
-		Code:        realm.Code,
-		Name:        realm.Name,
-		Description: realm.Description,
-		Enabled:     realm.Enabled,
+		ID:                fromID(realm.ID),
+		Code:              realm.Code,
+		Name:              realm.Name,
+		Description:       realm.Description,
+		Enabled:           realm.Enabled,
+		RedirectURIs:      realm.RedirectURIs,
+		APIKeyMaxLifetime: realm.APIKeyMaxLifetime,
+		APIKeyScopes:      realm.APIKeyScopes,
+		Roles:             mapSlice(realm.Roles, fromRole),
+		UserAttributes:    mapSlice(realm.UserAttributes, fromAttributeDefinition),
+		Version:           admin.Version(realm.Version),
+	}
+}
+
+func toRole(role admin.Role) dbRole {
+	return dbRole{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: mapSlice(role.Permissions, toPermission),
+	}
+}
+
+func fromRole(role dbRole) admin.Role {
+	return admin.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: mapSlice(role.Permissions, fromPermission),
+	}
+}
+
+func toAttributeDefinition(definition admin.AttributeDefinition) dbAttributeDefinition {
+	return dbAttributeDefinition{
+		Name:        definition.Name,
+		Description: definition.Description,
+		Type:        toAttributeType(definition.Type),
+		Required:    definition.Required,
+		Values:      definition.Values,
+	}
+}
+
+func fromAttributeDefinition(definition dbAttributeDefinition) admin.AttributeDefinition {
+	return admin.AttributeDefinition{
+		Name:        definition.Name,
+		Description: definition.Description,
+		Type:        fromAttributeType(definition.Type),
+		Required:    definition.Required,
+		Values:      definition.Values,
 	}
 }
 
@@ -41,6 +102,7 @@ func toProvider(provider admin.Provider) dbProvider {
 		ClientID:     provider.ClientID,
 		ClientSecret: provider.ClientSecret,
 		RedirectURL:  provider.RedirectURL,
+		Version:      int(provider.Version),
 	}
 }
 
@@ -55,6 +117,7 @@ func fromProvider(provider dbProvider) admin.Provider {
 		ClientID:     provider.ClientID,
 		ClientSecret: provider.ClientSecret,
 		RedirectURL:  provider.RedirectURL,
+		Version:      admin.Version(provider.Version),
 	}
 }
 
@@ -69,7 +132,60 @@ func toUser(user admin.User) dbUser {
 		Description: user.Description,
 		Enabled:     user.Enabled,
 		Role:        toSystemRole(user.Role),
+		Roles:       user.Roles,
+		Attributes:  user.Attributes,
 		APIKeys:     mapSlice(user.APIKeys, toAPIKey),
+		CreatedAt:   user.CreatedAt,
+		Version:     int(user.Version),
+	}
+}
+
+func toSearchableUser(user admin.User) dbSearchableUser {
+	return dbSearchableUser{
+		User:        toUser(user),
+		SearchTerms: toSearchTerms(user.Attributes),
+	}
+}
+
+// toSearchTerms returns the string values of the custom attributes, including
+// the items of the lists.
+func toSearchTerms(attributes map[string]any) []string {
+	terms := make([]string, 0, len(attributes))
+
+	for _, value := range attributes {
+		switch v := value.(type) {
+		case string:
+			terms = append(terms, v)
+		case []string:
+			terms = append(terms, v...)
+		}
+	}
+
+	slices.Sort(terms)
+
+	return terms
+}
+
+func fromUserHit(hit dbUserHit) admin.SearchHit {
+	return admin.SearchHit{
+		Kind:        admin.PrincipalKindUser,
+		RealmID:     fromID(hit.User.RealmID),
+		ID:          fromID(hit.User.ID),
+		Name:        hit.User.Username,
+		DisplayName: hit.User.DisplayName,
+		Email:       hit.User.Email,
+		Score:       hit.Score,
+	}
+}
+
+func fromDaemonHit(hit dbDaemonHit) admin.SearchHit {
+	return admin.SearchHit{
+		Kind:        admin.PrincipalKindDaemon,
+		RealmID:     fromID(hit.Daemon.RealmID),
+		ID:          fromID(hit.Daemon.ID),
+		Name:        hit.Daemon.Code,
+		DisplayName: hit.Daemon.Name,
+		Score:       hit.Score,
 	}
 }
 
@@ -84,52 +200,251 @@ func fromUser(user dbUser) admin.User {
 		Description: user.Description,
 		Enabled:     user.Enabled,
 		Role:        fromSystemRole(user.Role),
+		Roles:       user.Roles,
+		Attributes:  fromAttributes(user.Attributes),
 		APIKeys:     mapSlice(user.APIKeys, fromAPIKey),
+		CreatedAt:   user.CreatedAt,
+		Version:     admin.Version(user.Version),
 	}
 }
 
+// fromAttributes converts the attribute values decoded from BSON to their canonical
+// types: integers to float64 and arrays to string slices.
+func fromAttributes(attributes map[string]any) map[string]any {
+	if attributes == nil {
+		return nil
+	}
+
+	converted := make(map[string]any, len(attributes))
+
+	for name, value := range attributes {
+		switch v := value.(type) {
+		case int32:
+			converted[name] = float64(v)
+		case int64:
+			converted[name] = float64(v)
+		case primitive.A:
+			items := make([]string, 0, len(v))
+
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					items = append(items, s)
+				}
+			}
+
+			converted[name] = items
+		default:
+			converted[name] = value
+		}
+	}
+
+	return converted
+}
+
 func toDaemon(daemon admin.Daemon) dbDaemon {
 	return dbDaemon{
-		ID:          toID(daemon.ID),
-		RealmID:     toID(daemon.RealmID),
-		Code:        daemon.Code,
-		Name:        daemon.Name,
-		Description: daemon.Description,
-		Enabled:     daemon.Enabled,
-		APIKeys:     mapSlice(daemon.APIKeys, toAPIKey),
+		ID:            toID(daemon.ID),
+		RealmID:       toID(daemon.RealmID),
+		Code:          daemon.Code,
+		Name:          daemon.Name,
+		Description:   daemon.Description,
+		Enabled:       daemon.Enabled,
+		APIKeys:       mapSlice(daemon.APIKeys, toAPIKey),
+		AllowedRealms: mapSlice(daemon.AllowedRealms, toID),
+		AllowedCIDRs:  daemon.AllowedCIDRs,
+		CreatedAt:     daemon.CreatedAt,
+		Version:       int(daemon.Version),
 	}
 }
 
 func fromDaemon(daemon dbDaemon) admin.Daemon {
 	return admin.Daemon{
-		ID:          fromID(daemon.ID),
-		RealmID:     fromID(daemon.RealmID),
-		Code:        daemon.Code,
-		Name:        daemon.Name,
-		Description: daemon.Description,
-		Enabled:     daemon.Enabled,
-		APIKeys:     mapSlice(daemon.APIKeys, fromAPIKey),
+		ID:            fromID(daemon.ID),
+		RealmID:       fromID(daemon.RealmID),
+		Code:          daemon.Code,
+		Name:          daemon.Name,
+		Description:   daemon.Description,
+		Enabled:       daemon.Enabled,
+		APIKeys:       mapSlice(daemon.APIKeys, fromAPIKey),
+		AllowedRealms: mapSlice(daemon.AllowedRealms, fromID),
+		AllowedCIDRs:  daemon.AllowedCIDRs,
+		CreatedAt:     daemon.CreatedAt,
+		Version:       admin.Version(daemon.Version),
+	}
+}
+
+func toGroup(group admin.Group) dbGroup {
+	return dbGroup{
+		ID:          toID(group.ID),
+		RealmID:     toID(group.RealmID),
+		Code:        group.Code,
+		Name:        group.Name,
+		Description: group.Description,
+		Roles:       group.Roles,
+		Attributes:  group.Attributes,
+		Members:     mapSlice(group.Members, toID),
+		Version:     int(group.Version),
+	}
+}
+
+func fromGroup(group dbGroup) admin.Group {
+	return admin.Group{
+		ID:          fromID(group.ID),
+		RealmID:     fromID(group.RealmID),
+		Code:        group.Code,
+		Name:        group.Name,
+		Description: group.Description,
+		Roles:       group.Roles,
+		Attributes:  group.Attributes,
+		Members:     mapSlice(group.Members, fromID),
+		Version:     admin.Version(group.Version),
+	}
+}
+
+func toMembership(membership admin.Membership) dbMembership {
+	return dbMembership{
+		ID:          toID(membership.ID),
+		RealmID:     toID(membership.RealmID),
+		UserID:      toID(membership.UserID),
+		UserRealmID: toID(membership.UserRealmID),
+		Role:        toSystemRole(membership.Role),
+		Version:     int(membership.Version),
+	}
+}
+
+func fromMembership(membership dbMembership) admin.Membership {
+	return admin.Membership{
+		ID:          fromID(membership.ID),
+		RealmID:     fromID(membership.RealmID),
+		UserID:      fromID(membership.UserID),
+		UserRealmID: fromID(membership.UserRealmID),
+		Role:        fromSystemRole(membership.Role),
+		Version:     admin.Version(membership.Version),
+	}
+}
+
+func toPolicy(policy admin.Policy) dbPolicy {
+	return dbPolicy{
+		ID:          toID(policy.ID),
+		RealmID:     toID(policy.RealmID),
+		Code:        policy.Code,
+		Description: policy.Description,
+		Effect:      toPolicyEffect(policy.Effect),
+		Actions:     policy.Actions,
+		Resources:   policy.Resources,
+		Roles:       policy.Roles,
+		Groups:      policy.Groups,
+		Principals:  mapSlice(policy.Principals, toID),
+		Version:     int(policy.Version),
+	}
+}
+
+func fromPolicy(policy dbPolicy) admin.Policy {
+	return admin.Policy{
+		ID:          fromID(policy.ID),
+		RealmID:     fromID(policy.RealmID),
+		Code:        policy.Code,
+		Description: policy.Description,
+		Effect:      fromPolicyEffect(policy.Effect),
+		Actions:     policy.Actions,
+		Resources:   policy.Resources,
+		Roles:       policy.Roles,
+		Groups:      policy.Groups,
+		Principals:  mapSlice(policy.Principals, fromID),
+		Version:     admin.Version(policy.Version),
+	}
+}
+
+func toRule(rule admin.AccessRule) dbRule {
+	return dbRule{
+		ID:          toID(rule.ID),
+		RealmID:     toID(rule.RealmID),
+		Code:        rule.Code,
+		Description: rule.Description,
+		Enabled:     rule.Enabled,
+		Permissions: mapSlice(rule.Permissions, toPermission),
+		Expression:  rule.Expression,
+		Version:     int(rule.Version),
+	}
+}
+
+func fromRule(rule dbRule) admin.AccessRule {
+	return admin.AccessRule{
+		ID:          fromID(rule.ID),
+		RealmID:     fromID(rule.RealmID),
+		Code:        rule.Code,
+		Description: rule.Description,
+		Enabled:     rule.Enabled,
+		Permissions: mapSlice(rule.Permissions, fromPermission),
+		Expression:  rule.Expression,
+		Version:     admin.Version(rule.Version),
 	}
 }
 
 func toAPIKey(apiKey admin.APIKey) dbAPIKey {
 	return dbAPIKey{
-		ID:          toID(apiKey.ID),
-		Name:        apiKey.Name,
-		Description: apiKey.Description,
-		Enabled:     apiKey.Enabled,
-		Key:         apiKey.Key,
-		ExpiresAt:   apiKey.ExpiresAt,
+		ID:           toID(apiKey.ID),
+		Name:         apiKey.Name,
+		Description:  apiKey.Description,
+		Enabled:      apiKey.Enabled,
+		Key:          apiKey.Key,
+		Prefix:       apiKey.Prefix,
+		Hash:         apiKey.Hash,
+		CreatedAt:    apiKey.CreatedAt,
+		ExpiresAt:    apiKey.ExpiresAt,
+		Scopes:       apiKey.Scopes,
+		LastUsedAt:   apiKey.LastUsedAt,
+		LastUsedIP:   apiKey.LastUsedIP,
+		UsageDays:    apiKey.UsageDays,
+		AllowedCIDRs: apiKey.AllowedCIDRs,
+		RetiredAt:    apiKey.RetiredAt,
 	}
 }
 
 func fromAPIKey(apiKey dbAPIKey) admin.APIKey {
 	return admin.APIKey{
-		ID:          fromID(apiKey.ID),
-		Name:        apiKey.Name,
-		Description: apiKey.Description,
-		Enabled:     apiKey.Enabled,
-		Key:         apiKey.Key,
-		ExpiresAt:   apiKey.ExpiresAt,
+		ID:           fromID(apiKey.ID),
+		Name:         apiKey.Name,
+		Description:  apiKey.Description,
+		Enabled:      apiKey.Enabled,
+		Key:          apiKey.Key,
+		Prefix:       apiKey.Prefix,
+		Hash:         apiKey.Hash,
+		CreatedAt:    apiKey.CreatedAt,
+		ExpiresAt:    apiKey.ExpiresAt,
+		Scopes:       apiKey.Scopes,
+		LastUsedAt:   apiKey.LastUsedAt,
+		LastUsedIP:   apiKey.LastUsedIP,
+		UsageDays:    apiKey.UsageDays,
+		AllowedCIDRs: apiKey.AllowedCIDRs,
+		RetiredAt:    apiKey.RetiredAt,
+	}
+}
+
+func toLockout(lockout admin.Lockout) dbLockout {
+	return dbLockout{
+		ID:            toID(lockout.ID),
+		Kind:          toLockoutKind(lockout.Kind),
+		RealmID:       toID(lockout.RealmID),
+		Subject:       lockout.Subject,
+		Failures:      lockout.Failures,
+		Lockouts:      lockout.Lockouts,
+		LastFailureAt: lockout.LastFailureAt,
+		LockedUntil:   lockout.LockedUntil,
+		ExpiresAt:     lockout.ExpiresAt,
+	}
+}
+
+func fromLockout(lockout dbLockout) admin.Lockout {
+	return admin.Lockout{
+		ID:            fromID(lockout.ID),
+		Kind:          fromLockoutKind(lockout.Kind),
+		RealmID:       fromID(lockout.RealmID),
+		Subject:       lockout.Subject,
+		Failures:      lockout.Failures,
+		Lockouts:      lockout.Lockouts,
+		LastFailureAt: lockout.LastFailureAt,
+		LockedUntil:   lockout.LockedUntil,
+		ExpiresAt:     lockout.ExpiresAt,
 	}
 }
@@ -7,39 +7,84 @@ import (
 	"github.com/energimind/go-kit/testutil/mapping"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func Test_allUserFieldsAreMapped(t *testing.T) {
 	mapping.CheckAllFieldsAreMapped(t, admin.Realm{}, dbRealm{})
+	mapping.CheckAllFieldsAreMapped(t, admin.Role{}, dbRole{})
+	mapping.CheckAllFieldsAreMapped(t, admin.AttributeDefinition{}, dbAttributeDefinition{})
 	mapping.CheckAllFieldsAreMapped(t, admin.Provider{}, dbProvider{})
 	mapping.CheckAllFieldsAreMapped(t, admin.User{}, dbUser{})
 	mapping.CheckAllFieldsAreMapped(t, admin.Daemon{}, dbDaemon{})
+	mapping.CheckAllFieldsAreMapped(t, admin.Group{}, dbGroup{})
+	mapping.CheckAllFieldsAreMapped(t, admin.Membership{}, dbMembership{})
+	mapping.CheckAllFieldsAreMapped(t, admin.Policy{}, dbPolicy{})
+	mapping.CheckAllFieldsAreMapped(t, admin.AccessRule{}, dbRule{})
 	mapping.CheckAllFieldsAreMapped(t, admin.APIKey{}, dbAPIKey{})
+	mapping.CheckAllFieldsAreMapped(t, admin.Lockout{}, dbLockout{})
 
 	mapping.CheckAllFieldsAreMapped(t, dbRealm{}, admin.Realm{})
+	mapping.CheckAllFieldsAreMapped(t, dbRole{}, admin.Role{})
+	mapping.CheckAllFieldsAreMapped(t, dbAttributeDefinition{}, admin.AttributeDefinition{})
 	mapping.CheckAllFieldsAreMapped(t, dbProvider{}, admin.Provider{})
 	mapping.CheckAllFieldsAreMapped(t, dbUser{}, admin.User{})
 	mapping.CheckAllFieldsAreMapped(t, dbDaemon{}, admin.Daemon{})
+	mapping.CheckAllFieldsAreMapped(t, dbGroup{}, admin.Group{})
+	mapping.CheckAllFieldsAreMapped(t, dbMembership{}, admin.Membership{})
+	mapping.CheckAllFieldsAreMapped(t, dbPolicy{}, admin.Policy{})
+	mapping.CheckAllFieldsAreMapped(t, dbRule{}, admin.AccessRule{})
 	mapping.CheckAllFieldsAreMapped(t, dbAPIKey{}, admin.APIKey{})
+	mapping.CheckAllFieldsAreMapped(t, dbLockout{}, admin.Lockout{})
 }
 
 func Test_mapRealm(t *testing.T) {
 	t.Parallel()
 
 	from := admin.Realm{
-		ID:          "realm1",
-		Code:        "realm1",
-		Name:        "Realm 1",
-		Description: "Realm 1",
-		Enabled:     true,
+		ID:                "realm1",
+		Code:              "realm1",
+		Name:              "Realm 1",
+		Description:       "Realm 1",
+		Enabled:           true,
+		RedirectURIs:      []string{"https://app.somedomain.com/*"},
+		APIKeyMaxLifetime: 90 * 24 * time.Hour,
+		APIKeyScopes:      []string{"reports:read"},
+		Roles: []admin.Role{{
+			Name:        "auditor",
+			Description: "Auditor",
+			Permissions: []admin.Permission{admin.PermissionUsersRead},
+		}},
+		UserAttributes: []admin.AttributeDefinition{{
+			Name:        "costCentre",
+			Description: "Cost centre",
+			Type:        admin.AttributeTypeEnum,
+			Required:    true,
+			Values:      []string{"R&D", "Sales"},
+		}},
 	}
 
 	expected := dbRealm{
-		ID:          "realm1",
-		Code:        "realm1",
-		Name:        "Realm 1",
-		Description: "Realm 1",
-		Enabled:     true,
+		ID:                "realm1",
+		Code:              "realm1",
+		Name:              "Realm 1",
+		Description:       "Realm 1",
+		Enabled:           true,
+		RedirectURIs:      []string{"https://app.somedomain.com/*"},
+		APIKeyMaxLifetime: 90 * 24 * time.Hour,
+		APIKeyScopes:      []string{"reports:read"},
+		Roles: []dbRole{{
+			Name:        "auditor",
+			Description: "Auditor",
+			Permissions: []string{"users.read"},
+		}},
+		UserAttributes: []dbAttributeDefinition{{
+			Name:        "costCentre",
+			Description: "Cost centre",
+			Type:        dbAttributeTypeEnum,
+			Required:    true,
+			Values:      []string{"R&D", "Sales"},
+		}},
 	}
 
 	mapped := toRealm(from)
@@ -95,6 +140,8 @@ func Test_mapUser(t *testing.T) {
 		Description: "User 1",
 		Enabled:     true,
 		Role:        admin.SystemRoleManager,
+		Roles:       []string{"auditor"},
+		Attributes:  map[string]any{"employeeNumber": 42.0, "skills": []string{"go"}},
 		APIKeys:     []admin.APIKey{{}},
 	}
 
@@ -107,6 +154,8 @@ func Test_mapUser(t *testing.T) {
 		Description: "User 1",
 		Enabled:     true,
 		Role:        dbSystemRoleManager,
+		Roles:       []string{"auditor"},
+		Attributes:  map[string]any{"employeeNumber": 42.0, "skills": []string{"go"}},
 		APIKeys:     []dbAPIKey{{}},
 	}
 
@@ -117,31 +166,198 @@ func Test_mapUser(t *testing.T) {
 	require.Equal(t, from, back)
 }
 
+func Test_fromAttributes(t *testing.T) {
+	t.Parallel()
+
+	decoded := map[string]any{
+		"name":   "John",
+		"int32":  int32(7),
+		"int64":  int64(8),
+		"double": 1.5,
+		"bool":   true,
+		"list":   primitive.A{"a", "b"},
+	}
+
+	expected := map[string]any{
+		"name":   "John",
+		"int32":  7.0,
+		"int64":  8.0,
+		"double": 1.5,
+		"bool":   true,
+		"list":   []string{"a", "b"},
+	}
+
+	require.Equal(t, expected, fromAttributes(decoded))
+	require.Nil(t, fromAttributes(nil))
+}
+
+func Test_toSearchTerms(t *testing.T) {
+	t.Parallel()
+
+	attributes := map[string]any{
+		"team":   "core",
+		"tags":   []string{"ops", "admin"},
+		"level":  3.0,
+		"active": true,
+	}
+
+	require.Equal(t, []string{"admin", "core", "ops"}, toSearchTerms(attributes))
+	require.Equal(t, []string{}, toSearchTerms(nil))
+}
+
 func Test_mapDaemon(t *testing.T) {
 	t.Parallel()
 
 	from := admin.Daemon{
-		ID:          "daemon1",
+		ID:            "daemon1",
+		RealmID:       "realm1",
+		Code:          "daemon1",
+		Name:          "Daemon 1",
+		Description:   "Daemon 1",
+		Enabled:       true,
+		APIKeys:       []admin.APIKey{{}},
+		AllowedRealms: []admin.ID{"realm2"},
+		AllowedCIDRs:  []string{"10.0.0.0/8"},
+	}
+
+	expected := dbDaemon{
+		ID:            "daemon1",
+		RealmID:       "realm1",
+		Code:          "daemon1",
+		Name:          "Daemon 1",
+		Description:   "Daemon 1",
+		Enabled:       true,
+		APIKeys:       []dbAPIKey{{}},
+		AllowedRealms: []string{"realm2"},
+		AllowedCIDRs:  []string{"10.0.0.0/8"},
+	}
+
+	mapped := toDaemon(from)
+	back := fromDaemon(mapped)
+
+	require.Equal(t, expected, mapped)
+	require.Equal(t, from, back)
+}
+
+func Test_mapGroup(t *testing.T) {
+	t.Parallel()
+
+	from := admin.Group{
+		ID:          "group1",
+		RealmID:     "realm1",
+		Code:        "group1",
+		Name:        "Group 1",
+		Description: "Group 1",
+		Roles:       []string{"auditor"},
+		Attributes:  map[string]string{"department": "sales"},
+		Members:     []admin.ID{"user1"},
+	}
+
+	expected := dbGroup{
+		ID:          "group1",
+		RealmID:     "realm1",
+		Code:        "group1",
+		Name:        "Group 1",
+		Description: "Group 1",
+		Roles:       []string{"auditor"},
+		Attributes:  map[string]string{"department": "sales"},
+		Members:     []string{"user1"},
+	}
+
+	mapped := toGroup(from)
+	back := fromGroup(mapped)
+
+	require.Equal(t, expected, mapped)
+	require.Equal(t, from, back)
+}
+
+func Test_mapMembership(t *testing.T) {
+	t.Parallel()
+
+	from := admin.Membership{
+		ID:          "membership1",
+		RealmID:     "realm1",
+		UserID:      "user1",
+		UserRealmID: "realm2",
+		Role:        admin.SystemRoleManager,
+	}
+
+	expected := dbMembership{
+		ID:          "membership1",
 		RealmID:     "realm1",
-		Code:        "daemon1",
-		Name:        "Daemon 1",
-		Description: "Daemon 1",
+		UserID:      "user1",
+		UserRealmID: "realm2",
+		Role:        dbSystemRoleManager,
+	}
+
+	mapped := toMembership(from)
+	back := fromMembership(mapped)
+
+	require.Equal(t, expected, mapped)
+	require.Equal(t, from, back)
+}
+
+func Test_mapPolicy(t *testing.T) {
+	t.Parallel()
+
+	from := admin.Policy{
+		ID:          "policy1",
+		RealmID:     "realm1",
+		Code:        "policy1",
+		Description: "Policy 1",
+		Effect:      admin.PolicyEffectAllow,
+		Actions:     []string{"invoices:read"},
+		Resources:   []string{"invoices/*"},
+		Roles:       []string{"auditor"},
+		Groups:      []string{"sales"},
+		Principals:  []admin.ID{"user1"},
+	}
+
+	expected := dbPolicy{
+		ID:          "policy1",
+		RealmID:     "realm1",
+		Code:        "policy1",
+		Description: "Policy 1",
+		Effect:      dbPolicyEffectAllow,
+		Actions:     []string{"invoices:read"},
+		Resources:   []string{"invoices/*"},
+		Roles:       []string{"auditor"},
+		Groups:      []string{"sales"},
+		Principals:  []string{"user1"},
+	}
+
+	mapped := toPolicy(from)
+	back := fromPolicy(mapped)
+
+	require.Equal(t, expected, mapped)
+	require.Equal(t, from, back)
+}
+
+func Test_mapRule(t *testing.T) {
+	t.Parallel()
+
+	from := admin.AccessRule{
+		ID:          "rule1",
+		RealmID:     "realm1",
+		Code:        "rule1",
+		Description: "Rule 1",
 		Enabled:     true,
-		APIKeys:     []admin.APIKey{{}},
+		Permissions: []admin.Permission{admin.PermissionUsersWrite},
+		Expression:  "actor.attributes.department == target.attributes.department",
 	}
 
-	expected := dbDaemon{
-		ID:          "daemon1",
+	expected := dbRule{
+		ID:          "rule1",
 		RealmID:     "realm1",
-		Code:        "daemon1",
-		Name:        "Daemon 1",
-		Description: "Daemon 1",
+		Code:        "rule1",
+		Description: "Rule 1",
 		Enabled:     true,
-		APIKeys:     []dbAPIKey{{}},
+		Permissions: []string{"users.write"},
+		Expression:  "actor.attributes.department == target.attributes.department",
 	}
 
-	mapped := toDaemon(from)
-	back := fromDaemon(mapped)
+	mapped := toRule(from)
+	back := fromRule(mapped)
 
 	require.Equal(t, expected, mapped)
 	require.Equal(t, from, back)
@@ -153,19 +369,37 @@ func Test_mapAPIKey(t *testing.T) {
 	now := time.Now().Round(time.Second)
 
 	from := admin.APIKey{
-		Name:        "Key 1",
-		Description: "Key 1",
-		Enabled:     true,
-		Key:         "key1",
-		ExpiresAt:   now,
+		Name:         "Key 1",
+		Description:  "Key 1",
+		Enabled:      true,
+		Key:          "key1",
+		Prefix:       "ke",
+		Hash:         "hash1",
+		CreatedAt:    now,
+		ExpiresAt:    now,
+		Scopes:       []string{"sessions:read"},
+		LastUsedAt:   now,
+		LastUsedIP:   "10.0.0.1",
+		UsageDays:    map[string]int64{"2024-01-02": 42},
+		AllowedCIDRs: []string{"10.0.0.0/8"},
+		RetiredAt:    now,
 	}
 
 	expected := dbAPIKey{
-		Name:        "Key 1",
-		Description: "Key 1",
-		Enabled:     true,
-		Key:         "key1",
-		ExpiresAt:   now,
+		Name:         "Key 1",
+		Description:  "Key 1",
+		Enabled:      true,
+		Key:          "key1",
+		Prefix:       "ke",
+		Hash:         "hash1",
+		CreatedAt:    now,
+		ExpiresAt:    now,
+		Scopes:       []string{"sessions:read"},
+		LastUsedAt:   now,
+		LastUsedIP:   "10.0.0.1",
+		UsageDays:    map[string]int64{"2024-01-02": 42},
+		AllowedCIDRs: []string{"10.0.0.0/8"},
+		RetiredAt:    now,
 	}
 
 	mapped := toAPIKey(from)
@@ -174,3 +408,39 @@ func Test_mapAPIKey(t *testing.T) {
 	require.Equal(t, expected, mapped)
 	require.Equal(t, from, back)
 }
+
+func Test_mapLockout(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Round(time.Second)
+
+	from := admin.Lockout{
+		ID:            "lockout1",
+		Kind:          admin.LockoutKindAPIKeyPrefix,
+		RealmID:       "realm1",
+		Subject:       "01234567",
+		Failures:      2,
+		Lockouts:      1,
+		LastFailureAt: now,
+		LockedUntil:   now,
+		ExpiresAt:     now,
+	}
+
+	expected := dbLockout{
+		ID:            "lockout1",
+		Kind:          dbLockoutKindAPIKeyPrefix,
+		RealmID:       "realm1",
+		Subject:       "01234567",
+		Failures:      2,
+		Lockouts:      1,
+		LastFailureAt: now,
+		LockedUntil:   now,
+		ExpiresAt:     now,
+	}
+
+	mapped := toLockout(from)
+	back := fromLockout(mapped)
+
+	require.Equal(t, expected, mapped)
+	require.Equal(t, from, back)
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// pageCursor is the position of the last item of a page. It is encoded into the
+// opaque cursor the next page starts after.
+type pageCursor struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Time       bool   `json:"t,omitempty"`
+	Value      string `json:"v"`
+	ID         string `json:"id"`
+}
+
+// findPage finds a page of the documents of the collection matching the filter.
+// The documents are sorted by the sort field of the list options, then by their
+// ID, so that the pages are stable. The sort field must be a string or a date;
+// the documents are only sorted by their ID without a sort field.
+//
+// The name of the documents is used in the error messages.
+func findPage[T, M any](
+	ctx context.Context,
+	coll *mongo.Collection,
+	name string,
+	qFilter bson.M,
+	listOptions admin.ListOptions,
+	mapper func(T) M,
+) (admin.Page[M], error) {
+	if listOptions.SortBy == "" {
+		listOptions.SortBy = "id"
+	}
+
+	total, err := coll.CountDocuments(ctx, qFilter)
+	if err != nil {
+		return admin.Page[M]{}, domain.NewStoreError("failed to count %s: %v", name, err)
+	}
+
+	if listOptions.Cursor != "" {
+		qAfter, cErr := afterCursor(listOptions)
+		if cErr != nil {
+			return admin.Page[M]{}, cErr
+		}
+
+		qFilter = bson.M{"$and": bson.A{qFilter, qAfter}}
+	}
+
+	direction := 1
+
+	if listOptions.Descending {
+		direction = -1
+	}
+
+	qSort := bson.D{{Key: "id", Value: direction}}
+
+	if listOptions.SortBy != "id" {
+		qSort = append(bson.D{{Key: listOptions.SortBy, Value: direction}}, qSort...)
+	}
+
+	qOptions := options.Find().SetSort(qSort)
+
+	if listOptions.Limit > 0 {
+		// one more document tells whether there is a next page
+		qOptions.SetLimit(int64(listOptions.Limit) + 1)
+	}
+
+	qCursor, err := coll.Find(ctx, qFilter, qOptions)
+	if err != nil {
+		return admin.Page[M]{}, domain.NewStoreError("failed to find %s: %v", name, err)
+	}
+
+	docs, err := drainCursor[bson.Raw](ctx, qCursor, func(doc bson.Raw) bson.Raw { return doc })
+	if err != nil {
+		return admin.Page[M]{}, domain.NewStoreError("failed to get %s: %v", name, err)
+	}
+
+	page := admin.Page[M]{Total: int(total)}
+
+	if listOptions.Limit > 0 && len(docs) > listOptions.Limit {
+		docs = docs[:listOptions.Limit]
+
+		page.NextCursor, err = nextCursor(docs[len(docs)-1], listOptions)
+		if err != nil {
+			return admin.Page[M]{}, err
+		}
+	}
+
+	page.Items = make([]M, len(docs))
+
+	for i, doc := range docs {
+		var item T
+
+		if err := bson.Unmarshal(doc, &item); err != nil {
+			return admin.Page[M]{}, domain.NewStoreError("failed to decode %s: %v", name, err)
+		}
+
+		page.Items[i] = mapper(item)
+	}
+
+	return page, nil
+}
+
+// nextCursor returns the cursor of the page starting after the given document.
+func nextCursor(doc bson.Raw, listOptions admin.ListOptions) (string, error) {
+	position := pageCursor{
+		SortBy:     listOptions.SortBy,
+		Descending: listOptions.Descending,
+		ID:         doc.Lookup("id").StringValue(),
+	}
+
+	value := doc.Lookup(listOptions.SortBy)
+
+	switch value.Type {
+	case bson.TypeString:
+		position.Value = value.StringValue()
+	case bson.TypeDateTime:
+		position.Time = true
+		position.Value = value.Time().Format(time.RFC3339Nano)
+	default:
+		return "", domain.NewStoreError("cannot sort by %s of type %s", listOptions.SortBy, value.Type)
+	}
+
+	return encodeCursor(position)
+}
+
+// afterCursor returns the filter of the documents following the cursor of the
+// list options, in their sort order.
+func afterCursor(listOptions admin.ListOptions) (bson.M, error) {
+	position, err := decodeCursor(listOptions.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if position.SortBy != listOptions.SortBy || position.Descending != listOptions.Descending {
+		return nil, domain.NewValidationError("the cursor does not match the sort order")
+	}
+
+	var value any = position.Value
+
+	if position.Time {
+		value, err = time.Parse(time.RFC3339Nano, position.Value)
+		if err != nil {
+			return nil, domain.NewValidationError("invalid cursor")
+		}
+	}
+
+	operator := "$gt"
+
+	if position.Descending {
+		operator = "$lt"
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{position.SortBy: bson.M{operator: value}},
+		bson.M{position.SortBy: value, "id": bson.M{operator: position.ID}},
+	}}, nil
+}
+
+func encodeCursor(position pageCursor) (string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", domain.NewStoreError("failed to encode cursor: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(encoded string) (pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return pageCursor{}, domain.NewValidationError("invalid cursor")
+	}
+
+	position := pageCursor{}
+
+	if err := json.Unmarshal(data, &position); err != nil || position.SortBy == "" || position.ID == "" {
+		return pageCursor{}, domain.NewValidationError("invalid cursor")
+	}
+
+	return position, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func Test_nextCursor(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+
+	doc, err := bson.Marshal(dbUser{ID: "u1", Username: "alice", CreatedAt: createdAt})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		options   admin.ListOptions
+		wantAfter bson.M
+	}{
+		"string": {
+			options: admin.ListOptions{SortBy: "username"},
+			wantAfter: bson.M{"$or": bson.A{
+				bson.M{"username": bson.M{"$gt": "alice"}},
+				bson.M{"username": "alice", "id": bson.M{"$gt": "u1"}},
+			}},
+		},
+		"dateDescending": {
+			options: admin.ListOptions{SortBy: "createdAt", Descending: true},
+			wantAfter: bson.M{"$or": bson.A{
+				bson.M{"createdAt": bson.M{"$lt": createdAt}},
+				bson.M{"createdAt": createdAt, "id": bson.M{"$lt": "u1"}},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cursor, err := nextCursor(doc, test.options)
+			require.NoError(t, err)
+
+			test.options.Cursor = cursor
+
+			after, err := afterCursor(test.options)
+			require.NoError(t, err)
+			require.Equal(t, test.wantAfter, after)
+		})
+	}
+
+	t.Run("unsupportedType", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := nextCursor(doc, admin.ListOptions{SortBy: "enabled"})
+		require.ErrorAs(t, err, &domain.StoreError{})
+	})
+}
+
+func Test_afterCursor_invalid(t *testing.T) {
+	t.Parallel()
+
+	cursor, err := encodeCursor(pageCursor{SortBy: "username", Value: "alice", ID: "u1"})
+	require.NoError(t, err)
+
+	tests := map[string]admin.ListOptions{
+		"notBase64":       {SortBy: "username", Cursor: "!"},
+		"notJSON":         {SortBy: "username", Cursor: "invalid"},
+		"otherSortField":  {SortBy: "email", Cursor: cursor},
+		"otherSortOrder":  {SortBy: "username", Descending: true, Cursor: cursor},
+		"missingPosition": {SortBy: "username", Cursor: "e30"},
+	}
+
+	for name, options := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := afterCursor(options)
+			require.ErrorAs(t, err, &domain.ValidationError{})
+		})
+	}
+}
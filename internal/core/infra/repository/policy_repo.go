@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PolicyRepository is a MongoDB implementation of PolicyRepository.
+type PolicyRepository struct {
+	db *mongo.Database
+}
+
+// NewPolicyRepository creates a new MongoDB policy repository.
+func NewPolicyRepository(db *mongo.Database) *PolicyRepository {
+	return &PolicyRepository{db: db}
+}
+
+// Ensure repository implements the admin.PolicyRepository interface.
+var _ admin.PolicyRepository = (*PolicyRepository)(nil)
+
+// GetPolicies implements the admin.PolicyRepository interface.
+func (r *PolicyRepository) GetPolicies(
+	ctx context.Context,
+	realmID admin.ID,
+	listOptions admin.ListOptions,
+) (admin.Page[admin.Policy], error) {
+	coll := r.db.Collection("policies")
+	qFilter := live(bson.M{"realmId": realmID})
+
+	return findPage(ctx, coll, "policies", qFilter, listOptions, fromPolicy)
+}
+
+// GetPolicy implements the admin.PolicyRepository interface.
+func (r *PolicyRepository) GetPolicy(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (admin.Policy, error) {
+	coll := r.db.Collection("policies")
+	qFilter := live(bson.M{"id": id, "realmId": realmID})
+	policy := dbPolicy{}
+
+	if err := coll.FindOne(ctx, qFilter).Decode(&policy); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return admin.Policy{}, domain.NewNotFoundError("policy %v not found", id)
+		}
+
+		return admin.Policy{}, domain.NewStoreError("failed to get policy: %v", err)
+	}
+
+	return fromPolicy(policy), nil
+}
+
+// CreatePolicy implements the admin.PolicyRepository interface.
+func (r *PolicyRepository) CreatePolicy(
+	ctx context.Context,
+	policy admin.Policy,
+) error {
+	coll := r.db.Collection("policies")
+
+	if _, err := coll.InsertOne(ctx, toPolicy(policy)); err != nil {
+		return domain.NewStoreError("failed to create policy: %v", err)
+	}
+
+	return nil
+}
+
+// UpdatePolicy implements the admin.PolicyRepository interface.
+func (r *PolicyRepository) UpdatePolicy(
+	ctx context.Context,
+	policy admin.Policy,
+) error {
+	coll := r.db.Collection("policies")
+	qFilter := live(bson.M{"id": policy.ID, "realmId": policy.RealmID})
+	version := policy.Version
+
+	policy.Version = version.Next()
+
+	qUpdate := bson.M{"$set": toPolicy(policy)}
+
+	result, err := coll.UpdateOne(ctx, withVersion(qFilter, version), qUpdate)
+	if err != nil {
+		return domain.NewStoreError("failed to update policy: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return missingOrStale(ctx, coll, qFilter, "policy", policy.ID, version)
+	}
+
+	return nil
+}
+
+// DeletePolicy implements the admin.PolicyRepository interface.
+func (r *PolicyRepository) DeletePolicy(
+	ctx context.Context,
+	realmID, id admin.ID,
+	version admin.Version,
+	deletedAt time.Time,
+) error {
+	coll := r.db.Collection("policies")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return softDelete(ctx, coll, qFilter, "policy", id, version, deletedAt)
+}
+
+// RestorePolicy implements the admin.PolicyRepository interface.
+func (r *PolicyRepository) RestorePolicy(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (time.Time, error) {
+	coll := r.db.Collection("policies")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return restore(ctx, coll, qFilter, "policy", id)
+}
+
+// EnsurePolicyIndexes creates the indexes of the policies collection.
+// The policies of a realm are loaded on every authorization check, and listed
+// sorted by code.
+func EnsurePolicyIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("policies")
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "code", Value: 1}, {Key: "id", Value: 1}}},
+	})
+	if err != nil {
+		return domain.NewStoreError("failed to create policy indexes: %v", err)
+	}
+
+	return nil
+}
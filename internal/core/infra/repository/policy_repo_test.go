@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/energimind/go-kit/testutil/crud"
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/repository"
+)
+
+func TestPolicyRepository_CRUD(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewPolicyRepository(db)
+	realmID := admin.ID("1")
+
+	crud.RunTests(t, crud.Setup[admin.Policy, admin.ID]{
+		RepoOps: crud.RepoOps[admin.Policy, admin.ID]{
+			GetAll: func(ctx context.Context) ([]admin.Policy, error) {
+				page, err := repo.GetPolicies(ctx, realmID, admin.ListOptions{})
+
+				return page.Items, err
+			},
+			GetByID: func(ctx context.Context, id admin.ID) (admin.Policy, error) {
+				return repo.GetPolicy(ctx, realmID, id)
+			},
+			Create: func(ctx context.Context, policy admin.Policy) error {
+				return repo.CreatePolicy(ctx, policy)
+			},
+			Update: func(ctx context.Context, policy admin.Policy) error {
+				// the modified entity holds the version stored by the update
+				policy.Version = admin.FirstVersion
+
+				return repo.UpdatePolicy(ctx, policy)
+			},
+			Delete: func(ctx context.Context, id admin.ID) error {
+				return repo.DeletePolicy(ctx, realmID, id, admin.FirstVersion.Next(), time.Now())
+			},
+		},
+		EntityOps: crud.EntityOps[admin.Policy, admin.ID]{
+			NewEntity: func(key int) admin.Policy {
+				return admin.Policy{
+					ID:          admin.ID(strconv.Itoa(key)),
+					RealmID:     realmID,
+					Code:        "policy",
+					Description: "Policy description",
+					Effect:      admin.PolicyEffectAllow,
+					Actions:     []string{"invoices:read"},
+					Resources:   []string{"invoices/*"},
+					Roles:       []string{"auditor"},
+					Groups:      []string{"sales"},
+					Principals:  []admin.ID{"user1"},
+					Version:     admin.FirstVersion,
+				}
+			},
+			ModifyEntity: func(policy admin.Policy) admin.Policy {
+				policy.Effect = admin.PolicyEffectDeny
+				policy.Version = policy.Version.Next()
+
+				return policy
+			},
+			UnboundEntity: func() admin.Policy {
+				return admin.Policy{ID: ""}
+			},
+			ExtractKey: func(policy admin.Policy) admin.ID {
+				return policy.ID
+			},
+			MissingKey: func() admin.ID {
+				return "missing"
+			},
+		},
+		NotFoundErr: func() any {
+			return domain.NotFoundError{}
+		},
+	})
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -26,20 +27,11 @@ var _ admin.ProviderRepository = (*ProviderRepository)(nil)
 // GetProviders implements the admin.ProviderRepository interface.
 func (r *ProviderRepository) GetProviders(
 	ctx context.Context,
-) ([]admin.Provider, error) {
+	listOptions admin.ListOptions,
+) (admin.Page[admin.Provider], error) {
 	coll := r.db.Collection("providers")
 
-	qCursor, err := coll.Find(ctx, bson.M{})
-	if err != nil {
-		return nil, domain.NewStoreError("failed to find providers: %v", err)
-	}
-
-	providers, err := drainCursor[dbProvider](ctx, qCursor, fromProvider)
-	if err != nil {
-		return nil, domain.NewStoreError("failed to get providers: %v", err)
-	}
-
-	return providers, nil
+	return findPage(ctx, coll, "providers", live(bson.M{}), listOptions, fromProvider)
 }
 
 // GetProvider implements the admin.ProviderRepository interface.
@@ -48,7 +40,7 @@ func (r *ProviderRepository) GetProvider(
 	id admin.ID,
 ) (admin.Provider, error) {
 	coll := r.db.Collection("providers")
-	qFilter := bson.M{"id": id}
+	qFilter := live(bson.M{"id": id})
 	provider := dbProvider{}
 
 	if err := coll.FindOne(ctx, qFilter).Decode(&provider); err != nil {
@@ -82,16 +74,20 @@ func (r *ProviderRepository) UpdateProvider(
 	provider admin.Provider,
 ) error {
 	coll := r.db.Collection("providers")
-	qFilter := bson.M{"id": provider.ID}
+	qFilter := live(bson.M{"id": provider.ID})
+	version := provider.Version
+
+	provider.Version = version.Next()
+
 	qUpdate := bson.M{"$set": toProvider(provider)}
 
-	result, err := coll.UpdateOne(ctx, qFilter, qUpdate)
+	result, err := coll.UpdateOne(ctx, withVersion(qFilter, version), qUpdate)
 	if err != nil {
 		return domain.NewStoreError("failed to update provider: %v", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return domain.NewNotFoundError("provider %v not found", provider.ID)
+		return missingOrStale(ctx, coll, qFilter, "provider", provider.ID, version)
 	}
 
 	return nil
@@ -101,18 +97,22 @@ func (r *ProviderRepository) UpdateProvider(
 func (r *ProviderRepository) DeleteProvider(
 	ctx context.Context,
 	id admin.ID,
+	version admin.Version,
+	deletedAt time.Time,
 ) error {
 	coll := r.db.Collection("providers")
 	qFilter := bson.M{"id": id}
 
-	result, err := coll.DeleteOne(ctx, qFilter)
-	if err != nil {
-		return domain.NewStoreError("failed to delete provider: %v", err)
-	}
+	return softDelete(ctx, coll, qFilter, "provider", id, version, deletedAt)
+}
 
-	if result.DeletedCount == 0 {
-		return domain.NewNotFoundError("provider %v not found", id)
-	}
+// RestoreProvider implements the admin.ProviderRepository interface.
+func (r *ProviderRepository) RestoreProvider(
+	ctx context.Context,
+	id admin.ID,
+) (time.Time, error) {
+	coll := r.db.Collection("providers")
+	qFilter := bson.M{"id": id}
 
-	return nil
+	return restore(ctx, coll, qFilter, "provider", id)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/energimind/go-kit/testutil/crud"
 	"github.com/energimind/identity-server/internal/core/domain"
@@ -22,7 +23,9 @@ func TestProviderRepository_CRUD(t *testing.T) {
 	crud.RunTests(t, crud.Setup[admin.Provider, admin.ID]{
 		RepoOps: crud.RepoOps[admin.Provider, admin.ID]{
 			GetAll: func(ctx context.Context) ([]admin.Provider, error) {
-				return repo.GetProviders(ctx)
+				page, err := repo.GetProviders(ctx, admin.ListOptions{})
+
+				return page.Items, err
 			},
 			GetByID: func(ctx context.Context, id admin.ID) (admin.Provider, error) {
 				return repo.GetProvider(ctx, id)
@@ -31,10 +34,13 @@ func TestProviderRepository_CRUD(t *testing.T) {
 				return repo.CreateProvider(ctx, provider)
 			},
 			Update: func(ctx context.Context, provider admin.Provider) error {
+				// the modified entity holds the version stored by the update
+				provider.Version = admin.FirstVersion
+
 				return repo.UpdateProvider(ctx, provider)
 			},
 			Delete: func(ctx context.Context, id admin.ID) error {
-				return repo.DeleteProvider(ctx, id)
+				return repo.DeleteProvider(ctx, id, admin.FirstVersion.Next(), time.Now())
 			},
 		},
 		EntityOps: crud.EntityOps[admin.Provider, admin.ID]{
@@ -49,10 +55,12 @@ func TestProviderRepository_CRUD(t *testing.T) {
 					ClientID:     "client-id",
 					ClientSecret: "client-secret",
 					RedirectURL:  "https://example.com",
+					Version:      admin.FirstVersion,
 				}
 			},
 			ModifyEntity: func(provider admin.Provider) admin.Provider {
 				provider.Name = "Google 2"
+				provider.Version = provider.Version.Next()
 
 				return provider
 			},
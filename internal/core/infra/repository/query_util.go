@@ -1,7 +1,19 @@
 package repository
 
 import (
+	"cmp"
 	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // cursor is an interface for a MongoDB cursor.
@@ -35,3 +47,158 @@ func drainCursor[T, M any](ctx context.Context, cursor cursor, mapper func(T) M)
 
 	return mapped, nil
 }
+
+// matchPrefix returns the condition of the string fields starting with the prefix.
+func matchPrefix(prefix string) bson.M {
+	return bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}
+}
+
+// matchRange returns the condition of the date fields within the half-open range.
+// A zero bound leaves the range open on its side.
+func matchRange(after, before time.Time) bson.M {
+	condition := bson.M{}
+
+	if !after.IsZero() {
+		condition["$gte"] = after
+	}
+
+	if !before.IsZero() {
+		condition["$lt"] = before
+	}
+
+	return condition
+}
+
+// searchField is a field of the documents searched by prefix, with its weight in the
+// text index and its value in the search hits.
+type searchField struct {
+	name   string
+	weight int
+	value  func(hit admin.SearchHit) string
+}
+
+// searchWeights returns the weights of the fields in the text index.
+func searchWeights(fields []searchField) bson.D {
+	weights := make(bson.D, len(fields))
+
+	for i, field := range fields {
+		weights[i] = bson.E{Key: field.name, Value: field.weight}
+	}
+
+	return weights
+}
+
+// searchText finds the documents of the collection matching the search, best matches
+// first. The documents of all the realms are searched if the realm is empty.
+//
+// The text index only matches whole words, so the documents whose given fields start
+// with the text, regardless of case, match as well. A prefix match scores the weight
+// of its field in the proportion of the field it covers, which ranks a whole field
+// like a text match of the field, and it adds to the text score of the document.
+//
+// The name of the documents is used in the error messages.
+func searchText[T any](
+	ctx context.Context,
+	coll *mongo.Collection,
+	name string,
+	realmID string,
+	text string,
+	fields []searchField,
+	limit int,
+	mapper func(T) admin.SearchHit,
+) ([]admin.SearchHit, error) {
+	score := bson.M{"$meta": "textScore"}
+	qText := bson.M{"$text": bson.M{"$search": text}}
+	qTextOptions := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}}).
+		SetLimit(int64(limit))
+
+	hits, err := findHits(ctx, coll, name, realmID, qText, qTextOptions, mapper)
+	if err != nil {
+		return nil, err
+	}
+
+	qPrefixes := make(bson.A, len(fields))
+
+	for i, field := range fields {
+		qPrefixes[i] = bson.M{field.name: bson.M{"$regex": "^" + regexp.QuoteMeta(text), "$options": "i"}}
+	}
+
+	prefixHits, err := findHits(ctx, coll, name, realmID, bson.M{"$or": qPrefixes}, options.Find().SetLimit(int64(limit)), mapper)
+	if err != nil {
+		return nil, err
+	}
+
+	return rankHits(hits, prefixHits, text, fields, limit), nil
+}
+
+// findHits finds the documents of the realm matching the filter.
+func findHits[T any](
+	ctx context.Context,
+	coll *mongo.Collection,
+	name string,
+	realmID string,
+	qFilter bson.M,
+	qOptions *options.FindOptions,
+	mapper func(T) admin.SearchHit,
+) ([]admin.SearchHit, error) {
+	qFilter = live(qFilter)
+
+	if realmID != "" {
+		qFilter["realmId"] = realmID
+	}
+
+	qCursor, err := coll.Find(ctx, qFilter, qOptions)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to search %s: %v", name, err)
+	}
+
+	hits, err := drainCursor[T](ctx, qCursor, mapper)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to get %s: %v", name, err)
+	}
+
+	return hits, nil
+}
+
+// rankHits merges the prefix hits into the text hits, and returns the best of them.
+func rankHits(hits, prefixHits []admin.SearchHit, text string, fields []searchField, limit int) []admin.SearchHit {
+	for _, prefixHit := range prefixHits {
+		prefixScore := scorePrefix(prefixHit, text, fields)
+
+		i := slices.IndexFunc(hits, func(hit admin.SearchHit) bool { return hit.ID == prefixHit.ID })
+		if i >= 0 {
+			hits[i].Score += prefixScore
+
+			continue
+		}
+
+		prefixHit.Score = prefixScore
+		hits = append(hits, prefixHit)
+	}
+
+	slices.SortStableFunc(hits, func(a, b admin.SearchHit) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	return hits[:min(limit, len(hits))]
+}
+
+// scorePrefix returns the best score of the fields of the hit starting with the text.
+func scorePrefix(hit admin.SearchHit, text string, fields []searchField) float64 {
+	var best float64
+
+	for _, field := range fields {
+		value := field.value(hit)
+
+		if len(value) < len(text) || !strings.EqualFold(value[:len(text)], text) {
+			continue
+		}
+
+		coverage := float64(utf8.RuneCountInString(text)) / float64(utf8.RuneCountInString(value))
+		best = max(best, float64(field.weight)*coverage)
+	}
+
+	return best
+}
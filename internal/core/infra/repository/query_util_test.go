@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/energimind/identity-server/internal/core/domain/admin"
 	"github.com/stretchr/testify/require"
 )
 
@@ -78,3 +79,38 @@ func (m *mockCursor) All(_ context.Context, v any) error {
 func (m *mockCursor) Close(_ context.Context) error {
 	return m.close()
 }
+
+func Test_rankHits(t *testing.T) {
+	t.Parallel()
+
+	hits := []admin.SearchHit{
+		{ID: "1", Name: "john", Score: 11},
+		{ID: "2", Name: "bob", DisplayName: "John Doe", Score: 6},
+	}
+	prefixHits := []admin.SearchHit{
+		{ID: "1", Name: "john"},
+		{ID: "3", Name: "johnny"},
+		{ID: "4", Name: "alice", Email: "johanna@domain.com"},
+	}
+
+	ranked := rankHits(hits, prefixHits, "JO", userSearchFields, 3)
+
+	require.Len(t, ranked, 3)
+	require.Equal(t, admin.ID("1"), ranked[0].ID)
+	require.InDelta(t, 16, ranked[0].Score, 0.001) // text match plus half of the username
+	require.Equal(t, admin.ID("2"), ranked[1].ID)
+	require.Equal(t, admin.ID("3"), ranked[2].ID)
+	require.InDelta(t, 10.0/3, ranked[2].Score, 0.001)
+}
+
+func Test_scorePrefix(t *testing.T) {
+	t.Parallel()
+
+	hit := admin.SearchHit{Name: "jsmith", DisplayName: "John Smith", Email: "john@domain.com"}
+
+	require.InDelta(t, 10.0/3, scorePrefix(hit, "js", userSearchFields), 0.001)
+	require.InDelta(t, 10, scorePrefix(hit, "JSMITH", userSearchFields), 0.001)
+	require.InDelta(t, 5.0*4/10, scorePrefix(hit, "john", userSearchFields), 0.001)
+	require.Zero(t, scorePrefix(hit, "smith", userSearchFields))
+	require.Zero(t, scorePrefix(hit, "jsmiths", userSearchFields))
+}
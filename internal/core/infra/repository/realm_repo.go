@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -26,21 +27,11 @@ var _ admin.RealmRepository = (*RealmRepository)(nil)
 // GetRealms implements the admin.RealmRepository interface.
 func (r *RealmRepository) GetRealms(
 	ctx context.Context,
-) ([]admin.Realm, error) {
+	listOptions admin.ListOptions,
+) (admin.Page[admin.Realm], error) {
 	coll := r.db.Collection("realms")
-	qFilter := bson.M{}
 
-	qCursor, err := coll.Find(ctx, qFilter)
-	if err != nil {
-		return nil, domain.NewStoreError("failed to find realms: %v", err)
-	}
-
-	realms, err := drainCursor[dbRealm](ctx, qCursor, fromRealm)
-	if err != nil {
-		return nil, domain.NewStoreError("failed to get realms: %v", err)
-	}
-
-	return realms, nil
+	return findPage(ctx, coll, "realms", live(bson.M{}), listOptions, fromRealm)
 }
 
 // GetRealm implements the admin.RealmRepository interface.
@@ -49,7 +40,7 @@ func (r *RealmRepository) GetRealm(
 	id admin.ID,
 ) (admin.Realm, error) {
 	coll := r.db.Collection("realms")
-	qFilter := bson.M{"id": id}
+	qFilter := live(bson.M{"id": id})
 	realm := dbRealm{}
 
 	if err := coll.FindOne(ctx, qFilter).Decode(&realm); err != nil {
@@ -83,16 +74,20 @@ func (r *RealmRepository) UpdateRealm(
 	realm admin.Realm,
 ) error {
 	coll := r.db.Collection("realms")
-	qFilter := bson.M{"id": realm.ID}
+	qFilter := live(bson.M{"id": realm.ID})
+	version := realm.Version
+
+	realm.Version = version.Next()
+
 	qUpdate := bson.M{"$set": toRealm(realm)}
 
-	result, err := coll.UpdateOne(ctx, qFilter, qUpdate)
+	result, err := coll.UpdateOne(ctx, withVersion(qFilter, version), qUpdate)
 	if err != nil {
 		return domain.NewStoreError("failed to update realm: %v", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return domain.NewNotFoundError("realm %v not found", realm.ID)
+		return missingOrStale(ctx, coll, qFilter, "realm", realm.ID, version)
 	}
 
 	return nil
@@ -102,18 +97,22 @@ func (r *RealmRepository) UpdateRealm(
 func (r *RealmRepository) DeleteRealm(
 	ctx context.Context,
 	id admin.ID,
+	version admin.Version,
+	deletedAt time.Time,
 ) error {
 	coll := r.db.Collection("realms")
 	qFilter := bson.M{"id": id}
 
-	result, err := coll.DeleteOne(ctx, qFilter)
-	if err != nil {
-		return domain.NewStoreError("failed to delete realm: %v", err)
-	}
+	return softDelete(ctx, coll, qFilter, "realm", id, version, deletedAt)
+}
 
-	if result.DeletedCount == 0 {
-		return domain.NewNotFoundError("realm %v not found", id)
-	}
+// RestoreRealm implements the admin.RealmRepository interface.
+func (r *RealmRepository) RestoreRealm(
+	ctx context.Context,
+	id admin.ID,
+) (time.Time, error) {
+	coll := r.db.Collection("realms")
+	qFilter := bson.M{"id": id}
 
-	return nil
+	return restore(ctx, coll, qFilter, "realm", id)
 }
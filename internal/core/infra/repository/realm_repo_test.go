@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/energimind/go-kit/testutil/crud"
 	"github.com/energimind/identity-server/internal/core/domain"
@@ -22,7 +23,9 @@ func TestRealmRepository_CRUD(t *testing.T) {
 	crud.RunTests(t, crud.Setup[admin.Realm, admin.ID]{
 		RepoOps: crud.RepoOps[admin.Realm, admin.ID]{
 			GetAll: func(ctx context.Context) ([]admin.Realm, error) {
-				return repo.GetRealms(ctx)
+				page, err := repo.GetRealms(ctx, admin.ListOptions{})
+
+				return page.Items, err
 			},
 			GetByID: func(ctx context.Context, id admin.ID) (admin.Realm, error) {
 				return repo.GetRealm(ctx, id)
@@ -31,10 +34,13 @@ func TestRealmRepository_CRUD(t *testing.T) {
 				return repo.CreateRealm(ctx, realm)
 			},
 			Update: func(ctx context.Context, realm admin.Realm) error {
+				// the modified entity holds the version stored by the update
+				realm.Version = admin.FirstVersion
+
 				return repo.UpdateRealm(ctx, realm)
 			},
 			Delete: func(ctx context.Context, id admin.ID) error {
-				return repo.DeleteRealm(ctx, id)
+				return repo.DeleteRealm(ctx, id, admin.FirstVersion.Next(), time.Now())
 			},
 		},
 		EntityOps: crud.EntityOps[admin.Realm, admin.ID]{
@@ -45,10 +51,16 @@ func TestRealmRepository_CRUD(t *testing.T) {
 					Name:        "Realm 1",
 					Description: "Realm 1",
 					Enabled:     true,
+					Roles: []admin.Role{{
+						Name:        "auditor",
+						Permissions: []admin.Permission{admin.PermissionUsersRead},
+					}},
+					Version: admin.FirstVersion,
 				}
 			},
 			ModifyEntity: func(realm admin.Realm) admin.Realm {
 				realm.Name = "Realm 2"
+				realm.Version = realm.Version.Next()
 
 				return realm
 			},
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RuleRepository is a MongoDB implementation of RuleRepository.
+type RuleRepository struct {
+	db *mongo.Database
+}
+
+// NewRuleRepository creates a new MongoDB rule repository.
+func NewRuleRepository(db *mongo.Database) *RuleRepository {
+	return &RuleRepository{db: db}
+}
+
+// Ensure repository implements the admin.RuleRepository interface.
+var _ admin.RuleRepository = (*RuleRepository)(nil)
+
+// GetRules implements the admin.RuleRepository interface.
+func (r *RuleRepository) GetRules(
+	ctx context.Context,
+	realmID admin.ID,
+	listOptions admin.ListOptions,
+) (admin.Page[admin.AccessRule], error) {
+	coll := r.db.Collection("rules")
+	qFilter := live(bson.M{"realmId": realmID})
+
+	return findPage(ctx, coll, "rules", qFilter, listOptions, fromRule)
+}
+
+// GetRule implements the admin.RuleRepository interface.
+func (r *RuleRepository) GetRule(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (admin.AccessRule, error) {
+	coll := r.db.Collection("rules")
+	qFilter := live(bson.M{"id": id, "realmId": realmID})
+	rule := dbRule{}
+
+	if err := coll.FindOne(ctx, qFilter).Decode(&rule); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return admin.AccessRule{}, domain.NewNotFoundError("rule %v not found", id)
+		}
+
+		return admin.AccessRule{}, domain.NewStoreError("failed to get rule: %v", err)
+	}
+
+	return fromRule(rule), nil
+}
+
+// CreateRule implements the admin.RuleRepository interface.
+func (r *RuleRepository) CreateRule(
+	ctx context.Context,
+	rule admin.AccessRule,
+) error {
+	coll := r.db.Collection("rules")
+
+	if _, err := coll.InsertOne(ctx, toRule(rule)); err != nil {
+		return domain.NewStoreError("failed to create rule: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateRule implements the admin.RuleRepository interface.
+func (r *RuleRepository) UpdateRule(
+	ctx context.Context,
+	rule admin.AccessRule,
+) error {
+	coll := r.db.Collection("rules")
+	qFilter := live(bson.M{"id": rule.ID, "realmId": rule.RealmID})
+	version := rule.Version
+
+	rule.Version = version.Next()
+
+	qUpdate := bson.M{"$set": toRule(rule)}
+
+	result, err := coll.UpdateOne(ctx, withVersion(qFilter, version), qUpdate)
+	if err != nil {
+		return domain.NewStoreError("failed to update rule: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return missingOrStale(ctx, coll, qFilter, "rule", rule.ID, version)
+	}
+
+	return nil
+}
+
+// DeleteRule implements the admin.RuleRepository interface.
+func (r *RuleRepository) DeleteRule(
+	ctx context.Context,
+	realmID, id admin.ID,
+	version admin.Version,
+	deletedAt time.Time,
+) error {
+	coll := r.db.Collection("rules")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return softDelete(ctx, coll, qFilter, "rule", id, version, deletedAt)
+}
+
+// RestoreRule implements the admin.RuleRepository interface.
+func (r *RuleRepository) RestoreRule(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (time.Time, error) {
+	coll := r.db.Collection("rules")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return restore(ctx, coll, qFilter, "rule", id)
+}
+
+// EnsureRuleIndexes creates the indexes of the rules collection.
+// The rules of a realm are loaded on every mutation of a user, a daemon or a group,
+// and listed sorted by code.
+func EnsureRuleIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("rules")
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "code", Value: 1}, {Key: "id", Value: 1}}},
+	})
+	if err != nil {
+		return domain.NewStoreError("failed to create rule indexes: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/energimind/go-kit/testutil/crud"
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/repository"
+)
+
+func TestRuleRepository_CRUD(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewRuleRepository(db)
+	realmID := admin.ID("1")
+
+	crud.RunTests(t, crud.Setup[admin.AccessRule, admin.ID]{
+		RepoOps: crud.RepoOps[admin.AccessRule, admin.ID]{
+			GetAll: func(ctx context.Context) ([]admin.AccessRule, error) {
+				page, err := repo.GetRules(ctx, realmID, admin.ListOptions{})
+
+				return page.Items, err
+			},
+			GetByID: func(ctx context.Context, id admin.ID) (admin.AccessRule, error) {
+				return repo.GetRule(ctx, realmID, id)
+			},
+			Create: func(ctx context.Context, rule admin.AccessRule) error {
+				return repo.CreateRule(ctx, rule)
+			},
+			Update: func(ctx context.Context, rule admin.AccessRule) error {
+				// the modified entity holds the version stored by the update
+				rule.Version = admin.FirstVersion
+
+				return repo.UpdateRule(ctx, rule)
+			},
+			Delete: func(ctx context.Context, id admin.ID) error {
+				return repo.DeleteRule(ctx, realmID, id, admin.FirstVersion.Next(), time.Now())
+			},
+		},
+		EntityOps: crud.EntityOps[admin.AccessRule, admin.ID]{
+			NewEntity: func(key int) admin.AccessRule {
+				return admin.AccessRule{
+					ID:          admin.ID(strconv.Itoa(key)),
+					RealmID:     realmID,
+					Code:        "rule",
+					Description: "Rule description",
+					Enabled:     true,
+					Permissions: []admin.Permission{admin.PermissionUsersWrite},
+					Expression:  "true",
+					Version:     admin.FirstVersion,
+				}
+			},
+			ModifyEntity: func(rule admin.AccessRule) admin.AccessRule {
+				rule.Enabled = false
+				rule.Version = rule.Version.Next()
+
+				return rule
+			},
+			UnboundEntity: func() admin.AccessRule {
+				return admin.AccessRule{ID: ""}
+			},
+			ExtractKey: func(rule admin.AccessRule) admin.ID {
+				return rule.ID
+			},
+			MissingKey: func() admin.ID {
+				return "missing"
+			},
+		},
+		NotFoundErr: func() any {
+			return domain.NotFoundError{}
+		},
+	})
+}
@@ -3,11 +3,13 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // UserRepository is a MongoDB implementation of UserRepository.
@@ -24,24 +26,41 @@ func NewUserRepository(db *mongo.Database) *UserRepository {
 var _ admin.UserRepository = (*UserRepository)(nil)
 
 // GetUsers implements the admin.UserRepository interface.
+// A list attribute matches the filter if one of its items equals the value.
 func (r *UserRepository) GetUsers(
 	ctx context.Context,
 	realmID admin.ID,
-) ([]admin.User, error) {
+	filter admin.UserFilter,
+	listOptions admin.ListOptions,
+) (admin.Page[admin.User], error) {
 	coll := r.db.Collection("users")
-	qFilter := bson.M{"realmId": realmID}
+	qFilter := live(bson.M{"realmId": realmID})
 
-	qCursor, err := coll.Find(ctx, qFilter)
-	if err != nil {
-		return nil, domain.NewStoreError("failed to find users: %v", err)
+	if filter.Enabled != nil {
+		qFilter["enabled"] = *filter.Enabled
 	}
 
-	users, err := drainCursor[dbUser](ctx, qCursor, fromUser)
-	if err != nil {
-		return nil, domain.NewStoreError("failed to get users: %v", err)
+	if filter.Role != admin.SystemRoleNone {
+		qFilter["role"] = toSystemRole(filter.Role)
 	}
 
-	return users, nil
+	if filter.UsernamePrefix != "" {
+		qFilter["username"] = matchPrefix(filter.UsernamePrefix)
+	}
+
+	if filter.EmailPrefix != "" {
+		qFilter["email"] = matchPrefix(filter.EmailPrefix)
+	}
+
+	if !filter.CreatedAfter.IsZero() || !filter.CreatedBefore.IsZero() {
+		qFilter["createdAt"] = matchRange(filter.CreatedAfter, filter.CreatedBefore)
+	}
+
+	for name, value := range filter.Attributes {
+		qFilter["attributes."+name] = value
+	}
+
+	return findPage(ctx, coll, "users", qFilter, listOptions, fromUser)
 }
 
 // GetUser implements the admin.UserRepository interface.
@@ -50,7 +69,7 @@ func (r *UserRepository) GetUser(
 	realmID, id admin.ID,
 ) (admin.User, error) {
 	coll := r.db.Collection("users")
-	qFilter := bson.M{"id": id, "realmId": realmID}
+	qFilter := live(bson.M{"id": id, "realmId": realmID})
 	user := dbUser{}
 
 	if err := coll.FindOne(ctx, qFilter).Decode(&user); err != nil {
@@ -71,7 +90,7 @@ func (r *UserRepository) CreateUser(
 ) error {
 	coll := r.db.Collection("users")
 
-	if _, err := coll.InsertOne(ctx, toUser(user)); err != nil {
+	if _, err := coll.InsertOne(ctx, toSearchableUser(user)); err != nil {
 		return domain.NewStoreError("failed to create user: %v", err)
 	}
 
@@ -84,16 +103,23 @@ func (r *UserRepository) UpdateUser(
 	user admin.User,
 ) error {
 	coll := r.db.Collection("users")
-	qFilter := bson.M{"id": user.ID}
-	qUpdate := bson.M{"$set": toUser(user)}
+	qFilter := live(bson.M{"id": user.ID})
+	version := user.Version
+
+	user.Version = version.Next()
+
+	qUpdate, err := keepAPIKeyUsage(toSearchableUser(user))
+	if err != nil {
+		return err
+	}
 
-	result, err := coll.UpdateOne(ctx, qFilter, qUpdate)
+	result, err := coll.UpdateOne(ctx, withVersion(qFilter, version), qUpdate)
 	if err != nil {
 		return domain.NewStoreError("failed to update user: %v", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return domain.NewNotFoundError("user %v not found", user.ID)
+		return missingOrStale(ctx, coll, qFilter, "user", user.ID, version)
 	}
 
 	return nil
@@ -103,20 +129,35 @@ func (r *UserRepository) UpdateUser(
 func (r *UserRepository) DeleteUser(
 	ctx context.Context,
 	realmID, id admin.ID,
+	version admin.Version,
+	deletedAt time.Time,
 ) error {
 	coll := r.db.Collection("users")
 	qFilter := bson.M{"id": id, "realmId": realmID}
 
-	result, err := coll.DeleteOne(ctx, qFilter)
-	if err != nil {
-		return domain.NewStoreError("failed to delete user: %v", err)
-	}
+	return softDelete(ctx, coll, qFilter, "user", id, version, deletedAt)
+}
 
-	if result.DeletedCount == 0 {
-		return domain.NewNotFoundError("user %v not found", id)
-	}
+// RestoreUser implements the admin.UserRepository interface.
+func (r *UserRepository) RestoreUser(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (time.Time, error) {
+	coll := r.db.Collection("users")
+	qFilter := bson.M{"id": id, "realmId": realmID}
 
-	return nil
+	return restore(ctx, coll, qFilter, "user", id)
+}
+
+// GetDeletedUser implements the admin.UserRepository interface.
+func (r *UserRepository) GetDeletedUser(
+	ctx context.Context,
+	realmID, id admin.ID,
+) (admin.User, error) {
+	coll := r.db.Collection("users")
+	qFilter := bson.M{"id": id, "realmId": realmID}
+
+	return findDeleted(ctx, coll, qFilter, "user", id, fromUser)
 }
 
 // GetUserByBindID implements the admin.UserRepository interface.
@@ -126,7 +167,7 @@ func (r *UserRepository) GetUserByBindID(
 	bindID string,
 ) (admin.User, error) {
 	coll := r.db.Collection("users")
-	qFilter := bson.M{"bindId": bindID, "realmId": realmID}
+	qFilter := live(bson.M{"bindId": bindID, "realmId": realmID})
 	user := dbUser{}
 
 	if err := coll.FindOne(ctx, qFilter).Decode(&user); err != nil {
@@ -140,38 +181,121 @@ func (r *UserRepository) GetUserByBindID(
 	return fromUser(user), nil
 }
 
-// GetAPIKey implements the admin.UserRepository interface.
+// SearchUsers implements the admin.UserRepository interface.
+func (r *UserRepository) SearchUsers(
+	ctx context.Context,
+	realmID admin.ID,
+	text string,
+	limit int,
+) ([]admin.SearchHit, error) {
+	return searchText(ctx, r.db.Collection("users"), "users", toID(realmID), text, userSearchFields, limit, fromUserHit)
+}
+
+// GetAPIKeysByPrefix implements the admin.UserRepository interface.
 //
 // This method takes in account the enabled field of the user and the API key.
-func (r *UserRepository) GetAPIKey(
+func (r *UserRepository) GetAPIKeysByPrefix(
 	ctx context.Context,
 	realmID admin.ID,
-	key string,
-) (admin.APIKey, error) {
+	prefix string,
+) ([]admin.OwnedAPIKey, error) {
 	coll := r.db.Collection("users")
-	qFilter := bson.M{
+	qFilter := live(bson.M{
 		"realmId": realmID,
 		"enabled": true,
 		"apiKeys": bson.M{"$elemMatch": bson.M{
-			"key":     key,
+			"prefix":  prefix,
 			"enabled": true,
 		}},
-	}
-	user := dbUser{}
+	})
 
-	if err := coll.FindOne(ctx, qFilter).Decode(&user); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return admin.APIKey{}, domain.NewNotFoundError("API key not found")
-		}
+	qCursor, err := coll.Find(ctx, qFilter)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to find API keys: %v", err)
+	}
 
-		return admin.APIKey{}, domain.NewStoreError("failed to API api key: %v", err)
+	users, err := drainCursor[dbUser](ctx, qCursor, fromUser)
+	if err != nil {
+		return nil, domain.NewStoreError("failed to get API keys: %v", err)
 	}
 
-	for _, apiKey := range user.APIKeys {
-		if apiKey.Key == key {
-			return fromAPIKey(apiKey), nil
+	apiKeys := make([]admin.OwnedAPIKey, 0, len(users))
+
+	for _, user := range users {
+		for _, apiKey := range user.APIKeys {
+			if apiKey.Prefix == prefix && apiKey.Enabled {
+				apiKeys = append(apiKeys, admin.OwnedAPIKey{
+					RealmID: user.RealmID,
+					OwnerID: user.ID,
+					APIKey:  apiKey,
+				})
+			}
 		}
 	}
 
-	return admin.APIKey{}, domain.NewNotFoundError("API key not found")
+	return apiKeys, nil
+}
+
+// GetAPIKeysExpiringBefore implements the admin.UserRepository interface.
+func (r *UserRepository) GetAPIKeysExpiringBefore(
+	ctx context.Context,
+	before time.Time,
+) ([]admin.OwnedAPIKey, error) {
+	return getAPIKeysExpiringBefore(ctx, r.db.Collection("users"), before)
+}
+
+// DisableExpiredAPIKeys implements the admin.UserRepository interface.
+func (r *UserRepository) DisableExpiredAPIKeys(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+	return disableExpiredAPIKeys(ctx, r.db.Collection("users"), now)
+}
+
+// RecordAPIKeyUsage implements the admin.UserRepository interface.
+func (r *UserRepository) RecordAPIKeyUsage(
+	ctx context.Context,
+	usages []admin.APIKeyUsage,
+) error {
+	return recordAPIKeyUsage(ctx, r.db.Collection("users"), usages)
+}
+
+// userSearchFields are the fields of the users searched by prefix. They are weighted
+// like in the text index, which ranks the usernames first.
+//
+//nolint:gochecknoglobals // read-only list
+var userSearchFields = []searchField{
+	{name: "username", weight: 10, value: func(hit admin.SearchHit) string { return hit.Name }},
+	{name: "displayName", weight: 5, value: func(hit admin.SearchHit) string { return hit.DisplayName }},
+	{name: "email", weight: 5, value: func(hit admin.SearchHit) string { return hit.Email }},
+}
+
+// EnsureUserIndexes creates the indexes of the users collection.
+// They support the sort orders of the user listings of a realm and the text search
+// of the users, which ranks the usernames first.
+func EnsureUserIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("users")
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "username", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "email", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "realmId", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "id", Value: 1}}},
+		{
+			Keys: bson.D{
+				{Key: "username", Value: "text"},
+				{Key: "displayName", Value: "text"},
+				{Key: "email", Value: "text"},
+				{Key: "searchTerms", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("search").
+				SetDefaultLanguage("none").
+				SetWeights(searchWeights(userSearchFields)),
+		},
+	})
+	if err != nil {
+		return domain.NewStoreError("failed to create user indexes: %v", err)
+	}
+
+	return nil
 }
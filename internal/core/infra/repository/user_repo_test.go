@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/energimind/go-kit/testutil/crud"
 	"github.com/energimind/identity-server/internal/core/domain"
@@ -24,7 +25,9 @@ func TestUserRepository_CRUD(t *testing.T) {
 	crud.RunTests(t, crud.Setup[admin.User, admin.ID]{
 		RepoOps: crud.RepoOps[admin.User, admin.ID]{
 			GetAll: func(ctx context.Context) ([]admin.User, error) {
-				return repo.GetUsers(ctx, realmID)
+				page, err := repo.GetUsers(ctx, realmID, admin.UserFilter{}, admin.ListOptions{SortBy: admin.SortByUsername})
+
+				return page.Items, err
 			},
 			GetByID: func(ctx context.Context, id admin.ID) (admin.User, error) {
 				return repo.GetUser(ctx, realmID, id)
@@ -33,10 +36,13 @@ func TestUserRepository_CRUD(t *testing.T) {
 				return repo.CreateUser(ctx, user)
 			},
 			Update: func(ctx context.Context, user admin.User) error {
+				// the modified entity holds the version stored by the update
+				user.Version = admin.FirstVersion
+
 				return repo.UpdateUser(ctx, user)
 			},
 			Delete: func(ctx context.Context, id admin.ID) error {
-				return repo.DeleteUser(ctx, realmID, id)
+				return repo.DeleteUser(ctx, realmID, id, admin.FirstVersion.Next(), time.Now())
 			},
 		},
 		EntityOps: crud.EntityOps[admin.User, admin.ID]{
@@ -48,11 +54,14 @@ func TestUserRepository_CRUD(t *testing.T) {
 					Description: "description",
 					Enabled:     true,
 					Role:        admin.SystemRoleAdmin,
+					Roles:       []string{"auditor"},
 					APIKeys:     []admin.APIKey{{}},
+					Version:     admin.FirstVersion,
 				}
 			},
 			ModifyEntity: func(user admin.User) admin.User {
 				user.Username = "user2"
+				user.Version = user.Version.Next()
 
 				return user
 			},
@@ -75,6 +84,42 @@ func TestUserRepository_CRUD(t *testing.T) {
 	})
 }
 
+func TestUserRepository_versions(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewUserRepository(db)
+	ctx := context.Background()
+	user := admin.User{
+		ID:       "1",
+		RealmID:  "1",
+		Username: "user1",
+		Role:     admin.SystemRoleUser,
+		APIKeys:  []admin.APIKey{},
+		Version:  admin.FirstVersion,
+	}
+
+	require.NoError(t, repo.CreateUser(ctx, user))
+	require.NoError(t, repo.UpdateUser(ctx, user))
+
+	stored, err := repo.GetUser(ctx, "1", "1")
+	require.NoError(t, err)
+	require.Equal(t, admin.FirstVersion.Next(), stored.Version)
+
+	staleErr := domain.ConflictError{}
+
+	require.ErrorAs(t, repo.UpdateUser(ctx, user), &staleErr)
+	require.True(t, staleErr.Stale)
+
+	require.ErrorAs(t, repo.DeleteUser(ctx, "1", "1", admin.FirstVersion, time.Now()), &staleErr)
+	require.True(t, staleErr.Stale)
+
+	require.NoError(t, repo.DeleteUser(ctx, "1", "1", stored.Version, time.Now()))
+	require.ErrorAs(t, repo.DeleteUser(ctx, "1", "1", stored.Version, time.Now()), &domain.NotFoundError{})
+}
+
 func TestUserRepository_GetUserByBindID(t *testing.T) {
 	t.Parallel()
 
@@ -103,3 +148,390 @@ func TestUserRepository_GetUserByBindID(t *testing.T) {
 
 	require.Equal(t, user, got)
 }
+
+func TestUserRepository_GetUsers_attributes(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewUserRepository(db)
+	realmID := admin.ID("1")
+	ctx := context.Background()
+
+	sales := admin.User{
+		ID:         "1",
+		RealmID:    realmID,
+		Attributes: map[string]any{"costCentre": "sales", "level": 3.0, "skills": []string{"go", "sql"}},
+		APIKeys:    []admin.APIKey{},
+	}
+	research := admin.User{
+		ID:         "2",
+		RealmID:    realmID,
+		Attributes: map[string]any{"costCentre": "research", "level": 5.0, "skills": []string{"go"}},
+		APIKeys:    []admin.APIKey{},
+	}
+
+	require.NoError(t, repo.CreateUser(ctx, sales))
+	require.NoError(t, repo.CreateUser(ctx, research))
+
+	tests := map[string]struct {
+		attributes map[string]any
+		wantIDs    []admin.ID
+	}{
+		"string":       {attributes: map[string]any{"costCentre": "sales"}, wantIDs: []admin.ID{"1"}},
+		"number":       {attributes: map[string]any{"level": 5.0}, wantIDs: []admin.ID{"2"}},
+		"list":         {attributes: map[string]any{"skills": "go"}, wantIDs: []admin.ID{"1", "2"}},
+		"list-partial": {attributes: map[string]any{"skills": "sql"}, wantIDs: []admin.ID{"1"}},
+		"combined":     {attributes: map[string]any{"skills": "go", "level": 3.0}, wantIDs: []admin.ID{"1"}},
+		"no-match":     {attributes: map[string]any{"costCentre": "legal"}, wantIDs: nil},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, err := repo.GetUsers(ctx, realmID, admin.UserFilter{Attributes: test.attributes},
+				admin.ListOptions{SortBy: admin.SortByUsername})
+			require.NoError(t, err)
+
+			var ids []admin.ID
+
+			for _, user := range page.Items {
+				ids = append(ids, user.ID)
+			}
+
+			require.ElementsMatch(t, test.wantIDs, ids)
+		})
+	}
+
+	stored, err := repo.GetUser(ctx, realmID, "1")
+	require.NoError(t, err)
+	require.Equal(t, sales, stored)
+}
+
+func TestUserRepository_GetUsers_pages(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewUserRepository(db)
+	realmID := admin.ID("1")
+	ctx := context.Background()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, username := range []string{"carol", "alice", "dave", "bob", "alan"} {
+		require.NoError(t, repo.CreateUser(ctx, admin.User{
+			ID:        admin.ID(strconv.Itoa(i + 1)),
+			RealmID:   realmID,
+			Username:  username,
+			Email:     username + "@domain.com",
+			Enabled:   username != "dave",
+			Role:      admin.SystemRoleUser,
+			APIKeys:   []admin.APIKey{},
+			CreatedAt: createdAt.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+
+	enabled := true
+
+	tests := map[string]struct {
+		filter  admin.UserFilter
+		options admin.ListOptions
+		want    [][]string
+	}{
+		"byUsername": {
+			options: admin.ListOptions{SortBy: admin.SortByUsername, Limit: 2},
+			want:    [][]string{{"alan", "alice"}, {"bob", "carol"}, {"dave"}},
+		},
+		"byCreationDescending": {
+			options: admin.ListOptions{SortBy: admin.SortByCreatedAt, Descending: true, Limit: 3},
+			want:    [][]string{{"alan", "bob", "dave"}, {"alice", "carol"}},
+		},
+		"enabledWithPrefix": {
+			filter:  admin.UserFilter{Enabled: &enabled, UsernamePrefix: "a"},
+			options: admin.ListOptions{SortBy: admin.SortByEmail, Limit: 1},
+			want:    [][]string{{"alan"}, {"alice"}},
+		},
+		"createdRange": {
+			filter: admin.UserFilter{
+				CreatedAfter:  createdAt.Add(time.Hour),
+				CreatedBefore: createdAt.Add(3 * time.Hour),
+			},
+			options: admin.ListOptions{SortBy: admin.SortByCreatedAt},
+			want:    [][]string{{"alice", "dave"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			options := test.options
+
+			for i, want := range test.want {
+				page, err := repo.GetUsers(ctx, realmID, test.filter, options)
+				require.NoError(t, err)
+
+				var usernames []string
+
+				for _, user := range page.Items {
+					usernames = append(usernames, user.Username)
+				}
+
+				total := 0
+
+				for _, w := range test.want {
+					total += len(w)
+				}
+
+				require.Equal(t, want, usernames)
+				require.Equal(t, total, page.Total)
+				require.Equal(t, i == len(test.want)-1, page.NextCursor == "")
+
+				options.Cursor = page.NextCursor
+			}
+		})
+	}
+
+	_, err := repo.GetUsers(ctx, realmID, admin.UserFilter{},
+		admin.ListOptions{SortBy: admin.SortByUsername, Cursor: "invalid"})
+	require.ErrorAs(t, err, &domain.ValidationError{})
+}
+
+func TestUserRepository_SearchUsers(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	ctx := context.Background()
+
+	require.NoError(t, repository.EnsureUserIndexes(ctx, db))
+
+	repo := repository.NewUserRepository(db)
+	users := []admin.User{
+		{ID: "1", RealmID: "r1", Username: "jsmith", Email: "john@domain.com", Attributes: map[string]any{"team": "ops"}},
+		{ID: "2", RealmID: "r1", Username: "alice", DisplayName: "Alice Smith", Email: "alice@domain.com"},
+		{ID: "3", RealmID: "r2", Username: "bob", Email: "bob@domain.com", Attributes: map[string]any{"tags": []string{"ops"}}},
+	}
+
+	for _, user := range users {
+		user.Role = admin.SystemRoleUser
+		user.APIKeys = []admin.APIKey{}
+
+		require.NoError(t, repo.CreateUser(ctx, user))
+	}
+
+	tests := map[string]struct {
+		realmID admin.ID
+		text    string
+		want    []admin.ID
+	}{
+		"username":        {realmID: "r1", text: "alice", want: []admin.ID{"2"}},
+		"attribute":       {realmID: "r1", text: "ops", want: []admin.ID{"1"}},
+		"allRealms":       {text: "ops", want: []admin.ID{"1", "3"}},
+		"noMatch":         {realmID: "r2", text: "alice", want: nil},
+		"partialUsername": {realmID: "r1", text: "js", want: []admin.ID{"1"}},
+		"partialEmail":    {realmID: "r1", text: "john@dom", want: []admin.ID{"1"}},
+		"ignoreCase":      {realmID: "r1", text: "ALI", want: []admin.ID{"2"}},
+		"regexText":       {realmID: "r1", text: ".*", want: nil},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			hits, err := repo.SearchUsers(ctx, test.realmID, test.text, 10)
+			require.NoError(t, err)
+
+			var ids []admin.ID
+
+			for _, hit := range hits {
+				require.Equal(t, admin.PrincipalKindUser, hit.Kind)
+				require.Positive(t, hit.Score)
+
+				ids = append(ids, hit.ID)
+			}
+
+			require.ElementsMatch(t, test.want, ids)
+		})
+	}
+
+	// the search terms follow the attributes
+	users[0].Attributes = nil
+	users[0].Role = admin.SystemRoleUser
+	users[0].APIKeys = []admin.APIKey{}
+
+	require.NoError(t, repo.UpdateUser(ctx, users[0]))
+
+	hits, err := repo.SearchUsers(ctx, "r1", "ops", 10)
+	require.NoError(t, err)
+	require.Empty(t, hits)
+}
+
+func TestUserRepository_GetAPIKeysByPrefix(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewUserRepository(db)
+	realmID := admin.ID("1")
+
+	ctx := context.Background()
+	apiKey := admin.APIKey{ID: "k1", Enabled: true, Prefix: "prefix01", Hash: "hash"}
+	user := admin.User{
+		ID:      "1",
+		RealmID: realmID,
+		Enabled: true,
+		APIKeys: []admin.APIKey{apiKey, {ID: "k2", Enabled: false, Prefix: "prefix01", Hash: "hash"}},
+	}
+
+	if err := repo.CreateUser(ctx, user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	got, err := repo.GetAPIKeysByPrefix(ctx, realmID, "prefix01")
+	if err != nil {
+		t.Fatalf("failed to get API keys by prefix: %v", err)
+	}
+
+	require.Equal(t, []admin.OwnedAPIKey{{RealmID: realmID, OwnerID: "1", APIKey: apiKey}}, got)
+
+	got, err = repo.GetAPIKeysByPrefix(ctx, realmID, "missing")
+	if err != nil {
+		t.Fatalf("failed to get API keys by prefix: %v", err)
+	}
+
+	require.Empty(t, got)
+}
+
+func TestUserRepository_ExpireAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewUserRepository(db)
+	ctx := context.Background()
+	now := time.Now().Round(time.Millisecond)
+
+	expired := admin.APIKey{ID: "k1", Enabled: true, ExpiresAt: now.Add(-time.Hour)}
+	expiring := admin.APIKey{ID: "k2", Enabled: true, ExpiresAt: now.Add(time.Hour)}
+	permanent := admin.APIKey{ID: "k3", Enabled: true}
+	user := admin.User{
+		ID:      "1",
+		RealmID: "r1",
+		Enabled: true,
+		APIKeys: []admin.APIKey{expired, expiring, permanent},
+	}
+
+	require.NoError(t, repo.CreateUser(ctx, user))
+
+	disabled, err := repo.DisableExpiredAPIKeys(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, disabled)
+
+	stored, err := repo.GetUser(ctx, "r1", "1")
+	require.NoError(t, err)
+	require.False(t, stored.APIKeys[0].Enabled)
+	require.True(t, stored.APIKeys[1].Enabled)
+	require.True(t, stored.APIKeys[2].Enabled)
+
+	soon, err := repo.GetAPIKeysExpiringBefore(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, soon, 1)
+	require.Equal(t, admin.ID("1"), soon[0].OwnerID)
+	require.Equal(t, admin.ID("r1"), soon[0].RealmID)
+	require.Equal(t, admin.ID("k2"), soon[0].APIKey.ID)
+}
+
+func TestUserRepository_RecordAPIKeyUsage(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewUserRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC().Round(time.Millisecond)
+
+	user := admin.User{
+		ID:      "1",
+		RealmID: "r1",
+		Enabled: true,
+		APIKeys: []admin.APIKey{{ID: "k1", Enabled: true}, {ID: "k2", Enabled: true}},
+	}
+
+	require.NoError(t, repo.CreateUser(ctx, user))
+
+	// the usage of the days before the usage window is dropped by the next usage
+	longAgo := now.AddDate(0, 0, -admin.APIKeyUsageWindowDays)
+
+	require.NoError(t, repo.RecordAPIKeyUsage(ctx, []admin.APIKeyUsage{
+		{APIKeyID: "k2", LastUsedAt: longAgo, LastUsedIP: "10.0.0.4", Count: 5},
+	}))
+
+	require.NoError(t, repo.RecordAPIKeyUsage(ctx, []admin.APIKeyUsage{
+		{APIKeyID: "k1", LastUsedAt: now, LastUsedIP: "10.0.0.1", Count: 2},
+		{APIKeyID: "k2", LastUsedAt: now, LastUsedIP: "10.0.0.4", Count: 1},
+		{APIKeyID: "unknown", LastUsedAt: now, LastUsedIP: "10.0.0.2", Count: 1},
+	}))
+
+	require.NoError(t, repo.RecordAPIKeyUsage(ctx, []admin.APIKeyUsage{
+		{APIKeyID: "k1", LastUsedAt: now.Add(-time.Minute), LastUsedIP: "10.0.0.3", Count: 1},
+	}))
+
+	stored, err := repo.GetUser(ctx, "r1", "1")
+	require.NoError(t, err)
+	require.Equal(t, now, stored.APIKeys[0].LastUsedAt)
+	require.Equal(t, "10.0.0.1", stored.APIKeys[0].LastUsedIP)
+	require.Equal(t, int64(3), stored.APIKeys[0].UsageCount(now))
+	require.Equal(t, map[string]int64{admin.UsageDay(now): 1}, stored.APIKeys[1].UsageDays)
+}
+
+func TestUserRepository_UpdateUser_keepsAPIKeyUsage(t *testing.T) {
+	t.Parallel()
+
+	db, closer := mongoEnv.NewInstance()
+	defer closer()
+
+	repo := repository.NewUserRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC().Round(time.Millisecond)
+
+	user := admin.User{
+		ID:      "1",
+		RealmID: "r1",
+		Enabled: true,
+		APIKeys: []admin.APIKey{{ID: "k1", Enabled: true}},
+		Version: admin.FirstVersion,
+	}
+
+	require.NoError(t, repo.CreateUser(ctx, user))
+
+	// the usage is recorded after the user was read for the update
+	require.NoError(t, repo.RecordAPIKeyUsage(ctx, []admin.APIKeyUsage{
+		{APIKeyID: "k1", LastUsedAt: now, LastUsedIP: "10.0.0.1", Count: 2},
+	}))
+
+	user.Description = "$description"
+	user.APIKeys = append(user.APIKeys, admin.APIKey{ID: "k2", Enabled: true})
+
+	require.NoError(t, repo.UpdateUser(ctx, user))
+
+	stored, err := repo.GetUser(ctx, "r1", "1")
+	require.NoError(t, err)
+	require.Equal(t, "$description", stored.Description)
+	require.Len(t, stored.APIKeys, 2)
+	require.Equal(t, now, stored.APIKeys[0].LastUsedAt)
+	require.Equal(t, "10.0.0.1", stored.APIKeys[0].LastUsedIP)
+	require.Equal(t, int64(2), stored.APIKeys[0].UsageCount(now))
+	require.Zero(t, stored.APIKeys[1].UsageCount(now))
+
+	// the last API key can be removed
+	user.Version = stored.Version
+	user.APIKeys = nil
+
+	require.NoError(t, repo.UpdateUser(ctx, user))
+
+	stored, err = repo.GetUser(ctx, "r1", "1")
+	require.NoError(t, err)
+	require.Empty(t, stored.APIKeys)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"maps"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// withVersion returns a copy of the filter of a document that also matches its version.
+func withVersion(qFilter bson.M, version admin.Version) bson.M {
+	qVersioned := maps.Clone(qFilter)
+	qVersioned["version"] = int(version)
+
+	return qVersioned
+}
+
+// missingOrStale returns the error of an update or a delete of the given version
+// of a document which matched no document. The document is either missing, or it
+// was changed since that version.
+//
+// The filter identifies the document regardless of its version. The name of the
+// document is used in the error messages.
+func missingOrStale(
+	ctx context.Context,
+	coll *mongo.Collection,
+	qFilter bson.M,
+	name string,
+	id admin.ID,
+	version admin.Version,
+) error {
+	count, err := coll.CountDocuments(ctx, qFilter)
+	if err != nil {
+		return domain.NewStoreError("failed to check %s %v: %v", name, id, err)
+	}
+
+	if count == 0 {
+		return domain.NewNotFoundError("%s %v not found", name, id)
+	}
+
+	return domain.NewStaleVersionError("%s %v was changed since version %d", name, id, version)
+}
@@ -0,0 +1,86 @@
+package bearer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// ParseAPIKey parses the Authorization header carrying an API key token.
+//
+// The header has the format "Bearer <token>". The token is either an API key in
+// the server format, which carries its realm ID, or the legacy base64 encoded
+// "realmID:apiKey" pair. It returns the realm ID and the API key.
+//
+// Keys in the server format are rejected if they are malformed or if their realm
+// does not match the realm of the legacy envelope.
+//
+//nolint:goerr113 // no need to wrap this internal error
+func ParseAPIKey(header string) (string, string, error) {
+	if header == "" {
+		return "", "", fmt.Errorf("authorization header must not be empty")
+	}
+
+	const partCount = 2 // Bearer token
+
+	parts := strings.SplitN(header, " ", partCount)
+	if len(parts) != partCount || strings.ToLower(parts[0]) != "bearer" {
+		return "", "", fmt.Errorf("invalid authorization header format")
+	}
+
+	token := parts[1]
+
+	if admin.IsFormattedAPIKey(token) {
+		return parseFormattedAPIKey(token)
+	}
+
+	realmID, apiKey, err := decodeAPIKeyToken(token)
+	if err != nil {
+		return "", "", err
+	}
+
+	if admin.IsFormattedAPIKey(apiKey) {
+		keyRealmID, _, pErr := parseFormattedAPIKey(apiKey)
+		if pErr != nil {
+			return "", "", pErr
+		}
+
+		if keyRealmID != realmID {
+			return "", "", fmt.Errorf("API key does not belong to realm %s", realmID)
+		}
+	}
+
+	return realmID, apiKey, nil
+}
+
+//nolint:goerr113 // no need to wrap this internal error
+func parseFormattedAPIKey(apiKey string) (string, string, error) {
+	realmID, _, ok := admin.ParseAPIKey(apiKey)
+	if !ok {
+		return "", "", fmt.Errorf("malformed API key")
+	}
+
+	return string(realmID), apiKey, nil
+}
+
+//nolint:goerr113 // no need to wrap this internal error
+func decodeAPIKeyToken(token string) (string, string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode API key token: %w", err)
+	}
+
+	const partCount = 2 // realmID:apiKey
+
+	parts := strings.Split(string(decoded), ":")
+	if len(parts) != partCount {
+		return "", "", fmt.Errorf("invalid API key token format")
+	}
+
+	realmID := parts[0]
+	apiKey := parts[1]
+
+	return realmID, apiKey, nil
+}
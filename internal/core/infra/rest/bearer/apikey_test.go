@@ -0,0 +1,87 @@
+package bearer_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/bearer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAPIKey(t *testing.T) {
+	t.Parallel()
+
+	token := base64.StdEncoding.EncodeToString([]byte("realm1:key1"))
+	formatted := admin.FormatAPIKey("realm1", "0123456789abcdefABCDEF")
+
+	tests := map[string]struct {
+		header      string
+		wantRealmID string
+		wantKey     string
+		wantError   bool
+	}{
+		"valid": {
+			header:      "Bearer " + token,
+			wantRealmID: "realm1",
+			wantKey:     "key1",
+		},
+		"lowercaseScheme": {
+			header:      "bearer " + token,
+			wantRealmID: "realm1",
+			wantKey:     "key1",
+		},
+		"empty": {
+			header:    "",
+			wantError: true,
+		},
+		"wrongScheme": {
+			header:    "Basic " + token,
+			wantError: true,
+		},
+		"notBase64": {
+			header:    "Bearer not-base64!",
+			wantError: true,
+		},
+		"formatted": {
+			header:      "Bearer " + formatted,
+			wantRealmID: "realm1",
+			wantKey:     formatted,
+		},
+		"formattedInEnvelope": {
+			header:      "Bearer " + base64.StdEncoding.EncodeToString([]byte("realm1:"+formatted)),
+			wantRealmID: "realm1",
+			wantKey:     formatted,
+		},
+		"formattedInForeignEnvelope": {
+			header:    "Bearer " + base64.StdEncoding.EncodeToString([]byte("realm2:"+formatted)),
+			wantError: true,
+		},
+		"malformed": {
+			header:    "Bearer " + formatted[:len(formatted)-1],
+			wantError: true,
+		},
+		"noRealm": {
+			header:    "Bearer " + base64.StdEncoding.EncodeToString([]byte("key1")),
+			wantError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			realmID, key, err := bearer.ParseAPIKey(test.header)
+
+			if test.wantError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wantRealmID, realmID)
+			require.Equal(t, test.wantKey, key)
+		})
+	}
+}
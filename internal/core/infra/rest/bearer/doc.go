@@ -0,0 +1,2 @@
+// Package bearer provides parsing of the API key bearer tokens.
+package bearer
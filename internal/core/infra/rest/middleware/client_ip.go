@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// forwardedForHeader is the header name for the addresses of the forwarding proxies.
+const forwardedForHeader = "X-Forwarded-For"
+
+// ClientIPResolver is a middleware that resolves the IP address of the client.
+//
+// The X-Forwarded-For header is only honored if the request comes from one of the
+// trusted proxies. The header is walked from right to left, and the first address
+// that is not a trusted proxy is the client IP. Without trusted proxies, the remote
+// address of the request is always the client IP, so the header cannot be spoofed.
+//
+// The client IP can be retrieved from the request context using the reqctx.ClientIP function.
+func ClientIPResolver(trustedProxies []netip.Prefix) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientIP := resolveClientIP(c.RemoteIP(), c.Request.Header.Values(forwardedForHeader), trustedProxies)
+
+		reqctx.SetClientIP(c, clientIP)
+
+		// add the client IP to the request context logger
+		reqctx.UpdateLogger(c, func(current *zerolog.Logger) zerolog.Logger {
+			return current.With().Str("clientIp", clientIP).Logger()
+		})
+
+		c.Next()
+	}
+}
+
+// ParseTrustedProxies parses the addresses and CIDR ranges of the trusted proxies.
+// A single IP address is accepted as a range of one address.
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+
+		if proxy == "" {
+			continue
+		}
+
+		if addr, err := netip.ParseAddr(proxy); err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // the error describes the invalid value
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// resolveClientIP returns the client IP of a request with the given remote address
+// and X-Forwarded-For header values.
+func resolveClientIP(remoteIP string, forwardedFor []string, trustedProxies []netip.Prefix) string {
+	if !isTrustedProxy(remoteIP, trustedProxies) {
+		return remoteIP
+	}
+
+	hops := make([]string, 0, len(forwardedFor))
+
+	for _, value := range forwardedFor {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	clientIP := remoteIP
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			// the header is malformed from here on, keep the last trusted hop
+			break
+		}
+
+		clientIP = addr.Unmap().String()
+
+		if !isTrustedProxy(clientIP, trustedProxies) {
+			break
+		}
+	}
+
+	return clientIP
+}
+
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resolveClientIP(t *testing.T) {
+	t.Parallel()
+
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		remoteIP     string
+		forwardedFor []string
+		want         string
+	}{
+		"direct": {
+			remoteIP: "203.0.113.7",
+			want:     "203.0.113.7",
+		},
+		"untrustedPeer": {
+			remoteIP:     "203.0.113.7",
+			forwardedFor: []string{"198.51.100.1"},
+			want:         "203.0.113.7",
+		},
+		"trustedPeer": {
+			remoteIP:     "10.0.0.1",
+			forwardedFor: []string{"198.51.100.1"},
+			want:         "198.51.100.1",
+		},
+		"proxyChain": {
+			remoteIP:     "10.0.0.1",
+			forwardedFor: []string{"198.51.100.9, 198.51.100.1", "192.168.1.1"},
+			want:         "198.51.100.1",
+		},
+		"onlyTrustedHops": {
+			remoteIP:     "10.0.0.1",
+			forwardedFor: []string{"10.0.0.2"},
+			want:         "10.0.0.2",
+		},
+		"malformedHop": {
+			remoteIP:     "10.0.0.1",
+			forwardedFor: []string{"unknown, 10.0.0.2"},
+			want:         "10.0.0.2",
+		},
+		"mappedIPv4": {
+			remoteIP:     "10.0.0.1",
+			forwardedFor: []string{"::ffff:198.51.100.1"},
+			want:         "198.51.100.1",
+		},
+		"noHeader": {
+			remoteIP: "10.0.0.1",
+			want:     "10.0.0.1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.want, resolveClientIP(test.remoteIP, test.forwardedFor, trusted))
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+}
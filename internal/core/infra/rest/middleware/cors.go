@@ -15,9 +15,10 @@ import (
 func CORS(allowOrigin string) gin.HandlerFunc {
 	const (
 		allowCredentials = "true"
-		allowMethods     = "OPTIONS, POST, GET, PUT, DELETE"
+		allowMethods     = "OPTIONS, POST, GET, PUT, PATCH, DELETE"
 		allowHeaders     = "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, " +
-			"Authorization, Accept, Origin, Cache-Control, X-Requested-With"
+			"Authorization, Accept, Origin, Cache-Control, X-Requested-With, If-Match"
+		exposeHeaders = "X-Total-Count, X-Next-Cursor, ETag"
 	)
 
 	return func(c *gin.Context) {
@@ -31,6 +32,7 @@ func CORS(allowOrigin string) gin.HandlerFunc {
 		c.Header("Access-Control-Allow-Credentials", allowCredentials)
 		c.Header("Access-Control-Allow-Methods", allowMethods)
 		c.Header("Access-Control-Allow-Headers", allowHeaders)
+		c.Header("Access-Control-Expose-Headers", exposeHeaders)
 
 		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusOK)
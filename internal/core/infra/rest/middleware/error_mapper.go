@@ -3,6 +3,8 @@ package middleware
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/gin-gonic/gin"
@@ -33,10 +35,13 @@ func mapError(c *gin.Context) { //nolint:funlen
 		notFoundError     domain.NotFoundError
 		validationError   domain.ValidationError
 		conflictError     domain.ConflictError
+		preconditionError domain.PreconditionRequiredError
 		storeError        domain.StoreError
 		gatewayError      domain.GatewayError
 		sessionError      domain.SessionError
 		unauthorizedError domain.UnauthorizedError
+		networkError      domain.NetworkDeniedError
+		rateLimitedError  domain.RateLimitedError
 	)
 
 	err := c.Errors.Last().Err
@@ -66,7 +71,19 @@ func mapError(c *gin.Context) { //nolint:funlen
 	}
 
 	if errors.As(err, &conflictError) {
-		c.JSON(http.StatusConflict, gin.H{"error": conflictError.Error()})
+		status := http.StatusConflict
+
+		if conflictError.Stale {
+			status = http.StatusPreconditionFailed
+		}
+
+		c.JSON(status, gin.H{"error": conflictError.Error()})
+
+		return
+	}
+
+	if errors.As(err, &preconditionError) {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": preconditionError.Error()})
 
 		return
 	}
@@ -95,5 +112,26 @@ func mapError(c *gin.Context) { //nolint:funlen
 		return
 	}
 
+	if errors.As(err, &networkError) {
+		c.JSON(http.StatusForbidden, gin.H{"error": networkError.Error(), "reason": "network_not_allowed"})
+
+		return
+	}
+
+	if errors.As(err, &rateLimitedError) {
+		c.Header("Retry-After", retryAfterSeconds(rateLimitedError.RetryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": rateLimitedError.Error()})
+
+		return
+	}
+
 	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
+
+// retryAfterSeconds formats the value of the Retry-After header.
+// The duration is rounded up to whole seconds, and it is at least one second.
+func retryAfterSeconds(retryAfter time.Duration) string {
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+
+	return strconv.FormatInt(max(seconds, 1), 10)
+}
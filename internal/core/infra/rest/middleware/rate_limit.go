@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy configures the rate limits of a route group.
+//
+// The hits are limited per window for every client IP and for every realm of the
+// verified API key. A zero limit disables the limit.
+type RateLimitPolicy struct {
+	Group          string
+	Window         time.Duration
+	PerClientIP    int
+	PerAPIKeyRealm int
+}
+
+// RateLimit is a middleware that limits the rate of requests per client IP according
+// to the policy. It comes before the authentication, so that it also applies to
+// invalid credentials.
+//
+// Requests exceeding a limit are rejected with a rate limited error, which carries
+// the time after which the client can retry. Requests are not limited if the
+// limiter fails.
+func RateLimit(limiter domain.RateLimiter, policy RateLimitPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policy.PerClientIP > 0 {
+			key := policy.Group + ":ip:" + reqctx.ClientIP(c)
+
+			if !allowRequest(c, limiter, key, policy.PerClientIP, policy.Window) {
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimitAPIKeyRealm is a middleware that limits the rate of requests per realm of
+// the API key according to the policy. It comes after the RequireAPIKey middleware:
+// only the realm of a verified API key is charged, so that a client cannot exhaust
+// the limit of a realm by presenting invalid API keys of it. Requests authenticated
+// otherwise are not limited.
+func RateLimitAPIKeyRealm(limiter domain.RateLimiter, policy RateLimitPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := reqctx.APIKeyPrincipal(c); ok && policy.PerAPIKeyRealm > 0 {
+			key := policy.Group + ":realm:" + principal.RealmID.String()
+
+			if !allowRequest(c, limiter, key, policy.PerAPIKeyRealm, policy.Window) {
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// allowRequest counts the request against the limit of the key. It aborts the request
+// and returns false if the limit is exceeded.
+func allowRequest(c *gin.Context, limiter domain.RateLimiter, key string, limit int, window time.Duration) bool {
+	retryAfter, err := limiter.Allow(c.Request.Context(), key, limit, window)
+	if err != nil {
+		reqctx.RequestLogger(c).Error().Err(err).Str("key", key).Msg("Rate limiter failed")
+
+		return true
+	}
+
+	if retryAfter > 0 {
+		reqctx.RequestLogger(c).Warn().Str("key", key).Dur("retryAfter", retryAfter).Msg("Rate limit exceeded")
+
+		_ = c.Error(domain.NewRateLimitedError(retryAfter, "rate limit exceeded"))
+
+		c.Abort()
+
+		return false
+	}
+
+	return true
+}
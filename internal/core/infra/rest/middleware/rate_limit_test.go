@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitAPIKeyRealm(t *testing.T) {
+	t.Parallel()
+
+	policy := RateLimitPolicy{Group: "sessions", Window: time.Minute, PerAPIKeyRealm: 1}
+
+	tests := map[string]struct {
+		principal   *admin.APIKeyPrincipal
+		retryAfter  time.Duration
+		wantKeys    []string
+		wantAborted bool
+	}{
+		"unverifiedAPIKey": {},
+		"verifiedAPIKey": {
+			principal: &admin.APIKeyPrincipal{RealmID: "r1"},
+			wantKeys:  []string{"sessions:realm:r1"},
+		},
+		"limitExceeded": {
+			principal:   &admin.APIKeyPrincipal{RealmID: "r1"},
+			retryAfter:  time.Second,
+			wantKeys:    []string{"sessions:realm:r1"},
+			wantAborted: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			limiter := &stubRateLimiter{retryAfter: test.retryAfter}
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/sessions/s1", nil)
+			c.Request.Header.Set("Authorization", "Bearer "+admin.FormatAPIKey("r1", "0123456789abcdef"))
+
+			if test.principal != nil {
+				reqctx.SetAPIKeyPrincipal(c, *test.principal)
+			}
+
+			RateLimitAPIKeyRealm(limiter, policy)(c)
+
+			require.Equal(t, test.wantKeys, limiter.keys)
+			require.Equal(t, test.wantAborted, c.IsAborted())
+
+			if test.wantAborted {
+				require.ErrorAs(t, c.Errors.Last(), &domain.RateLimitedError{})
+			}
+		})
+	}
+}
+
+type stubRateLimiter struct {
+	mu         sync.Mutex
+	retryAfter time.Duration
+	keys       []string
+}
+
+func (l *stubRateLimiter) Allow(_ context.Context, key string, _ int, _ time.Duration) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.keys = append(l.keys, key)
+
+	return l.retryAfter, nil
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 	"github.com/energimind/identity-server/internal/core/domain/admin"
@@ -22,10 +23,20 @@ type sessionRefresher interface {
 //
 // If the actor can not be found, the request is aborted with a 401 Unauthorized error.
 //
+// If the user switched to another realm, the actor acts in that realm with the role
+// of the membership of the user. The membership is looked up on every request, so
+// that revoked memberships take effect immediately: the actor falls back to its own
+// realm if the membership is gone.
+//
+// If an admin impersonates the user, the actor records the admin as well, and every
+// request is logged against both identities. Expired impersonations fall back to
+// the session of the admin.
+//
 //nolint:funlen
 func RequireActor(
 	cookieOperator admin.CookieOperator,
 	sessionRefresher sessionRefresher,
+	membershipFinder admin.MembershipFinder,
 	localAdminEnabled bool,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -41,6 +52,11 @@ func RequireActor(
 		// add sessionID to the request
 		c.Set("sessionId", us.SessionID)
 
+		ended := us.ImpersonationExpired(time.Now())
+		if ended {
+			us = us.EndImpersonation()
+		}
+
 		if localAdminEnabled && us.SessionID == local.AdminSessionID && us.UserID == local.AdminID {
 			// add the actor to the request context
 			reqctx.SetActor(c, admin.NewActor(local.AdminID, local.AdminRealmID, local.AdminRole))
@@ -62,7 +78,20 @@ func RequireActor(
 			return
 		}
 
-		if refreshed {
+		actor, left, err := resolveActor(c, membershipFinder, us)
+		if err != nil {
+			_ = c.Error(err)
+
+			c.Abort()
+
+			return
+		}
+
+		if left {
+			us.ActiveRealmID = ""
+		}
+
+		if refreshed || left || ended {
 			// update the session cookie
 			if err := cookieOperator.CreateCookie(c, us); err != nil {
 				_ = c.Error(domain.NewSessionError("failed to update session cookie: %v", err))
@@ -73,16 +102,67 @@ func RequireActor(
 			}
 		}
 
-		actor := admin.NewActor(admin.ID(us.UserID), admin.ID(us.RealmID), admin.SystemRole(us.UserRole))
-
 		// add the actor to the request context
 		reqctx.SetActor(c, actor)
 
 		// add the actorId to the request context logger
 		reqctx.UpdateLogger(c, func(current *zerolog.Logger) zerolog.Logger {
-			return current.With().Str("actorId", actor.UserID.String()).Logger()
+			logger := current.With().Str("actorId", actor.UserID.String())
+
+			if actor.IsImpersonated() {
+				logger = logger.Str("impersonatorId", actor.ImpersonatorID.String())
+			}
+
+			return logger.Logger()
 		})
 
 		c.Next()
+
+		if actor.IsImpersonated() {
+			logImpersonatedRequest(c, actor)
+		}
 	}
 }
+
+// logImpersonatedRequest records the request of an impersonated actor in the audit trail.
+func logImpersonatedRequest(c *gin.Context, actor admin.Actor) {
+	reqctx.RequestLogger(c).Info().
+		Str("method", c.Request.Method).
+		Str("path", c.Request.URL.Path).
+		Int("status", c.Writer.Status()).
+		Str("actorRealmId", actor.RealmID.String()).
+		Str("impersonatorRealmId", actor.ImpersonatorRealmID.String()).
+		Msg("Impersonated request")
+}
+
+// resolveActor returns the actor of the user session, in the active realm of the user.
+// It also reports whether the user has left the active realm because its membership
+// no longer exists.
+func resolveActor(
+	ctx context.Context,
+	membershipFinder admin.MembershipFinder,
+	us domain.UserSession,
+) (admin.Actor, bool, error) {
+	userID := admin.ID(us.UserID)
+	actor := admin.NewActor(userID, admin.ID(us.RealmID), admin.SystemRole(us.UserRole))
+	actor.ImpersonatorID = admin.ID(us.ImpersonatorID)
+	actor.ImpersonatorRealmID = admin.ID(us.ImpersonatorRealmID)
+
+	if !us.InActiveRealm() {
+		return actor, false, nil
+	}
+
+	membership, err := membershipFinder.GetUserMembershipSys(ctx, admin.ID(us.ActiveRealmID), userID)
+	if err != nil {
+		if domain.IsNotFoundError(err) {
+			return actor, true, nil
+		}
+
+		return admin.Actor{}, false, err //nolint:wrapcheck // already a domain error
+	}
+
+	actor.RealmID = membership.RealmID
+	actor.Role = membership.Role
+
+	return actor, false, nil
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resolveActor(t *testing.T) {
+	t.Parallel()
+
+	member := admin.Membership{RealmID: "r2", UserID: "u1", UserRealmID: "r1", Role: admin.SystemRoleManager}
+	home := admin.NewActor("u1", "r1", admin.SystemRoleUser)
+	impersonated := home
+	impersonated.ImpersonatorID = "admin1"
+	impersonated.ImpersonatorRealmID = "r0"
+	impersonatedMember := impersonated
+	impersonatedMember.RealmID = "r2"
+	impersonatedMember.Role = admin.SystemRoleManager
+
+	tests := map[string]struct {
+		activeRealmID  string
+		impersonatorID string
+		finder         stubMembershipFinder
+		wantActor      admin.Actor
+		wantLeft       bool
+		wantError      error
+	}{
+		"ownRealm": {
+			wantActor: home,
+		},
+		"ownRealm-active": {
+			activeRealmID: "r1",
+			wantActor:     home,
+		},
+		"member": {
+			activeRealmID: "r2",
+			finder:        stubMembershipFinder{membership: member},
+			wantActor:     admin.NewActor("u1", "r2", admin.SystemRoleManager),
+		},
+		"revoked": {
+			activeRealmID: "r2",
+			finder:        stubMembershipFinder{err: domain.NewNotFoundError("membership not found")},
+			wantActor:     home,
+			wantLeft:      true,
+		},
+		"impersonated": {
+			impersonatorID: "admin1",
+			wantActor:      impersonated,
+		},
+		"impersonated-member": {
+			activeRealmID:  "r2",
+			impersonatorID: "admin1",
+			finder:         stubMembershipFinder{membership: member},
+			wantActor:      impersonatedMember,
+		},
+		"storeError": {
+			activeRealmID: "r2",
+			finder:        stubMembershipFinder{err: domain.NewStoreError("forcedError")},
+			wantError:     domain.StoreError{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			us := domain.NewUserSession("s1", "r1", "u1", string(admin.SystemRoleUser))
+			us.ActiveRealmID = test.activeRealmID
+
+			if test.impersonatorID != "" {
+				us.ImpersonatorID = test.impersonatorID
+				us.ImpersonatorRealmID = "r0"
+			}
+
+			actor, left, err := resolveActor(context.Background(), test.finder, us)
+
+			if test.wantError != nil {
+				require.ErrorAs(t, err, &test.wantError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.wantActor, actor)
+			require.Equal(t, test.wantLeft, left)
+		})
+	}
+}
+
+type stubMembershipFinder struct {
+	membership admin.Membership
+	err        error
+}
+
+func (f stubMembershipFinder) GetUserMembershipSys(context.Context, admin.ID, admin.ID) (admin.Membership, error) {
+	return f.membership, f.err
+}
+
+func (f stubMembershipFinder) GetUserMembershipsSys(context.Context, admin.ID) ([]admin.Membership, error) {
+	return []admin.Membership{f.membership}, f.err
+}
@@ -1,17 +1,90 @@
 package middleware
 
 import (
+	"context"
+
 	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/energimind/identity-server/internal/core/infra/rest/bearer"
+	"github.com/energimind/identity-server/internal/core/infra/rest/reqctx"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
 )
 
+// apiKeyVerifier is an interface for verifying realm API keys.
+type apiKeyVerifier interface {
+	VerifyAPIKey(
+		ctx context.Context,
+		realmID admin.ID,
+		apiKey, clientIP string,
+		scopes ...string,
+	) (admin.APIKeyPrincipal, error)
+}
+
 // RequireAPIKey is a middleware that requires an API key to be present in the request.
-func RequireAPIKey(apiKey string) gin.HandlerFunc {
+//
+// The request is accepted with a realm API key of a user or a daemon. The principal
+// owning the verified API key is added to the request context and can be retrieved
+// using the reqctx.APIKeyPrincipal function. The principal is also added to the
+// request logger, so that the access is attributable.
+//
+// API keys restricted to other networks than the one of the client IP are rejected
+// with a network denied error. The reason is logged, so that the rejection can be
+// told apart from an invalid API key. The client IP is resolved by the
+// ClientIPResolver middleware.
+//
+// Locked out API key prefixes and client IPs are rejected with a rate limited error.
+//
+// The shared API key is deprecated. If it is not empty, it is still accepted and
+// grants full access.
+func RequireAPIKey(sharedAPIKey string, verifier apiKeyVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
-		bearer := "Bearer " + apiKey
 
-		if auth != bearer {
+		if sharedAPIKey != "" && auth == "Bearer "+sharedAPIKey {
+			reqctx.UpdateLogger(c, func(current *zerolog.Logger) zerolog.Logger {
+				return current.With().Bool("sharedApiKey", true).Logger()
+			})
+
+			c.Next()
+
+			return
+		}
+
+		realmID, key, err := bearer.ParseAPIKey(auth)
+		if err != nil {
+			_ = c.Error(domain.NewUnauthorizedError("invalid API key"))
+
+			c.Abort()
+
+			return
+		}
+
+		clientIP := reqctx.ClientIP(c)
+
+		principal, err := verifier.VerifyAPIKey(c.Request.Context(), admin.ID(realmID), key, clientIP)
+		if domain.IsNetworkDeniedError(err) {
+			reqctx.RequestLogger(c).Warn().
+				Str("reason", err.Error()).
+				Str("clientIp", clientIP).
+				Msg("API key rejected")
+
+			_ = c.Error(err)
+
+			c.Abort()
+
+			return
+		}
+
+		if domain.IsRateLimitedError(err) {
+			_ = c.Error(err)
+
+			c.Abort()
+
+			return
+		}
+
+		if err != nil {
 			_ = c.Error(domain.NewUnauthorizedError("invalid API key"))
 
 			c.Abort()
@@ -19,6 +92,32 @@ func RequireAPIKey(apiKey string) gin.HandlerFunc {
 			return
 		}
 
+		reqctx.SetAPIKeyPrincipal(c, principal)
+
+		reqctx.UpdateLogger(c, func(current *zerolog.Logger) zerolog.Logger {
+			return current.With().
+				Str("apiKeyId", principal.APIKey.ID.String()).
+				Str(string(principal.Kind)+"Id", principal.OwnerID.String()).
+				Logger()
+		})
+
+		c.Next()
+	}
+}
+
+// RequireScope is a middleware that requires the realm API key of the request to grant
+// the given scope. Requests authenticated otherwise are not restricted by scopes.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := reqctx.APIKeyPrincipal(c)
+		if ok && !principal.APIKey.HasScopes(scope) {
+			_ = c.Error(domain.NewAccessDeniedError("API key %s does not grant scope %s", principal.APIKey.ID, scope))
+
+			c.Abort()
+
+			return
+		}
+
 		c.Next()
 	}
 }
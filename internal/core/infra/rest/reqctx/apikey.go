@@ -0,0 +1,31 @@
+package reqctx
+
+import (
+	"context"
+
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyPrincipalKey is a context key for the API key principal.
+type apiKeyPrincipalKey struct{}
+
+// SetAPIKeyPrincipal sets the principal authenticated by an API key in the underlying
+// request context.
+func SetAPIKeyPrincipal(c *gin.Context, principal admin.APIKeyPrincipal) {
+	ctx := context.WithValue(c.Request.Context(), apiKeyPrincipalKey{}, principal)
+
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// APIKeyPrincipal returns the principal authenticated by an API key from the given context.
+// The second return value is false if the request is not authenticated with a realm API key.
+func APIKeyPrincipal(c *gin.Context) (admin.APIKeyPrincipal, bool) {
+	if value := c.Request.Context().Value(apiKeyPrincipalKey{}); value != nil {
+		if principal, ok := value.(admin.APIKeyPrincipal); ok {
+			return principal, true
+		}
+	}
+
+	return admin.APIKeyPrincipal{}, false
+}
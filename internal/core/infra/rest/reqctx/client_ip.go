@@ -0,0 +1,29 @@
+package reqctx
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIPKey is a context key for the client IP.
+type clientIPKey struct{}
+
+// SetClientIP sets the resolved client IP in the underlying request context.
+func SetClientIP(c *gin.Context, clientIP string) {
+	ctx := context.WithValue(c.Request.Context(), clientIPKey{}, clientIP)
+
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// ClientIP returns the client IP resolved by the middleware.ClientIPResolver middleware.
+// If the client IP was not resolved, it falls back to the remote address of the request.
+func ClientIP(c *gin.Context) string {
+	if value := c.Request.Context().Value(clientIPKey{}); value != nil {
+		if clientIP, ok := value.(string); ok {
+			return clientIP
+		}
+	}
+
+	return c.RemoteIP()
+}
@@ -1,7 +1,9 @@
 package sessioncookie
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/energimind/identity-server/internal/core/domain"
 )
@@ -9,27 +11,62 @@ import (
 const fieldSeparator = ":"
 
 // serializeUserSession returns the serialized representation of the UserSession.
+// The impersonation fields are only appended to impersonated sessions.
 func serializeUserSession(us domain.UserSession) string {
-	return us.SessionID + fieldSeparator +
+	serialized := us.SessionID + fieldSeparator +
 		us.RealmID + fieldSeparator +
 		us.UserID + fieldSeparator +
-		us.UserRole
+		us.UserRole + fieldSeparator +
+		us.ActiveRealmID
+
+	if !us.IsImpersonated() {
+		return serialized
+	}
+
+	return serialized + fieldSeparator +
+		us.ImpersonatorID + fieldSeparator +
+		us.ImpersonatorRealmID + fieldSeparator +
+		us.ImpersonatorRole + fieldSeparator +
+		strconv.FormatInt(us.ImpersonationExpiresAt.Unix(), 10)
 }
 
 // deserializeUserSession deserializes the given string into a UserSession.
+// Cookies created before the realm switcher was introduced lack the active realm.
 func deserializeUserSession(serialized string) (domain.UserSession, error) {
-	const expectedPartCount = 4
+	const (
+		legacyPartCount        = 4
+		expectedPartCount      = 5
+		impersonationPartCount = 9
+	)
 
 	parts := strings.Split(serialized, fieldSeparator)
 
-	if len(parts) != expectedPartCount {
+	if len(parts) != expectedPartCount && len(parts) != legacyPartCount && len(parts) != impersonationPartCount {
 		return domain.UserSession{}, NewError("invalid serialized user session")
 	}
 
-	return domain.UserSession{
+	us := domain.UserSession{
 		SessionID: parts[0],
 		RealmID:   parts[1],
 		UserID:    parts[2],
 		UserRole:  parts[3],
-	}, nil
+	}
+
+	if len(parts) >= expectedPartCount {
+		us.ActiveRealmID = parts[4]
+	}
+
+	if len(parts) == impersonationPartCount {
+		expiresAt, err := strconv.ParseInt(parts[8], 10, 64)
+		if err != nil {
+			return domain.UserSession{}, NewError("invalid impersonation expiry: %s", err)
+		}
+
+		us.ImpersonatorID = parts[5]
+		us.ImpersonatorRealmID = parts[6]
+		us.ImpersonatorRole = parts[7]
+		us.ImpersonationExpiresAt = time.Unix(expiresAt, 0)
+	}
+
+	return us, nil
 }
@@ -0,0 +1,3 @@
+// Package ruleengine implements the compilation and the evaluation of the
+// access rules with the Common Expression Language (CEL).
+package ruleengine
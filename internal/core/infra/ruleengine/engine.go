@@ -0,0 +1,126 @@
+package ruleengine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/google/cel-go/cel"
+)
+
+const (
+	// maxExpressionLength is the maximum length of an expression.
+	maxExpressionLength = 4096
+
+	// maxCost is the maximum cost of an evaluation, which bounds the
+	// iterations over the lists and the maps of the variables.
+	maxCost = 100_000
+
+	// maxPrograms is the maximum number of compiled programs kept in the cache.
+	maxPrograms = 1024
+)
+
+// Engine compiles and evaluates CEL expressions.
+//
+// It implements the admin.RuleEngine interface.
+//
+// The expressions see three variables of type map(string, dyn): actor, target
+// and request. The compiled programs are cached by expression, so that the rules
+// are compiled once, not on every operation they are bound to.
+type Engine struct {
+	env      *cel.Env
+	mu       sync.Mutex
+	programs map[string]cel.Program
+}
+
+// Ensure Engine implements the admin.RuleEngine interface.
+var _ admin.RuleEngine = (*Engine)(nil)
+
+// NewEngine returns a new Engine instance.
+func NewEngine() (*Engine, error) {
+	variableType := cel.MapType(cel.StringType, cel.DynType)
+
+	env, err := cel.NewEnv(
+		cel.Variable("actor", variableType),
+		cel.Variable("target", variableType),
+		cel.Variable("request", variableType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule environment: %w", err)
+	}
+
+	return &Engine{
+		env:      env,
+		programs: make(map[string]cel.Program),
+	}, nil
+}
+
+// Compile implements the admin.RuleEngine interface.
+func (e *Engine) Compile(expression string) error {
+	_, err := e.program(expression)
+
+	return err
+}
+
+// Evaluate implements the admin.RuleEngine interface.
+func (e *Engine) Evaluate(expression string, input admin.RuleInput) (bool, error) {
+	program, err := e.program(expression)
+	if err != nil {
+		return false, err
+	}
+
+	out, _, err := program.Eval(map[string]any{
+		"actor":   input.Actor,
+		"target":  input.Target,
+		"request": input.Request,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate expression: %w", err)
+	}
+
+	result, ok := out.Value().(bool)
+	if !ok {
+		return false, errors.New("expression did not yield a boolean")
+	}
+
+	return result, nil
+}
+
+// program returns the compiled program of the expression.
+func (e *Engine) program(expression string) (cel.Program, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if program, found := e.programs[expression]; found {
+		return program, nil
+	}
+
+	if len(expression) > maxExpressionLength {
+		return nil, domain.NewValidationError("expression is longer than %d characters", maxExpressionLength)
+	}
+
+	ast, issues := e.env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, domain.NewValidationError("invalid expression: %v", issues.Err())
+	}
+
+	if !ast.OutputType().IsExactType(cel.BoolType) {
+		return nil, domain.NewValidationError("expression must yield a boolean, not %s", ast.OutputType())
+	}
+
+	program, err := e.env.Program(ast, cel.CostLimit(maxCost))
+	if err != nil {
+		return nil, domain.NewValidationError("invalid expression: %v", err)
+	}
+
+	// the dry runs compile arbitrary expressions, so the cache is bounded
+	if len(e.programs) >= maxPrograms {
+		clear(e.programs)
+	}
+
+	e.programs[expression] = program
+
+	return program, nil
+}
@@ -0,0 +1,146 @@
+package ruleengine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Compile(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		expression string
+		wantError  bool
+	}{
+		"valid": {
+			expression: `actor.attributes.department == target.attributes.department`,
+		},
+		"macro": {
+			expression: `actor.groups.exists(g, g == "sales") && request.operation != "delete"`,
+		},
+		"syntaxError": {
+			expression: `actor.role ==`,
+			wantError:  true,
+		},
+		"unknownVariable": {
+			expression: `subject.role == "manager"`,
+			wantError:  true,
+		},
+		"notBoolean": {
+			expression: `"manager"`,
+			wantError:  true,
+		},
+		"tooLong": {
+			expression: strings.Repeat("true && ", maxExpressionLength) + "true",
+			wantError:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			engine, err := NewEngine()
+			require.NoError(t, err)
+
+			err = engine.Compile(test.expression)
+
+			if test.wantError {
+				require.ErrorAs(t, err, &domain.ValidationError{})
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	sales := admin.Group{Code: "sales", Attributes: map[string]string{"department": "sales"}}
+	support := admin.Group{Code: "support", Attributes: map[string]string{"department": "support"}}
+	actor := admin.Actor{UserID: "u1", RealmID: "a1", Role: admin.SystemRoleManager}
+
+	input := func(actorGroups, targetGroups []admin.Group) admin.RuleInput {
+		target := admin.UserTarget(admin.User{ID: "u2", RealmID: "a1", Username: "user2"})
+
+		return admin.RuleInput{
+			Actor:   admin.ActorVariables(actor, admin.User{}, actorGroups),
+			Target:  target.Variables(admin.MergeAttributes(targetGroups)),
+			Request: admin.RequestVariables(admin.PermissionUsersWrite, admin.RuleOperationUpdate),
+		}
+	}
+
+	const sameDepartment = `actor.role != "manager" || actor.attributes.department == target.attributes.department`
+
+	tests := map[string]struct {
+		expression  string
+		input       admin.RuleInput
+		wantAllowed bool
+		wantError   bool
+	}{
+		"sameDepartment": {
+			expression:  sameDepartment,
+			input:       input([]admin.Group{sales}, []admin.Group{sales}),
+			wantAllowed: true,
+		},
+		"otherDepartment": {
+			expression: sameDepartment,
+			input:      input([]admin.Group{sales}, []admin.Group{support}),
+		},
+		"missingAttribute": {
+			expression: sameDepartment,
+			input:      input(nil, []admin.Group{sales}),
+			wantError:  true,
+		},
+		"missingAttribute-guarded": {
+			expression: `has(actor.attributes.department) && actor.attributes.department == target.attributes.department`,
+			input:      input(nil, []admin.Group{sales}),
+		},
+		"request": {
+			expression:  `request.permission == "users.write" && request.operation == "update"`,
+			input:       input(nil, nil),
+			wantAllowed: true,
+		},
+		"target": {
+			expression:  `target.kind == "user" && target.username.startsWith("user")`,
+			input:       input(nil, nil),
+			wantAllowed: true,
+		},
+		"customAttributes": {
+			expression: `target.custom.level < 5.0 && "go" in target.custom.skills`,
+			input: admin.RuleInput{
+				Actor: admin.ActorVariables(actor, admin.User{}, nil),
+				Target: admin.UserTarget(admin.User{
+					ID:         "u2",
+					RealmID:    "a1",
+					Attributes: map[string]any{"level": 3.0, "skills": []string{"go", "sql"}},
+				}).Variables(nil),
+				Request: admin.RequestVariables(admin.PermissionUsersWrite, admin.RuleOperationCreate),
+			},
+			wantAllowed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			engine, err := NewEngine()
+			require.NoError(t, err)
+
+			allowed, err := engine.Evaluate(test.expression, test.input)
+
+			if test.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.wantAllowed, allowed)
+			}
+		})
+	}
+}
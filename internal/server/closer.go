@@ -8,9 +8,10 @@ func (c *closer) add(f func()) {
 	*c = append(*c, f)
 }
 
-// closeAll closes all the closer functions.
+// closeAll closes all the closer functions in the reverse order of addition,
+// so that background jobs are stopped before the connections they use.
 func (c *closer) closeAll() {
-	for _, f := range *c {
-		f()
+	for i := len(*c) - 1; i >= 0; i-- {
+		(*c)[i]()
 	}
 }
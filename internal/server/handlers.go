@@ -1,12 +1,16 @@
 package server
 
 import (
+	"time"
+
 	"github.com/energimind/identity-server/internal/core/api"
 	adminapi "github.com/energimind/identity-server/internal/core/api/handler/admin"
+	authzapi "github.com/energimind/identity-server/internal/core/api/handler/authz"
 	healthapi "github.com/energimind/identity-server/internal/core/api/handler/health"
 	sessionapi "github.com/energimind/identity-server/internal/core/api/handler/session"
 	utilapi "github.com/energimind/identity-server/internal/core/api/handler/util"
 	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
 	adminsvc "github.com/energimind/identity-server/internal/core/domain/admin/service"
 	authsvc "github.com/energimind/identity-server/internal/core/domain/session/service"
 	"github.com/energimind/identity-server/internal/core/infra/repository"
@@ -20,10 +24,19 @@ type dependencies struct {
 	idGen             domain.IDGenerator
 	shortIDGen        domain.IDGenerator
 	keyGen            domain.IDGenerator
+	keyHasher         domain.KeyHasher
+	ruleEngine        admin.RuleEngine
+	usageRecorder     admin.APIKeyUsageRecorder
 	sessionsAPIKey    string
+	apiKeyGracePeriod time.Duration
 	localAdminEnabled bool
+	impersonationTTL  time.Duration
 	cookieOperator    *sessioncookie.Provider
 	cache             domain.Cache
+	rateLimiter       domain.RateLimiter
+	authRateLimit     middleware.RateLimitPolicy
+	sessionsRateLimit middleware.RateLimitPolicy
+	lockoutPolicy     admin.LockoutPolicy
 }
 
 func setupHandlersAndMiddlewares(deps dependencies) (api.Handlers, api.Middlewares) {
@@ -31,45 +44,77 @@ func setupHandlersAndMiddlewares(deps dependencies) (api.Handlers, api.Middlewar
 	idGen := deps.idGen
 	shortIDGen := deps.shortIDGen
 	keyGen := deps.keyGen
+	keyHasher := deps.keyHasher
+	ruleEngine := deps.ruleEngine
+	usageRecorder := deps.usageRecorder
 	sessionAPIKey := deps.sessionsAPIKey
+	apiKeyGracePeriod := deps.apiKeyGracePeriod
 	localAdminEnabled := deps.localAdminEnabled
 	cookieOperator := deps.cookieOperator
 	cache := deps.cache
+	rateLimiter := deps.rateLimiter
 
 	realmRepo := repository.NewRealmRepository(mongoDB)
 	providerRepo := repository.NewProviderRepository(mongoDB)
 	userRepo := repository.NewUserRepository(mongoDB)
 	daemonRepo := repository.NewDaemonRepository(mongoDB)
+	lockoutRepo := repository.NewLockoutRepository(mongoDB)
+	groupRepo := repository.NewGroupRepository(mongoDB)
+	membershipRepo := repository.NewMembershipRepository(mongoDB)
+	policyRepo := repository.NewPolicyRepository(mongoDB)
+	ruleRepo := repository.NewRuleRepository(mongoDB)
+	deletionRepo := repository.NewDeletionRepository(mongoDB)
 
-	realmService := adminsvc.NewRealmService(realmRepo, idGen)
-	providerService := adminsvc.NewProviderService(providerRepo, idGen)
-	userService := adminsvc.NewUserService(userRepo, idGen)
-	daemonService := adminsvc.NewDaemonService(daemonRepo, idGen)
+	authorizer := adminsvc.NewAuthorizer(userRepo, realmRepo, groupRepo, ruleRepo, ruleEngine)
+	realmService := adminsvc.NewRealmService(realmRepo, deletionRepo, authorizer, idGen)
+	providerService := adminsvc.NewProviderService(providerRepo, authorizer, idGen)
+	userService := adminsvc.NewUserService(userRepo, realmRepo, groupRepo, membershipRepo, authorizer, idGen, keyGen, keyHasher)
+	daemonService := adminsvc.NewDaemonService(daemonRepo, realmRepo, authorizer, idGen, keyGen, keyHasher)
+	groupService := adminsvc.NewGroupService(groupRepo, userRepo, realmRepo, authorizer, idGen)
+	membershipService := adminsvc.NewMembershipService(membershipRepo, userRepo, realmRepo, authorizer, idGen)
+	policyService := adminsvc.NewPolicyService(policyRepo, authorizer, idGen)
+	ruleService := adminsvc.NewRuleService(ruleRepo, ruleEngine, authorizer, idGen)
+	authzService := adminsvc.NewAuthzService(policyRepo, userRepo, daemonRepo, realmRepo, groupRepo)
 	realmLookupService := adminsvc.NewRealmLookupService(realmService)
 	providerLookupService := adminsvc.NewProviderLookupService(providerService)
-	apiKeyLookupService := adminsvc.NewAPIKeyLookupService(userRepo, daemonRepo)
+	apiKeyLookupService := adminsvc.NewAPIKeyLookupService(userRepo, daemonRepo, keyHasher, usageRecorder)
+	lockoutService := adminsvc.NewLockoutService(lockoutRepo, authorizer, deps.lockoutPolicy)
+	searchService := adminsvc.NewSearchService(userRepo, daemonRepo, authorizer)
 	sessionService := authsvc.NewService(
 		realmLookupService,
 		providerLookupService,
 		apiKeyLookupService,
+		lockoutService,
 		shortIDGen,
 		cache,
 	)
 
 	handlers := api.Handlers{
-		Auth:     adminapi.NewAuthHandler(sessionService, userService, cookieOperator, localAdminEnabled),
-		Realm:    adminapi.NewRealmHandler(realmService),
-		Provider: adminapi.NewProviderHandler(providerService),
-		User:     adminapi.NewUserHandler(userService),
-		Daemon:   adminapi.NewDaemonHandler(daemonService),
-		Session:  sessionapi.NewHandler(sessionService, userService),
-		Util:     utilapi.NewHandler(keyGen),
-		Health:   healthapi.NewHandler(),
+		Auth: adminapi.NewAuthHandler(sessionService, userService, membershipService, userService,
+			cookieOperator, lockoutService, localAdminEnabled, deps.impersonationTTL),
+		Realm:      adminapi.NewRealmHandler(realmService),
+		Provider:   adminapi.NewProviderHandler(providerService),
+		User:       adminapi.NewUserHandler(userService, apiKeyGracePeriod),
+		Daemon:     adminapi.NewDaemonHandler(daemonService, apiKeyGracePeriod),
+		Group:      adminapi.NewGroupHandler(groupService),
+		Membership: adminapi.NewMembershipHandler(membershipService),
+		Policy:     adminapi.NewPolicyHandler(policyService),
+		Rule:       adminapi.NewRuleHandler(ruleService),
+		Search:     adminapi.NewSearchHandler(searchService),
+		Lockout:    adminapi.NewLockoutHandler(lockoutService),
+		Session:    sessionapi.NewHandler(sessionService, userService, groupService),
+		Authz:      authzapi.NewHandler(authzService, sessionService, userService),
+		Util:       utilapi.NewHandler(keyGen),
+		Health:     healthapi.NewHandler(),
 	}
 
 	middlewares := api.Middlewares{
-		RequireActor:  middleware.RequireActor(cookieOperator, sessionService, localAdminEnabled),
-		RequireAPIKey: middleware.RequireAPIKey(sessionAPIKey),
+		RequireActor:           middleware.RequireActor(cookieOperator, sessionService, membershipService, localAdminEnabled),
+		RequireAPIKey:          middleware.RequireAPIKey(sessionAPIKey, sessionService),
+		RequireScope:           middleware.RequireScope,
+		RateLimitAuth:          middleware.RateLimit(rateLimiter, deps.authRateLimit),
+		RateLimitSessions:      middleware.RateLimit(rateLimiter, deps.sessionsRateLimit),
+		RateLimitSessionsRealm: middleware.RateLimitAPIKeyRealm(rateLimiter, deps.sessionsRateLimit),
 	}
 
 	return handlers, middlewares
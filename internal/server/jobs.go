@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/energimind/go-kit/slog"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+)
+
+// startAPIKeyExpiryJob starts a background job that periodically disables expired
+// API keys and reports the API keys expiring within the warning period.
+// The job runs once immediately. It is stopped by the closer.
+func startAPIKeyExpiryJob(
+	service admin.APIKeyExpiryService,
+	interval, warnWithin time.Duration,
+	closer *closer,
+) {
+	if interval <= 0 {
+		slog.Warn().Msg("API key expiry job disabled")
+
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			expireAPIKeys(ctx, service, warnWithin)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	closer.add(func() {
+		cancel()
+		<-done
+	})
+}
+
+func expireAPIKeys(ctx context.Context, service admin.APIKeyExpiryService, warnWithin time.Duration) {
+	report, err := service.ExpireAPIKeys(ctx, time.Now(), warnWithin)
+	if err != nil {
+		slog.Error().Err(err).Msg("Failed to expire API keys")
+
+		return
+	}
+
+	if report.DisabledOwners > 0 {
+		slog.Info().Int("owners", report.DisabledOwners).Msg("Disabled expired API keys")
+	}
+
+	logExpiringAPIKeys("userId", report.ExpiringUser)
+	logExpiringAPIKeys("daemonId", report.ExpiringDaemon)
+}
+
+func logExpiringAPIKeys(ownerField string, expiring []admin.OwnedAPIKey) {
+	for _, key := range expiring {
+		slog.Warn().
+			Str("realmId", string(key.RealmID)).
+			Str(ownerField, string(key.OwnerID)).
+			Str("apiKeyId", string(key.APIKey.ID)).
+			Str("apiKeyName", key.APIKey.Name).
+			Time("expiresAt", key.APIKey.ExpiresAt).
+			Msg("API key is about to expire")
+	}
+}
+
+// startPurgeJob starts a background job that periodically purges the entities
+// deleted for longer than the retention period. The job runs once immediately.
+// It is stopped by the closer.
+func startPurgeJob(
+	service admin.PurgeService,
+	interval, retention time.Duration,
+	closer *closer,
+) {
+	if interval <= 0 {
+		slog.Warn().Msg("Purge job disabled")
+
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purgeDeleted(ctx, service, retention)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	closer.add(func() {
+		cancel()
+		<-done
+	})
+}
+
+func purgeDeleted(ctx context.Context, service admin.PurgeService, retention time.Duration) {
+	purged, err := service.PurgeDeleted(ctx, time.Now().Add(-retention))
+	if err != nil {
+		slog.Error().Err(err).Msg("Failed to purge deleted entities")
+
+		return
+	}
+
+	if purged > 0 {
+		slog.Info().Int("count", purged).Msg("Purged deleted entities")
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+
+	"github.com/energimind/go-kit/slog"
+	"github.com/energimind/identity-server/internal/core/domain"
+	"github.com/energimind/identity-server/internal/core/infra/repository"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migrateAPIKeys replaces plain text API keys stored by previous versions
+// with their hashed form. It is a no-op when all keys are already hashed.
+func migrateAPIKeys(ctx context.Context, db *mongo.Database, hasher domain.KeyHasher) error {
+	migrated, err := repository.MigrateAPIKeys(ctx, db, hasher)
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate API keys")
+	}
+
+	if migrated > 0 {
+		slog.Info().Int("count", migrated).Msg("Migrated plain text API keys")
+	}
+
+	return nil
+}
+
+// migrateCreationTimes sets the zero creation time on the users and daemons
+// stored by previous versions, which did not record it.
+func migrateCreationTimes(ctx context.Context, db *mongo.Database) error {
+	migrated, err := repository.MigrateCreationTimes(ctx, db)
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate creation times")
+	}
+
+	if migrated > 0 {
+		slog.Info().Int("count", migrated).Msg("Migrated users and daemons without creation time")
+	}
+
+	return nil
+}
+
+// migrateSearchTerms sets the search terms of the users stored by previous
+// versions, which did not record them.
+func migrateSearchTerms(ctx context.Context, db *mongo.Database) error {
+	migrated, err := repository.MigrateSearchTerms(ctx, db)
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate search terms")
+	}
+
+	if migrated > 0 {
+		slog.Info().Int("count", migrated).Msg("Migrated users without search terms")
+	}
+
+	return nil
+}
+
+// migrateVersions sets the first version on the admin entities stored by previous
+// versions, which did not version them.
+func migrateVersions(ctx context.Context, db *mongo.Database) error {
+	migrated, err := repository.MigrateVersions(ctx, db)
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate versions")
+	}
+
+	if migrated > 0 {
+		slog.Info().Int("count", migrated).Msg("Migrated admin entities without version")
+	}
+
+	return nil
+}
@@ -7,13 +7,19 @@ import (
 	"github.com/energimind/go-kit/httpd"
 	"github.com/energimind/go-kit/idgen/cuuid"
 	"github.com/energimind/go-kit/idgen/shortid"
-	"github.com/energimind/go-kit/idgen/uuid"
 	"github.com/energimind/go-kit/rest/router"
 	"github.com/energimind/go-kit/slog"
 	"github.com/energimind/identity-server/internal/config"
 	"github.com/energimind/identity-server/internal/core/api"
+	"github.com/energimind/identity-server/internal/core/domain/admin"
+	adminsvc "github.com/energimind/identity-server/internal/core/domain/admin/service"
+	"github.com/energimind/identity-server/internal/core/infra/keygen"
+	"github.com/energimind/identity-server/internal/core/infra/keyhash"
+	"github.com/energimind/identity-server/internal/core/infra/ratelimit"
+	"github.com/energimind/identity-server/internal/core/infra/repository"
 	"github.com/energimind/identity-server/internal/core/infra/rest/middleware"
 	"github.com/energimind/identity-server/internal/core/infra/rest/sessioncookie"
+	"github.com/energimind/identity-server/internal/core/infra/ruleengine"
 	"github.com/gin-gonic/gin"
 )
 
@@ -33,18 +39,107 @@ func setupServer(cfg *config.Config) (*httpd.Server, *closer, error) { //nolint:
 
 	idGen := cuuid.NewGenerator()
 	shortIDGen := shortid.NewGenerator()
-	keyGen := uuid.NewGenerator()
+	keyGen := keygen.NewGenerator()
+
+	keyHasher, err := keyhash.NewHasher(cfg.Auth.APIKeySecret)
+	if err != nil {
+		return startupFailure(err)
+	}
+
+	ruleEngine, err := ruleengine.NewEngine()
+	if err != nil {
+		return startupFailure(err)
+	}
+
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.Router.TrustedProxies)
+	if err != nil {
+		return startupFailure(fmt.Errorf("invalid trusted proxies: %w", err))
+	}
 
 	mongoDB, err := connectMongo(ctx, cfg.Mongo, clr)
 	if err != nil {
 		return startupFailure(err)
 	}
 
+	if err := migrateAPIKeys(ctx, mongoDB, keyHasher); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := migrateCreationTimes(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := migrateSearchTerms(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := migrateVersions(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := repository.EnsureUserIndexes(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := repository.EnsureDaemonIndexes(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := repository.EnsureLockoutIndexes(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := repository.EnsureGroupIndexes(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := repository.EnsureMembershipIndexes(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := repository.EnsurePolicyIndexes(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	if err := repository.EnsureRuleIndexes(ctx, mongoDB); err != nil {
+		return startupFailure(err)
+	}
+
+	startAPIKeyExpiryJob(
+		adminsvc.NewAPIKeyExpiryService(
+			repository.NewUserRepository(mongoDB),
+			repository.NewDaemonRepository(mongoDB),
+		),
+		cfg.Auth.APIKeyExpiryCheckInterval,
+		cfg.Auth.APIKeyExpiryWarningPeriod,
+		clr,
+	)
+
+	startPurgeJob(
+		adminsvc.NewPurgeService(repository.NewDeletionRepository(mongoDB)),
+		cfg.Deletion.PurgeInterval,
+		cfg.Deletion.RetentionPeriod,
+		clr,
+	)
+
+	apiKeyUsageRecorder := adminsvc.NewAPIKeyUsageRecorder(
+		repository.NewUserRepository(mongoDB),
+		repository.NewDaemonRepository(mongoDB),
+		cfg.Auth.APIKeyUsageFlushInterval,
+	)
+
+	apiKeyUsageRecorder.Start()
+	clr.add(apiKeyUsageRecorder.Stop)
+
 	redisCache, err := connectRedis(ctx, cfg.Redis, clr)
 	if err != nil {
 		return startupFailure(err)
 	}
 
+	if cfg.Auth.APIKey != "" {
+		slog.Warn().Msg("The shared API key is deprecated, use the API keys of the daemons instead")
+	}
+
 	cookieOperator := sessioncookie.NewProvider(cfg.Cookie.Name, cfg.Cookie.Secret)
 
 	handlers, middlewares := setupHandlersAndMiddlewares(
@@ -53,10 +148,33 @@ func setupServer(cfg *config.Config) (*httpd.Server, *closer, error) { //nolint:
 			idGen:             idGen,
 			shortIDGen:        shortIDGen,
 			keyGen:            keyGen,
+			keyHasher:         keyHasher,
+			ruleEngine:        ruleEngine,
+			usageRecorder:     apiKeyUsageRecorder,
 			sessionsAPIKey:    cfg.Auth.APIKey,
+			apiKeyGracePeriod: cfg.Auth.APIKeyRotationGracePeriod,
 			localAdminEnabled: cfg.Auth.LocalAdminEnabled,
+			impersonationTTL:  cfg.Auth.ImpersonationDuration,
 			cookieOperator:    cookieOperator,
 			cache:             redisCache,
+			rateLimiter:       ratelimit.NewLimiter(redisCache),
+			authRateLimit: middleware.RateLimitPolicy{
+				Group:       "auth",
+				Window:      cfg.RateLimit.AuthWindow,
+				PerClientIP: cfg.RateLimit.AuthPerClientIP,
+			},
+			sessionsRateLimit: middleware.RateLimitPolicy{
+				Group:          "sessions",
+				Window:         cfg.RateLimit.SessionsWindow,
+				PerClientIP:    cfg.RateLimit.SessionsPerClientIP,
+				PerAPIKeyRealm: cfg.RateLimit.SessionsPerAPIKeyRealm,
+			},
+			lockoutPolicy: admin.LockoutPolicy{
+				Threshold:     cfg.Lockout.Threshold,
+				FailureWindow: cfg.Lockout.FailureWindow,
+				BaseDuration:  cfg.Lockout.BaseDuration,
+				MaxDuration:   cfg.Lockout.MaxDuration,
+			},
 		},
 	)
 
@@ -66,6 +184,7 @@ func setupServer(cfg *config.Config) (*httpd.Server, *closer, error) { //nolint:
 		gin.Recovery(),
 		middleware.LoggerInjector(),
 		middleware.RequestIDInjector(),
+		middleware.ClientIPResolver(trustedProxies),
 		middleware.RequestLogger(),
 		middleware.CORS(cfg.Router.AllowOrigin),
 		middleware.ErrorMapper())